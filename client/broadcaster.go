@@ -0,0 +1,366 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/constants"
+	"github.com/bcdevtools/cvp-streaming-core/internal/websocket"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"github.com/pkg/errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrBroadcasterClosed is returned when sending through a closed Broadcaster.
+var ErrBroadcasterClosed = fmt.Errorf("broadcaster closed")
+
+// FrameRejectedError is returned by the Broadcaster when the server rejected a frame.
+type FrameRejectedError struct {
+	Sequence uint64
+	Reason   string
+}
+
+func (e *FrameRejectedError) Error() string {
+	return fmt.Sprintf("frame %d rejected: %s", e.Sequence, e.Reason)
+}
+
+// pendingFrame is a frame sent or to be sent, which had not been acknowledged yet.
+type pendingFrame struct {
+	sequence uint64
+	message  []byte
+}
+
+// Broadcaster streams frames of the current session of the client over WebSocket.
+//
+// Sending does not wait for the acknowledgement, up to BroadcastWindow frames can be in-flight,
+// sending more blocks until the oldest one is acknowledged. When the connection is lost, the broadcaster
+// reconnects, resumes from the last sequence accepted by the server and re-sends the in-flight frames.
+// Frames rejected by the server are reported as FrameRejectedError by the next Send or Flush.
+//
+// Broadcaster is safe for concurrent use.
+type Broadcaster struct {
+	client     *Client
+	url        string
+	sessionId  types.PreVoteStreamingSessionId
+	sessionKey types.PreVoteStreamingSessionKey
+
+	// slots limits the number of in-flight frames, each pending frame holds a slot.
+	slots chan struct{}
+	// sendMu keeps frames written in order of sequence.
+	sendMu sync.Mutex
+
+	mu           sync.Mutex
+	conn         *websocket.Conn // nil while reconnecting
+	nextSequence uint64
+	pending      []pendingFrame
+	rejection    error // returned once by the next Send or Flush
+	err          error // the broadcaster is no longer usable
+
+	done chan struct{} // closed when the broadcaster is closed or failed to reconnect
+}
+
+// DialBroadcaster opens the WebSocket broadcast connection of the current session,
+// a session must be registered or resumed before.
+func (c *Client) DialBroadcaster(ctx context.Context) (*Broadcaster, error) {
+	sessionId, sessionKey := c.Session()
+	if sessionId == "" {
+		return nil, ErrNoSession
+	}
+
+	b := &Broadcaster{
+		client:     c,
+		url:        utils.GetRemoteUrlBroadcastPreVoteWebSocket(c.config.BaseUrl, string(sessionId)),
+		sessionId:  sessionId,
+		sessionKey: sessionKey,
+		slots:      make(chan struct{}, c.config.BroadcastWindow),
+		done:       make(chan struct{}),
+	}
+
+	conn, lastSequence, err := b.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.conn = conn
+	b.nextSequence = lastSequence + 1
+
+	go b.readLoop(conn)
+	go b.keepAliveLoop()
+
+	return b, nil
+}
+
+// SendVotingInfo sends the next block voting information.
+func (b *Broadcaster) SendVotingInfo(ctx context.Context, inf *types.StreamingNextBlockVotingInformation) error {
+	bz, err := b.client.config.Codec.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		return err
+	}
+	return b.send(ctx, constants.STREAMING_WS_FRAME_KIND_NEXT_BLOCK_VOTING_INFO, bz)
+}
+
+// SendValidators replaces the validator set of the session.
+func (b *Broadcaster) SendValidators(ctx context.Context, validators types.StreamingLightValidators) error {
+	bz, err := b.client.config.Codec.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		return err
+	}
+	return b.send(ctx, constants.STREAMING_WS_FRAME_KIND_LIGHT_VALIDATORS, bz)
+}
+
+// Flush waits until all the sent frames are acknowledged.
+func (b *Broadcaster) Flush(ctx context.Context) error {
+	// every in-flight frame holds a slot, so holding all the slots means nothing is in-flight
+	acquired := 0
+	defer func() {
+		for ; acquired > 0; acquired-- {
+			<-b.slots
+		}
+	}()
+	for acquired < cap(b.slots) {
+		if err := b.acquireSlot(ctx); err != nil {
+			return err
+		}
+		acquired++
+	}
+
+	return b.takeError()
+}
+
+// Close closes the connection, frames which had not been acknowledged are dropped.
+func (b *Broadcaster) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err == nil {
+		b.err = ErrBroadcasterClosed
+		close(b.done)
+	}
+	if b.conn != nil {
+		_ = b.conn.Close()
+		b.conn = nil
+	}
+	return nil
+}
+
+func (b *Broadcaster) send(ctx context.Context, kind byte, payload []byte) error {
+	if err := b.takeError(); err != nil {
+		return err
+	}
+	if err := b.acquireSlot(ctx); err != nil {
+		return err
+	}
+
+	b.sendMu.Lock()
+	defer b.sendMu.Unlock()
+
+	b.mu.Lock()
+	frame := pendingFrame{
+		sequence: b.nextSequence,
+		message:  make([]byte, constants.STREAMING_WS_FRAME_HEADER_SIZE, constants.STREAMING_WS_FRAME_HEADER_SIZE+len(payload)),
+	}
+	b.nextSequence++
+	binary.BigEndian.PutUint64(frame.message, frame.sequence)
+	frame.message[8] = kind
+	frame.message = append(frame.message, payload...)
+	b.pending = append(b.pending, frame)
+	conn := b.conn
+	b.mu.Unlock()
+
+	if conn == nil {
+		// reconnecting, the frame will be sent after reconnected
+		return nil
+	}
+	if err := conn.WriteMessage(websocket.OpBinary, frame.message); err != nil {
+		// the read loop detects the broken connection and reconnects
+		_ = conn.Close()
+	}
+	return nil
+}
+
+func (b *Broadcaster) acquireSlot(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.done:
+		return b.takeError()
+	}
+}
+
+// takeError returns the fatal error if any, otherwise returns and clears the rejection.
+func (b *Broadcaster) takeError() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return b.err
+	}
+	err := b.rejection
+	b.rejection = nil
+	return err
+}
+
+// dial opens the connection and reads the acknowledgement of the last accepted sequence,
+// the handshake is authenticated by signing an empty payload.
+func (b *Broadcaster) dial(ctx context.Context) (*websocket.Conn, uint64, error) {
+	u, err := url.Parse(b.url)
+	if err != nil {
+		return nil, 0, err
+	}
+	header := make(http.Header)
+	if err := b.client.authenticate(header, http.MethodGet, u.Path, b.sessionId, b.sessionKey, nil); err != nil {
+		return nil, 0, err
+	}
+
+	conn, err := websocket.Dial(ctx, b.url, header)
+	if err != nil {
+		var handshakeErr *websocket.HandshakeError
+		if errors.As(err, &handshakeErr) {
+			return nil, 0, &HttpError{
+				Method:     http.MethodGet,
+				Url:        b.url,
+				StatusCode: handshakeErr.Response.StatusCode,
+				Message:    handshakeErr.Body,
+			}
+		}
+		return nil, 0, err
+	}
+
+	ack, err := readAck(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, 0, &ProtocolError{Url: b.url, Reason: "invalid initial acknowledgement", Cause: err}
+	}
+
+	return conn, ack.Sequence, nil
+}
+
+func (b *Broadcaster) readLoop(conn *websocket.Conn) {
+	for {
+		ack, err := readAck(conn)
+		if err != nil {
+			_ = conn.Close()
+			if conn = b.reconnect(); conn == nil {
+				return
+			}
+			continue
+		}
+
+		b.mu.Lock()
+		b.releaseAcknowledged(ack.Sequence)
+		if ack.Error != "" && b.rejection == nil {
+			b.rejection = &FrameRejectedError{Sequence: ack.Sequence, Reason: ack.Error}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// releaseAcknowledged removes the pending frames up to the sequence and releases their slots, mu must be held.
+func (b *Broadcaster) releaseAcknowledged(sequence uint64) {
+	for len(b.pending) > 0 && b.pending[0].sequence <= sequence {
+		b.pending = b.pending[1:]
+		<-b.slots
+	}
+}
+
+// reconnect re-establishes the connection and re-sends the pending frames which had not been accepted,
+// returns nil if the broadcaster is closed or all the attempts failed.
+func (b *Broadcaster) reconnect() *websocket.Conn {
+	b.mu.Lock()
+	b.conn = nil
+	b.mu.Unlock()
+
+	var lastErr error
+	for attempt := 0; attempt < b.client.config.MaxReconnectAttempts; attempt++ {
+		select {
+		case <-b.done:
+			return nil
+		case <-time.After(b.client.config.ReconnectBackoff * time.Duration(attempt+1)):
+		}
+
+		timeout := b.client.config.HttpClient.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		conn, lastSequence, err := b.dial(ctx)
+		cancel()
+		if err != nil {
+			lastErr = err
+			if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrSessionNotFound) || errors.Is(err, types.ErrPreVoteStreamingSessionRevoked) {
+				break
+			}
+			continue
+		}
+
+		b.sendMu.Lock()
+		b.mu.Lock()
+		if b.err != nil {
+			b.mu.Unlock()
+			b.sendMu.Unlock()
+			_ = conn.Close()
+			return nil
+		}
+		b.releaseAcknowledged(lastSequence)
+		b.conn = conn
+		resend := append([]pendingFrame(nil), b.pending...)
+		b.mu.Unlock()
+
+		for _, frame := range resend {
+			if err := conn.WriteMessage(websocket.OpBinary, frame.message); err != nil {
+				_ = conn.Close()
+				break
+			}
+		}
+		b.sendMu.Unlock()
+		return conn
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.err = fmt.Errorf("failed to reconnect: %w", lastErr)
+		close(b.done)
+	}
+	return nil
+}
+
+func (b *Broadcaster) keepAliveLoop() {
+	ticker := time.NewTicker(b.client.config.KeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			conn := b.conn
+			b.mu.Unlock()
+			if conn != nil {
+				_ = conn.WriteMessage(websocket.OpPing, nil)
+			}
+		}
+	}
+}
+
+func readAck(conn *websocket.Conn) (types.PreVoteStreamingBroadcastAck, error) {
+	var ack types.PreVoteStreamingBroadcastAck
+	opcode, message, err := conn.ReadMessage()
+	if err != nil {
+		return ack, err
+	}
+	if opcode != websocket.OpText {
+		return ack, fmt.Errorf("unexpected message opcode %d", opcode)
+	}
+	if err := json.Unmarshal(message, &ack); err != nil {
+		return ack, err
+	}
+	return ack, nil
+}
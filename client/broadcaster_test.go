@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"github.com/bcdevtools/cvp-streaming-core/internal/websocket"
+	"github.com/bcdevtools/cvp-streaming-core/server"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestInformationAtStep(step types.RoundStepType) *types.StreamingNextBlockVotingInformation {
+	inf := *testInformation
+	inf.HeightRoundStep.Step = step
+	return &inf
+}
+
+func TestBroadcaster(t *testing.T) {
+	srv := httptest.NewServer(server.NewServer(server.Config{}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(Config{BaseUrl: srv.URL, BroadcastWindow: 2})
+
+	_, err := c.DialBroadcaster(ctx)
+	require.ErrorIs(t, err, ErrNoSession)
+
+	registration, err := c.Register(ctx, "cosmoshub-4", testValidators)
+	require.NoError(t, err)
+
+	b, err := c.DialBroadcaster(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = b.Close()
+	}()
+	require.Equal(t, uint64(1), b.nextSequence)
+
+	for step := types.RoundStepNewHeight; step <= types.RoundStepPrecommit; step++ {
+		require.NoError(t, b.SendVotingInfo(ctx, newTestInformationAtStep(step)))
+	}
+	require.NoError(t, b.Flush(ctx))
+
+	viewer := NewClient(Config{BaseUrl: srv.URL})
+	inf, err := viewer.FetchUpdate(ctx, registration.SessionId)
+	require.NoError(t, err)
+	require.Equal(t, newTestInformationAtStep(types.RoundStepPrecommit), inf)
+
+	t.Run("rejected frame", func(t *testing.T) {
+		require.NoError(t, b.SendValidators(ctx, testValidators[:1]))
+		require.NoError(t, b.SendVotingInfo(ctx, testInformation)) // refers the removed validator
+
+		err := b.Flush(ctx)
+		var rejectedErr *FrameRejectedError
+		require.True(t, errors.As(err, &rejectedErr))
+		require.Contains(t, rejectedErr.Reason, "unknown validator index 1")
+
+		require.NoError(t, b.Flush(ctx), "rejection is reported once")
+		require.NoError(t, b.SendValidators(ctx, testValidators))
+		require.NoError(t, b.Flush(ctx))
+	})
+
+	t.Run("reconnect and resume", func(t *testing.T) {
+		b.mu.Lock()
+		lastSequence := b.nextSequence - 1
+		conn := b.conn
+		b.mu.Unlock()
+
+		// drop the connection, frames sent meanwhile are re-sent after reconnected
+		_ = conn.Close()
+		require.NoError(t, b.SendVotingInfo(ctx, newTestInformationAtStep(types.RoundStepPrevote)))
+		require.NoError(t, b.SendVotingInfo(ctx, newTestInformationAtStep(types.RoundStepCommit)))
+		require.NoError(t, b.Flush(ctx))
+
+		b.mu.Lock()
+		require.NotSame(t, conn, b.conn)
+		require.Equal(t, lastSequence+3, b.nextSequence)
+		b.mu.Unlock()
+
+		inf, err := viewer.FetchUpdate(ctx, registration.SessionId)
+		require.NoError(t, err)
+		require.Equal(t, newTestInformationAtStep(types.RoundStepCommit), inf)
+	})
+
+	t.Run("another broadcaster resumes the sequence", func(t *testing.T) {
+		b.mu.Lock()
+		nextSequence := b.nextSequence
+		b.mu.Unlock()
+
+		resumed := NewClient(Config{BaseUrl: srv.URL})
+		_, err := resumed.Resume(ctx, registration.SessionId, registration.SessionKey)
+		require.NoError(t, err)
+
+		b2, err := resumed.DialBroadcaster(ctx)
+		require.NoError(t, err)
+		defer func() {
+			_ = b2.Close()
+		}()
+		require.Equal(t, nextSequence, b2.nextSequence)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		require.NoError(t, b.Close())
+		require.ErrorIs(t, b.SendVotingInfo(ctx, testInformation), ErrBroadcasterClosed)
+	})
+}
+
+func TestBroadcaster_Unauthorized(t *testing.T) {
+	srv := httptest.NewServer(server.NewServer(server.Config{}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	registration, err := NewClient(Config{BaseUrl: srv.URL}).Register(ctx, "cosmoshub-4", testValidators)
+	require.NoError(t, err)
+
+	c := NewClient(Config{BaseUrl: srv.URL})
+	c.sessionId = registration.SessionId
+	c.sessionKey = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	_, err = c.DialBroadcaster(ctx)
+	require.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestBroadcaster_Backpressure(t *testing.T) {
+	// server which never acknowledges the frames
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		_ = conn.WriteMessage(websocket.OpText, []byte(`{"seq":0}`))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(Config{BaseUrl: srv.URL, BroadcastWindow: 2})
+	c.sessionId = "cosmoshub-4_0000000000000000000000000000000000000000000000000000000000000000"
+	c.sessionKey = "0000000000000000000000000000000000000000000000000000000000000000"
+
+	b, err := c.DialBroadcaster(ctx)
+	require.NoError(t, err)
+	defer func() {
+		_ = b.Close()
+	}()
+
+	require.NoError(t, b.SendVotingInfo(ctx, testInformation))
+	require.NoError(t, b.SendVotingInfo(ctx, testInformation))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, b.SendVotingInfo(timeoutCtx, testInformation), context.DeadlineExceeded, "window is full")
+	require.ErrorIs(t, b.Flush(timeoutCtx), context.DeadlineExceeded)
+}
@@ -0,0 +1,298 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/constants"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTimeout is the timeout of requests, when not specified by the configured HTTP client.
+const defaultTimeout = 10 * time.Second
+
+// maxResponseBytes limits the size of responses read from the streaming server.
+const maxResponseBytes = 1 << 20
+
+// Config is the configuration of the streaming client.
+type Config struct {
+	// BaseUrl is the base URL of the streaming server, default is constants.STREAMING_BASE_URL.
+	BaseUrl string
+
+	// Codec encodes the broadcast data and decodes the fetched updates,
+	// default is the proxy codec which encodes using the default version and decodes all the registered versions.
+	Codec codec.CvpCodec
+
+	// HttpClient sends the requests, default is a client with 10 seconds timeout.
+	HttpClient *http.Client
+
+	// BroadcastWindow is the maximum number of in-flight frames of the WebSocket Broadcaster, default is 16.
+	BroadcastWindow int
+
+	// MaxReconnectAttempts is the number of attempts of the WebSocket Broadcaster to reconnect
+	// before giving up, default is 5.
+	MaxReconnectAttempts int
+
+	// ReconnectBackoff is the delay before the first reconnect attempt, increased linearly
+	// for the next attempts, default is 500 milliseconds.
+	ReconnectBackoff time.Duration
+
+	// KeepAliveInterval is the interval of pinging the WebSocket connection, default is 15 seconds.
+	KeepAliveInterval time.Duration
+
+	// SendRawSessionKey sends the raw session key header instead of signing the requests,
+	// for streaming servers which do not support the signature headers.
+	SendRawSessionKey bool
+}
+
+// Client is a client of the pre-vote streaming protocol.
+//
+// After a session is registered or resumed, the client keeps the session id and key to broadcast within the session.
+// Client is safe for concurrent use.
+type Client struct {
+	config Config
+
+	mu         sync.RWMutex
+	sessionId  types.PreVoteStreamingSessionId
+	sessionKey types.PreVoteStreamingSessionKey
+}
+
+// NewClient returns a new Client, zero fields of the config are filled with defaults.
+func NewClient(config Config) *Client {
+	if config.BaseUrl == "" {
+		config.BaseUrl = constants.STREAMING_BASE_URL
+	}
+	if config.Codec == nil {
+		config.Codec = codec.NewProxyCvpCodec()
+	}
+	if config.HttpClient == nil {
+		config.HttpClient = &http.Client{
+			Timeout: defaultTimeout,
+		}
+	}
+	if config.BroadcastWindow < 1 {
+		config.BroadcastWindow = 16
+	}
+	if config.MaxReconnectAttempts < 1 {
+		config.MaxReconnectAttempts = 5
+	}
+	if config.ReconnectBackoff <= 0 {
+		config.ReconnectBackoff = 500 * time.Millisecond
+	}
+	if config.KeepAliveInterval <= 0 {
+		config.KeepAliveInterval = 15 * time.Second
+	}
+	return &Client{
+		config: config,
+	}
+}
+
+// Session returns the current session id and key, empty if no session had been registered or resumed.
+func (c *Client) Session() (types.PreVoteStreamingSessionId, types.PreVoteStreamingSessionKey) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sessionId, c.sessionKey
+}
+
+// Register registers a new streaming session for the chain with the initial validator set,
+// the new session becomes the current session of the client.
+func (c *Client) Register(ctx context.Context, chainId string, validators types.StreamingLightValidators) (*types.PreVoteStreamingSessionRegistrationResponse, error) {
+	bz, err := c.config.Codec.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		return nil, err
+	}
+
+	url := utils.GetRemoteUrlRegisterPreVoteStreamingSession(c.config.BaseUrl, chainId)
+	return c.postForRegistration(ctx, url, "", "", bz)
+}
+
+// Resume resumes an existing streaming session, which becomes the current session of the client.
+func (c *Client) Resume(ctx context.Context, sessionId types.PreVoteStreamingSessionId, sessionKey types.PreVoteStreamingSessionKey) (*types.PreVoteStreamingSessionRegistrationResponse, error) {
+	if err := sessionId.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid session id: %v", err)
+	}
+	if err := sessionKey.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid session key: %v", err)
+	}
+
+	url := utils.GetRemoteUrlResumePreVoteStreamingSession(c.config.BaseUrl, string(sessionId))
+	return c.postForRegistration(ctx, url, sessionId, sessionKey, nil)
+}
+
+// BroadcastValidators replaces the validator set of the current session,
+// the streaming server drops the latest voting information which may refer validators no longer in the set.
+func (c *Client) BroadcastValidators(ctx context.Context, validators types.StreamingLightValidators) error {
+	sessionId, sessionKey := c.Session()
+	if sessionId == "" {
+		return ErrNoSession
+	}
+
+	bz, err := c.config.Codec.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		return err
+	}
+
+	url := utils.GetRemoteUrlResumePreVoteStreamingSession(c.config.BaseUrl, string(sessionId))
+	_, err = c.postForRegistration(ctx, url, sessionId, sessionKey, bz)
+	return err
+}
+
+// BroadcastVotingInfo broadcasts the next block voting information within the current session.
+func (c *Client) BroadcastVotingInfo(ctx context.Context, inf *types.StreamingNextBlockVotingInformation) error {
+	sessionId, sessionKey := c.Session()
+	if sessionId == "" {
+		return ErrNoSession
+	}
+
+	bz, err := c.config.Codec.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		return err
+	}
+
+	url := utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(c.config.BaseUrl, string(sessionId))
+	_, _, err = c.do(ctx, http.MethodPost, url, sessionId, sessionKey, bz)
+	return err
+}
+
+// Revoke revokes the current session, which is no longer usable by anyone, and clears the current session of the client.
+func (c *Client) Revoke(ctx context.Context) error {
+	sessionId, sessionKey := c.Session()
+	if sessionId == "" {
+		return ErrNoSession
+	}
+
+	url := utils.GetRemoteUrlRevokePreVoteStreamingSession(c.config.BaseUrl, string(sessionId))
+	if _, _, err := c.do(ctx, http.MethodPost, url, sessionId, sessionKey, nil); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.sessionId == sessionId {
+		c.sessionId = ""
+		c.sessionKey = ""
+	}
+	c.mu.Unlock()
+
+	return nil
+}
+
+// FetchUpdate fetches the latest next block voting information of any session, no session key is required.
+// Returns nil without error if nothing had been broadcast within the session.
+func (c *Client) FetchUpdate(ctx context.Context, sessionId types.PreVoteStreamingSessionId) (*types.StreamingNextBlockVotingInformation, error) {
+	if err := sessionId.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid session id: %v", err)
+	}
+
+	url := utils.GetUrlFetchPreVoteStreamingSessionUpdate(c.config.BaseUrl, string(sessionId))
+	statusCode, bz, err := c.do(ctx, http.MethodGet, url, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode == http.StatusNoContent || len(bz) == 0 {
+		return nil, nil
+	}
+
+	inf, err := c.config.Codec.DecodeStreamingNextBlockVotingInformation(bz)
+	if err != nil {
+		return nil, &ProtocolError{Url: url, Reason: "invalid next block voting information", Cause: err}
+	}
+	return inf, nil
+}
+
+// postForRegistration sends the request and decodes the registration response,
+// which becomes the current session of the client.
+func (c *Client) postForRegistration(ctx context.Context, url string, sessionId types.PreVoteStreamingSessionId, sessionKey types.PreVoteStreamingSessionKey, body []byte) (*types.PreVoteStreamingSessionRegistrationResponse, error) {
+	_, bz, err := c.do(ctx, http.MethodPost, url, sessionId, sessionKey, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var registration types.PreVoteStreamingSessionRegistrationResponse
+	if err := json.Unmarshal(bz, &registration); err != nil {
+		return nil, &ProtocolError{Url: url, Reason: "invalid registration response", Cause: err}
+	}
+	if err := registration.SessionId.ValidateBasic(); err != nil {
+		return nil, &ProtocolError{Url: url, Reason: "invalid session id", Cause: err}
+	}
+	if registration.SessionKey == "" && sessionKey != "" {
+		// resuming responds no session key, the session key does not change
+		registration.SessionKey = sessionKey
+	}
+	if err := registration.SessionKey.ValidateBasic(); err != nil {
+		return nil, &ProtocolError{Url: url, Reason: "invalid session key", Cause: err}
+	}
+
+	c.mu.Lock()
+	c.sessionId = registration.SessionId
+	c.sessionKey = registration.SessionKey
+	c.mu.Unlock()
+
+	return &registration, nil
+}
+
+// do sends the request, authenticated within the session if the session key is provided,
+// and returns the status code and the body of the 2xx response, other statuses are returned as HttpError.
+func (c *Client) do(ctx context.Context, method, url string, sessionId types.PreVoteStreamingSessionId, sessionKey types.PreVoteStreamingSessionKey, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", constants.STREAMING_CONTENT_TYPE)
+	}
+	if sessionKey != "" {
+		if err := c.authenticate(req.Header, method, req.URL.Path, sessionId, sessionKey, body); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	res, err := c.config.HttpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	bz, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBytes))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return 0, nil, &HttpError{
+			Method:     method,
+			Url:        url,
+			StatusCode: res.StatusCode,
+			Message:    strings.TrimSpace(string(bz)),
+		}
+	}
+
+	return res.StatusCode, bz, nil
+}
+
+// authenticate sets the headers authenticating the request of the method and the URL path within the session,
+// the signature headers signing the payload, or the raw session key header if Config.SendRawSessionKey.
+func (c *Client) authenticate(header http.Header, method, path string, sessionId types.PreVoteStreamingSessionId, sessionKey types.PreVoteStreamingSessionKey, payload []byte) error {
+	if c.config.SendRawSessionKey {
+		header.Set(constants.STREAMING_HEADER_SESSION_KEY, string(sessionKey))
+		return nil
+	}
+
+	sig, err := types.SignBroadcast(sessionId, sessionKey, method, path, payload, time.Now())
+	if err != nil {
+		return err
+	}
+	header.Set(constants.STREAMING_HEADER_SIGNATURE, sig.Signature)
+	header.Set(constants.STREAMING_HEADER_SIGNATURE_TIMESTAMP, sig.TimestampString())
+	header.Set(constants.STREAMING_HEADER_SIGNATURE_NONCE, sig.Nonce)
+	return nil
+}
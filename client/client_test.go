@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"github.com/bcdevtools/cvp-streaming-core/server"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testValidators = types.StreamingLightValidators{
+	{Index: 0, VotingPowerDisplayPercent: 60, Moniker: "Validator One"},
+	{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator Two"},
+}
+
+var testInformation = &types.StreamingNextBlockVotingInformation{
+	HeightRoundStep: types.MustParseHeightRoundStep("100/0/4"),
+	Duration:        time.Second,
+	PreVotedPercent: 60,
+	ValidatorVoteStates: []types.StreamingValidatorVoteState{
+		{
+			ValidatorIndex:    0,
+			PreVotedBlockHash: "C0FF",
+			PreVoted:          true,
+		},
+		{
+			ValidatorIndex:    1,
+			PreVotedBlockHash: "----",
+		},
+	},
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(server.NewServer(server.Config{}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(Config{BaseUrl: srv.URL})
+
+	err := c.BroadcastVotingInfo(ctx, testInformation)
+	require.ErrorIs(t, err, ErrNoSession)
+
+	registration, err := c.Register(ctx, "cosmoshub-4", testValidators)
+	require.NoError(t, err)
+	require.True(t, registration.SessionId.ForChainId("cosmoshub-4"))
+	sessionId, sessionKey := c.Session()
+	require.Equal(t, registration.SessionId, sessionId)
+	require.Equal(t, registration.SessionKey, sessionKey)
+
+	inf, err := c.FetchUpdate(ctx, sessionId)
+	require.NoError(t, err)
+	require.Nil(t, inf, "nothing had been broadcast")
+
+	require.NoError(t, c.BroadcastVotingInfo(ctx, testInformation))
+
+	viewer := NewClient(Config{BaseUrl: srv.URL})
+	inf, err = viewer.FetchUpdate(ctx, sessionId)
+	require.NoError(t, err)
+	require.Equal(t, testInformation, inf)
+
+	t.Run("resume", func(t *testing.T) {
+		resumed := NewClient(Config{BaseUrl: srv.URL})
+		registration, err := resumed.Resume(ctx, sessionId, sessionKey)
+		require.NoError(t, err)
+		require.Equal(t, sessionKey, registration.SessionKey, "the server does not respond the session key when resuming")
+		require.NoError(t, resumed.BroadcastVotingInfo(ctx, testInformation))
+	})
+
+	t.Run("broadcast validators", func(t *testing.T) {
+		require.NoError(t, c.BroadcastValidators(ctx, testValidators[:1]))
+
+		err := c.BroadcastVotingInfo(ctx, testInformation)
+		var httpErr *HttpError
+		require.True(t, errors.As(err, &httpErr))
+		require.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+		require.Contains(t, httpErr.Message, "unknown validator index 1")
+	})
+
+	t.Run("resume with wrong session key", func(t *testing.T) {
+		_, err := NewClient(Config{BaseUrl: srv.URL}).Resume(ctx, sessionId, "0000000000000000000000000000000000000000000000000000000000000000")
+		require.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("fetch update of unknown session", func(t *testing.T) {
+		_, err := viewer.FetchUpdate(ctx, "cosmoshub-4_0000000000000000000000000000000000000000000000000000000000000000")
+		require.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("not encodable", func(t *testing.T) {
+		err := c.BroadcastVotingInfo(ctx, &types.StreamingNextBlockVotingInformation{})
+		require.Error(t, err)
+	})
+}
+
+func TestClient_Lifecycle(t *testing.T) {
+	srv := httptest.NewServer(server.NewServer(server.Config{MaxResumeCount: 1}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(Config{BaseUrl: srv.URL})
+
+	require.ErrorIs(t, c.Revoke(ctx), ErrNoSession)
+
+	registration, err := c.Register(ctx, "cosmoshub-4", testValidators)
+	require.NoError(t, err)
+
+	resumed := NewClient(Config{BaseUrl: srv.URL})
+	_, err = resumed.Resume(ctx, registration.SessionId, registration.SessionKey)
+	require.NoError(t, err)
+	_, err = resumed.Resume(ctx, registration.SessionId, registration.SessionKey)
+	require.ErrorIs(t, err, types.ErrPreVoteStreamingSessionResumeLimitReached)
+
+	require.NoError(t, c.Revoke(ctx))
+	sessionId, _ := c.Session()
+	require.Empty(t, sessionId, "revoked session must be cleared")
+
+	require.ErrorIs(t, resumed.BroadcastVotingInfo(ctx, testInformation), types.ErrPreVoteStreamingSessionRevoked)
+	_, err = resumed.DialBroadcaster(ctx)
+	require.ErrorIs(t, err, types.ErrPreVoteStreamingSessionRevoked)
+	_, err = NewClient(Config{BaseUrl: srv.URL}).FetchUpdate(ctx, registration.SessionId)
+	require.ErrorIs(t, err, types.ErrPreVoteStreamingSessionRevoked)
+}
+
+func TestClient_ProtocolError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"session-id":"invalid","session-key":"invalid"}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(Config{BaseUrl: srv.URL})
+
+	_, err := c.Register(ctx, "cosmoshub-4", testValidators)
+	var protocolErr *ProtocolError
+	require.True(t, errors.As(err, &protocolErr))
+	require.Equal(t, "invalid session id", protocolErr.Reason)
+	sessionId, _ := c.Session()
+	require.Empty(t, sessionId, "session must not be kept")
+
+	_, err = c.FetchUpdate(ctx, "cosmoshub-4_0000000000000000000000000000000000000000000000000000000000000000")
+	require.True(t, errors.As(err, &protocolErr))
+	require.Equal(t, "invalid next block voting information", protocolErr.Reason)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"net/http"
+)
+
+var (
+	// ErrUnauthorized is matched by HttpError of status 401, the session key is invalid.
+	ErrUnauthorized = fmt.Errorf("unauthorized")
+
+	// ErrSessionNotFound is matched by HttpError of status 404, the session does not exist or had been expired.
+	ErrSessionNotFound = fmt.Errorf("session not found")
+
+	// ErrNoSession is returned when broadcasting before registering or resuming a session.
+	ErrNoSession = fmt.Errorf("no session, register or resume a session first")
+)
+
+// HttpError is returned when the streaming server responds an unexpected HTTP status.
+type HttpError struct {
+	Method     string
+	Url        string
+	StatusCode int
+	// Message is the response body, trimmed.
+	Message string
+}
+
+func (e *HttpError) Error() string {
+	return fmt.Sprintf("%s %s: HTTP %d %s: %s", e.Method, e.Url, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is allows matching the HttpError with ErrUnauthorized, ErrSessionNotFound,
+// types.ErrPreVoteStreamingSessionRevoked (status 410) and types.ErrPreVoteStreamingSessionResumeLimitReached
+// (status 403) using errors.Is.
+func (e *HttpError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrSessionNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case types.ErrPreVoteStreamingSessionRevoked:
+		return e.StatusCode == http.StatusGone
+	case types.ErrPreVoteStreamingSessionResumeLimitReached:
+		return e.StatusCode == http.StatusForbidden
+	default:
+		return false
+	}
+}
+
+// ProtocolError is returned when the streaming server responds successfully but the response is invalid.
+type ProtocolError struct {
+	Url    string
+	Reason string
+	Cause  error // optional
+}
+
+func (e *ProtocolError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Url, e.Reason, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Url, e.Reason)
+}
+
+func (e *ProtocolError) Unwrap() error {
+	return e.Cause
+}
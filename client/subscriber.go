@@ -0,0 +1,123 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"github.com/bcdevtools/cvp-streaming-core/constants"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxEventLineBytes limits the size of a line of the Server-Sent Events stream.
+const maxEventLineBytes = 1 << 20
+
+// Subscribe subscribes the Server-Sent Events of the session and invokes the callback with each pushed
+// next block voting information, decoded by the codec of the client, no session key is required.
+//
+// It blocks until the context is done, the stream is closed by the server or the callback returns an error.
+// Returns nil when the context is done or the server closed the stream, otherwise the error.
+func (c *Client) Subscribe(ctx context.Context, sessionId types.PreVoteStreamingSessionId, onUpdate func(inf *types.StreamingNextBlockVotingInformation) error) error {
+	return c.subscribe(ctx, sessionId, func(url string, bz []byte) error {
+		inf, err := c.config.Codec.DecodeStreamingNextBlockVotingInformation(bz)
+		if err != nil {
+			return &ProtocolError{Url: url, Reason: "invalid next block voting information", Cause: err}
+		}
+		return onUpdate(inf)
+	})
+}
+
+// SubscribeEncoded is Subscribe without decoding, the callback receives each pushed encoded next block voting information
+// as is, like for recording what viewers received.
+func (c *Client) SubscribeEncoded(ctx context.Context, sessionId types.PreVoteStreamingSessionId, onUpdate func(encoded []byte) error) error {
+	return c.subscribe(ctx, sessionId, func(_ string, bz []byte) error {
+		return onUpdate(bz)
+	})
+}
+
+func (c *Client) subscribe(ctx context.Context, sessionId types.PreVoteStreamingSessionId, onFrame func(url string, bz []byte) error) error {
+	url := utils.GetUrlSubscribePreVoteStreamingSessionUpdates(c.config.BaseUrl, string(sessionId))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", constants.STREAMING_CONTENT_TYPE_EVENT_STREAM)
+
+	// the stream lives as long as the context, the timeout of the configured client does not apply
+	streamingHttpClient := *c.config.HttpClient
+	streamingHttpClient.Timeout = 0
+
+	res, err := streamingHttpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		bz, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBytes))
+		return &HttpError{
+			Method:     http.MethodGet,
+			Url:        url,
+			StatusCode: res.StatusCode,
+			Message:    strings.TrimSpace(string(bz)),
+		}
+	}
+	if contentType := res.Header.Get("Content-Type"); !strings.HasPrefix(contentType, constants.STREAMING_CONTENT_TYPE_EVENT_STREAM) {
+		return &ProtocolError{Url: url, Reason: "unexpected content type " + contentType}
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventLineBytes)
+
+	var event, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			// blank line dispatches the event
+			if event == constants.STREAMING_EVENT_UPDATE && data != "" {
+				if err := dispatchUpdate(url, data, onFrame); err != nil {
+					return err
+				}
+			}
+			event, data = "", ""
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			// comment, like keep-alive
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data += value
+		}
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+func dispatchUpdate(url, data string, onFrame func(url string, bz []byte) error) error {
+	bz, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return &ProtocolError{Url: url, Reason: "invalid base64 frame", Cause: err}
+	}
+	return onFrame(url, bz)
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"github.com/bcdevtools/cvp-streaming-core/server"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Subscribe(t *testing.T) {
+	srv := httptest.NewServer(server.NewServer(server.Config{}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	broadcaster := NewClient(Config{BaseUrl: srv.URL})
+	registration, err := broadcaster.Register(ctx, "cosmoshub-4", testValidators)
+	require.NoError(t, err)
+	require.NoError(t, broadcaster.BroadcastVotingInfo(ctx, testInformation))
+
+	subscribeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	updates := make(chan *types.StreamingNextBlockVotingInformation)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewClient(Config{BaseUrl: srv.URL}).Subscribe(subscribeCtx, registration.SessionId, func(inf *types.StreamingNextBlockVotingInformation) error {
+			updates <- inf
+			return nil
+		})
+	}()
+
+	receive := func() *types.StreamingNextBlockVotingInformation {
+		select {
+		case inf := <-updates:
+			return inf
+		case err := <-done:
+			t.Fatalf("subscription ended unexpectedly: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for update")
+		}
+		return nil
+	}
+
+	require.Equal(t, testInformation, receive(), "latest frame must be pushed on subscribing")
+
+	nextInformation := *testInformation
+	nextInformation.HeightRoundStep = types.MustParseHeightRoundStep("100/0/6")
+	nextInformation.PreCommitVotedPercent = 60
+	require.NoError(t, broadcaster.BroadcastVotingInfo(ctx, &nextInformation))
+	require.Equal(t, &nextInformation, receive())
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription did not end after cancellation")
+	}
+}
+
+func TestClient_Subscribe_Errors(t *testing.T) {
+	ctx := context.Background()
+	onUpdate := func(*types.StreamingNextBlockVotingInformation) error {
+		return nil
+	}
+
+	t.Run("unknown session", func(t *testing.T) {
+		srv := httptest.NewServer(server.NewServer(server.Config{}))
+		defer srv.Close()
+
+		err := NewClient(Config{BaseUrl: srv.URL}).Subscribe(ctx, "cosmoshub-4_0000000000000000000000000000000000000000000000000000000000000000", onUpdate)
+		require.ErrorIs(t, err, ErrSessionNotFound)
+	})
+
+	t.Run("not an event stream", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("{}"))
+		}))
+		defer srv.Close()
+
+		err := NewClient(Config{BaseUrl: srv.URL}).Subscribe(ctx, "cosmoshub-4_0000000000000000000000000000000000000000000000000000000000000000", onUpdate)
+		var protocolErr *ProtocolError
+		require.True(t, errors.As(err, &protocolErr))
+	})
+
+	t.Run("invalid frame", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte(": keep-alive\n\nevent: update\ndata: aW52YWxpZA==\n\n"))
+		}))
+		defer srv.Close()
+
+		err := NewClient(Config{BaseUrl: srv.URL}).Subscribe(ctx, "cosmoshub-4_0000000000000000000000000000000000000000000000000000000000000000", onUpdate)
+		var protocolErr *ProtocolError
+		require.True(t, errors.As(err, &protocolErr))
+		require.Equal(t, "invalid next block voting information", protocolErr.Reason)
+	})
+
+	t.Run("callback error stops the subscription", func(t *testing.T) {
+		srv := httptest.NewServer(server.NewServer(server.Config{}))
+		defer srv.Close()
+
+		broadcaster := NewClient(Config{BaseUrl: srv.URL})
+		registration, err := broadcaster.Register(ctx, "cosmoshub-4", testValidators)
+		require.NoError(t, err)
+		require.NoError(t, broadcaster.BroadcastVotingInfo(ctx, testInformation))
+
+		wantErr := errors.New("stop")
+		err = NewClient(Config{BaseUrl: srv.URL}).Subscribe(ctx, registration.SessionId, func(*types.StreamingNextBlockVotingInformation) error {
+			return wantErr
+		})
+		require.ErrorIs(t, err, wantErr)
+	})
+}
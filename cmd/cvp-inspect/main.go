@@ -0,0 +1,122 @@
+// Command cvp-inspect inspects encoded payloads of the pre-vote streaming protocol, for debugging failed broadcasts.
+//
+// It reads raw, hex or base64 payloads from the files, or stdin if none or "-", and prints the detected encoding version,
+// the decoded light validators or next block voting information, the byte-level breakdown of the fields with their offsets,
+// and the exact decode error if the payload can not be decoded.
+//
+// Usage:
+//
+//	cvp-inspect [-input auto|raw|hex|base64] [-kind auto|light-validators|next-block-voting-info] [-output table|json] [file ...]
+//
+// Exit code is 1 if any payload can not be decoded, 2 on invalid usage or input.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/internal/cliinput"
+	"io"
+	"os"
+)
+
+const (
+	exitOk            = 0
+	exitDecodeFailure = 1
+	exitInvalidUsage  = 2
+)
+
+const (
+	kindAuto = "auto"
+
+	outputTable = "table"
+	outputJson  = "json"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cvp-inspect", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	inputFormat := flags.String("input", cliinput.FormatAuto, "input format: auto, raw, hex or base64")
+	kind := flags.String("kind", kindAuto, fmt.Sprintf("payload kind: auto, %s or %s", codec.FrameKindLightValidators, codec.FrameKindNextBlockVotingInformation))
+	output := flags.String("output", outputTable, "output format: table or json")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(stderr, "Usage: cvp-inspect [flags] [file ...]")
+		_, _ = fmt.Fprintln(stderr, "Inspects encoded payloads read from the files, or stdin if none or \"-\".")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitInvalidUsage
+	}
+
+	usageError := func(format string, args ...any) int {
+		_, _ = fmt.Fprintf(stderr, "cvp-inspect: "+format+"\n", args...)
+		return exitInvalidUsage
+	}
+	if err := cliinput.ValidateFormat(*inputFormat); err != nil {
+		return usageError("%v", err)
+	}
+	switch *kind {
+	case kindAuto, codec.FrameKindLightValidators.String(), codec.FrameKindNextBlockVotingInformation.String():
+	default:
+		return usageError("invalid kind %q", *kind)
+	}
+	if *output != outputTable && *output != outputJson {
+		return usageError("invalid output format %q", *output)
+	}
+
+	sources := flags.Args()
+	if len(sources) == 0 {
+		sources = []string{"-"}
+	}
+
+	exitCode := exitOk
+	for i, source := range sources {
+		input, err := cliinput.ReadSource(source, stdin)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		payload, format, err := cliinput.Decode(input, *inputFormat)
+		if err != nil {
+			return usageError("%s: %v", source, err)
+		}
+
+		r := inspectPayload(source, format, payload, *kind)
+		if r.Error != nil {
+			exitCode = exitDecodeFailure
+		}
+
+		if *output == outputJson {
+			err = r.writeJson(stdout)
+		} else {
+			if i > 0 {
+				_, _ = fmt.Fprintln(stdout)
+			}
+			err = r.writeTable(stdout)
+		}
+		if err != nil {
+			return usageError("failed to write output: %v", err)
+		}
+	}
+	return exitCode
+}
+
+// hexBytes returns the space separated hex of the bytes, truncated to the max bytes.
+func hexBytes(bz []byte, max int) string {
+	var b bytes.Buffer
+	for i, c := range bz {
+		if i == max {
+			b.WriteString(" ...")
+			break
+		}
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		_, _ = fmt.Fprintf(&b, "%02x", c)
+	}
+	return b.String()
+}
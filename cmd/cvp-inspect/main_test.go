@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/internal/cliinput"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testValidators = types.StreamingLightValidators{
+	{Index: 0, VotingPowerDisplayPercent: 60, Moniker: "Validator One"},
+	{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator Two"},
+}
+
+var testInformation = &types.StreamingNextBlockVotingInformation{
+	HeightRoundStep: types.MustParseHeightRoundStep("100/0/4"),
+	Duration:        3 * time.Second,
+	PreVotedPercent: 60,
+	ValidatorVoteStates: []types.StreamingValidatorVoteState{
+		{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true},
+		{ValidatorIndex: 1, PreVotedBlockHash: "----"},
+	},
+}
+
+func runInspect(stdin []byte, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	exitCode := run(args, bytes.NewReader(stdin), &stdout, &stderr)
+	return exitCode, stdout.String(), stderr.String()
+}
+
+func runInspectJson(t *testing.T, stdin []byte, args ...string) (int, report) {
+	exitCode, stdout, stderr := runInspect(stdin, append([]string{"-output", "json"}, args...)...)
+	require.Empty(t, stderr)
+
+	var r report
+	require.NoError(t, json.Unmarshal([]byte(stdout), &r))
+	return exitCode, r
+}
+
+func TestRun_Decode(t *testing.T) {
+	for _, version := range []codec.CvpCodecVersion{codec.CvpCodecVersionV1, codec.CvpCodecVersionV2, codec.CvpCodecVersionV3} {
+		cvpCodec, found := codec.GetRegisteredCvpCodec(version)
+		require.True(t, found)
+		validators := cvpCodec.EncodeStreamingLightValidators(testValidators)
+		information := cvpCodec.EncodeStreamingNextBlockVotingInformation(testInformation)
+
+		tests := []struct {
+			name            string
+			stdin           []byte
+			args            []string
+			wantInputFormat string
+			wantKind        codec.FrameKind
+		}{
+			{
+				name:            "raw light validators",
+				stdin:           validators,
+				wantInputFormat: cliinput.FormatRaw,
+				wantKind:        codec.FrameKindLightValidators,
+			},
+			{
+				name:            "hex next block voting information",
+				stdin:           []byte(hex.EncodeToString(information) + "\n"),
+				wantInputFormat: cliinput.FormatHex,
+				wantKind:        codec.FrameKindNextBlockVotingInformation,
+			},
+			{
+				name:            "base64 next block voting information",
+				stdin:           []byte(base64.StdEncoding.EncodeToString(information) + "\n"),
+				wantInputFormat: cliinput.FormatBase64,
+				wantKind:        codec.FrameKindNextBlockVotingInformation,
+			},
+			{
+				name:            "explicit input format and kind",
+				stdin:           []byte(base64.StdEncoding.EncodeToString(validators)),
+				args:            []string{"-input", "base64", "-kind", "light-validators"},
+				wantInputFormat: cliinput.FormatBase64,
+				wantKind:        codec.FrameKindLightValidators,
+			},
+		}
+		for _, tt := range tests {
+			t.Run(string(version)+" "+tt.name, func(t *testing.T) {
+				exitCode, r := runInspectJson(t, tt.stdin, tt.args...)
+				require.Equal(t, exitOk, exitCode)
+				require.Nil(t, r.Error)
+				require.Equal(t, tt.wantInputFormat, r.InputFormat)
+				require.Equal(t, version, r.DetectedVersion)
+				require.Equal(t, tt.wantKind.String(), r.Kind)
+				require.False(t, r.KindGuessed)
+				require.NotNil(t, r.Breakdown)
+				require.Empty(t, r.BreakdownError)
+
+				if tt.wantKind == codec.FrameKindLightValidators {
+					require.Equal(t, testValidators, r.LightValidators)
+					require.Nil(t, r.NextBlockVotingInformation)
+				} else {
+					require.Equal(t, testInformation, r.NextBlockVotingInformation)
+					require.Nil(t, r.LightValidators)
+				}
+			})
+		}
+	}
+}
+
+func TestRun_DecodeError(t *testing.T) {
+	bz := codec.GetCvpCodecV2().EncodeStreamingNextBlockVotingInformation(testInformation)
+	bz[len(bz)-1] = 'Z' // invalid vote flag of the last validator
+
+	exitCode, r := runInspectJson(t, bz, "-kind", "next-block-voting-info")
+	require.Equal(t, exitDecodeFailure, exitCode)
+	require.NotNil(t, r.Error)
+	require.Equal(t, "invalid validator vote flag: Z", r.Error.Message)
+	require.Equal(t, codec.ErrInvalidField.Error(), r.Error.Kind)
+	require.Equal(t, "VoteFlag", r.Error.Field)
+	require.NotNil(t, r.Error.Offset)
+	require.Equal(t, len(bz)-1, *r.Error.Offset)
+
+	// the breakdown points the same offset
+	last := r.Breakdown.Fields[len(r.Breakdown.Fields)-1]
+	require.Equal(t, "ValidatorVoteStates[1].VoteFlag", last.Name)
+	require.Equal(t, *r.Error.Offset, last.Offset)
+	require.Equal(t, `invalid 'Z'`, last.Value)
+
+	t.Run("kind is guessed", func(t *testing.T) {
+		exitCode, r := runInspectJson(t, bz)
+		require.Equal(t, exitDecodeFailure, exitCode)
+		require.True(t, r.KindGuessed)
+		require.Equal(t, codec.FrameKindNextBlockVotingInformation.String(), r.Kind)
+		require.Equal(t, "VoteFlag", r.Error.Field)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		exitCode, r := runInspectJson(t, []byte("garbage"))
+		require.Equal(t, exitDecodeFailure, exitCode)
+		require.Equal(t, codec.CvpCodecVersionUnknown, r.DetectedVersion)
+		require.Equal(t, codec.ErrBadPrefix.Error(), r.Error.Kind)
+		require.NotEmpty(t, r.BreakdownError)
+	})
+}
+
+func TestRun_Table(t *testing.T) {
+	dir := t.TempDir()
+	validatorsPath := filepath.Join(dir, "validators.bin")
+	require.NoError(t, os.WriteFile(validatorsPath, codec.GetCvpCodecV3().EncodeStreamingLightValidators(testValidators), 0o600))
+	informationPath := filepath.Join(dir, "information.hex")
+	require.NoError(t, os.WriteFile(informationPath, []byte(hex.EncodeToString(codec.GetCvpCodecV1().EncodeStreamingNextBlockVotingInformation(testInformation))), 0o600))
+
+	exitCode, stdout, stderr := runInspect(nil, validatorsPath, informationPath)
+	require.Equal(t, exitOk, exitCode)
+	require.Empty(t, stderr)
+
+	for _, want := range []string{
+		"source:   " + validatorsPath,
+		"version:  v3",
+		"Validator One",
+		"breakdown of the decompressed payload (v2):",
+		"Validators[1].Moniker",
+		"source:   " + informationPath,
+		"input:    hex",
+		"ValidatorVoteStates[0].VoteFlag",
+		"V (pre-voted)",
+	} {
+		require.Contains(t, stdout, want)
+	}
+	require.False(t, strings.Contains(stdout, "error:"))
+}
+
+func TestRun_InvalidUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      []byte
+		wantStderr string
+	}{
+		{
+			name:       "unknown flag",
+			args:       []string{"-unknown"},
+			wantStderr: "flag provided but not defined",
+		},
+		{
+			name:       "invalid kind",
+			args:       []string{"-kind", "votes"},
+			wantStderr: `invalid kind "votes"`,
+		},
+		{
+			name:       "invalid hex",
+			args:       []string{"-input", "hex"},
+			stdin:      []byte("xyz"),
+			wantStderr: "invalid hex",
+		},
+		{
+			name:       "missing file",
+			args:       []string{filepath.Join(t.TempDir(), "missing")},
+			wantStderr: "no such file",
+		},
+		{
+			name:       "empty input",
+			wantStderr: "empty payload",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exitCode, _, stderr := runInspect(tt.stdin, tt.args...)
+			require.Equal(t, exitInvalidUsage, exitCode)
+			require.Contains(t, stderr, tt.wantStderr)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"io"
+	"text/tabwriter"
+)
+
+// maxBreakdownHexBytes limits the bytes of a field printed by the table output.
+const maxBreakdownHexBytes = 8
+
+// report is the result of inspecting a payload.
+type report struct {
+	Source          string                `json:"source"`
+	InputFormat     string                `json:"input-format"`
+	Size            int                   `json:"size"`
+	DetectedVersion codec.CvpCodecVersion `json:"detected-version"`
+	Kind            string                `json:"kind"`
+
+	// KindGuessed is true if the kind is not specified and the payload can not be decoded as any kind,
+	// the kind which decoded further is reported.
+	KindGuessed bool `json:"kind-guessed,omitempty"`
+
+	LightValidators            types.StreamingLightValidators             `json:"light-validators,omitempty"`
+	NextBlockVotingInformation *types.StreamingNextBlockVotingInformation `json:"next-block-voting-info,omitempty"`
+
+	Breakdown      *codec.Inspection `json:"breakdown,omitempty"`
+	BreakdownError string            `json:"breakdown-error,omitempty"`
+
+	Error *errorReport `json:"error,omitempty"`
+}
+
+// errorReport is the decode error, with the details of codec.DecodeError if any.
+type errorReport struct {
+	Message string                `json:"message"`
+	Kind    string                `json:"kind,omitempty"`
+	Version codec.CvpCodecVersion `json:"version,omitempty"`
+	Field   string                `json:"field,omitempty"`
+	Offset  *int                  `json:"offset,omitempty"`
+}
+
+func newErrorReport(err error) *errorReport {
+	errReport := &errorReport{
+		Message: err.Error(),
+	}
+	var decodeErr *codec.DecodeError
+	if errors.As(err, &decodeErr) {
+		errReport.Kind = decodeErr.Kind.Error()
+		errReport.Version = decodeErr.Version
+		errReport.Field = decodeErr.Field
+		errReport.Offset = &decodeErr.Offset
+	}
+	return errReport
+}
+
+// inspectPayload decodes the payload of the kind using the proxy codec, which decodes all the registered versions,
+// and breaks it down. The auto kind picks the kind which decodes the payload.
+func inspectPayload(source, inputFormat string, payload []byte, kind string) *report {
+	r := &report{
+		Source:      source,
+		InputFormat: inputFormat,
+		Size:        len(payload),
+	}
+	r.DetectedVersion, _ = codec.DetectEncodingVersion(payload)
+
+	cvpCodec := codec.NewProxyCvpCodec()
+	var validatorsErr, informationErr error
+	if kind != codec.FrameKindNextBlockVotingInformation.String() {
+		r.LightValidators, validatorsErr = cvpCodec.DecodeStreamingLightValidators(payload)
+	}
+	if kind != codec.FrameKindLightValidators.String() {
+		r.NextBlockVotingInformation, informationErr = cvpCodec.DecodeStreamingNextBlockVotingInformation(payload)
+	}
+
+	if kind == kindAuto {
+		switch {
+		case informationErr == nil:
+			kind, r.LightValidators = codec.FrameKindNextBlockVotingInformation.String(), nil
+		case validatorsErr == nil:
+			kind = codec.FrameKindLightValidators.String()
+		default:
+			r.KindGuessed = true
+			kind = codec.FrameKindNextBlockVotingInformation.String()
+			if decodeErrorOffset(validatorsErr) > decodeErrorOffset(informationErr) {
+				kind = codec.FrameKindLightValidators.String()
+			}
+		}
+	}
+	r.Kind = kind
+
+	inspect := codec.InspectStreamingNextBlockVotingInformation
+	err := informationErr
+	if kind == codec.FrameKindLightValidators.String() {
+		inspect = codec.InspectStreamingLightValidators
+		err = validatorsErr
+	}
+	if err != nil {
+		r.Error = newErrorReport(err)
+	}
+
+	if inspection, err := inspect(payload); err != nil {
+		r.BreakdownError = err.Error()
+	} else {
+		r.Breakdown = inspection
+	}
+
+	return r
+}
+
+// decodeErrorOffset returns the offset of the DecodeError, -1 if not a DecodeError.
+func decodeErrorOffset(err error) int {
+	var decodeErr *codec.DecodeError
+	if errors.As(err, &decodeErr) {
+		return decodeErr.Offset
+	}
+	return -1
+}
+
+func (r *report) writeJson(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *report) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	printf := func(format string, args ...any) {
+		_, _ = fmt.Fprintf(tw, format, args...)
+	}
+
+	printf("source:\t%s\n", r.Source)
+	printf("input:\t%s, %d bytes\n", r.InputFormat, r.Size)
+	printf("version:\t%s\n", r.DetectedVersion)
+	if r.KindGuessed {
+		printf("kind:\t%s (guessed, use -kind to choose)\n", r.Kind)
+	} else {
+		printf("kind:\t%s\n", r.Kind)
+	}
+
+	if r.Error != nil {
+		printf("error:\t%s\n", r.Error.Message)
+		if r.Error.Offset != nil {
+			printf("\t%s of %s at offset %d, version %s\n", r.Error.Kind, r.Error.Field, *r.Error.Offset, r.Error.Version)
+		}
+	}
+
+	if r.LightValidators != nil {
+		printf("\ndecoded:\n")
+		printf("  INDEX\tVOTING POWER %%\tMONIKER\n")
+		for _, v := range r.LightValidators {
+			printf("  %d\t%.2f\t%s\n", v.Index, v.VotingPowerDisplayPercent, v.Moniker)
+		}
+	}
+	if inf := r.NextBlockVotingInformation; inf != nil {
+		printf("\ndecoded:\n")
+		printf("  height/round/step:\t%s\n", inf.HeightRoundStep)
+		printf("  duration:\t%s\n", inf.Duration)
+		printf("  pre-voted:\t%.2f%%\n", inf.PreVotedPercent)
+		printf("  pre-commit voted:\t%.2f%%\n", inf.PreCommitVotedPercent)
+		printf("\n  VALIDATOR\tPRE-VOTED BLOCK HASH\tPRE-VOTED\tVOTED ZEROES\tPRE-COMMIT VOTED\n")
+		for _, state := range inf.ValidatorVoteStates {
+			printf("  %d\t%s\t%t\t%t\t%t\n", state.ValidatorIndex, state.PreVotedBlockHash, state.PreVoted, state.VotedZeroes, state.PreCommitVoted)
+		}
+	}
+
+	if r.BreakdownError != "" {
+		printf("\nbreakdown:\t%s\n", r.BreakdownError)
+	}
+	title := "breakdown"
+	for inspection := r.Breakdown; inspection != nil; inspection = inspection.Inner {
+		printf("\n%s (%s):\n", title, inspection.Version)
+		printf("  OFFSET\tLENGTH\tFIELD\tBYTES\tVALUE\n")
+		for _, field := range inspection.Fields {
+			value := field.Value
+			if field.Malformed {
+				value = "MALFORMED: " + value
+			}
+			printf("  %d\t%d\t%s\t%s\t%s\n", field.Offset, field.Length, field.Name, hexBytes(field.Bytes, maxBreakdownHexBytes), value)
+		}
+		title = "breakdown of the decompressed payload"
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/internal/cliinput"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"io"
+	"strings"
+)
+
+// frame is a light validators or next block voting information read from the input.
+type frame struct {
+	source string
+
+	// format is the codec version of the decoded payload, or json.
+	format string
+	size   int
+
+	kind        codec.FrameKind
+	validators  types.StreamingLightValidators
+	information *types.StreamingNextBlockVotingInformation
+
+	// err is the error of decoding the payload, or unmarshalling the JSON value.
+	err error
+}
+
+// conversion is the result of converting a frame to the target.
+type conversion struct {
+	target string
+	bz     []byte
+
+	// changes are the fields of the frame altered by the conversion, empty if lossless.
+	changes []codec.FieldChange
+}
+
+// readFrames reads the frames of the input. The auto input format reads JSON if the input starts with a JSON array or object,
+// otherwise the hex, base64 or raw payloads.
+func readFrames(input []byte, inputFormat, kind string) ([]*frame, error) {
+	if inputFormat == formatJson || (inputFormat == cliinput.FormatAuto && isJson(input)) {
+		return readJsonFrames(input, kind)
+	}
+
+	payloads, _, err := cliinput.DecodeLines(input, inputFormat)
+	if err != nil {
+		return nil, err
+	}
+	frames := make([]*frame, len(payloads))
+	for i, payload := range payloads {
+		frames[i] = decodeFrame(payload, kind)
+	}
+	return frames, nil
+}
+
+func isJson(input []byte) bool {
+	trimmed := bytes.TrimSpace(input)
+	return len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{')
+}
+
+// decodeFrame decodes the payload of the kind using the proxy codec, which decodes all the registered versions.
+// The auto kind prefers the next block voting information, the same as cvp-inspect.
+func decodeFrame(payload []byte, kind string) *frame {
+	f := &frame{
+		size: len(payload),
+	}
+	version, _ := codec.DetectEncodingVersion(payload)
+	f.format = string(version)
+
+	cvpCodec := codec.NewProxyCvpCodec()
+	var informationErr error
+	if kind != codec.FrameKindLightValidators.String() {
+		if f.information, informationErr = cvpCodec.DecodeStreamingNextBlockVotingInformation(payload); informationErr == nil {
+			f.kind = codec.FrameKindNextBlockVotingInformation
+			return f
+		}
+		if kind != kindAuto {
+			f.err = informationErr
+			return f
+		}
+	}
+
+	validators, err := cvpCodec.DecodeStreamingLightValidators(payload)
+	switch {
+	case err == nil:
+		f.kind, f.validators = codec.FrameKindLightValidators, validators
+	case kind == kindAuto:
+		f.err = fmt.Errorf("neither %s (%v) nor %s (%v)", codec.FrameKindNextBlockVotingInformation, informationErr, codec.FrameKindLightValidators, err)
+	default:
+		f.err = err
+	}
+	return f
+}
+
+// readJsonFrames reads the stream of JSON values, an array is the light validators and an object is the next block voting information.
+// Unknown fields are rejected, so typos do not silently produce zero values.
+func readJsonFrames(input []byte, kind string) ([]*frame, error) {
+	var frames []*frame
+	decoder := json.NewDecoder(bytes.NewReader(input))
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		f := &frame{
+			format: formatJson,
+			size:   compacted.Len(),
+		}
+		frames = append(frames, f)
+
+		switch raw[0] {
+		case '[':
+			f.kind = codec.FrameKindLightValidators
+			f.err = unmarshalStrict(raw, &f.validators)
+		case '{':
+			f.kind = codec.FrameKindNextBlockVotingInformation
+			f.err = unmarshalStrict(raw, &f.information)
+		default:
+			f.err = fmt.Errorf("JSON value must be an array of light validators or an object of next block voting information")
+			continue
+		}
+		if f.err == nil && kind != kindAuto && kind != f.kind.String() {
+			f.err = fmt.Errorf("JSON value is %s, expected %s", f.kind, kind)
+		}
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("empty payload")
+	}
+	return frames, nil
+}
+
+func unmarshalStrict(raw []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func (f *frame) value() any {
+	if f.kind == codec.FrameKindLightValidators {
+		return f.validators
+	}
+	return f.information
+}
+
+// toJson marshals the frame to JSON, which is lossless.
+func (f *frame) toJson() (*conversion, error) {
+	bz, err := json.Marshal(f.value())
+	if err != nil {
+		return nil, err
+	}
+	return &conversion{
+		target: formatJson,
+		bz:     bz,
+	}, nil
+}
+
+// encode encodes the frame by the codec, the fields altered by the round trip through the codec make the conversion lossy.
+func (f *frame) encode(cvpCodec codec.CvpCodec) (*conversion, error) {
+	c := &conversion{
+		target: string(cvpCodec.GetVersion()),
+	}
+
+	var err error
+	if f.kind == codec.FrameKindLightValidators {
+		if _, c.changes, err = codec.RoundTrip(cvpCodec, f.validators); err == nil {
+			c.bz = cvpCodec.EncodeStreamingLightValidators(f.validators)
+		}
+	} else {
+		if _, c.changes, err = codec.RoundTrip(cvpCodec, f.information); err == nil {
+			c.bz = cvpCodec.EncodeStreamingNextBlockVotingInformation(f.information)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// lossy describes the fields altered by the conversion, empty if lossless.
+func (c *conversion) lossy() string {
+	descriptions := make([]string, len(c.changes))
+	for i, change := range c.changes {
+		descriptions[i] = change.String()
+	}
+	return strings.Join(descriptions, "; ")
+}
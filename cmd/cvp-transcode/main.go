@@ -0,0 +1,212 @@
+// Command cvp-transcode converts payloads of the pre-vote streaming protocol between codec versions and JSON,
+// for migrating archived payloads to a newer codec version and generating fixtures.
+//
+// It reads raw, hex or base64 payloads, decoded by the proxy codec which decodes all the registered versions,
+// or JSON values of the light validators and next block voting information as defined by the struct tags in types,
+// from the files, or stdin if none or "-". Hex and base64 text contains a payload per line, JSON contains any number of values.
+//
+// Each frame is encoded by the target codec version, or marshalled to a JSON line, and the size of each frame
+// before and after the conversion is reported to stderr. A conversion to a codec version is lossy if the encoded frame
+// does not decode back to the same value, see codec.RoundTrip, it fails unless -allow-lossy is set.
+//
+// Usage:
+//
+//	cvp-transcode -to <version>|json [-input auto|raw|hex|base64|json] [-kind auto|light-validators|next-block-voting-info] [-output hex|base64|raw] [-allow-lossy] [file ...]
+//
+// Exit code is 1 if any frame can not be decoded, encoded or is converted lossy, 2 on invalid usage or input.
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/internal/cliinput"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	exitOk               = 0
+	exitTranscodeFailure = 1
+	exitInvalidUsage     = 2
+)
+
+const (
+	// formatJson is the input format and the target of JSON values.
+	formatJson = "json"
+
+	kindAuto = "auto"
+
+	outputHex    = "hex"
+	outputBase64 = "base64"
+	outputRaw    = "raw"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("cvp-transcode", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	to := flags.String("to", "", fmt.Sprintf("target: json or one of the codec versions %s", joinVersions(codec.GetRegisteredCvpCodecVersions())))
+	inputFormat := flags.String("input", cliinput.FormatAuto, "input format: auto, raw, hex, base64 or json")
+	kind := flags.String("kind", kindAuto, fmt.Sprintf("payload kind: auto, %s or %s", codec.FrameKindLightValidators, codec.FrameKindNextBlockVotingInformation))
+	output := flags.String("output", outputHex, "output format of the encoded payloads: hex, base64 or raw, one payload per line except raw")
+	allowLossy := flags.Bool("allow-lossy", false, "output the lossy conversions instead of failing")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(stderr, "Usage: cvp-transcode -to <version>|json [flags] [file ...]")
+		_, _ = fmt.Fprintln(stderr, "Converts payloads read from the files, or stdin if none or \"-\", to the codec version or JSON.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return exitInvalidUsage
+	}
+
+	usageError := func(format string, args ...any) int {
+		_, _ = fmt.Fprintf(stderr, "cvp-transcode: "+format+"\n", args...)
+		return exitInvalidUsage
+	}
+
+	var targetCodec codec.CvpCodec
+	switch *to {
+	case "":
+		return usageError("-to is required")
+	case formatJson:
+	default:
+		var found bool
+		if targetCodec, found = codec.GetRegisteredCvpCodec(codec.CvpCodecVersion(*to)); !found {
+			return usageError("unknown target %q, must be json or one of the codec versions %s", *to, joinVersions(codec.GetRegisteredCvpCodecVersions()))
+		}
+	}
+	if *inputFormat != formatJson {
+		if err := cliinput.ValidateFormat(*inputFormat); err != nil {
+			return usageError("%v", err)
+		}
+	}
+	switch *kind {
+	case kindAuto, codec.FrameKindLightValidators.String(), codec.FrameKindNextBlockVotingInformation.String():
+	default:
+		return usageError("invalid kind %q", *kind)
+	}
+	switch *output {
+	case outputHex, outputBase64, outputRaw:
+	default:
+		return usageError("invalid output format %q", *output)
+	}
+
+	sources := flags.Args()
+	if len(sources) == 0 {
+		sources = []string{"-"}
+	}
+
+	var frames []*frame
+	for _, source := range sources {
+		input, err := cliinput.ReadSource(source, stdin)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		sourceFrames, err := readFrames(input, *inputFormat, *kind)
+		if err != nil {
+			return usageError("%s: %v", source, err)
+		}
+		for _, f := range sourceFrames {
+			f.source = source
+		}
+		frames = append(frames, sourceFrames...)
+	}
+	if *output == outputRaw && targetCodec != nil && len(frames) > 1 {
+		return usageError("raw output is a single payload, got %d frames", len(frames))
+	}
+
+	exitCode := exitOk
+	var totalBefore, totalAfter, converted int
+	for i, f := range frames {
+		prefix := fmt.Sprintf("frame %d (%s)", i+1, f.source)
+		if f.err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: failed to decode: %v\n", prefix, f.err)
+			exitCode = exitTranscodeFailure
+			continue
+		}
+
+		var result *conversion
+		var err error
+		if targetCodec == nil {
+			result, err = f.toJson()
+		} else {
+			result, err = f.encode(targetCodec)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "%s: failed to convert %s: %v\n", prefix, f.kind, err)
+			exitCode = exitTranscodeFailure
+			continue
+		}
+
+		if lossy := result.lossy(); lossy != "" {
+			if !*allowLossy {
+				_, _ = fmt.Fprintf(stderr, "%s: lossy conversion of %s to %s: %s\n", prefix, f.kind, result.target, lossy)
+				exitCode = exitTranscodeFailure
+				continue
+			}
+			_, _ = fmt.Fprintf(stderr, "%s: warning: lossy conversion of %s to %s: %s\n", prefix, f.kind, result.target, lossy)
+		}
+
+		if targetCodec == nil {
+			err = writeLine(stdout, string(result.bz))
+		} else {
+			err = writePayload(stdout, result.bz, *output)
+		}
+		if err != nil {
+			return usageError("failed to write output: %v", err)
+		}
+
+		_, _ = fmt.Fprintf(stderr, "%s: %s %s %d bytes -> %s %d bytes, %s\n", prefix, f.kind, f.format, f.size, result.target, len(result.bz), sizeChange(f.size, len(result.bz)))
+		totalBefore += f.size
+		totalAfter += len(result.bz)
+		converted++
+	}
+	if len(frames) > 1 {
+		_, _ = fmt.Fprintf(stderr, "total: %d of %d frames converted, %d bytes -> %d bytes, %s\n", converted, len(frames), totalBefore, totalAfter, sizeChange(totalBefore, totalAfter))
+	}
+	return exitCode
+}
+
+// writePayload writes the encoded payload in the output format.
+func writePayload(w io.Writer, bz []byte, output string) error {
+	switch output {
+	case outputRaw:
+		_, err := w.Write(bz)
+		return err
+	case outputBase64:
+		return writeLine(w, base64.StdEncoding.EncodeToString(bz))
+	default:
+		return writeLine(w, hex.EncodeToString(bz))
+	}
+}
+
+func writeLine(w io.Writer, line string) error {
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// sizeChange describes the bytes saved, or grown, from the size before to the size after.
+func sizeChange(before, after int) string {
+	if before == 0 {
+		return "n/a"
+	}
+	if after <= before {
+		return fmt.Sprintf("saved %d bytes (%.1f%%)", before-after, float64(before-after)*100/float64(before))
+	}
+	return fmt.Sprintf("grew %d bytes (%.1f%%)", after-before, float64(after-before)*100/float64(before))
+}
+
+func joinVersions(versions []codec.CvpCodecVersion) string {
+	names := make([]string, len(versions))
+	for i, version := range versions {
+		names[i] = string(version)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testValidators = types.StreamingLightValidators{
+	{Index: 0, VotingPowerDisplayPercent: 60, Moniker: "Validator One"},
+	{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator Two"},
+}
+
+var testInformation = &types.StreamingNextBlockVotingInformation{
+	HeightRoundStep: types.MustParseHeightRoundStep("100/0/4"),
+	Duration:        3 * time.Second,
+	PreVotedPercent: 60,
+	ValidatorVoteStates: []types.StreamingValidatorVoteState{
+		{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true},
+		{ValidatorIndex: 1, PreVotedBlockHash: "----"},
+	},
+}
+
+func runTranscode(stdin []byte, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	exitCode := run(args, bytes.NewReader(stdin), &stdout, &stderr)
+	return exitCode, stdout.String(), stderr.String()
+}
+
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestRun_Encode(t *testing.T) {
+	v1Codec := codec.GetCvpCodecV1()
+	stdin := hex.EncodeToString(v1Codec.EncodeStreamingLightValidators(testValidators)) + "\n" +
+		hex.EncodeToString(v1Codec.EncodeStreamingNextBlockVotingInformation(testInformation)) + "\n"
+
+	for _, version := range codec.GetRegisteredCvpCodecVersions() {
+		t.Run(string(version), func(t *testing.T) {
+			exitCode, stdout, stderr := runTranscode([]byte(stdin), "-to", string(version))
+			require.Equal(t, exitOk, exitCode, stderr)
+
+			lines := nonEmptyLines(stdout)
+			require.Len(t, lines, 2)
+			cvpCodec := codec.NewProxyCvpCodec()
+
+			bz, err := hex.DecodeString(lines[0])
+			require.NoError(t, err)
+			detected, _ := codec.DetectEncodingVersion(bz)
+			require.Equal(t, version, detected)
+			validators, err := cvpCodec.DecodeStreamingLightValidators(bz)
+			require.NoError(t, err)
+			require.Equal(t, testValidators, validators)
+
+			bz, err = hex.DecodeString(lines[1])
+			require.NoError(t, err)
+			information, err := cvpCodec.DecodeStreamingNextBlockVotingInformation(bz)
+			require.NoError(t, err)
+			require.Equal(t, testInformation, information)
+
+			require.Contains(t, stderr, "frame 1 (-): light-validators v1 ")
+			require.Contains(t, stderr, "frame 2 (-): next-block-voting-info v1 ")
+			require.Contains(t, stderr, "total: 2 of 2 frames converted")
+		})
+	}
+}
+
+func TestRun_Json(t *testing.T) {
+	dir := t.TempDir()
+	validatorsPath := filepath.Join(dir, "validators.bin")
+	require.NoError(t, os.WriteFile(validatorsPath, codec.GetCvpCodecV3().EncodeStreamingLightValidators(testValidators), 0o600))
+	informationPath := filepath.Join(dir, "information.b64")
+	require.NoError(t, os.WriteFile(informationPath, []byte(base64.StdEncoding.EncodeToString(codec.GetCvpCodecV2().EncodeStreamingNextBlockVotingInformation(testInformation))), 0o600))
+
+	// encoded to JSON
+	exitCode, stdout, stderr := runTranscode(nil, "-to", "json", validatorsPath, informationPath)
+	require.Equal(t, exitOk, exitCode, stderr)
+	lines := nonEmptyLines(stdout)
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], `[{"i":0,"vdp":60,"m":"Validator One"}`))
+	require.True(t, strings.HasPrefix(lines[1], `{"hrs":"100/0/4","d":3000000000,"pv":60,"v":[`))
+
+	var validators types.StreamingLightValidators
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &validators))
+	require.Equal(t, testValidators, validators)
+	var information types.StreamingNextBlockVotingInformation
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &information))
+	require.Equal(t, testInformation, &information)
+
+	// and JSON back to encoded, as a single raw payload
+	exitCode, stdout, stderr = runTranscode([]byte(lines[1]), "-to", "v9", "-output", "raw")
+	require.Equal(t, exitOk, exitCode, stderr)
+	decoded, err := codec.GetCvpCodecV9().DecodeStreamingNextBlockVotingInformation([]byte(stdout))
+	require.NoError(t, err)
+	require.Equal(t, testInformation, decoded)
+	require.Contains(t, stderr, "next-block-voting-info json ")
+	require.Contains(t, stderr, "-> v9 ")
+	require.Contains(t, stderr, "saved ")
+
+	t.Run("base64 output", func(t *testing.T) {
+		exitCode, stdout, stderr := runTranscode([]byte(lines[0]), "-to", "v2", "-output", "base64", "-kind", "light-validators")
+		require.Equal(t, exitOk, exitCode, stderr)
+		bz, err := base64.StdEncoding.DecodeString(strings.TrimSpace(stdout))
+		require.NoError(t, err)
+		require.Equal(t, codec.GetCvpCodecV2().EncodeStreamingLightValidators(testValidators), bz)
+	})
+}
+
+func TestRun_Lossy(t *testing.T) {
+	information := *testInformation
+	information.ValidatorVoteStates = []types.StreamingValidatorVoteState{
+		{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true, PreCommitVoted: true, PreCommittedBlockHash: "AB12"},
+	}
+	bz, err := json.Marshal(&information)
+	require.NoError(t, err)
+
+	exitCode, stdout, stderr := runTranscode(bz, "-to", "v3")
+	require.Equal(t, exitTranscodeFailure, exitCode)
+	require.Empty(t, stdout)
+	require.Contains(t, stderr, "lossy conversion of next-block-voting-info to v3: ValidatorVoteStates[0].PreCommittedBlockHash: AB12 -> , not supported by the codec")
+
+	exitCode, stdout, stderr = runTranscode(bz, "-to", "v3", "-allow-lossy")
+	require.Equal(t, exitOk, exitCode)
+	require.Len(t, nonEmptyLines(stdout), 1)
+	require.Contains(t, stderr, "warning: lossy conversion")
+
+	// v8 encodes the pre-committed block hash
+	exitCode, _, stderr = runTranscode(bz, "-to", "v8")
+	require.Equal(t, exitOk, exitCode, stderr)
+
+	t.Run("rounded percent", func(t *testing.T) {
+		exitCode, _, stderr := runTranscode([]byte(`[{"i":0,"vdp":60.123,"m":"Validator"}]`), "-to", "v9")
+		require.Equal(t, exitTranscodeFailure, exitCode)
+		require.Contains(t, stderr, "Validators[0].VotingPowerDisplayPercent: 60.123 -> 60.12, limited to 2 decimals")
+	})
+}
+
+func TestRun_Failures(t *testing.T) {
+	t.Run("decode failure", func(t *testing.T) {
+		valid := hex.EncodeToString(codec.GetCvpCodecV2().EncodeStreamingLightValidators(testValidators))
+		exitCode, stdout, stderr := runTranscode([]byte(valid+"\n"+hex.EncodeToString([]byte{0x2, '|', 'x'})+"\n"), "-to", "v3")
+		require.Equal(t, exitTranscodeFailure, exitCode)
+		require.Len(t, nonEmptyLines(stdout), 1)
+		require.Contains(t, stderr, "frame 2 (-): failed to decode: neither next-block-voting-info")
+		require.Contains(t, stderr, "total: 1 of 2 frames converted")
+	})
+
+	t.Run("unknown JSON field", func(t *testing.T) {
+		exitCode, _, stderr := runTranscode([]byte(`{"hrs":"100/0/4","x":1}`), "-to", "v2")
+		require.Equal(t, exitTranscodeFailure, exitCode)
+		require.Contains(t, stderr, `unknown field "x"`)
+	})
+
+	t.Run("JSON kind mismatch", func(t *testing.T) {
+		exitCode, _, stderr := runTranscode([]byte(`[]`), "-to", "v2", "-kind", "next-block-voting-info")
+		require.Equal(t, exitTranscodeFailure, exitCode)
+		require.Contains(t, stderr, "JSON value is light-validators, expected next-block-voting-info")
+	})
+
+	t.Run("encode failure", func(t *testing.T) {
+		exitCode, _, stderr := runTranscode([]byte(`{"hrs":"100/0/4","pv":101}`), "-to", "v2")
+		require.Equal(t, exitTranscodeFailure, exitCode)
+		require.Contains(t, stderr, "failed to convert next-block-voting-info")
+	})
+}
+
+func TestRun_InvalidUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      []byte
+		wantStderr string
+	}{
+		{
+			name:       "missing target",
+			wantStderr: "-to is required",
+		},
+		{
+			name:       "unknown target",
+			args:       []string{"-to", "v0"},
+			wantStderr: `unknown target "v0"`,
+		},
+		{
+			name:       "invalid input format",
+			args:       []string{"-to", "v3", "-input", "xml"},
+			wantStderr: `invalid input format "xml"`,
+		},
+		{
+			name:       "invalid kind",
+			args:       []string{"-to", "v3", "-kind", "votes"},
+			wantStderr: `invalid kind "votes"`,
+		},
+		{
+			name:       "invalid output format",
+			args:       []string{"-to", "v3", "-output", "json"},
+			wantStderr: `invalid output format "json"`,
+		},
+		{
+			name:       "invalid JSON",
+			args:       []string{"-to", "v3"},
+			stdin:      []byte(`{"hrs":`),
+			wantStderr: "invalid JSON",
+		},
+		{
+			name:       "raw output of multiple frames",
+			args:       []string{"-to", "v3", "-output", "raw"},
+			stdin:      []byte(`[] []`),
+			wantStderr: "raw output is a single payload, got 2 frames",
+		},
+		{
+			name:       "empty input",
+			args:       []string{"-to", "v3"},
+			wantStderr: "empty payload",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exitCode, _, stderr := runTranscode(tt.stdin, tt.args...)
+			require.Equal(t, exitInvalidUsage, exitCode)
+			require.Contains(t, stderr, tt.wantStderr)
+		})
+	}
+}
@@ -11,6 +11,10 @@ type CvpCodec interface {
 	// Input is assumed to be valid, otherwise panic.
 	EncodeStreamingLightValidators(types.StreamingLightValidators) []byte
 
+	// TryEncodeStreamingLightValidators is the same as EncodeStreamingLightValidators
+	// but returns error instead of panic when input is invalid, the error matches ErrInvalidEncodeInput.
+	TryEncodeStreamingLightValidators(types.StreamingLightValidators) ([]byte, error)
+
 	// DecodeStreamingLightValidators decodes the given string into light validators.
 	DecodeStreamingLightValidators([]byte) (types.StreamingLightValidators, error)
 
@@ -18,9 +22,18 @@ type CvpCodec interface {
 	// Input is assumed to be valid, otherwise panic.
 	EncodeStreamingNextBlockVotingInformation(*types.StreamingNextBlockVotingInformation) []byte
 
+	// TryEncodeStreamingNextBlockVotingInformation is the same as EncodeStreamingNextBlockVotingInformation
+	// but returns error instead of panic when input is invalid, the error matches ErrInvalidEncodeInput.
+	TryEncodeStreamingNextBlockVotingInformation(*types.StreamingNextBlockVotingInformation) ([]byte, error)
+
 	// DecodeStreamingNextBlockVotingInformation decodes the given string into next block voting information.
 	DecodeStreamingNextBlockVotingInformation([]byte) (*types.StreamingNextBlockVotingInformation, error)
 
+	// GetLimits returns the limits of this codec instance, input exceeds the limits is rejected when encoding.
+	//
+	// In case a proxy CvpCodec, it returns limits of the underlying implementation used for encoding.
+	GetLimits() CvpCodecLimits
+
 	// GetVersion returns the implementation version of this codec instance.
 	//
 	// In case a proxy CvpCodec, it returns the underlying implementation version
@@ -35,4 +48,30 @@ const (
 	CvpCodecVersionV1      CvpCodecVersion = "v1"
 	CvpCodecVersionV2      CvpCodecVersion = "v2"
 	CvpCodecVersionV3      CvpCodecVersion = "v3"
+	CvpCodecVersionV4      CvpCodecVersion = "v4"
+	CvpCodecVersionV5      CvpCodecVersion = "v5"
+	CvpCodecVersionV6      CvpCodecVersion = "v6"
+	CvpCodecVersionV7      CvpCodecVersion = "v7"
+	CvpCodecVersionV8      CvpCodecVersion = "v8"
+	CvpCodecVersionV9      CvpCodecVersion = "v9"
+	CvpCodecVersionDelta   CvpCodecVersion = "delta"
 )
+
+// CvpCodecLimits holds the limits of a CvpCodec implementation.
+type CvpCodecLimits struct {
+	// MaxValidators is the maximum number of validators can be encoded,
+	// both light validators and validator vote states.
+	MaxValidators int
+
+	// MaxValidatorIndex is the maximum validator index can be encoded.
+	MaxValidatorIndex int
+
+	// MaxEncodedLightValidatorsBytes is the maximum size of the encoded light validators.
+	MaxEncodedLightValidatorsBytes int
+
+	// MaxEncodedNextBlockVotingInformationBytes is the maximum size of the encoded next block voting information.
+	//
+	// For codecs which encode HeightRoundStep and Duration as text,
+	// HeightRoundStep is assumed to be not longer than 19 characters, like "999999999999/9999/8", and Duration is less than 3 years.
+	MaxEncodedNextBlockVotingInformationBytes int
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/bcdevtools/cvp-streaming-core/constants"
 	"github.com/bcdevtools/cvp-streaming-core/types"
+	"math"
+	"math/rand"
 	"reflect"
 	"strings"
 	"testing"
@@ -20,6 +22,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingLightValidators(t *testing
 		wantErrDecode                      bool
 		wantErrDecodeContains              string
 		wantDecodedOrUseInputAsWantDecoded types.StreamingLightValidators // if missing, use input as expect
+		skipVersions                       []CvpCodecVersion
 	}{
 		{
 			name: "normal, 2 validators",
@@ -79,6 +82,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingLightValidators(t *testing
 					Moniker:                   "❌❌❌❌❌❌",
 				},
 			},
+			skipVersions: []CvpCodecVersion{CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // full-length moniker
 		},
 		{
 			name: "normal, validator with 100% VP",
@@ -120,6 +124,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingLightValidators(t *testing
 				},
 			},
 			wantPanicEncode: true,
+			skipVersions:    []CvpCodecVersion{CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // wide-index codecs
 		},
 		{
 			name: "not accept validator negative voting power percent",
@@ -157,6 +162,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingLightValidators(t *testing
 				return validators
 			}(),
 			wantPanicEncode: true,
+			skipVersions:    []CvpCodecVersion{CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // wide-index codecs
 		},
 		{
 			name: "keep only first 20 bytes of moniker",
@@ -176,6 +182,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingLightValidators(t *testing
 				},
 			},
 			wantErrDecode: false,
+			skipVersions:  []CvpCodecVersion{CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // full-length moniker
 		},
 		{
 			name: "sanitize moniker",
@@ -215,6 +222,12 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingLightValidators(t *testing
 	}
 	for _, tt := range testsGeneral {
 		testHandler := func(codec CvpCodec, t *testing.T) {
+			for _, skipVersion := range tt.skipVersions {
+				if skipVersion == codec.GetVersion() {
+					t.Skipf("not applicable for %s", skipVersion)
+				}
+			}
+
 			gotEncoded := func() (bz []byte) {
 				defer func() {
 					err := recover()
@@ -267,6 +280,27 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingLightValidators(t *testing
 		t.Run(fmt.Sprintf("%s_v3", tt.name), func(t *testing.T) {
 			testHandler(cvpV3CodecImpl, t)
 		})
+		t.Run(fmt.Sprintf("%s_v4", tt.name), func(t *testing.T) {
+			testHandler(cvpV4CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v5", tt.name), func(t *testing.T) {
+			testHandler(cvpV5CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v6", tt.name), func(t *testing.T) {
+			testHandler(cvpV6CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v7", tt.name), func(t *testing.T) {
+			testHandler(cvpV7CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v8", tt.name), func(t *testing.T) {
+			testHandler(cvpV8CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v9", tt.name), func(t *testing.T) {
+			testHandler(cvpV9CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_delta", tt.name), func(t *testing.T) {
+			testHandler(cvpDeltaCodecImpl, t)
+		})
 	}
 
 	//goland:noinspection SpellCheckingInspection
@@ -414,6 +448,27 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingLightValidators(t *testing
 		t.Run(fmt.Sprintf("%s_v3", tt.name), func(t *testing.T) {
 			monikerNameContainsSeparatorHandler(cvpCodecV3Separator, cvpV3CodecImpl, t)
 		})
+		t.Run(fmt.Sprintf("%s_v4", tt.name), func(t *testing.T) {
+			monikerNameContainsSeparatorHandler(cvpCodecV4Separator, cvpV4CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v5", tt.name), func(t *testing.T) {
+			monikerNameContainsSeparatorHandler(cvpCodecV5Separator, cvpV5CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v6", tt.name), func(t *testing.T) {
+			monikerNameContainsSeparatorHandler(cvpCodecV6Separator, cvpV6CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v7", tt.name), func(t *testing.T) {
+			monikerNameContainsSeparatorHandler(cvpCodecV7Separator, cvpV7CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v8", tt.name), func(t *testing.T) {
+			monikerNameContainsSeparatorHandler(cvpCodecV8Separator, cvpV8CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v9", tt.name), func(t *testing.T) {
+			monikerNameContainsSeparatorHandler(cvpCodecV9Separator, cvpV9CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_delta", tt.name), func(t *testing.T) {
+			monikerNameContainsSeparatorHandler(cvpCodecDeltaSeparator, cvpDeltaCodecImpl, t)
+		})
 	}
 }
 
@@ -426,11 +481,12 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		wantDecodedOrUseInputAsWantDecoded *types.StreamingNextBlockVotingInformation // if missing, use input as expect
 		wantErrDecode                      bool
 		wantErrDecodeContains              string
+		skipVersions                       []CvpCodecVersion
 	}{
 		{
 			name: "normal, 4 validators",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -470,7 +526,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		{
 			name: "normal, 1 validators",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -489,7 +545,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		{
 			name: "can not decode zero validators vote state",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -501,7 +557,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		{
 			name: "duration will be corrected to zero if negative",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              -1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -516,7 +572,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 				},
 			},
 			wantDecodedOrUseInputAsWantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              0,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -534,7 +590,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		{
 			name: "panic encode if negative validator index",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -549,7 +605,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		{
 			name: "panic encode if validator index greater than 998",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -560,12 +616,13 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 				},
 			},
 			wantPanicEncode: true,
+			skipVersions:    []CvpCodecVersion{CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // wide-index codecs
 		},
 		{
 			name: "panic encode if validator list size larger than cap",
 			inf: func() types.StreamingNextBlockVotingInformation {
 				inf := types.StreamingNextBlockVotingInformation{
-					HeightRoundStep:       "1/2/3",
+					HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 					Duration:              time.Second,
 					PreVotedPercent:       1,
 					PreCommitVotedPercent: 2,
@@ -580,11 +637,12 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 				return inf
 			}(),
 			wantPanicEncode: true,
+			skipVersions:    []CvpCodecVersion{CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // wide-index codecs
 		},
 		{
 			name: "panic encode if block hash length is not 0 or 4",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -601,7 +659,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		{
 			name: "panic encode if block hash length is not 0 or 4",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -618,7 +676,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		{
 			name: "automatically fill prevoted block hash if empty",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -630,7 +688,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 				},
 			},
 			wantDecodedOrUseInputAsWantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -646,7 +704,7 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 			name: "collision of separator byte with bytes index",
 			inf: func() types.StreamingNextBlockVotingInformation {
 				nextBlockVotingInfo := types.StreamingNextBlockVotingInformation{
-					HeightRoundStep:       "1/2/3",
+					HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 					Duration:              time.Second,
 					PreVotedPercent:       1,
 					PreCommitVotedPercent: 2,
@@ -666,6 +724,12 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 	}
 	for _, tt := range tests {
 		testHandler := func(codec CvpCodec, t *testing.T) {
+			for _, skipVersion := range tt.skipVersions {
+				if skipVersion == codec.GetVersion() {
+					t.Skipf("not applicable for %s", skipVersion)
+				}
+			}
+
 			gotEncoded := func() (bz []byte) {
 				defer func() {
 					err := recover()
@@ -718,6 +782,27 @@ func Test_cvpCodecAllVersions_EncodeAndDecodeStreamingNextBlockVotingInformation
 		t.Run(fmt.Sprintf("%s_v3", tt.name), func(t *testing.T) {
 			testHandler(cvpV3CodecImpl, t)
 		})
+		t.Run(fmt.Sprintf("%s_v4", tt.name), func(t *testing.T) {
+			testHandler(cvpV4CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v5", tt.name), func(t *testing.T) {
+			testHandler(cvpV5CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v6", tt.name), func(t *testing.T) {
+			testHandler(cvpV6CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v7", tt.name), func(t *testing.T) {
+			testHandler(cvpV7CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v8", tt.name), func(t *testing.T) {
+			testHandler(cvpV8CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_v9", tt.name), func(t *testing.T) {
+			testHandler(cvpV9CodecImpl, t)
+		})
+		t.Run(fmt.Sprintf("%s_delta", tt.name), func(t *testing.T) {
+			testHandler(cvpDeltaCodecImpl, t)
+		})
 	}
 }
 
@@ -741,6 +826,9 @@ func Test_cvpCodecAllVersions_LargestEncodedLightValidators(t *testing.T) {
 	encodedV3 := cvpV3CodecImpl.EncodeStreamingLightValidators(validators)
 	bytes[3] = len(encodedV3)
 
+	encodedV4 := cvpV4CodecImpl.EncodeStreamingLightValidators(validators)
+	bytes[4] = len(encodedV4)
+
 	var maxSize int
 	for _, size := range bytes {
 		if size > maxSize {
@@ -759,8 +847,8 @@ func Test_cvpCodecAllVersions_LargestEncodedLightValidators(t *testing.T) {
 
 func Test_cvpCodecAllVersions_LargestEncodedPreVoteInfo(t *testing.T) {
 	inf := types.StreamingNextBlockVotingInformation{
-		HeightRoundStep:       "999999999/9999/9999",    // very big
-		Duration:              365 * 2 * 24 * time.Hour, // very far
+		HeightRoundStep:       types.MustParseLegacyHeightRoundStep("999999999/9999/9999"), // very big
+		Duration:              365 * 2 * 24 * time.Hour,                                    // very far
 		PreVotedPercent:       99.98,
 		PreCommitVotedPercent: 99.98,
 		ValidatorVoteStates:   nil,
@@ -785,6 +873,9 @@ func Test_cvpCodecAllVersions_LargestEncodedPreVoteInfo(t *testing.T) {
 	encodedV3 := cvpV3CodecImpl.EncodeStreamingNextBlockVotingInformation(&inf)
 	bytes[3] = len(encodedV3)
 
+	encodedV4 := cvpV4CodecImpl.EncodeStreamingNextBlockVotingInformation(&inf)
+	bytes[4] = len(encodedV4)
+
 	var maxSize int
 	for _, size := range bytes {
 		if size > maxSize {
@@ -801,6 +892,197 @@ func Test_cvpCodecAllVersions_LargestEncodedPreVoteInfo(t *testing.T) {
 	}
 }
 
+func Test_cvpCodecAllVersions_GetLimits(t *testing.T) {
+	// pseudo-random monikers, so the compressed codecs can not compress much
+	random := rand.New(rand.NewSource(1))
+	randomMoniker := func() string {
+		bz := make([]byte, 20)
+		_, _ = random.Read(bz)
+		return string(bz)
+	}
+
+	tests := []struct {
+		codec                                              CvpCodec
+		wantExactMaxEncodedLightValidatorsBytes            int // optional
+		wantExactMaxEncodedNextBlockVotingInformationBytes int // optional
+	}{
+		{
+			codec: cvpV1CodecImpl,
+			//goland:noinspection GoDeprecation
+			wantExactMaxEncodedLightValidatorsBytes: constants.MAX_ENCODED_LIGHT_VALIDATORS_BYTES,
+			//goland:noinspection GoDeprecation
+			wantExactMaxEncodedNextBlockVotingInformationBytes: constants.MAX_ENCODED_NEXT_BLOCK_PRE_VOTE_INFO_BYTES,
+		},
+		{
+			codec:                                   cvpV2CodecImpl,
+			wantExactMaxEncodedLightValidatorsBytes: 8251,
+			wantExactMaxEncodedNextBlockVotingInformationBytes: 1786,
+		},
+		{
+			codec: cvpV3CodecImpl,
+		},
+		{
+			codec: cvpV4CodecImpl,
+		},
+		{
+			codec: cvpV5CodecImpl,
+		},
+		{
+			codec: cvpV6CodecImpl,
+		},
+		{
+			codec: cvpV7CodecImpl,
+		},
+		{
+			codec: cvpV8CodecImpl,
+		},
+		{
+			codec: cvpV9CodecImpl,
+		},
+		{
+			codec:                                   cvpDeltaCodecImpl,
+			wantExactMaxEncodedLightValidatorsBytes: 8251,
+			wantExactMaxEncodedNextBlockVotingInformationBytes: 1812,
+		},
+		{
+			codec: cvpProxyCodecImpl,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.codec.GetVersion()), func(t *testing.T) {
+			limits := tt.codec.GetLimits()
+
+			validators := make(types.StreamingLightValidators, limits.MaxValidators)
+			for i := range validators {
+				validators[i] = types.StreamingLightValidator{
+					Index:                     i,
+					VotingPowerDisplayPercent: 99.98,
+					Moniker:                   randomMoniker(),
+				}
+			}
+			if tt.wantExactMaxEncodedLightValidatorsBytes > 0 {
+				for i := range validators {
+					validators[i].Moniker = fmt.Sprintf("Val%d✅✅✅✅✅✅✅", i+1)
+				}
+			}
+
+			bz, err := tt.codec.TryEncodeStreamingLightValidators(validators)
+			if err != nil {
+				t.Errorf("TryEncodeStreamingLightValidators() error = %v", err)
+				return
+			}
+			if tt.wantExactMaxEncodedLightValidatorsBytes > 0 && limits.MaxEncodedLightValidatorsBytes != tt.wantExactMaxEncodedLightValidatorsBytes {
+				t.Errorf("MaxEncodedLightValidatorsBytes = %d, want %d", limits.MaxEncodedLightValidatorsBytes, tt.wantExactMaxEncodedLightValidatorsBytes)
+			}
+			if len(bz) > limits.MaxEncodedLightValidatorsBytes {
+				t.Errorf("largest encoded light validators bytes = %d, exceeds limit %d", len(bz), limits.MaxEncodedLightValidatorsBytes)
+			} else if tt.wantExactMaxEncodedLightValidatorsBytes > 0 && len(bz) != limits.MaxEncodedLightValidatorsBytes {
+				t.Errorf("largest encoded light validators bytes = %d, want exact %d", len(bz), limits.MaxEncodedLightValidatorsBytes)
+			}
+
+			if _, err := tt.codec.TryEncodeStreamingLightValidators(append(validators, types.StreamingLightValidator{
+				Index: limits.MaxValidators,
+			})); err == nil {
+				t.Errorf("TryEncodeStreamingLightValidators() expect error when exceeds MaxValidators")
+			}
+			if _, err := tt.codec.TryEncodeStreamingLightValidators(types.StreamingLightValidators{{
+				Index: limits.MaxValidatorIndex + 1,
+			}}); err == nil {
+				t.Errorf("TryEncodeStreamingLightValidators() expect error when exceeds MaxValidatorIndex")
+			}
+
+			inf := &types.StreamingNextBlockVotingInformation{
+				HeightRoundStep:       types.MustParseLegacyHeightRoundStep("999999999/9999/9999"), // very big
+				Duration:              365 * 2 * 24 * time.Hour,                                    // very far
+				PreVotedPercent:       99.98,
+				PreCommitVotedPercent: 99.98,
+			}
+			if tt.codec.GetVersion() == CvpCodecVersionV9 {
+				// the binary form encodes only valid height round step, the largest one
+				inf.HeightRoundStep = types.HeightRoundStep{Height: math.MaxInt64, Round: math.MaxInt32, Step: types.RoundStepCommit}
+			}
+			for i := 0; i < limits.MaxValidators; i++ {
+				inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, types.StreamingValidatorVoteState{
+					ValidatorIndex:    i,
+					PreVotedBlockHash: fmt.Sprintf("%04X", random.Intn(0x10000)),
+					PreVoted:          true,
+					PreCommitVoted:    true,
+				})
+			}
+
+			if deltaCodec, ok := tt.codec.(CvpDeltaCodec); ok {
+				// the largest frame is a non-key frame with all the vote states changed
+				base := copyNextBlockVotingInformationForDelta(inf)
+				base.HeightRoundStep = types.MustParseLegacyHeightRoundStep("999999999/9999/9998")
+				for i := range base.ValidatorVoteStates {
+					base.ValidatorVoteStates[i].PreCommitVoted = false
+				}
+				bz, err = deltaCodec.TryEncodeStreamingNextBlockVotingInformationDelta(base, inf)
+			} else {
+				bz, err = tt.codec.TryEncodeStreamingNextBlockVotingInformation(inf)
+			}
+			if err != nil {
+				t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+			if tt.wantExactMaxEncodedNextBlockVotingInformationBytes > 0 && limits.MaxEncodedNextBlockVotingInformationBytes != tt.wantExactMaxEncodedNextBlockVotingInformationBytes {
+				t.Errorf("MaxEncodedNextBlockVotingInformationBytes = %d, want %d", limits.MaxEncodedNextBlockVotingInformationBytes, tt.wantExactMaxEncodedNextBlockVotingInformationBytes)
+			}
+			if len(bz) > limits.MaxEncodedNextBlockVotingInformationBytes {
+				t.Errorf("largest encoded next block voting information bytes = %d, exceeds limit %d", len(bz), limits.MaxEncodedNextBlockVotingInformationBytes)
+			} else if tt.wantExactMaxEncodedNextBlockVotingInformationBytes > 0 && len(bz) != limits.MaxEncodedNextBlockVotingInformationBytes {
+				t.Errorf("largest encoded next block voting information bytes = %d, want exact %d", len(bz), limits.MaxEncodedNextBlockVotingInformationBytes)
+			}
+
+			inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, types.StreamingValidatorVoteState{
+				ValidatorIndex: limits.MaxValidators,
+			})
+			if _, err := tt.codec.TryEncodeStreamingNextBlockVotingInformation(inf); err == nil {
+				t.Errorf("TryEncodeStreamingNextBlockVotingInformation() expect error when exceeds MaxValidators")
+			}
+		})
+	}
+}
+
+// Test_cvpCodecAllVersions_DecodeLegacyHeightRoundStep ensures the codecs encoding HeightRoundStep in text form
+// still decode the steps accepted before HeightRoundStep was structured.
+func Test_cvpCodecAllVersions_DecodeLegacyHeightRoundStep(t *testing.T) {
+	textCodecs := []CvpCodec{
+		cvpV1CodecImpl, cvpV2CodecImpl, cvpV3CodecImpl, cvpV4CodecImpl,
+		cvpV5CodecImpl, cvpV6CodecImpl, cvpV7CodecImpl, cvpV8CodecImpl,
+	}
+
+	// accepted as any digits before HeightRoundStep was structured
+	for _, str := range []string{"100/0/0", "100/0/9", "1/2/300", "1/9999999999/3", "999999999/9999/9999"} {
+		information := &types.StreamingNextBlockVotingInformation{
+			HeightRoundStep: types.MustParseLegacyHeightRoundStep(str),
+			ValidatorVoteStates: []types.StreamingValidatorVoteState{
+				{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true},
+			},
+		}
+
+		for _, codec := range textCodecs {
+			bz, err := codec.TryEncodeStreamingNextBlockVotingInformation(information)
+			if err != nil {
+				t.Errorf("%s: encode %s error = %v", codec.GetVersion(), str, err)
+				continue
+			}
+			decoded, err := codec.DecodeStreamingNextBlockVotingInformation(bz)
+			if err != nil {
+				t.Errorf("%s: decode %s error = %v", codec.GetVersion(), str, err)
+				continue
+			}
+			if got := decoded.HeightRoundStep.String(); got != str {
+				t.Errorf("%s: decoded height round step = %s, want %s", codec.GetVersion(), got, str)
+			}
+		}
+
+		if _, err := cvpV9CodecImpl.TryEncodeStreamingNextBlockVotingInformation(information); err == nil {
+			t.Errorf("%s: encode %s expect error", CvpCodecVersionV9, str)
+		}
+	}
+}
+
 func Test_cvpCodecAllVersions_GetVersion(t *testing.T) {
 	tests := []struct {
 		codec       CvpCodec
@@ -818,6 +1100,34 @@ func Test_cvpCodecAllVersions_GetVersion(t *testing.T) {
 			codec:       cvpV3CodecImpl,
 			wantVersion: CvpCodecVersionV3,
 		},
+		{
+			codec:       cvpV4CodecImpl,
+			wantVersion: CvpCodecVersionV4,
+		},
+		{
+			codec:       cvpV5CodecImpl,
+			wantVersion: CvpCodecVersionV5,
+		},
+		{
+			codec:       cvpV6CodecImpl,
+			wantVersion: CvpCodecVersionV6,
+		},
+		{
+			codec:       cvpV7CodecImpl,
+			wantVersion: CvpCodecVersionV7,
+		},
+		{
+			codec:       cvpV8CodecImpl,
+			wantVersion: CvpCodecVersionV8,
+		},
+		{
+			codec:       cvpV9CodecImpl,
+			wantVersion: CvpCodecVersionV9,
+		},
+		{
+			codec:       cvpDeltaCodecImpl,
+			wantVersion: CvpCodecVersionDelta,
+		},
 		{
 			codec:       NewProxyCvpCodec(),
 			wantVersion: CvpCodecVersionV3,
@@ -27,6 +27,10 @@ func BenchmarkEncodeLightValidators(b *testing.B) {
 		b.Run(fmt.Sprintf("codec v3 encode %d validators", benchmarkDataSize), func(b *testing.B) {
 			_ = cvpV3CodecImpl.EncodeStreamingLightValidators(validators)
 		})
+
+		b.Run(fmt.Sprintf("codec v4 encode %d validators", benchmarkDataSize), func(b *testing.B) {
+			_ = cvpV4CodecImpl.EncodeStreamingLightValidators(validators)
+		})
 	}
 }
 
@@ -43,6 +47,7 @@ func BenchmarkDecodeLightValidators(b *testing.B) {
 
 		encodedV2 := cvpV2CodecImpl.EncodeStreamingLightValidators(validators)
 		encodedV3 := cvpV3CodecImpl.EncodeStreamingLightValidators(validators)
+		encodedV4 := cvpV4CodecImpl.EncodeStreamingLightValidators(validators)
 
 		b.Run(fmt.Sprintf("codec v2 decode %d validators", benchmarkDataSize), func(b *testing.B) {
 			_, _ = cvpV2CodecImpl.DecodeStreamingLightValidators(encodedV2)
@@ -51,13 +56,17 @@ func BenchmarkDecodeLightValidators(b *testing.B) {
 		b.Run(fmt.Sprintf("codec v3 decode %d validators", benchmarkDataSize), func(b *testing.B) {
 			_, _ = cvpV3CodecImpl.DecodeStreamingLightValidators(encodedV3)
 		})
+
+		b.Run(fmt.Sprintf("codec v4 decode %d validators", benchmarkDataSize), func(b *testing.B) {
+			_, _ = cvpV4CodecImpl.DecodeStreamingLightValidators(encodedV4)
+		})
 	}
 }
 
 func BenchmarkEncodeNextBlockPreVoteInfo(b *testing.B) {
 	for _, benchmarkDataSize := range benchmarkDataSizes {
 		inf := types.StreamingNextBlockVotingInformation{
-			HeightRoundStep:       "999999999/9999/9999",
+			HeightRoundStep:       types.MustParseLegacyHeightRoundStep("999999999/9999/9999"),
 			Duration:              365 * 2 * 24 * time.Hour,
 			PreVotedPercent:       99.98,
 			PreCommitVotedPercent: 99.98,
@@ -80,13 +89,17 @@ func BenchmarkEncodeNextBlockPreVoteInfo(b *testing.B) {
 		b.Run(fmt.Sprintf("codec v3 encode %d votes", benchmarkDataSize), func(b *testing.B) {
 			_ = cvpV3CodecImpl.EncodeStreamingNextBlockVotingInformation(&inf)
 		})
+
+		b.Run(fmt.Sprintf("codec v4 encode %d votes", benchmarkDataSize), func(b *testing.B) {
+			_ = cvpV4CodecImpl.EncodeStreamingNextBlockVotingInformation(&inf)
+		})
 	}
 }
 
 func BenchmarkDecodeNextBlockPreVoteInfo(b *testing.B) {
 	for _, benchmarkDataSize := range benchmarkDataSizes {
 		inf := types.StreamingNextBlockVotingInformation{
-			HeightRoundStep:       "999999999/9999/9999",
+			HeightRoundStep:       types.MustParseLegacyHeightRoundStep("999999999/9999/9999"),
 			Duration:              365 * 2 * 24 * time.Hour,
 			PreVotedPercent:       99.98,
 			PreCommitVotedPercent: 99.98,
@@ -104,6 +117,7 @@ func BenchmarkDecodeNextBlockPreVoteInfo(b *testing.B) {
 
 		encodedV2 := cvpV2CodecImpl.EncodeStreamingNextBlockVotingInformation(&inf)
 		encodedV3 := cvpV3CodecImpl.EncodeStreamingNextBlockVotingInformation(&inf)
+		encodedV4 := cvpV4CodecImpl.EncodeStreamingNextBlockVotingInformation(&inf)
 
 		b.Run(fmt.Sprintf("codec v2 decode %d votes", benchmarkDataSize), func(b *testing.B) {
 			_, _ = cvpV2CodecImpl.DecodeStreamingNextBlockVotingInformation(encodedV2)
@@ -112,5 +126,47 @@ func BenchmarkDecodeNextBlockPreVoteInfo(b *testing.B) {
 		b.Run(fmt.Sprintf("codec v3 decode %d votes", benchmarkDataSize), func(b *testing.B) {
 			_, _ = cvpV3CodecImpl.DecodeStreamingNextBlockVotingInformation(encodedV3)
 		})
+
+		b.Run(fmt.Sprintf("codec v4 decode %d votes", benchmarkDataSize), func(b *testing.B) {
+			_, _ = cvpV4CodecImpl.DecodeStreamingNextBlockVotingInformation(encodedV4)
+		})
+	}
+}
+
+func BenchmarkEncodeNextBlockPreVoteInfoDelta(b *testing.B) {
+	for _, benchmarkDataSize := range benchmarkDataSizes {
+		base := sampleNextBlockVotingInformationForDelta(benchmarkDataSize)
+		next := copyNextBlockVotingInformationForDelta(base)
+		next.ValidatorVoteStates[0] = types.StreamingValidatorVoteState{
+			ValidatorIndex:    0,
+			PreVotedBlockHash: "C0FF",
+			PreVoted:          true,
+			VotedZeroes:       false,
+			PreCommitVoted:    true,
+		}
+
+		b.Run(fmt.Sprintf("codec delta encode %d votes", benchmarkDataSize), func(b *testing.B) {
+			_ = cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformationDelta(base, next)
+		})
+	}
+}
+
+func BenchmarkDecodeNextBlockPreVoteInfoDelta(b *testing.B) {
+	for _, benchmarkDataSize := range benchmarkDataSizes {
+		base := sampleNextBlockVotingInformationForDelta(benchmarkDataSize)
+		next := copyNextBlockVotingInformationForDelta(base)
+		next.ValidatorVoteStates[0] = types.StreamingValidatorVoteState{
+			ValidatorIndex:    0,
+			PreVotedBlockHash: "C0FF",
+			PreVoted:          true,
+			VotedZeroes:       false,
+			PreCommitVoted:    true,
+		}
+
+		encodedDelta := cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformationDelta(base, next)
+
+		b.Run(fmt.Sprintf("codec delta decode %d votes", benchmarkDataSize), func(b *testing.B) {
+			_, _ = cvpDeltaCodecImpl.ApplyStreamingNextBlockVotingInformationDelta(base, encodedDelta)
+		})
 	}
-}
\ No newline at end of file
+}
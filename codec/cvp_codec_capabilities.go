@@ -0,0 +1,126 @@
+package codec
+
+import (
+	"math"
+	"time"
+)
+
+// CvpCodecCapabilities describes which information a codec version carries and how the rest is altered by encoding,
+// so the broadcaster can pick a version knowing what the subscribers will lose.
+//
+// Information altered by all the built-in codecs is not described per version:
+//   - monikers are sanitized, angle brackets are replaced by parentheses and quotes are replaced by backticks.
+//   - light validators and validator vote states are decoded sorted by index.
+//   - the vote state is encoded as a single flag, so PreCommitVoted drops VotedZeroes,
+//     and both PreCommitVoted and VotedZeroes imply PreVoted.
+//   - empty pre-voted fingerprint block hash is decoded as "----".
+//
+// Use Diff to see the exact changes of a decoded value.
+type CvpCodecCapabilities struct {
+	Version CvpCodecVersion
+
+	// DurationPrecision is the unit which Duration is truncated to. Negative Duration is encoded as zero.
+	DurationPrecision time.Duration
+
+	// MaxDuration is the longest Duration can be encoded, longer Duration is clamped. Zero means unlimited.
+	MaxDuration time.Duration
+
+	// PercentDecimals is the number of decimals of the percents.
+	PercentDecimals int
+
+	// PercentRounded is true if the percents are rounded to PercentDecimals, otherwise they are truncated.
+	PercentRounded bool
+
+	// MaxMonikerBytes is the maximum size of the moniker, longer monikers are truncated at UTF-8 rune boundary.
+	MaxMonikerBytes int
+
+	// MonikerSpacesTrimmed is true if leading and trailing spaces of the moniker are trimmed.
+	MonikerSpacesTrimmed bool
+
+	// BlockHashUpperCased is true if the fingerprint block hashes are decoded as upper case.
+	BlockHashUpperCased bool
+
+	// OperatorAddress is true if the optional operator address of light validators is carried.
+	OperatorAddress bool
+
+	// ConsensusAddress is true if the optional consensus address of light validators is carried,
+	// it is decoded as upper case hex.
+	ConsensusAddress bool
+
+	// PreCommittedBlockHash is true if the pre-committed fingerprint block hash of validator vote states is carried.
+	PreCommittedBlockHash bool
+}
+
+// cvpCodecCapabilitiesV2 is shared by v2 and the codecs built on top of it: v3, v4 and delta.
+var cvpCodecCapabilitiesV2 = CvpCodecCapabilities{
+	Version:              CvpCodecVersionV2,
+	DurationPrecision:    time.Second,
+	PercentDecimals:      2,
+	PercentRounded:       true,
+	MaxMonikerBytes:      cvpCodecV2MonikerBufferSize,
+	MonikerSpacesTrimmed: true,
+}
+
+// cvpCodecCapabilitiesV5 is shared by v5 and the later binary codecs, which encode Duration as uint32 seconds.
+var cvpCodecCapabilitiesV5 = CvpCodecCapabilities{
+	Version:              CvpCodecVersionV5,
+	DurationPrecision:    time.Second,
+	MaxDuration:          math.MaxUint32 * time.Second,
+	PercentDecimals:      2,
+	PercentRounded:       true,
+	MaxMonikerBytes:      cvpCodecV5MonikerBufferSize,
+	MonikerSpacesTrimmed: true,
+}
+
+var cvpCodecCapabilities = func() map[CvpCodecVersion]CvpCodecCapabilities {
+	v1 := CvpCodecCapabilities{
+		Version:              CvpCodecVersionV1,
+		DurationPrecision:    time.Millisecond,
+		PercentDecimals:      2,
+		MaxMonikerBytes:      cvpCodecV1MonikerBufferSize,
+		MonikerSpacesTrimmed: true,
+		BlockHashUpperCased:  true,
+	}
+
+	v6 := cvpCodecCapabilitiesV5
+	v6.Version = CvpCodecVersionV6
+	v6.MaxMonikerBytes = CvpCodecV6DefaultMaxMonikerBytes
+	v6.MonikerSpacesTrimmed = false
+
+	v7 := v6
+	v7.Version = CvpCodecVersionV7
+	v7.OperatorAddress = true
+	v7.ConsensusAddress = true
+
+	v8 := v7
+	v8.Version = CvpCodecVersionV8
+	v8.PreCommittedBlockHash = true
+
+	v9 := v8
+	v9.Version = CvpCodecVersionV9
+
+	capabilities := map[CvpCodecVersion]CvpCodecCapabilities{
+		CvpCodecVersionV1: v1,
+		CvpCodecVersionV2: cvpCodecCapabilitiesV2,
+		CvpCodecVersionV5: cvpCodecCapabilitiesV5,
+		CvpCodecVersionV6: v6,
+		CvpCodecVersionV7: v7,
+		CvpCodecVersionV8: v8,
+		CvpCodecVersionV9: v9,
+	}
+	for _, version := range []CvpCodecVersion{CvpCodecVersionV3, CvpCodecVersionV4, CvpCodecVersionDelta} {
+		v2Based := cvpCodecCapabilitiesV2
+		v2Based.Version = version
+		capabilities[version] = v2Based
+	}
+	return capabilities
+}()
+
+// Capabilities returns the capabilities of the built-in codec version.
+// Not found for the codecs registered via RegisterCvpCodec, use RoundTrip to find out what they alter.
+//
+// Capabilities of v6 are of the default maximum moniker size, see GetCvpCodecV6WithMaxMonikerBytes.
+func Capabilities(version CvpCodecVersion) (capabilities CvpCodecCapabilities, found bool) {
+	capabilities, found = cvpCodecCapabilities[version]
+	return
+}
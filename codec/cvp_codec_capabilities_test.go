@@ -0,0 +1,182 @@
+package codec
+
+import (
+	"bytes"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var builtInCvpCodecVersions = []CvpCodecVersion{
+	CvpCodecVersionV1, CvpCodecVersionV2, CvpCodecVersionV3, CvpCodecVersionV4, CvpCodecVersionV5,
+	CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9, CvpCodecVersionDelta,
+}
+
+func changedFields(changes []FieldChange) map[string]FieldChange {
+	fields := make(map[string]FieldChange, len(changes))
+	for _, change := range changes {
+		fields[change.Field] = change
+	}
+	return fields
+}
+
+func checkChangedFields(t *testing.T, changes []FieldChange, want map[string]string) {
+	t.Helper()
+	got := changedFields(changes)
+	for field, wantReason := range want {
+		change, found := got[field]
+		if !found {
+			t.Errorf("missing change of %s", field)
+		} else if !strings.Contains(change.Reason, wantReason) {
+			t.Errorf("change of %s reason = %q, want contains %q", field, change.Reason, wantReason)
+		}
+	}
+	for field, change := range got {
+		if _, found := want[field]; !found {
+			t.Errorf("unexpected change %s", change)
+		}
+	}
+}
+
+// TestCapabilities checks the capabilities of each built-in codec against the changes of the actual round trip.
+func TestCapabilities(t *testing.T) {
+	monikerTooLong := " <Validator> " + strings.Repeat("ä", 40)
+
+	validators := types.StreamingLightValidators{
+		{Index: 1, VotingPowerDisplayPercent: 60.126, Moniker: monikerTooLong},
+		{
+			Index:                     0,
+			VotingPowerDisplayPercent: 39.874,
+			Moniker:                   "Two",
+			OperatorAddress:           mustBech32("cosmosvaloper", bytes.Repeat([]byte{1}, 20)),
+			ConsensusAddress:          strings.Repeat("ab", types.ConsensusAddressBytes),
+		},
+	}
+	information := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.MustParseHeightRoundStep("100/0/4"),
+		Duration:        3*time.Second + 500*time.Millisecond + 250*time.Microsecond,
+		PreVotedPercent: 60.126,
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{ValidatorIndex: 0, PreVotedBlockHash: "c0ff", VotedZeroes: true, PreCommitVoted: true, PreCommittedBlockHash: "ab12"},
+			{ValidatorIndex: 1},
+		},
+	}
+
+	for _, version := range builtInCvpCodecVersions {
+		t.Run(string(version), func(t *testing.T) {
+			capabilities, found := Capabilities(version)
+			if !found {
+				t.Fatalf("capabilities not found")
+			}
+			if capabilities.Version != version {
+				t.Errorf("version = %s, want %s", capabilities.Version, version)
+			}
+			codec, _ := GetRegisteredCvpCodec(version)
+
+			percentReason := "truncated to 2 decimals"
+			if capabilities.PercentRounded {
+				percentReason = "rounded to 2 decimals"
+			}
+
+			decodedValidators, changes, err := RoundTrip(codec, validators)
+			if err != nil {
+				t.Fatalf("round trip light validators error = %v", err)
+			}
+			wantValidatorChanges := map[string]string{
+				"Validators": "sorted by index",
+				"Validators[0].VotingPowerDisplayPercent": percentReason,
+				"Validators[0].Moniker":                   "sanitized",
+				"Validators[1].VotingPowerDisplayPercent": "limited to 2 decimals",
+				"Validators[1].ConsensusAddress":          "not supported",
+			}
+			if capabilities.ConsensusAddress {
+				wantValidatorChanges["Validators[1].ConsensusAddress"] = "upper cased"
+			}
+			if !capabilities.OperatorAddress {
+				wantValidatorChanges["Validators[1].OperatorAddress"] = "not supported"
+			}
+			checkChangedFields(t, changes, wantValidatorChanges)
+
+			moniker := decodedValidators[1].Moniker
+			if len(moniker) > capabilities.MaxMonikerBytes || !utf8.ValidString(moniker) || !strings.HasSuffix(moniker, "ä") {
+				t.Errorf("moniker = %q, want valid UTF-8 truncated to %d bytes", moniker, capabilities.MaxMonikerBytes)
+			}
+			if wantPrefix := map[bool]string{true: "(Validator)", false: " (Validator)"}[capabilities.MonikerSpacesTrimmed]; !strings.HasPrefix(moniker, wantPrefix) {
+				t.Errorf("moniker = %q, want prefix %q", moniker, wantPrefix)
+			}
+
+			decodedInformation, changes, err := RoundTrip(codec, information)
+			if err != nil {
+				t.Fatalf("round trip next block voting information error = %v", err)
+			}
+			wantInformationChanges := map[string]string{
+				"Duration":                                     "truncated to",
+				"PreVotedPercent":                              percentReason,
+				"ValidatorVoteStates[0].PreVoted":              "implied by PreCommitVoted",
+				"ValidatorVoteStates[0].VotedZeroes":           "PreCommitVoted is set",
+				"ValidatorVoteStates[1].PreVotedBlockHash":     `encoded as "----"`,
+				"ValidatorVoteStates[0].PreCommittedBlockHash": "not supported",
+			}
+			if capabilities.PreCommittedBlockHash {
+				delete(wantInformationChanges, "ValidatorVoteStates[0].PreCommittedBlockHash")
+			}
+			if capabilities.BlockHashUpperCased {
+				wantInformationChanges["ValidatorVoteStates[0].PreVotedBlockHash"] = "upper cased"
+			}
+			checkChangedFields(t, changes, wantInformationChanges)
+
+			if want := information.Duration.Truncate(capabilities.DurationPrecision); decodedInformation.Duration != want {
+				t.Errorf("duration = %s, want %s", decodedInformation.Duration, want)
+			}
+		})
+	}
+}
+
+func TestCapabilities_MaxDuration(t *testing.T) {
+	information := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.MustParseHeightRoundStep("100/0/4"),
+		Duration:        200 * 365 * 24 * time.Hour,
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true},
+		},
+	}
+	for _, version := range builtInCvpCodecVersions {
+		capabilities, _ := Capabilities(version)
+		codec, _ := GetRegisteredCvpCodec(version)
+
+		decoded, changes, err := RoundTrip(codec, information)
+		if err != nil {
+			t.Fatalf("%s: round trip error = %v", version, err)
+		}
+		if capabilities.MaxDuration == 0 {
+			if len(changes) != 0 {
+				t.Errorf("%s: unexpected changes %v", version, changes)
+			}
+			continue
+		}
+		if decoded.Duration != capabilities.MaxDuration {
+			t.Errorf("%s: duration = %s, want %s", version, decoded.Duration, capabilities.MaxDuration)
+		}
+		checkChangedFields(t, changes, map[string]string{"Duration": "clamped"})
+	}
+}
+
+func TestCapabilities_NotFound(t *testing.T) {
+	for _, version := range []CvpCodecVersion{CvpCodecVersionUnknown, "v0"} {
+		if _, found := Capabilities(version); found {
+			t.Errorf("capabilities of %s must not be found", version)
+		}
+	}
+
+	var versions []string
+	for version := range cvpCodecCapabilities {
+		versions = append(versions, string(version))
+	}
+	sort.Strings(versions)
+	if len(versions) != len(builtInCvpCodecVersions) {
+		t.Errorf("capabilities of %v, want all the built-in versions", versions)
+	}
+}
@@ -0,0 +1,376 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//goland:noinspection SpellCheckingInspection
+
+var _ CvpDeltaCodec = (*cvpCodecDelta)(nil)
+
+const cvpCodecDeltaSeparator byte = '|'
+
+var prefixDataEncodedByCvpCodecDelta = []byte{0xD, cvpCodecDeltaSeparator}
+
+// cvpCodecDeltaVoteStateRecordSize is size of a vote state record: 2 bytes index + 4 bytes hash + 1 byte flag.
+const cvpCodecDeltaVoteStateRecordSize = 7
+
+// CvpDeltaCodec is a CvpCodec that able to encode next block voting information as the difference
+// against the previous frame, so only vote states those changed since the previous frame are transferred.
+//
+// A delta frame is keyed by the HeightRoundStep and checksum of the vote states of the base frame,
+// so it can not be applied on top of a different base frame.
+type CvpDeltaCodec interface {
+	CvpCodec
+
+	// EncodeStreamingNextBlockVotingInformationDelta encodes the next block voting information
+	// as the difference against the base, which is the previous frame sent.
+	// When base is nil, a key frame which contains all the vote states is produced.
+	// Input is assumed to be valid, otherwise panic.
+	EncodeStreamingNextBlockVotingInformationDelta(base, next *types.StreamingNextBlockVotingInformation) []byte
+
+	// TryEncodeStreamingNextBlockVotingInformationDelta is the same as EncodeStreamingNextBlockVotingInformationDelta
+	// but returns error instead of panic when input is invalid, the error matches ErrInvalidEncodeInput.
+	TryEncodeStreamingNextBlockVotingInformationDelta(base, next *types.StreamingNextBlockVotingInformation) ([]byte, error)
+
+	// ApplyStreamingNextBlockVotingInformationDelta reconstructs the full next block voting information
+	// by applying the given delta frame on top of the base, which is the previous frame decoded.
+	// Base can be nil if the given frame is a key frame.
+	ApplyStreamingNextBlockVotingInformationDelta(base *types.StreamingNextBlockVotingInformation, bz []byte) (*types.StreamingNextBlockVotingInformation, error)
+}
+
+// cvpCodecDeltaLimits is the same as v2 for light validators.
+// The largest next block voting information frame is a non-key frame with all the vote states changed.
+var cvpCodecDeltaLimits = CvpCodecLimits{
+	MaxValidators:                             cvpCodecV2Limits.MaxValidators,
+	MaxValidatorIndex:                         cvpCodecV2Limits.MaxValidatorIndex,
+	MaxEncodedLightValidatorsBytes:            cvpCodecV2Limits.MaxEncodedLightValidatorsBytes,
+	MaxEncodedNextBlockVotingInformationBytes: 1812,
+}
+
+type cvpCodecDelta struct {
+	v2Codec CvpCodec
+}
+
+// GetCvpDeltaCodec returns new instance of CvpDeltaCodec.
+//
+// Light validators are encoded the same way as v2 codec, only the prefix is different.
+//
+// Next block voting information encoded via EncodeStreamingNextBlockVotingInformation are key frames,
+// which can be decoded without base frame.
+// Frames encoded via EncodeStreamingNextBlockVotingInformationDelta with a non-nil base
+// can only be decoded via ApplyStreamingNextBlockVotingInformationDelta.
+func GetCvpDeltaCodec() CvpDeltaCodec {
+	return cvpCodecDelta{
+		v2Codec: GetCvpCodecV2(),
+	}
+}
+
+func (c cvpCodecDelta) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecDelta) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	bzByV2, err := c.v2Codec.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		return nil, withEncodeErrorVersion(err, CvpCodecVersionDelta)
+	}
+	return append(append([]byte{}, prefixDataEncodedByCvpCodecDelta...), bzByV2[len(prefixDataEncodedByCvpCodecV2):]...), nil
+}
+
+func (c cvpCodecDelta) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecDelta) {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	bzByV2 := append(append([]byte{}, prefixDataEncodedByCvpCodecV2...), bz[len(prefixDataEncodedByCvpCodecDelta):]...)
+	return c.v2Codec.DecodeStreamingLightValidators(bzByV2)
+}
+
+func (c cvpCodecDelta) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
+	return c.EncodeStreamingNextBlockVotingInformationDelta(nil, inf)
+}
+
+func (c cvpCodecDelta) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	return c.TryEncodeStreamingNextBlockVotingInformationDelta(nil, inf)
+}
+
+func (c cvpCodecDelta) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	return c.ApplyStreamingNextBlockVotingInformationDelta(nil, bz)
+}
+
+func (c cvpCodecDelta) EncodeStreamingNextBlockVotingInformationDelta(base, next *types.StreamingNextBlockVotingInformation) []byte {
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformationDelta(base, next)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecDelta) TryEncodeStreamingNextBlockVotingInformationDelta(base, next *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	if len(next.ValidatorVoteStates) > cvpCodecDeltaLimits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionDelta, "ValidatorVoteStates", "too many validators: %d/%d", len(next.ValidatorVoteStates), cvpCodecDeltaLimits.MaxValidators)
+	}
+	if err := validateTextHeightRoundStepForEncoding(CvpCodecVersionDelta, "HeightRoundStep", next.HeightRoundStep); err != nil {
+		return nil, err
+	}
+	if err := validatePercentForEncoding(CvpCodecVersionDelta, "PreVotedPercent", next.PreVotedPercent); err != nil {
+		return nil, err
+	}
+	if err := validatePercentForEncoding(CvpCodecVersionDelta, "PreCommitVotedPercent", next.PreCommitVotedPercent); err != nil {
+		return nil, err
+	}
+
+	var err error
+	var baseRecords [][]byte
+	var baseHeightRoundStep string
+	if base != nil {
+		if err := base.HeightRoundStep.ValidateLegacy(); err != nil {
+			return nil, newEncodeError(CvpCodecVersionDelta, "HeightRoundStep", "invalid height round step of base %s: %v", base.HeightRoundStep, err)
+		}
+		baseHeightRoundStep = base.HeightRoundStep.String()
+		baseRecords, err = encodeVoteStateRecordsByIndexDelta(base.ValidatorVoteStates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	nextRecords, err := encodeVoteStateRecordsByIndexDelta(next.ValidatorVoteStates)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.Write(prefixDataEncodedByCvpCodecDelta)
+
+	b.Write([]byte(baseHeightRoundStep))
+	b.WriteByte(cvpCodecDeltaSeparator)
+
+	b.Write([]byte(next.HeightRoundStep.String()))
+	b.WriteByte(cvpCodecDeltaSeparator)
+
+	duration := next.Duration
+	if duration < 0 {
+		duration = 0
+	}
+	b.Write([]byte(strconv.Itoa(int(duration.Seconds()))))
+	b.WriteByte(cvpCodecDeltaSeparator)
+
+	b.Write(toPercentBuffer(next.PreVotedPercent))
+	b.Write(toPercentBuffer(next.PreCommitVotedPercent))
+	b.WriteByte(cvpCodecDeltaSeparator)
+
+	bzChecksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(bzChecksum, checksumVoteStateRecordsDelta(baseRecords))
+	b.Write(bzChecksum)
+
+	b.Write(toUint16Buffer(len(nextRecords)))
+
+	for i, record := range nextRecords {
+		if i < len(baseRecords) && bytes.Equal(record, baseRecords[i]) {
+			continue
+		}
+		b.Write(record)
+	}
+
+	return b.Bytes(), nil
+}
+
+func (c cvpCodecDelta) ApplyStreamingNextBlockVotingInformationDelta(base *types.StreamingNextBlockVotingInformation, bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecDelta) {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	var result types.StreamingNextBlockVotingInformation
+
+	cursor := 2 // skipped first byte is version and second byte is separator
+
+	bzBaseHeightRoundStep := takeUntilSeparatorOrEnd(bz, cursor, cvpCodecDeltaSeparator)
+	var baseHeightRoundStep *types.HeightRoundStep
+	if len(bzBaseHeightRoundStep) > 0 {
+		parsed, err := types.ParseLegacyHeightRoundStep(string(bzBaseHeightRoundStep))
+		if err != nil {
+			return nil, wrapDecodeError(CvpCodecVersionDelta, ErrInvalidField, "BaseHeightRoundStep", cursor, err, fmt.Sprintf("invalid base height round step: %s", bzBaseHeightRoundStep))
+		}
+		baseHeightRoundStep = &parsed
+	}
+
+	cursor += len(bzBaseHeightRoundStep) + 1 /*separator*/
+
+	bzHeightRoundStep := takeUntilSeparatorOrEnd(bz, cursor, cvpCodecDeltaSeparator)
+	heightRoundStep, err := parseHeightRoundStepForDecoding(CvpCodecVersionDelta, "HeightRoundStep", cursor, string(bzHeightRoundStep))
+	if err != nil {
+		return nil, err
+	}
+	result.HeightRoundStep = heightRoundStep
+
+	cursor += len(bzHeightRoundStep) + 1 /*separator*/
+
+	bzDurationSec := takeUntilSeparatorOrEnd(bz, cursor, cvpCodecDeltaSeparator)
+	durationSecStr := string(bzDurationSec)
+	durationSec, err := strconv.ParseInt(durationSecStr, 10, 64)
+	if err != nil {
+		return nil, wrapDecodeError(CvpCodecVersionDelta, ErrInvalidField, "Duration", cursor, err, fmt.Sprintf("failed to parse duration sec: %s", durationSecStr))
+	}
+	if durationSec < 0 {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "Duration", cursor, "negative duration sec: %d", durationSec)
+	}
+	result.Duration = time.Duration(durationSec) * time.Second
+
+	cursor += len(bzDurationSec) + 1 /*separator*/
+
+	bzPreVotedAndPreCommitVotedPercent, ok := tryTakeNBytesFrom(bz, cursor, 4+1 /*separator*/)
+	if !ok {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrTruncated, "PreVotedPercent", cursor, "invalid buffer of pre-voted and pre-commit voted percent")
+	}
+	if bzPreVotedAndPreCommitVotedPercent[4] != cvpCodecDeltaSeparator {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "PreVotedPercent", cursor, "invalid buffer of pre-voted and pre-commit voted percent")
+	}
+	result.PreVotedPercent = fromPercentBuffer(bzPreVotedAndPreCommitVotedPercent[:2])
+	if result.PreVotedPercent < 0 || result.PreVotedPercent > 100 {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "PreVotedPercent", cursor, "invalid pre-voted percent: %f", result.PreVotedPercent)
+	}
+	result.PreCommitVotedPercent = fromPercentBuffer(bzPreVotedAndPreCommitVotedPercent[2:4])
+	if result.PreCommitVotedPercent < 0 || result.PreCommitVotedPercent > 100 {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "PreCommitVotedPercent", cursor+2, "invalid pre-commit voted percent: %f", result.PreCommitVotedPercent)
+	}
+
+	cursor += 4 + 1 /*separator*/
+
+	bzChecksumAndCount, ok := tryTakeNBytesFrom(bz, cursor, 4+2)
+	if !ok {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrTruncated, "Checksum", cursor, "missing checksum and number of validator vote states")
+	}
+	checksum := binary.BigEndian.Uint32(bzChecksumAndCount[:4])
+	count := fromUint16Buffer(bzChecksumAndCount[4:])
+	if count < 1 {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "ValidatorVoteStates", cursor+4, "missing validator vote states")
+	}
+	if count > cvpCodecDeltaLimits.MaxValidators {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "ValidatorVoteStates", cursor+4, "too many validators: %d/%d", count, cvpCodecDeltaLimits.MaxValidators)
+	}
+
+	offsetOfChecksum := cursor
+	cursor += 4 + 2
+
+	var baseValidatorVoteStates []types.StreamingValidatorVoteState
+	if baseHeightRoundStep != nil {
+		if base == nil {
+			return nil, newDecodeError(CvpCodecVersionDelta, ErrBaseFrameMismatch, "BaseHeightRoundStep", 2, "missing base frame %s to apply delta", baseHeightRoundStep)
+		}
+		if base.HeightRoundStep != *baseHeightRoundStep {
+			return nil, newDecodeError(CvpCodecVersionDelta, ErrBaseFrameMismatch, "BaseHeightRoundStep", 2, "base frame mismatch, delta built on top of %s but provided %s", baseHeightRoundStep, base.HeightRoundStep)
+		}
+		baseRecords, err := encodeVoteStateRecordsByIndexDelta(base.ValidatorVoteStates)
+		if err != nil {
+			return nil, wrapDecodeError(CvpCodecVersionDelta, ErrBaseFrameMismatch, "BaseHeightRoundStep", 2, err, "invalid base frame")
+		}
+		if checksumVoteStateRecordsDelta(baseRecords) != checksum {
+			return nil, newDecodeError(CvpCodecVersionDelta, ErrBaseFrameMismatch, "Checksum", offsetOfChecksum, "base frame mismatch, checksum of validator vote states of %s is different", baseHeightRoundStep)
+		}
+		baseValidatorVoteStates = base.ValidatorVoteStates
+	} else if checksum != checksumVoteStateRecordsDelta(nil) {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "Checksum", offsetOfChecksum, "invalid checksum of key frame")
+	}
+
+	bzRecords := bz[cursor:]
+	if len(bzRecords)%cvpCodecDeltaVoteStateRecordSize != 0 {
+		return nil, newDecodeError(CvpCodecVersionDelta, ErrTruncated, "ValidatorVoteStates", cursor, "invalid validator vote states length: %d", len(bzRecords))
+	}
+
+	validatorVoteStates := make([]types.StreamingValidatorVoteState, count)
+	filled := make([]bool, count)
+	for _, state := range baseValidatorVoteStates {
+		if state.ValidatorIndex >= 0 && state.ValidatorIndex < count {
+			validatorVoteStates[state.ValidatorIndex] = state
+			filled[state.ValidatorIndex] = true
+		}
+	}
+
+	offsetOfRecords := cursor
+	changed := make(map[int]bool)
+	for cursor = 0; cursor < len(bzRecords); cursor += cvpCodecDeltaVoteStateRecordSize {
+		offset := offsetOfRecords + cursor
+		bzRecord := bzRecords[cursor : cursor+cvpCodecDeltaVoteStateRecordSize]
+
+		validatorIndex := fromUint16Buffer(bzRecord[:2])
+		if validatorIndex >= count {
+			return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "ValidatorIndex", offset, "invalid validator index: %d, out of %d validators", validatorIndex, count)
+		}
+		if changed[validatorIndex] {
+			return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "ValidatorIndex", offset, "duplicated validator index: %d", validatorIndex)
+		}
+		changed[validatorIndex] = true
+
+		validatorVoteState, err := readValidatorVoteStateBodyV2(bzRecord[2:], validatorIndex, CvpCodecVersionDelta, offset+2)
+		if err != nil {
+			return nil, err
+		}
+
+		validatorVoteStates[validatorIndex] = validatorVoteState
+		filled[validatorIndex] = true
+	}
+
+	for i, ok := range filled {
+		if !ok {
+			return nil, newDecodeError(CvpCodecVersionDelta, ErrInvalidField, "ValidatorVoteStates", offsetOfRecords, "missing vote state of validator index %d", i)
+		}
+	}
+	result.ValidatorVoteStates = validatorVoteStates
+
+	return &result, nil
+}
+
+func (c cvpCodecDelta) GetLimits() CvpCodecLimits {
+	return cvpCodecDeltaLimits
+}
+
+func (c cvpCodecDelta) GetVersion() CvpCodecVersion {
+	return CvpCodecVersionDelta
+}
+
+// encodeVoteStateRecordsByIndexDelta encodes each vote state into a 7 bytes record, sorted by validator index.
+// Validator indexes must be a sequence starting from 0.
+func encodeVoteStateRecordsByIndexDelta(validatorVoteStates []types.StreamingValidatorVoteState) ([][]byte, error) {
+	sorted := make([]types.StreamingValidatorVoteState, len(validatorVoteStates))
+	copy(sorted, validatorVoteStates)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ValidatorIndex < sorted[j].ValidatorIndex
+	})
+
+	records := make([][]byte, len(sorted))
+	for i, v := range sorted {
+		if v.ValidatorIndex < 0 {
+			return nil, newEncodeError(CvpCodecVersionDelta, "ValidatorIndex", "invalid validator index: %d, must not be negative", v.ValidatorIndex)
+		}
+		if v.ValidatorIndex > cvpCodecDeltaLimits.MaxValidatorIndex {
+			return nil, newEncodeError(CvpCodecVersionDelta, "ValidatorIndex", "invalid validator index: %d, must be less than %d", v.ValidatorIndex, cvpCodecDeltaLimits.MaxValidatorIndex+1)
+		}
+		if v.ValidatorIndex != i {
+			return nil, newEncodeError(CvpCodecVersionDelta, "ValidatorIndex", "invalid validator index sequence, %d at %d", v.ValidatorIndex, i)
+		}
+
+		var b bytes.Buffer
+		b.Write(toUint16Buffer(v.ValidatorIndex))
+		if err := writeValidatorVoteStateBodyV2(&b, v, CvpCodecVersionDelta); err != nil {
+			return nil, err
+		}
+		records[i] = b.Bytes()
+	}
+
+	return records, nil
+}
+
+func checksumVoteStateRecordsDelta(records [][]byte) uint32 {
+	return crc32.ChecksumIEEE(bytes.Join(records, nil))
+}
@@ -0,0 +1,467 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cvpDeltaCodecImpl = GetCvpDeltaCodec()
+
+func sampleNextBlockVotingInformationForDelta(numberOfValidators int) *types.StreamingNextBlockVotingInformation {
+	inf := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep:       types.MustParseHeightRoundStep("100/0/3"),
+		Duration:              2 * time.Second,
+		PreVotedPercent:       0,
+		PreCommitVotedPercent: 0,
+	}
+	for i := 0; i < numberOfValidators; i++ {
+		inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, types.StreamingValidatorVoteState{
+			ValidatorIndex:    i,
+			PreVotedBlockHash: "----",
+		})
+	}
+	return inf
+}
+
+func copyNextBlockVotingInformationForDelta(inf *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+	cloned := *inf
+	cloned.ValidatorVoteStates = append([]types.StreamingValidatorVoteState{}, inf.ValidatorVoteStates...)
+	return &cloned
+}
+
+func Test_cvpCodecDelta_EncodeDecodeStreamingLightValidators(t *testing.T) {
+	validators := types.StreamingLightValidators{
+		{
+			Index:                     0,
+			VotingPowerDisplayPercent: 10.11,
+			Moniker:                   "Val1",
+		},
+		{
+			Index:                     1,
+			VotingPowerDisplayPercent: 01.02,
+			Moniker:                   "Val2",
+		},
+	}
+
+	gotEncoded := cvpDeltaCodecImpl.EncodeStreamingLightValidators(validators)
+	wantEncoded := mergeBuffers(
+		prefixDataEncodedByCvpCodecDelta,
+		[]byte{0x0, 0x0}, []byte{0x0a, 0x0b}, b64bz(fssut("Val1", 20)),
+		[]byte{cvpCodecV2Separator},
+		[]byte{0x0, 0x1}, []byte{0x01, 0x02}, b64bz(fssut("Val2", 20)),
+	)
+	if !reflect.DeepEqual(gotEncoded, wantEncoded) {
+		t.Errorf("EncodeStreamingLightValidators()\n%v (got)\n%v (want)", gotEncoded, wantEncoded)
+		return
+	}
+
+	gotDecoded, err := cvpDeltaCodecImpl.DecodeStreamingLightValidators(gotEncoded)
+	if err != nil {
+		t.Errorf("DecodeStreamingLightValidators() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(gotDecoded, validators) {
+		t.Errorf("DecodeStreamingLightValidators()\ngot = %v,\nwant %v", gotDecoded, validators)
+	}
+
+	_, err = cvpDeltaCodecImpl.DecodeStreamingLightValidators(cvpV2CodecImpl.EncodeStreamingLightValidators(validators))
+	if err == nil || !strings.Contains(err.Error(), "bad encoding prefix") {
+		t.Errorf("DecodeStreamingLightValidators() error = %v, want bad encoding prefix", err)
+	}
+}
+
+func Test_cvpCodecDelta_EncodeStreamingNextBlockVotingInformationDelta(t *testing.T) {
+	base := sampleNextBlockVotingInformationForDelta(4)
+
+	next := copyNextBlockVotingInformationForDelta(base)
+	next.HeightRoundStep = types.MustParseHeightRoundStep("100/0/4")
+	next.Duration = 3 * time.Second
+	next.PreVotedPercent = 25.5
+	next.ValidatorVoteStates[2] = types.StreamingValidatorVoteState{
+		ValidatorIndex:    2,
+		PreVotedBlockHash: "ABCD",
+		PreVoted:          true,
+	}
+
+	baseRecords, err := encodeVoteStateRecordsByIndexDelta(base.ValidatorVoteStates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bzBaseChecksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(bzBaseChecksum, checksumVoteStateRecordsDelta(baseRecords))
+
+	gotEncoded := cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformationDelta(base, next)
+	wantEncoded := mergeBuffers(
+		prefixDataEncodedByCvpCodecDelta,
+		[]byte("100/0/3"), []byte{cvpCodecDeltaSeparator},
+		[]byte("100/0/4"), []byte{cvpCodecDeltaSeparator},
+		[]byte("3"), []byte{cvpCodecDeltaSeparator},
+		[]byte{25, 50}, []byte{0, 0}, []byte{cvpCodecDeltaSeparator},
+		bzBaseChecksum, []byte{0x00, 0x04},
+		[]byte{0x00, 0x02}, []byte("ABCD"), []byte("V"),
+	)
+	if !reflect.DeepEqual(gotEncoded, wantEncoded) {
+		t.Errorf("EncodeStreamingNextBlockVotingInformationDelta()\n%v (got)\n%v (want)", gotEncoded, wantEncoded)
+		return
+	}
+
+	gotDecoded, err := cvpDeltaCodecImpl.ApplyStreamingNextBlockVotingInformationDelta(base, gotEncoded)
+	if err != nil {
+		t.Errorf("ApplyStreamingNextBlockVotingInformationDelta() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(gotDecoded, next) {
+		t.Errorf("ApplyStreamingNextBlockVotingInformationDelta()\ngot = %v,\nwant %v", gotDecoded, next)
+	}
+}
+
+func Test_cvpCodecDelta_ApplyStreamingNextBlockVotingInformationDelta(t *testing.T) {
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name                  string
+		base                  *types.StreamingNextBlockVotingInformation
+		next                  func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation
+		applyOn               func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation
+		wantErrApply          bool
+		wantErrApplyContains  string
+		wantNotSmallerThanKey bool
+	}{
+		{
+			name: "no change",
+			base: sampleNextBlockVotingInformationForDelta(180),
+			next: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				return copyNextBlockVotingInformationForDelta(base)
+			},
+		},
+		{
+			name: "some validators changed",
+			base: sampleNextBlockVotingInformationForDelta(180),
+			next: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				next := copyNextBlockVotingInformationForDelta(base)
+				next.ValidatorVoteStates[0] = types.StreamingValidatorVoteState{
+					ValidatorIndex:    0,
+					PreVotedBlockHash: "C0FF",
+					PreVoted:          true,
+					PreCommitVoted:    true,
+				}
+				next.ValidatorVoteStates[124] = types.StreamingValidatorVoteState{
+					ValidatorIndex:    124,
+					PreVotedBlockHash: "0000",
+					PreVoted:          true,
+					VotedZeroes:       true,
+				}
+				next.ValidatorVoteStates[179] = types.StreamingValidatorVoteState{
+					ValidatorIndex:    179,
+					PreVotedBlockHash: "C0FF",
+					PreVoted:          true,
+				}
+				return next
+			},
+		},
+		{
+			name: "all validators changed",
+			base: sampleNextBlockVotingInformationForDelta(10),
+			next: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				next := copyNextBlockVotingInformationForDelta(base)
+				for i := range next.ValidatorVoteStates {
+					next.ValidatorVoteStates[i].PreVotedBlockHash = "C0FF"
+					next.ValidatorVoteStates[i].PreVoted = true
+				}
+				return next
+			},
+			wantNotSmallerThanKey: true,
+		},
+		{
+			name: "validator set grows",
+			base: sampleNextBlockVotingInformationForDelta(10),
+			next: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				next := sampleNextBlockVotingInformationForDelta(12)
+				next.HeightRoundStep = types.MustParseHeightRoundStep("101/0/1")
+				return next
+			},
+		},
+		{
+			name: "validator set shrinks",
+			base: sampleNextBlockVotingInformationForDelta(10),
+			next: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				next := sampleNextBlockVotingInformationForDelta(8)
+				next.HeightRoundStep = types.MustParseHeightRoundStep("101/0/1")
+				return next
+			},
+		},
+		{
+			name: "missing base frame",
+			base: sampleNextBlockVotingInformationForDelta(10),
+			next: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				return copyNextBlockVotingInformationForDelta(base)
+			},
+			applyOn: func(_ *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				return nil
+			},
+			wantErrApply:         true,
+			wantErrApplyContains: "missing base frame 100/0/3",
+		},
+		{
+			name: "base frame has different height round step",
+			base: sampleNextBlockVotingInformationForDelta(10),
+			next: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				return copyNextBlockVotingInformationForDelta(base)
+			},
+			applyOn: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				other := copyNextBlockVotingInformationForDelta(base)
+				other.HeightRoundStep = types.MustParseHeightRoundStep("100/0/2")
+				return other
+			},
+			wantErrApply:         true,
+			wantErrApplyContains: "base frame mismatch",
+		},
+		{
+			name: "base frame has different vote states",
+			base: sampleNextBlockVotingInformationForDelta(10),
+			next: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				return copyNextBlockVotingInformationForDelta(base)
+			},
+			applyOn: func(base *types.StreamingNextBlockVotingInformation) *types.StreamingNextBlockVotingInformation {
+				other := copyNextBlockVotingInformationForDelta(base)
+				other.ValidatorVoteStates[1].PreVoted = true
+				return other
+			},
+			wantErrApply:         true,
+			wantErrApplyContains: "base frame mismatch",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := tt.next(tt.base)
+
+			gotEncoded := cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformationDelta(tt.base, next)
+			keyFrame := cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformation(next)
+			if !tt.wantNotSmallerThanKey && len(gotEncoded) >= len(keyFrame) {
+				t.Errorf("delta frame size %d is not smaller than key frame size %d", len(gotEncoded), len(keyFrame))
+			}
+
+			applyOn := tt.base
+			if tt.applyOn != nil {
+				applyOn = tt.applyOn(tt.base)
+			}
+
+			gotDecoded, err := cvpDeltaCodecImpl.ApplyStreamingNextBlockVotingInformationDelta(applyOn, gotEncoded)
+			if (err != nil) != tt.wantErrApply {
+				t.Errorf("ApplyStreamingNextBlockVotingInformationDelta() error = %v, wantErr %v", err, tt.wantErrApply)
+				return
+			}
+			if err == nil {
+				if !reflect.DeepEqual(gotDecoded, next) {
+					t.Errorf("ApplyStreamingNextBlockVotingInformationDelta()\ngot = %v,\nwant %v", gotDecoded, next)
+				}
+			} else {
+				if !strings.Contains(err.Error(), tt.wantErrApplyContains) {
+					t.Errorf("ApplyStreamingNextBlockVotingInformationDelta() error = %v, wantErr contains %v", err, tt.wantErrApplyContains)
+				}
+			}
+		})
+	}
+
+	t.Run("can not decode delta frame without base", func(t *testing.T) {
+		base := sampleNextBlockVotingInformationForDelta(4)
+		gotEncoded := cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformationDelta(base, base)
+
+		_, err := cvpDeltaCodecImpl.DecodeStreamingNextBlockVotingInformation(gotEncoded)
+		if err == nil || !strings.Contains(err.Error(), "missing base frame") {
+			t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, want missing base frame", err)
+		}
+
+		_, err = cvpProxyCodecImpl.DecodeStreamingNextBlockVotingInformation(gotEncoded)
+		if err == nil || !strings.Contains(err.Error(), "missing base frame") {
+			t.Errorf("proxy DecodeStreamingNextBlockVotingInformation() error = %v, want missing base frame", err)
+		}
+	})
+
+	t.Run("key frame can be applied on top of any base", func(t *testing.T) {
+		next := sampleNextBlockVotingInformationForDelta(4)
+		gotEncoded := cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformation(next)
+
+		gotDecoded, err := cvpDeltaCodecImpl.ApplyStreamingNextBlockVotingInformationDelta(sampleNextBlockVotingInformationForDelta(2), gotEncoded)
+		if err != nil {
+			t.Errorf("ApplyStreamingNextBlockVotingInformationDelta() error = %v", err)
+			return
+		}
+		if !reflect.DeepEqual(gotDecoded, next) {
+			t.Errorf("ApplyStreamingNextBlockVotingInformationDelta()\ngot = %v,\nwant %v", gotDecoded, next)
+		}
+	})
+
+	t.Run("consecutive frames", func(t *testing.T) {
+		sent := sampleNextBlockVotingInformationForDelta(180)
+		received, err := cvpDeltaCodecImpl.DecodeStreamingNextBlockVotingInformation(cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformation(sent))
+		if err != nil {
+			t.Fatalf("DecodeStreamingNextBlockVotingInformation() error = %v", err)
+		}
+
+		for tick := 0; tick < 180; tick++ {
+			next := copyNextBlockVotingInformationForDelta(sent)
+			next.Duration += time.Second
+			next.ValidatorVoteStates[tick] = types.StreamingValidatorVoteState{
+				ValidatorIndex:    tick,
+				PreVotedBlockHash: fmt.Sprintf("%04X", tick),
+				PreVoted:          true,
+			}
+
+			received, err = cvpDeltaCodecImpl.ApplyStreamingNextBlockVotingInformationDelta(received, cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformationDelta(sent, next))
+			if err != nil {
+				t.Fatalf("ApplyStreamingNextBlockVotingInformationDelta() at tick %d error = %v", tick, err)
+			}
+			if !reflect.DeepEqual(received, next) {
+				t.Fatalf("ApplyStreamingNextBlockVotingInformationDelta() at tick %d\ngot = %v,\nwant %v", tick, received, next)
+			}
+
+			sent = next
+		}
+	})
+}
+
+func Test_cvpCodecDelta_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	bzEmptyChecksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(bzEmptyChecksum, checksumVoteStateRecordsDelta(nil))
+
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name                  string
+		inputEncodedData      []byte
+		wantErrDecodeContains string
+	}{
+		{
+			name:                  "incorrect codec version",
+			inputEncodedData:      cvpV2CodecImpl.EncodeStreamingNextBlockVotingInformation(sampleNextBlockVotingInformationForDelta(1)),
+			wantErrDecodeContains: "bad encoding prefix",
+		},
+		{
+			name: "invalid height round step",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2"), []byte{cvpCodecDeltaSeparator},
+			),
+			wantErrDecodeContains: "invalid height round step",
+		},
+		{
+			name: "missing percent",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2/3"), []byte{cvpCodecDeltaSeparator},
+				[]byte("1"), []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x00},
+			),
+			wantErrDecodeContains: "invalid buffer of pre-voted and pre-commit voted percent",
+		},
+		{
+			name: "missing validator vote states",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2/3"), []byte{cvpCodecDeltaSeparator},
+				[]byte("1"), []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x00}, []byte{0x02, 0x36}, []byte{cvpCodecDeltaSeparator},
+				bzEmptyChecksum, []byte{0x00, 0x00},
+			),
+			wantErrDecodeContains: "missing validator vote states",
+		},
+		{
+			name: "key frame missing vote state",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2/3"), []byte{cvpCodecDeltaSeparator},
+				[]byte("1"), []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x00}, []byte{0x02, 0x36}, []byte{cvpCodecDeltaSeparator},
+				bzEmptyChecksum, []byte{0x00, 0x02},
+				[]byte{0x00, 0x01}, []byte("ABCD"), []byte("C"),
+			),
+			wantErrDecodeContains: "missing vote state of validator index 0",
+		},
+		{
+			name: "validator index out of range",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2/3"), []byte{cvpCodecDeltaSeparator},
+				[]byte("1"), []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x00}, []byte{0x02, 0x36}, []byte{cvpCodecDeltaSeparator},
+				bzEmptyChecksum, []byte{0x00, 0x01},
+				[]byte{0x00, 0x01}, []byte("ABCD"), []byte("C"),
+			),
+			wantErrDecodeContains: "invalid validator index: 1",
+		},
+		{
+			name: "duplicated validator index",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2/3"), []byte{cvpCodecDeltaSeparator},
+				[]byte("1"), []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x00}, []byte{0x02, 0x36}, []byte{cvpCodecDeltaSeparator},
+				bzEmptyChecksum, []byte{0x00, 0x01},
+				[]byte{0x00, 0x00}, []byte("ABCD"), []byte("C"),
+				[]byte{0x00, 0x00}, []byte("ABCD"), []byte("C"),
+			),
+			wantErrDecodeContains: "duplicated validator index: 0",
+		},
+		{
+			name: "invalid vote state records length",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2/3"), []byte{cvpCodecDeltaSeparator},
+				[]byte("1"), []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x00}, []byte{0x02, 0x36}, []byte{cvpCodecDeltaSeparator},
+				bzEmptyChecksum, []byte{0x00, 0x01},
+				[]byte{0x00, 0x00}, []byte("ABCD"),
+			),
+			wantErrDecodeContains: "invalid validator vote states length",
+		},
+		{
+			name: "invalid vote flag",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2/3"), []byte{cvpCodecDeltaSeparator},
+				[]byte("1"), []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x00}, []byte{0x02, 0x36}, []byte{cvpCodecDeltaSeparator},
+				bzEmptyChecksum, []byte{0x00, 0x01},
+				[]byte{0x00, 0x00}, []byte("ABCD"), []byte("Z"),
+			),
+			wantErrDecodeContains: "invalid validator vote flag: Z",
+		},
+		{
+			name: "invalid checksum of key frame",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecDelta,
+				[]byte{cvpCodecDeltaSeparator},
+				[]byte("1/2/3"), []byte{cvpCodecDeltaSeparator},
+				[]byte("1"), []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x00}, []byte{0x02, 0x36}, []byte{cvpCodecDeltaSeparator},
+				[]byte{0x01, 0x02, 0x03, 0x04}, []byte{0x00, 0x01},
+				[]byte{0x00, 0x00}, []byte("ABCD"), []byte("C"),
+			),
+			wantErrDecodeContains: "invalid checksum of key frame",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotDecoded, err := cvpDeltaCodecImpl.DecodeStreamingNextBlockVotingInformation(tt.inputEncodedData)
+			if err == nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() expect error but got %v", gotDecoded)
+				return
+			}
+			if !strings.Contains(err.Error(), tt.wantErrDecodeContains) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, wantErr contains %v", err, tt.wantErrDecodeContains)
+			}
+		})
+	}
+}
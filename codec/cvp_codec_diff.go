@@ -0,0 +1,327 @@
+package codec
+
+import (
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamingData is the data encoded by CvpCodec, which can be compared by Diff.
+type StreamingData interface {
+	types.StreamingLightValidators | *types.StreamingNextBlockVotingInformation
+}
+
+// FieldChange is a field altered by encoding, reported by Diff.
+type FieldChange struct {
+	// Field is the path of the field, like "PreVotedPercent" or "ValidatorVoteStates[3].VotedZeroes",
+	// the element index is the position in the original data.
+	Field string
+
+	// Original is the value of the original data, nil if the element is added.
+	Original any
+
+	// Decoded is the value of the decoded data, nil if the element is dropped.
+	Decoded any
+
+	// Reason is the human-readable reason of the change, like "truncated to whole seconds".
+	Reason string
+}
+
+func (c FieldChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v, %s", c.Field, c.Original, c.Decoded, c.Reason)
+}
+
+// Diff returns the fields of the original data which are altered in the decoded data, and why,
+// empty if the round trip is lossless.
+//
+// The reason is inferred from the values, like a Duration truncated to whole seconds, so it does not depend on the codec.
+// Elements are matched by the validator index, a different order is reported as sorted by index.
+func Diff[T StreamingData](original, decoded T) []FieldChange {
+	var d differ
+	switch original := any(original).(type) {
+	case types.StreamingLightValidators:
+		d.diffLightValidators(original, any(decoded).(types.StreamingLightValidators))
+	case *types.StreamingNextBlockVotingInformation:
+		d.diffNextBlockVotingInformation(original, any(decoded).(*types.StreamingNextBlockVotingInformation))
+	}
+	return d.changes
+}
+
+// RoundTrip encodes the data by the codec then decodes it, returns the decoded data and the fields altered by the round trip.
+// Error is returned if the data can not be encoded, the error matches ErrInvalidEncodeInput, or the encoded data can not be decoded.
+func RoundTrip[T StreamingData](codec CvpCodec, original T) (decoded T, changes []FieldChange, err error) {
+	var decodedData any
+	switch original := any(original).(type) {
+	case types.StreamingLightValidators:
+		var bz []byte
+		if bz, err = codec.TryEncodeStreamingLightValidators(original); err != nil {
+			return
+		}
+		decodedData, err = codec.DecodeStreamingLightValidators(bz)
+	case *types.StreamingNextBlockVotingInformation:
+		var bz []byte
+		if bz, err = codec.TryEncodeStreamingNextBlockVotingInformation(original); err != nil {
+			return
+		}
+		decodedData, err = codec.DecodeStreamingNextBlockVotingInformation(bz)
+	}
+	if err != nil {
+		return
+	}
+
+	decoded = decodedData.(T)
+	changes = Diff(original, decoded)
+	return
+}
+
+type differ struct {
+	changes []FieldChange
+}
+
+func (d *differ) add(field string, original, decoded any, reason string) {
+	d.changes = append(d.changes, FieldChange{
+		Field:    field,
+		Original: original,
+		Decoded:  decoded,
+		Reason:   reason,
+	})
+}
+
+func (d *differ) diffLightValidators(original, decoded types.StreamingLightValidators) {
+	decodedByIndex := make(map[int]types.StreamingLightValidator, len(decoded))
+	for _, v := range decoded {
+		decodedByIndex[v.Index] = v
+	}
+	originalIndexes := make(map[int]bool, len(original))
+	for i, v := range original {
+		field := fmt.Sprintf("Validators[%d]", i)
+		originalIndexes[v.Index] = true
+
+		decodedValidator, found := decodedByIndex[v.Index]
+		if !found {
+			d.add(field, v, nil, "validator is dropped")
+			continue
+		}
+		d.diffPercent(field+".VotingPowerDisplayPercent", v.VotingPowerDisplayPercent, decodedValidator.VotingPowerDisplayPercent)
+		d.diffMoniker(field+".Moniker", v.Moniker, decodedValidator.Moniker)
+		d.diffOptional(field+".OperatorAddress", v.OperatorAddress, decodedValidator.OperatorAddress, false)
+		d.diffOptional(field+".ConsensusAddress", v.ConsensusAddress, decodedValidator.ConsensusAddress, false)
+	}
+	for _, v := range decoded {
+		if !originalIndexes[v.Index] {
+			d.add(fmt.Sprintf("Validators[index %d]", v.Index), nil, v, "validator is added")
+		}
+	}
+
+	originalOrder := make([]int, len(original))
+	for i, v := range original {
+		originalOrder[i] = v.Index
+	}
+	decodedOrder := make([]int, len(decoded))
+	for i, v := range decoded {
+		decodedOrder[i] = v.Index
+	}
+	d.diffOrder("Validators", originalOrder, decodedOrder)
+}
+
+func (d *differ) diffNextBlockVotingInformation(original, decoded *types.StreamingNextBlockVotingInformation) {
+	if original == nil || decoded == nil {
+		if original != decoded {
+			d.add("StreamingNextBlockVotingInformation", original, decoded, "nil is changed")
+		}
+		return
+	}
+
+	if original.HeightRoundStep != decoded.HeightRoundStep {
+		d.add("HeightRoundStep", original.HeightRoundStep, decoded.HeightRoundStep, "height round step is altered")
+	}
+	d.diffDuration("Duration", original.Duration, decoded.Duration)
+	d.diffPercent("PreVotedPercent", original.PreVotedPercent, decoded.PreVotedPercent)
+	d.diffPercent("PreCommitVotedPercent", original.PreCommitVotedPercent, decoded.PreCommitVotedPercent)
+
+	decodedByIndex := make(map[int]types.StreamingValidatorVoteState, len(decoded.ValidatorVoteStates))
+	for _, state := range decoded.ValidatorVoteStates {
+		decodedByIndex[state.ValidatorIndex] = state
+	}
+	originalIndexes := make(map[int]bool, len(original.ValidatorVoteStates))
+	for i, state := range original.ValidatorVoteStates {
+		field := fmt.Sprintf("ValidatorVoteStates[%d]", i)
+		originalIndexes[state.ValidatorIndex] = true
+
+		decodedState, found := decodedByIndex[state.ValidatorIndex]
+		if !found {
+			d.add(field, state, nil, "validator vote state is dropped")
+			continue
+		}
+		d.diffVoteState(field, state, decodedState)
+	}
+	for _, state := range decoded.ValidatorVoteStates {
+		if !originalIndexes[state.ValidatorIndex] {
+			d.add(fmt.Sprintf("ValidatorVoteStates[index %d]", state.ValidatorIndex), nil, state, "validator vote state is added")
+		}
+	}
+
+	originalOrder := make([]int, len(original.ValidatorVoteStates))
+	for i, state := range original.ValidatorVoteStates {
+		originalOrder[i] = state.ValidatorIndex
+	}
+	decodedOrder := make([]int, len(decoded.ValidatorVoteStates))
+	for i, state := range decoded.ValidatorVoteStates {
+		decodedOrder[i] = state.ValidatorIndex
+	}
+	d.diffOrder("ValidatorVoteStates", originalOrder, decodedOrder)
+}
+
+// diffOrder reports the elements those are in both original and decoded data but in a different order,
+// given the validator indexes in order.
+func (d *differ) diffOrder(field string, originalOrder, decodedOrder []int) {
+	keepCommon := func(order, other []int) []int {
+		inOther := make(map[int]bool, len(other))
+		for _, index := range other {
+			inOther[index] = true
+		}
+		common := make([]int, 0, len(order))
+		for _, index := range order {
+			if inOther[index] {
+				common = append(common, index)
+			}
+		}
+		return common
+	}
+	originalCommon, decodedCommon := keepCommon(originalOrder, decodedOrder), keepCommon(decodedOrder, originalOrder)
+	for i := 0; i < len(originalCommon) && i < len(decodedCommon); i++ {
+		if originalCommon[i] == decodedCommon[i] {
+			continue
+		}
+		reason := "order is altered"
+		if sort.IntsAreSorted(decodedCommon) {
+			reason = "sorted by index"
+		}
+		d.add(field, originalOrder, decodedOrder, reason)
+		return
+	}
+}
+
+func (d *differ) diffVoteState(field string, original, decoded types.StreamingValidatorVoteState) {
+	if original.PreVotedBlockHash != decoded.PreVotedBlockHash {
+		if original.PreVotedBlockHash == "" && decoded.PreVotedBlockHash == "----" {
+			d.add(field+".PreVotedBlockHash", original.PreVotedBlockHash, decoded.PreVotedBlockHash, `empty hash is encoded as "----"`)
+		} else {
+			d.diffOptional(field+".PreVotedBlockHash", original.PreVotedBlockHash, decoded.PreVotedBlockHash, true)
+		}
+	}
+
+	if original.PreVoted != decoded.PreVoted {
+		reason := "pre-voted is altered"
+		if !original.PreVoted && original.PreCommitVoted && decoded.PreCommitVoted {
+			reason = "implied by PreCommitVoted"
+		} else if !original.PreVoted && original.VotedZeroes && decoded.VotedZeroes {
+			reason = "implied by VotedZeroes"
+		}
+		d.add(field+".PreVoted", original.PreVoted, decoded.PreVoted, reason)
+	}
+	if original.VotedZeroes != decoded.VotedZeroes {
+		reason := "voted zeroes is altered"
+		if original.VotedZeroes && original.PreCommitVoted && decoded.PreCommitVoted {
+			reason = "dropped since PreCommitVoted is set, the vote flag carries only one of them"
+		}
+		d.add(field+".VotedZeroes", original.VotedZeroes, decoded.VotedZeroes, reason)
+	}
+	if original.PreCommitVoted != decoded.PreCommitVoted {
+		d.add(field+".PreCommitVoted", original.PreCommitVoted, decoded.PreCommitVoted, "pre-commit voted is altered")
+	}
+
+	d.diffOptional(field+".PreCommittedBlockHash", original.PreCommittedBlockHash, decoded.PreCommittedBlockHash, true)
+}
+
+// diffOptional reports the optional text field which is dropped, not supported by the codec, or upper cased.
+func (d *differ) diffOptional(field, original, decoded string, isBlockHash bool) {
+	switch {
+	case original == decoded:
+		return
+	case decoded == "":
+		d.add(field, original, decoded, "not supported by the codec")
+	case strings.ToUpper(original) == decoded:
+		d.add(field, original, decoded, "upper cased")
+	case isBlockHash && strings.EqualFold(original, decoded):
+		d.add(field, original, decoded, "case is altered")
+	default:
+		d.add(field, original, decoded, "value is altered")
+	}
+}
+
+func (d *differ) diffDuration(field string, original, decoded time.Duration) {
+	if original == decoded {
+		return
+	}
+
+	var reason string
+	switch {
+	case original < 0 && decoded == 0:
+		reason = "negative duration is encoded as zero"
+	case decoded == original.Truncate(time.Second):
+		reason = "truncated to whole seconds"
+	case decoded == original.Truncate(time.Millisecond):
+		reason = "truncated to milliseconds"
+	case decoded < original && decoded%time.Second == 0:
+		reason = "clamped to the maximum duration of the codec"
+	default:
+		reason = "duration is altered"
+	}
+	d.add(field, original, decoded, reason)
+}
+
+// diffPercent reports the percent which lost decimals, rounded or truncated to 2 decimals as encoded by the built-in codecs.
+func (d *differ) diffPercent(field string, original, decoded float64) {
+	if original == decoded {
+		return
+	}
+
+	const epsilon = 1e-9
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(original, 'f', 2, 64), 64)
+	isRounded := math.Abs(decoded-rounded) < epsilon
+	isTruncated := math.Abs(decoded-math.Trunc(original*100)/100) < epsilon
+
+	var reason string
+	switch {
+	case isRounded && isTruncated:
+		reason = "limited to 2 decimals"
+	case isRounded:
+		reason = "rounded to 2 decimals"
+	case isTruncated:
+		reason = "truncated to 2 decimals"
+	default:
+		reason = "percent is altered"
+	}
+	d.add(field, original, decoded, reason)
+}
+
+// diffMoniker reports the moniker which is sanitized, trimmed or truncated, with all the reasons apply.
+func (d *differ) diffMoniker(field, original, decoded string) {
+	if original == decoded {
+		return
+	}
+
+	var reasons []string
+	expected := original
+	if sanitized := sanitizeMoniker(expected); sanitized != expected {
+		reasons = append(reasons, `sanitized, characters <>'" are replaced`)
+		expected = sanitized
+	}
+	if trimmed := strings.TrimSpace(expected); trimmed != expected && (trimmed == decoded || !strings.HasPrefix(expected, decoded)) {
+		reasons = append(reasons, "leading and trailing spaces are trimmed")
+		expected = trimmed
+	}
+	if expected != decoded && strings.HasPrefix(expected, decoded) {
+		reasons = append(reasons, fmt.Sprintf("truncated from %d to %d bytes", len(expected), len(decoded)))
+		expected = decoded
+	}
+	if expected != decoded {
+		reasons = append(reasons, "moniker is altered")
+	}
+	d.add(field, original, decoded, strings.Join(reasons, ", "))
+}
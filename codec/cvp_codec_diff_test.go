@@ -0,0 +1,163 @@
+package codec
+
+import (
+	"errors"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff_LightValidators(t *testing.T) {
+	original := types.StreamingLightValidators{
+		{Index: 0, VotingPowerDisplayPercent: 60, Moniker: "Validator One"},
+		{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator Two"},
+	}
+
+	tests := []struct {
+		name    string
+		decoded types.StreamingLightValidators
+		want    map[string]string
+	}{
+		{
+			name:    "lossless",
+			decoded: append(types.StreamingLightValidators{}, original...),
+			want:    map[string]string{},
+		},
+		{
+			name: "moniker truncated",
+			decoded: types.StreamingLightValidators{
+				original[0],
+				{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator"},
+			},
+			want: map[string]string{"Validators[1].Moniker": "truncated from 13 to 9 bytes"},
+		},
+		{
+			name: "moniker altered",
+			decoded: types.StreamingLightValidators{
+				original[0],
+				{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator 2"},
+			},
+			want: map[string]string{"Validators[1].Moniker": "moniker is altered"},
+		},
+		{
+			name:    "dropped",
+			decoded: original[:1],
+			want:    map[string]string{"Validators[1]": "validator is dropped"},
+		},
+		{
+			name:    "added",
+			decoded: append(append(types.StreamingLightValidators{}, original...), types.StreamingLightValidator{Index: 2}),
+			want:    map[string]string{"Validators[index 2]": "validator is added"},
+		},
+		{
+			name: "percent altered",
+			decoded: types.StreamingLightValidators{
+				{Index: 0, VotingPowerDisplayPercent: 50, Moniker: "Validator One"},
+				original[1],
+			},
+			want: map[string]string{"Validators[0].VotingPowerDisplayPercent": "percent is altered"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkChangedFields(t, Diff(original, tt.decoded), tt.want)
+		})
+	}
+}
+
+func TestDiff_Moniker(t *testing.T) {
+	tests := []struct {
+		original string
+		decoded  string
+		want     string
+	}{
+		{original: "Validator  ", decoded: "Validator", want: "leading and trailing spaces are trimmed"},
+		{original: `"Validator"`, decoded: "`Validator`", want: `sanitized, characters <>'" are replaced`},
+		{original: "  <Validator> One", decoded: "(Validator)", want: "sanitized, characters <>'\" are replaced, leading and trailing spaces are trimmed, truncated from 15 to 11 bytes"},
+	}
+	for _, tt := range tests {
+		changes := Diff(
+			types.StreamingLightValidators{{Moniker: tt.original}},
+			types.StreamingLightValidators{{Moniker: tt.decoded}},
+		)
+		if len(changes) != 1 || changes[0].Reason != tt.want {
+			t.Errorf("moniker %q -> %q changes = %v, want reason %q", tt.original, tt.decoded, changes, tt.want)
+		}
+	}
+}
+
+func TestDiff_NextBlockVotingInformation(t *testing.T) {
+	original := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.MustParseHeightRoundStep("100/0/4"),
+		Duration:        -time.Second,
+		PreVotedPercent: 60.129,
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{ValidatorIndex: 1, PreVotedBlockHash: "C0FF", PreVoted: true},
+			{ValidatorIndex: 0, VotedZeroes: true},
+		},
+	}
+	decoded := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.MustParseHeightRoundStep("100/1/4"),
+		PreVotedPercent: 60.12,
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{ValidatorIndex: 0, PreVotedBlockHash: "----", PreVoted: true, VotedZeroes: true},
+			{ValidatorIndex: 1, PreVotedBlockHash: "C0FF", PreVoted: true, PreCommitVoted: true},
+		},
+	}
+
+	changes := Diff(original, decoded)
+	checkChangedFields(t, changes, map[string]string{
+		"HeightRoundStep":                          "height round step is altered",
+		"Duration":                                 "negative duration is encoded as zero",
+		"PreVotedPercent":                          "truncated to 2 decimals",
+		"ValidatorVoteStates":                      "sorted by index",
+		"ValidatorVoteStates[0].PreCommitVoted":    "pre-commit voted is altered",
+		"ValidatorVoteStates[1].PreVotedBlockHash": `empty hash is encoded as "----"`,
+		"ValidatorVoteStates[1].PreVoted":          "implied by VotedZeroes",
+	})
+
+	if s := changes[1].String(); s != "Duration: -1s -> 0s, negative duration is encoded as zero" {
+		t.Errorf("change string = %s", s)
+	}
+
+	if changes := Diff(original, original); len(changes) != 0 {
+		t.Errorf("unexpected changes %v", changes)
+	}
+	if changes := Diff(original, nil); len(changes) != 1 {
+		t.Errorf("changes = %v, want nil reported", changes)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	validators := types.StreamingLightValidators{
+		{Index: 0, VotingPowerDisplayPercent: 100, Moniker: "Validator"},
+	}
+	decoded, changes, err := RoundTrip(GetCvpCodecV9(), validators)
+	if err != nil {
+		t.Fatalf("round trip error = %v", err)
+	}
+	if len(changes) != 0 || decoded[0] != validators[0] {
+		t.Errorf("round trip = %v, changes %v, want lossless", decoded, changes)
+	}
+
+	validators[0].Index = -1
+	if _, _, err := RoundTrip(GetCvpCodecV9(), validators); !errors.Is(err, ErrInvalidEncodeInput) {
+		t.Errorf("round trip error = %v, want ErrInvalidEncodeInput", err)
+	}
+
+	information := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.MustParseHeightRoundStep("100/0/4"),
+		Duration:        1500 * time.Millisecond,
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true},
+		},
+	}
+	_, changes, err = RoundTrip(NewProxyCvpCodec(), information)
+	if err != nil {
+		t.Fatalf("round trip error = %v", err)
+	}
+	if len(changes) != 1 || !strings.Contains(changes[0].Reason, "whole seconds") {
+		t.Errorf("changes = %v, want duration truncated to whole seconds", changes)
+	}
+}
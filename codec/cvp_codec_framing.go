@@ -0,0 +1,382 @@
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// FrameKind is the kind of the payload of a frame.
+type FrameKind byte
+
+const (
+	FrameKindLightValidators            FrameKind = 0x01
+	FrameKindNextBlockVotingInformation FrameKind = 0x02
+)
+
+func (k FrameKind) String() string {
+	switch k {
+	case FrameKindLightValidators:
+		return "light-validators"
+	case FrameKindNextBlockVotingInformation:
+		return "next-block-voting-info"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(k))
+	}
+}
+
+// frameKindTimestampFlag is set on the kind byte of frames carrying a timestamp.
+const frameKindTimestampFlag = 0x80
+
+// frameMagic starts every frame, so the reader can find the next frame after corrupted data.
+var frameMagic = []byte{0xC5, 'C', 'V', 'P'}
+
+// maxFrameVersionBytes is the maximum length of the codec version of a frame.
+const maxFrameVersionBytes = 16
+
+// frame layout: magic (4) | kind (1) | version length (1) | version | [timestamp (8)] | payload length (4) | payload | CRC-32C (4)
+// the timestamp, unix nanoseconds, presents only if the kind byte has frameKindTimestampFlag.
+// the checksum covers everything after the magic and before the checksum.
+const (
+	frameFixedHeaderBytes = 4 + 1 + 1
+	frameTimestampBytes   = 8
+	frameLengthBytes      = 4
+	frameChecksumBytes    = 4
+	maxFrameOverheadBytes = frameFixedHeaderBytes + maxFrameVersionBytes + frameTimestampBytes + frameLengthBytes + frameChecksumBytes
+)
+
+// MaxFramePayloadBytes returns the maximum payload size of the frames written or read using the codec,
+// the largest payload the codec decodes, see GetDecodingLimits. Larger length read from a stream means corruption.
+func MaxFramePayloadBytes(codec CvpCodec) int {
+	limits := GetDecodingLimits(codec)
+	return maxInt(limits.MaxEncodedLightValidatorsBytes, limits.MaxEncodedNextBlockVotingInformationBytes)
+}
+
+var frameChecksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorruptFrame is matched, via errors.Is, by CorruptFrameError.
+var ErrCorruptFrame = errors.New("corrupt frame")
+
+// CorruptFrameError is returned by FrameReader when corrupted data is found in the stream.
+// The reader had skipped the corrupted data and resynchronised to the next frame,
+// so reading can continue.
+type CorruptFrameError struct {
+	// Offset is the position of the corrupted data within the stream.
+	Offset int64
+
+	// Skipped is the number of bytes skipped to reach the next frame or the end of the stream.
+	Skipped int64
+
+	// Reason is the human-readable reason.
+	Reason string
+}
+
+func (e *CorruptFrameError) Error() string {
+	return fmt.Sprintf("corrupt frame at offset %d, skipped %d bytes: %s", e.Offset, e.Skipped, e.Reason)
+}
+
+func (e *CorruptFrameError) Unwrap() error {
+	return ErrCorruptFrame
+}
+
+// Frame is a self-delimiting unit of a stream of encoded payloads.
+type Frame struct {
+	Kind    FrameKind
+	Version CvpCodecVersion
+	Payload []byte
+
+	// Timestamp is the time written along with the frame by WriteTimestampedFrame, zero if not timestamped.
+	Timestamp time.Time
+
+	// LightValidators is the decoded payload of FrameKindLightValidators frames, set by FrameReader.
+	LightValidators types.StreamingLightValidators
+
+	// NextBlockVotingInformation is the decoded payload of FrameKindNextBlockVotingInformation frames, set by FrameReader.
+	NextBlockVotingInformation *types.StreamingNextBlockVotingInformation
+}
+
+// FrameWriter writes encoded payloads as frames to the underlying writer.
+//
+// FrameWriter is not safe for concurrent use.
+type FrameWriter struct {
+	w               io.Writer
+	codec           CvpCodec
+	maxPayloadBytes int
+}
+
+// NewFrameWriter returns a FrameWriter encoding using the given codec, nil means the default proxy codec.
+// Payloads larger than MaxFramePayloadBytes of the codec are rejected.
+func NewFrameWriter(w io.Writer, codec CvpCodec) *FrameWriter {
+	if codec == nil {
+		codec = NewProxyCvpCodec()
+	}
+	return &FrameWriter{
+		w:               w,
+		codec:           codec,
+		maxPayloadBytes: MaxFramePayloadBytes(codec),
+	}
+}
+
+// WriteLightValidators encodes the light validators and writes them as a frame.
+func (fw *FrameWriter) WriteLightValidators(validators types.StreamingLightValidators) error {
+	bz, err := fw.codec.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		return err
+	}
+	return fw.WriteFrame(FrameKindLightValidators, fw.codec.GetVersion(), bz)
+}
+
+// WriteNextBlockVotingInformation encodes the next block voting information and writes it as a frame.
+func (fw *FrameWriter) WriteNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) error {
+	bz, err := fw.codec.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		return err
+	}
+	return fw.WriteFrame(FrameKindNextBlockVotingInformation, fw.codec.GetVersion(), bz)
+}
+
+// WriteFrame writes the already encoded payload as a frame, in a single write to the underlying writer.
+func (fw *FrameWriter) WriteFrame(kind FrameKind, version CvpCodecVersion, payload []byte) error {
+	return fw.writeFrame(kind, version, time.Time{}, payload)
+}
+
+// WriteTimestampedFrame is WriteFrame with the timestamp, like the time the payload was received,
+// read back as Frame.Timestamp.
+func (fw *FrameWriter) WriteTimestampedFrame(kind FrameKind, version CvpCodecVersion, timestamp time.Time, payload []byte) error {
+	if timestamp.IsZero() {
+		return fmt.Errorf("zero timestamp")
+	}
+	return fw.writeFrame(kind, version, timestamp, payload)
+}
+
+func (fw *FrameWriter) writeFrame(kind FrameKind, version CvpCodecVersion, timestamp time.Time, payload []byte) error {
+	if kind != FrameKindLightValidators && kind != FrameKindNextBlockVotingInformation {
+		return fmt.Errorf("unknown frame kind %d", kind)
+	}
+	if len(version) < 1 || len(version) > maxFrameVersionBytes {
+		return fmt.Errorf("invalid codec version %s", version)
+	}
+	if len(payload) > fw.maxPayloadBytes {
+		return fmt.Errorf("payload exceeds %d bytes", fw.maxPayloadBytes)
+	}
+
+	kindByte := byte(kind)
+	if !timestamp.IsZero() {
+		kindByte |= frameKindTimestampFlag
+	}
+
+	bz := make([]byte, 0, frameFixedHeaderBytes+len(version)+frameTimestampBytes+frameLengthBytes+len(payload)+frameChecksumBytes)
+	bz = append(bz, frameMagic...)
+	bz = append(bz, kindByte, byte(len(version)))
+	bz = append(bz, version...)
+	if !timestamp.IsZero() {
+		bz = binary.BigEndian.AppendUint64(bz, uint64(timestamp.UnixNano()))
+	}
+	bz = binary.BigEndian.AppendUint32(bz, uint32(len(payload)))
+	bz = append(bz, payload...)
+	bz = binary.BigEndian.AppendUint32(bz, crc32.Checksum(bz[len(frameMagic):], frameChecksumTable))
+
+	_, err := fw.w.Write(bz)
+	return err
+}
+
+// FrameReader reads frames from the underlying reader and decodes the payloads.
+//
+// Corrupted data, like a truncated frame or checksum mismatch, is reported once as CorruptFrameError
+// after skipping to the next frame, the following reads continue from there.
+//
+// FrameReader is not safe for concurrent use.
+type FrameReader struct {
+	br              *bufio.Reader
+	codec           CvpCodec
+	maxPayloadBytes int
+	offset          int64
+}
+
+// NewFrameReader returns a FrameReader decoding using the given codec,
+// nil means the default proxy codec which decodes all the registered versions.
+// Frames of payload larger than MaxFramePayloadBytes of the codec are reported as corrupted.
+func NewFrameReader(r io.Reader, codec CvpCodec) *FrameReader {
+	if codec == nil {
+		codec = NewProxyCvpCodec()
+	}
+	maxPayloadBytes := MaxFramePayloadBytes(codec)
+	return &FrameReader{
+		br:              bufio.NewReaderSize(r, maxFrameOverheadBytes+maxPayloadBytes),
+		codec:           codec,
+		maxPayloadBytes: maxPayloadBytes,
+	}
+}
+
+// ReadFrame reads the next frame and decodes its payload.
+//
+// Returns io.EOF at the end of the stream, CorruptFrameError when corrupted data was skipped,
+// or DecodeError when the frame is intact but the payload can not be decoded, like delta frames.
+// Reading can continue after CorruptFrameError and DecodeError.
+func (fr *FrameReader) ReadFrame() (*Frame, error) {
+	if _, err := fr.br.Peek(1); err != nil {
+		return nil, err
+	}
+
+	frame, size, reason, err := fr.peekFrame()
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		offset := fr.offset
+		if err := fr.resync(); err != nil {
+			return nil, err
+		}
+		return nil, &CorruptFrameError{
+			Offset:  offset,
+			Skipped: fr.offset - offset,
+			Reason:  reason,
+		}
+	}
+
+	if err := fr.discard(size); err != nil {
+		return nil, err
+	}
+
+	if err := fr.decode(frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// peekFrame parses the frame at the current position without consuming it,
+// so the bytes of a corrupted frame can be searched for the next frame.
+// Returns the reason if the data at the current position is not an intact frame.
+func (fr *FrameReader) peekFrame() (frame *Frame, size int, corruptReason string, err error) {
+	peek := func(n int) ([]byte, bool, error) {
+		bz, err := fr.br.Peek(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return bz, true, nil
+	}
+
+	bz, ok, err := peek(frameFixedHeaderBytes)
+	if err != nil || !ok {
+		return nil, 0, "truncated frame header", err
+	}
+	if !bytes.Equal(bz[:len(frameMagic)], frameMagic) {
+		return nil, 0, "bad frame magic", nil
+	}
+	kind := FrameKind(bz[4] &^ frameKindTimestampFlag)
+	timestamped := bz[4]&frameKindTimestampFlag != 0
+	versionBytes := int(bz[5])
+	if kind != FrameKindLightValidators && kind != FrameKindNextBlockVotingInformation {
+		return nil, 0, fmt.Sprintf("unknown frame kind %d", kind), nil
+	}
+	if versionBytes < 1 || versionBytes > maxFrameVersionBytes {
+		return nil, 0, fmt.Sprintf("invalid codec version length %d", versionBytes), nil
+	}
+
+	headerBytes := frameFixedHeaderBytes + versionBytes
+	if timestamped {
+		headerBytes += frameTimestampBytes
+	}
+
+	bz, ok, err = peek(headerBytes + frameLengthBytes)
+	if err != nil || !ok {
+		return nil, 0, "truncated frame header", err
+	}
+	payloadBytes := binary.BigEndian.Uint32(bz[headerBytes:])
+	if int64(payloadBytes) > int64(fr.maxPayloadBytes) {
+		return nil, 0, fmt.Sprintf("payload length %d exceeds %d bytes", payloadBytes, fr.maxPayloadBytes), nil
+	}
+
+	size = headerBytes + frameLengthBytes + int(payloadBytes) + frameChecksumBytes
+	bz, ok, err = peek(size)
+	if err != nil || !ok {
+		return nil, 0, "truncated frame", err
+	}
+	checksum := binary.BigEndian.Uint32(bz[size-frameChecksumBytes:])
+	if crc32.Checksum(bz[len(frameMagic):size-frameChecksumBytes], frameChecksumTable) != checksum {
+		return nil, 0, "checksum mismatch", nil
+	}
+
+	payloadStart := headerBytes + frameLengthBytes
+	frame = &Frame{
+		Kind:    kind,
+		Version: CvpCodecVersion(bz[frameFixedHeaderBytes : frameFixedHeaderBytes+versionBytes]),
+		Payload: append([]byte(nil), bz[payloadStart:payloadStart+int(payloadBytes)]...),
+	}
+	if timestamped {
+		frame.Timestamp = time.Unix(0, int64(binary.BigEndian.Uint64(bz[frameFixedHeaderBytes+versionBytes:])))
+	}
+	return frame, size, "", nil
+}
+
+// resync skips at least one byte, then skips until the next intact frame or the end of the stream.
+// Candidates which look like the magic but are not intact frames, like the magic bytes within a payload, are skipped too,
+// so a corrupted region is reported once.
+func (fr *FrameReader) resync() error {
+	if err := fr.discard(1); err != nil {
+		return err
+	}
+	for {
+		if _, err := fr.br.Peek(len(frameMagic)); err == io.EOF {
+			return fr.discard(fr.br.Buffered())
+		} else if err != nil {
+			return err
+		}
+
+		buffered, _ := fr.br.Peek(fr.br.Buffered())
+		index := bytes.Index(buffered, frameMagic)
+		if index < 0 {
+			// keep the tail which may be the beginning of a magic split across reads
+			if err := fr.discard(len(buffered) - (len(frameMagic) - 1)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fr.discard(index); err != nil {
+			return err
+		}
+
+		_, _, reason, err := fr.peekFrame()
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			return nil
+		}
+		if err := fr.discard(1); err != nil {
+			return err
+		}
+	}
+}
+
+func (fr *FrameReader) discard(n int) error {
+	discarded, err := fr.br.Discard(n)
+	fr.offset += int64(discarded)
+	return err
+}
+
+// decode decodes the payload of the intact frame using the codec,
+// the payload must be encoded by the version declared in the frame.
+func (fr *FrameReader) decode(frame *Frame) error {
+	if detected, ok := DetectEncodingVersion(frame.Payload); ok && detected != frame.Version {
+		return newDecodeError(detected, ErrBadPrefix, "prefix", 0, "frame declares version %s but payload is encoded by %s", frame.Version, detected)
+	}
+
+	var err error
+	switch frame.Kind {
+	case FrameKindLightValidators:
+		frame.LightValidators, err = fr.codec.DecodeStreamingLightValidators(frame.Payload)
+	case FrameKindNextBlockVotingInformation:
+		frame.NextBlockVotingInformation, err = fr.codec.DecodeStreamingNextBlockVotingInformation(frame.Payload)
+	}
+	return err
+}
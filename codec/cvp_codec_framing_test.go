@@ -0,0 +1,347 @@
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var framingTestValidators = types.StreamingLightValidators{
+	{Index: 0, VotingPowerDisplayPercent: 60, Moniker: "Validator One"},
+	{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator Two"},
+}
+
+func framingTestInformation(step types.RoundStepType) *types.StreamingNextBlockVotingInformation {
+	return &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.HeightRoundStep{Height: 100, Round: 0, Step: step},
+		Duration:        time.Second,
+		PreVotedPercent: 60,
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true},
+			{ValidatorIndex: 1, PreVotedBlockHash: "----"},
+		},
+	}
+}
+
+// writeTestFrames writes the validators then the voting information of each step, returns the offset of each frame.
+func writeTestFrames(t *testing.T, buf *bytes.Buffer, codec CvpCodec, steps ...types.RoundStepType) []int {
+	fw := NewFrameWriter(buf, codec)
+	offsets := []int{buf.Len()}
+	if err := fw.WriteLightValidators(framingTestValidators); err != nil {
+		t.Fatalf("WriteLightValidators() error = %v", err)
+	}
+	for _, step := range steps {
+		offsets = append(offsets, buf.Len())
+		if err := fw.WriteNextBlockVotingInformation(framingTestInformation(step)); err != nil {
+			t.Fatalf("WriteNextBlockVotingInformation() error = %v", err)
+		}
+	}
+	return offsets
+}
+
+func TestFrameWriterReader_RoundTrip(t *testing.T) {
+	for _, version := range GetRegisteredCvpCodecVersions() {
+		t.Run(string(version), func(t *testing.T) {
+			codec, _ := GetRegisteredCvpCodec(version)
+
+			var buf bytes.Buffer
+			writeTestFrames(t, &buf, codec, types.RoundStepPrevote, types.RoundStepPrecommit)
+
+			fr := NewFrameReader(&buf, nil)
+
+			frame, err := fr.ReadFrame()
+			if err != nil {
+				t.Fatalf("ReadFrame() error = %v", err)
+			}
+			if frame.Kind != FrameKindLightValidators || frame.Version != version {
+				t.Errorf("ReadFrame() kind = %s, version = %s", frame.Kind, frame.Version)
+			}
+			if !reflect.DeepEqual(framingTestValidators, frame.LightValidators) {
+				t.Errorf("ReadFrame() light validators = %v, want %v", frame.LightValidators, framingTestValidators)
+			}
+
+			for _, step := range []types.RoundStepType{types.RoundStepPrevote, types.RoundStepPrecommit} {
+				frame, err = fr.ReadFrame()
+				if err != nil {
+					t.Fatalf("ReadFrame() error = %v", err)
+				}
+				if frame.Kind != FrameKindNextBlockVotingInformation || frame.Version != version {
+					t.Errorf("ReadFrame() kind = %s, version = %s", frame.Kind, frame.Version)
+				}
+				if want := framingTestInformation(step); !reflect.DeepEqual(want, frame.NextBlockVotingInformation) {
+					t.Errorf("ReadFrame() next block voting information = %v, want %v", frame.NextBlockVotingInformation, want)
+				}
+			}
+
+			if _, err = fr.ReadFrame(); err != io.EOF {
+				t.Errorf("ReadFrame() error = %v, want EOF", err)
+			}
+		})
+	}
+}
+
+func TestFrameReader_Resynchronisation(t *testing.T) {
+	codec := GetCvpCodecV2()
+
+	tests := []struct {
+		name string
+		// corrupt corrupts the stream of 4 frames, given the offset of each frame
+		corrupt func(bz []byte, offsets []int) []byte
+		// wantSteps are the steps of the voting information frames read successfully
+		wantSteps []types.RoundStepType
+		// wantValidators is true if the light validators frame is read successfully
+		wantValidators bool
+		wantSkipped    int64
+	}{
+		{
+			name: "garbage before the first frame",
+			corrupt: func(bz []byte, _ []int) []byte {
+				return append([]byte("garbage"), bz...)
+			},
+			wantValidators: true,
+			wantSteps:      []types.RoundStepType{types.RoundStepPrevote, types.RoundStepPrecommit, types.RoundStepCommit},
+			wantSkipped:    7,
+		},
+		{
+			name: "flipped byte in the payload of the second frame",
+			corrupt: func(bz []byte, offsets []int) []byte {
+				bz[offsets[2]-6] ^= 0xFF
+				return bz
+			},
+			wantValidators: true,
+			wantSteps:      []types.RoundStepType{types.RoundStepPrecommit, types.RoundStepCommit},
+		},
+		{
+			name: "second frame truncated in the middle",
+			corrupt: func(bz []byte, offsets []int) []byte {
+				return append(append([]byte{}, bz[:offsets[1]+10]...), bz[offsets[2]:]...)
+			},
+			wantValidators: true,
+			wantSteps:      []types.RoundStepType{types.RoundStepPrecommit, types.RoundStepCommit},
+			wantSkipped:    10,
+		},
+		{
+			name: "oversize length of the first frame",
+			corrupt: func(bz []byte, _ []int) []byte {
+				lengthOffset := frameFixedHeaderBytes + len(CvpCodecVersionV2)
+				bz[lengthOffset] = 0xFF
+				return bz
+			},
+			wantSteps: []types.RoundStepType{types.RoundStepPrevote, types.RoundStepPrecommit, types.RoundStepCommit},
+		},
+		{
+			name: "last frame truncated at the end of the stream",
+			corrupt: func(bz []byte, offsets []int) []byte {
+				return bz[:offsets[3]+5]
+			},
+			wantValidators: true,
+			wantSteps:      []types.RoundStepType{types.RoundStepPrevote, types.RoundStepPrecommit},
+			wantSkipped:    5,
+		},
+		{
+			name: "magic bytes within garbage",
+			corrupt: func(bz []byte, offsets []int) []byte {
+				garbage := append(append([]byte("xx"), frameMagic...), "yy"...)
+				return append(append(append([]byte{}, bz[:offsets[2]]...), garbage...), bz[offsets[2]:]...)
+			},
+			wantValidators: true,
+			wantSteps:      []types.RoundStepType{types.RoundStepPrevote, types.RoundStepPrecommit, types.RoundStepCommit},
+			wantSkipped:    8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			offsets := writeTestFrames(t, &buf, codec, types.RoundStepPrevote, types.RoundStepPrecommit, types.RoundStepCommit)
+
+			fr := NewFrameReader(bytes.NewReader(tt.corrupt(buf.Bytes(), offsets)), codec)
+
+			var gotValidators bool
+			var gotSteps []types.RoundStepType
+			var corruptErrors []*CorruptFrameError
+			for {
+				frame, err := fr.ReadFrame()
+				if err == io.EOF {
+					break
+				}
+				var corruptErr *CorruptFrameError
+				if errors.As(err, &corruptErr) {
+					if !errors.Is(err, ErrCorruptFrame) {
+						t.Errorf("ReadFrame() error = %v, want matches ErrCorruptFrame", err)
+					}
+					corruptErrors = append(corruptErrors, corruptErr)
+					continue
+				}
+				if err != nil {
+					t.Fatalf("ReadFrame() error = %v", err)
+				}
+				switch frame.Kind {
+				case FrameKindLightValidators:
+					gotValidators = true
+				case FrameKindNextBlockVotingInformation:
+					gotSteps = append(gotSteps, frame.NextBlockVotingInformation.HeightRoundStep.Step)
+				}
+			}
+
+			if gotValidators != tt.wantValidators {
+				t.Errorf("read light validators = %t, want %t", gotValidators, tt.wantValidators)
+			}
+			if !reflect.DeepEqual(tt.wantSteps, gotSteps) {
+				t.Errorf("read steps = %v, want %v", gotSteps, tt.wantSteps)
+			}
+			if len(corruptErrors) != 1 {
+				t.Fatalf("corruption must be reported once, got %v", corruptErrors)
+			}
+			if tt.wantSkipped > 0 && corruptErrors[0].Skipped != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", corruptErrors[0].Skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestFrameReader_UndecodablePayload(t *testing.T) {
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf, GetCvpCodecV2())
+	if err := fw.WriteFrame(FrameKindNextBlockVotingInformation, CvpCodecVersionV2, []byte("not encoded")); err != nil {
+		t.Fatalf("WriteFrame() error = %v", err)
+	}
+	if err := fw.WriteFrame(FrameKindLightValidators, CvpCodecVersionV1, GetCvpCodecV2().EncodeStreamingLightValidators(framingTestValidators)); err != nil {
+		t.Fatalf("WriteFrame() error = %v", err)
+	}
+	if err := fw.WriteLightValidators(framingTestValidators); err != nil {
+		t.Fatalf("WriteLightValidators() error = %v", err)
+	}
+
+	fr := NewFrameReader(&buf, nil)
+	var decodeErr *DecodeError
+	if _, err := fr.ReadFrame(); !errors.As(err, &decodeErr) {
+		t.Errorf("ReadFrame() error = %v, want DecodeError", err)
+	}
+	if _, err := fr.ReadFrame(); !errors.Is(err, ErrBadPrefix) {
+		t.Errorf("ReadFrame() error = %v, want version mismatch matches ErrBadPrefix", err)
+	}
+	frame, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v, intact frames must still be readable", err)
+	}
+	if !reflect.DeepEqual(framingTestValidators, frame.LightValidators) {
+		t.Errorf("ReadFrame() light validators = %v, want %v", frame.LightValidators, framingTestValidators)
+	}
+}
+
+func TestFrameWriter_WriteFrame_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    FrameKind
+		version CvpCodecVersion
+		payload []byte
+	}{
+		{
+			name:    "unknown kind",
+			kind:    0x03,
+			version: CvpCodecVersionV2,
+		},
+		{
+			name:    "empty version",
+			kind:    FrameKindLightValidators,
+			version: "",
+		},
+		{
+			name:    "version too long",
+			kind:    FrameKindLightValidators,
+			version: "v12345678901234567",
+		},
+		{
+			name:    "payload too large",
+			kind:    FrameKindLightValidators,
+			version: CvpCodecVersionV2,
+			payload: make([]byte, MaxFramePayloadBytes(NewProxyCvpCodec())+1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := NewFrameWriter(&buf, nil).WriteFrame(tt.kind, tt.version, tt.payload); err == nil {
+				t.Errorf("WriteFrame() error = nil, want error")
+			}
+			if buf.Len() != 0 {
+				t.Errorf("nothing must be written")
+			}
+		})
+	}
+}
+
+func TestFrameWriterReader_Timestamp(t *testing.T) {
+	codec := GetCvpCodecV2()
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 123456789, time.UTC)
+
+	var buf bytes.Buffer
+	fw := NewFrameWriter(&buf, codec)
+	if err := fw.WriteTimestampedFrame(FrameKindLightValidators, codec.GetVersion(), timestamp, codec.EncodeStreamingLightValidators(framingTestValidators)); err != nil {
+		t.Fatalf("WriteTimestampedFrame() error = %v", err)
+	}
+	if err := fw.WriteLightValidators(framingTestValidators); err != nil {
+		t.Fatalf("WriteLightValidators() error = %v", err)
+	}
+	if err := fw.WriteTimestampedFrame(FrameKindLightValidators, codec.GetVersion(), time.Time{}, nil); err == nil {
+		t.Errorf("WriteTimestampedFrame() error = nil, want error for zero timestamp")
+	}
+
+	fr := NewFrameReader(&buf, nil)
+	frame, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v", err)
+	}
+	if frame.Kind != FrameKindLightValidators || !frame.Timestamp.Equal(timestamp) {
+		t.Errorf("ReadFrame() kind = %s, timestamp = %v, want %v", frame.Kind, frame.Timestamp, timestamp)
+	}
+	if !reflect.DeepEqual(framingTestValidators, frame.LightValidators) {
+		t.Errorf("ReadFrame() light validators = %v, want %v", frame.LightValidators, framingTestValidators)
+	}
+
+	frame, err = fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v", err)
+	}
+	if !frame.Timestamp.IsZero() {
+		t.Errorf("ReadFrame() timestamp = %v, want zero", frame.Timestamp)
+	}
+}
+
+func TestFrameWriterReader_LargeLightValidators(t *testing.T) {
+	codec := GetCvpCodecV9()
+	limits := codec.GetLimits()
+
+	// pseudo-random monikers, so the payload is not compressed below the former 1 MiB frame limit
+	random := rand.New(rand.NewSource(1))
+	validators := make(types.StreamingLightValidators, limits.MaxValidators)
+	for i := range validators {
+		validators[i] = types.StreamingLightValidator{
+			Index:                     i,
+			VotingPowerDisplayPercent: 99.98,
+			Moniker:                   fmt.Sprintf("%x", random.Uint64()),
+		}
+	}
+	if bz := codec.EncodeStreamingLightValidators(validators); len(bz) <= 1<<20 {
+		t.Fatalf("encoded light validators bytes = %d, want larger than 1 MiB", len(bz))
+	}
+
+	var buf bytes.Buffer
+	if err := NewFrameWriter(&buf, codec).WriteLightValidators(validators); err != nil {
+		t.Fatalf("WriteLightValidators() error = %v", err)
+	}
+
+	frame, err := NewFrameReader(&buf, nil).ReadFrame()
+	if err != nil {
+		t.Fatalf("ReadFrame() error = %v", err)
+	}
+	if !reflect.DeepEqual(validators, frame.LightValidators) {
+		t.Errorf("ReadFrame() light validators mismatch")
+	}
+}
@@ -2,7 +2,6 @@ package codec
 
 //goland:noinspection SpellCheckingInspection
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
 	"math"
@@ -12,17 +11,21 @@ import (
 
 // DetectEncodingVersion will try to detect the encoding version of the given byte array based on the very first bytes.
 // The returned version is 'possible' because it is not guaranteed to be the correct version without actual decode it.
+//
+// Only versions registered via RegisterCvpCodec can be detected.
 func DetectEncodingVersion(bz []byte) (possible CvpCodecVersion, detected bool) {
-	if bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV3) {
-		return CvpCodecVersionV3, true
+	registration, found := findRegisteredCvpCodecByPrefix(bz)
+	if !found {
+		return CvpCodecVersionUnknown, false
 	}
-	if bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV2) {
-		return CvpCodecVersionV2, true
-	}
-	if bytes.HasPrefix(bz, []byte(prefixDataEncodedByCvpCodecV1)) {
-		return CvpCodecVersionV1, true
-	}
-	return CvpCodecVersionUnknown, false
+	return registration.version, true
+}
+
+// maxDeflatedBytes returns the upper bound size of the output of compress/flate for the given input size,
+// when the input is incompressible and stored as is: 5 bytes overhead per block of at most 16384 bytes,
+// plus the possible empty final block.
+func maxDeflatedBytes(inputBytes int) int {
+	return inputBytes + 5*(inputBytes/16384+2)
 }
 
 func toUint16Buffer(num int) []byte {
@@ -3,6 +3,7 @@ package codec
 import (
 	"encoding/hex"
 	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
 	"math"
 	"reflect"
 	"testing"
@@ -16,6 +17,24 @@ func TestDetectEncodingVersion(t *testing.T) {
 		wantPossible CvpCodecVersion
 		wantDetected bool
 	}{
+		{
+			name:         "delta",
+			bz:           []byte{0xD, cvpCodecDeltaSeparator, '|', '1', '/', '2', '/', '3'},
+			wantPossible: CvpCodecVersionDelta,
+			wantDetected: true,
+		},
+		{
+			name:         "v4",
+			bz:           cvpV4CodecImpl.EncodeStreamingLightValidators(types.StreamingLightValidators{{Index: 0, Moniker: "Val1"}}),
+			wantPossible: CvpCodecVersionV4,
+			wantDetected: true,
+		},
+		{
+			name:         "accept v4 malformed data",
+			bz:           []byte{0x4, cvpCodecV4Separator, 0x00},
+			wantPossible: CvpCodecVersionV4,
+			wantDetected: true,
+		},
 		{
 			name:         "v3",
 			bz:           bufferFromHex("037c1f8b08000000000000ff62aa6160e0e20ecb752bf60d764cf774c6c0b680000000ffffc73c489022000000"),
@@ -63,7 +82,7 @@ func TestDetectEncodingVersion(t *testing.T) {
 		},
 		{
 			name:         "unknown",
-			bz:           []byte{0x4, '|', 0x00},
+			bz:           []byte{0xF, '|', 0x00},
 			wantPossible: CvpCodecVersionUnknown,
 			wantDetected: false,
 		},
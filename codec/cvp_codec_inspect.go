@@ -0,0 +1,352 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InspectedField is a field of encoded data, located by its byte offset.
+type InspectedField struct {
+	// Offset is the byte offset of the field within the inspected data.
+	Offset int `json:"offset"`
+
+	// Length is the number of bytes of the field.
+	Length int `json:"length"`
+
+	// Name is the path of the field, like ValidatorVoteStates[1].PreVotedBlockHash.
+	Name string `json:"name"`
+
+	// Bytes is the encoded bytes of the field.
+	Bytes []byte `json:"bytes"`
+
+	// Value is the human-readable value as encoded, not validated,
+	// or the reason if Malformed.
+	Value string `json:"value"`
+
+	// Malformed is true if the bytes from Offset can not be broken down further, the field is the last one.
+	Malformed bool `json:"malformed,omitempty"`
+}
+
+// Inspection is the byte-level breakdown of encoded data, produced by InspectStreamingLightValidators
+// and InspectStreamingNextBlockVotingInformation.
+type Inspection struct {
+	Version CvpCodecVersion  `json:"version"`
+	Fields  []InspectedField `json:"fields"`
+
+	// Inner is the breakdown of the decompressed payload, for versions compressing another version like v3.
+	// Offsets of the inner fields are within the decompressed payload.
+	Inner *Inspection `json:"inner,omitempty"`
+}
+
+// InspectStreamingLightValidators breaks the encoded light validators down to fields with their byte offsets,
+// for debugging. The breakdown is best-effort, it continues past invalid values and stops at the first malformed
+// structure, reported as the Malformed field. Use the decoder for validation.
+//
+// Only v1, v2 and v3 are supported, returns error for the other versions or if the version can not be detected.
+func InspectStreamingLightValidators(bz []byte) (*Inspection, error) {
+	return inspect(bz, inspectStreamingLightValidatorsV1, inspectStreamingLightValidatorsV2)
+}
+
+// InspectStreamingNextBlockVotingInformation is InspectStreamingLightValidators for the encoded next block voting information.
+func InspectStreamingNextBlockVotingInformation(bz []byte) (*Inspection, error) {
+	return inspect(bz, inspectStreamingNextBlockVotingInformationV1, inspectStreamingNextBlockVotingInformationV2)
+}
+
+func inspect(bz []byte, inspectV1, inspectV2 func(in *inspector, bz []byte)) (*Inspection, error) {
+	version, detected := DetectEncodingVersion(bz)
+	if !detected {
+		return nil, newDecodeError(CvpCodecVersionUnknown, ErrBadPrefix, "prefix", 0, "unable to detect encoding version")
+	}
+
+	in := newInspector(version, bz)
+	switch version {
+	case CvpCodecVersionV1:
+		inspectV1(in, bz)
+	case CvpCodecVersionV2:
+		inspectV2(in, bz)
+	case CvpCodecVersionV3:
+		in.add(0, len(prefixDataEncodedByCvpCodecV3), "prefix", string(version))
+		bzByV2, err := gunzipV3(bz[len(prefixDataEncodedByCvpCodecV3):])
+		if err != nil {
+			in.malformed(len(prefixDataEncodedByCvpCodecV3), len(bz)-len(prefixDataEncodedByCvpCodecV3), "payload", err.Error())
+			break
+		}
+		in.add(len(prefixDataEncodedByCvpCodecV3), len(bz)-len(prefixDataEncodedByCvpCodecV3), "payload", fmt.Sprintf("gzip of %d bytes encoded by v2", len(bzByV2)))
+
+		inner := newInspector(CvpCodecVersionV2, bzByV2)
+		inspectV2(inner, bzByV2)
+		in.inspection.Inner = inner.inspection
+	default:
+		return nil, fmt.Errorf("byte-level breakdown of %s is not supported", version)
+	}
+	return in.inspection, nil
+}
+
+// inspector accumulates the fields of an Inspection of the data.
+type inspector struct {
+	bz         []byte
+	inspection *Inspection
+	done       bool // a malformed field was added, no more fields are accepted
+}
+
+func newInspector(version CvpCodecVersion, bz []byte) *inspector {
+	return &inspector{
+		bz: bz,
+		inspection: &Inspection{
+			Version: version,
+			Fields:  make([]InspectedField, 0),
+		},
+	}
+}
+
+func (in *inspector) add(offset, length int, name, value string) {
+	if in.done {
+		return
+	}
+	in.inspection.Fields = append(in.inspection.Fields, InspectedField{
+		Offset: offset,
+		Length: length,
+		Name:   name,
+		Bytes:  in.bz[offset : offset+length],
+		Value:  value,
+	})
+}
+
+func (in *inspector) malformed(offset, length int, name, reason string) {
+	if in.done {
+		return
+	}
+	in.inspection.Fields = append(in.inspection.Fields, InspectedField{
+		Offset:    offset,
+		Length:    length,
+		Name:      name,
+		Bytes:     in.bz[offset : offset+length],
+		Value:     reason,
+		Malformed: true,
+	})
+	in.done = true
+}
+
+// separator adds the separator expected at the offset, returns false after adding a malformed field if missing.
+func (in *inspector) separator(bz []byte, offset int, separator byte, nextField string) bool {
+	if offset >= len(bz) {
+		in.malformed(offset, 0, nextField, "missing separator, data ended")
+		return false
+	}
+	if bz[offset] != separator {
+		in.malformed(offset, len(bz)-offset, nextField, fmt.Sprintf("missing separator, found 0x%02x", bz[offset]))
+		return false
+	}
+	in.add(offset, 1, "separator", string(separator))
+	return true
+}
+
+// textNumber returns the value of the decimal text, divided by the divisor, or the reason if not a number.
+func textNumber(text string, divisor int) string {
+	num, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Sprintf("not a number %q", text)
+	}
+	if divisor == 1 {
+		return strconv.FormatInt(num, 10)
+	}
+	return strconv.FormatFloat(float64(num)/float64(divisor), 'f', -1, 64)
+}
+
+func uint16Index(bz []byte) string {
+	if bytes.Equal(bz, collisionSeparator2BytesReplacement) {
+		bz = collisionSeparator2Bytes
+	}
+	return strconv.Itoa(fromUint16Buffer(bz))
+}
+
+func percentBuffer(bz []byte) string {
+	return strconv.FormatFloat(fromPercentBuffer(bz), 'f', -1, 64)
+}
+
+func voteFlag(flag byte) string {
+	switch flag {
+	case 'C':
+		return "C (pre-commit voted)"
+	case '0':
+		return "0 (voted zeroes)"
+	case 'V':
+		return "V (pre-voted)"
+	case 'X':
+		return "X (not voted)"
+	default:
+		return fmt.Sprintf("invalid %q", flag)
+	}
+}
+
+func inspectStreamingLightValidatorsV1(in *inspector, bz []byte) {
+	in.add(0, len(prefixDataEncodedByCvpCodecV1), "prefix", string(CvpCodecVersionV1))
+
+	const lengthOmittingMoniker = 3 + 5
+	const lengthWithMoniker = lengthOmittingMoniker + cvpCodecV1HexEncodedMonikerBufferSize
+
+	offset := len(prefixDataEncodedByCvpCodecV1)
+	for i, raw := range strings.Split(string(bz[offset:]), cvpCodecV1Separator) {
+		name := fmt.Sprintf("Validators[%d]", i)
+		if i > 0 {
+			in.add(offset, 1, "separator", cvpCodecV1Separator)
+			offset++
+		}
+		if len(raw) != lengthOmittingMoniker && len(raw) != lengthWithMoniker {
+			in.malformed(offset, len(raw), name, fmt.Sprintf("invalid validator raw data length %d, must be %d or %d", len(raw), lengthOmittingMoniker, lengthWithMoniker))
+			return
+		}
+
+		in.add(offset, 3, name+".Index", textNumber(raw[:3], 1))
+		in.add(offset+3, 5, name+".VotingPowerDisplayPercent", textNumber(raw[3:8], 100))
+		if len(raw) == lengthWithMoniker {
+			if moniker, err := hex.DecodeString(raw[8:]); err != nil {
+				in.add(offset+8, cvpCodecV1HexEncodedMonikerBufferSize, name+".Moniker", fmt.Sprintf("invalid hex: %v", err))
+			} else {
+				in.add(offset+8, cvpCodecV1HexEncodedMonikerBufferSize, name+".Moniker", strconv.Quote(string(moniker)))
+			}
+		}
+		offset += len(raw)
+	}
+}
+
+func inspectStreamingLightValidatorsV2(in *inspector, bz []byte) {
+	in.add(0, len(prefixDataEncodedByCvpCodecV2), "prefix", string(CvpCodecVersionV2))
+
+	const lengthOmittingMoniker = 2 + 2
+	const lengthWithMoniker = lengthOmittingMoniker + cvpCodecV2Base64EncodedMonikerBufferSize
+
+	offset := len(prefixDataEncodedByCvpCodecV2)
+	for i := 0; i == 0 || offset < len(bz); i++ {
+		name := fmt.Sprintf("Validators[%d]", i)
+		if i > 0 {
+			if !in.separator(bz, offset, cvpCodecV2Separator, name) {
+				return
+			}
+			offset++
+		}
+
+		raw := takeUntilSeparatorOrEnd(bz, offset, cvpCodecV2Separator)
+		if len(raw) != lengthOmittingMoniker && len(raw) != lengthWithMoniker {
+			in.malformed(offset, len(raw), name, fmt.Sprintf("invalid validator raw data length %d, must be %d or %d", len(raw), lengthOmittingMoniker, lengthWithMoniker))
+			return
+		}
+
+		in.add(offset, 2, name+".Index", uint16Index(raw[:2]))
+		in.add(offset+2, 2, name+".VotingPowerDisplayPercent", percentBuffer(raw[2:4]))
+		if len(raw) == lengthWithMoniker {
+			if moniker, err := base64.StdEncoding.DecodeString(string(raw[4:])); err != nil {
+				in.add(offset+4, cvpCodecV2Base64EncodedMonikerBufferSize, name+".Moniker", fmt.Sprintf("invalid base64: %v", err))
+			} else {
+				in.add(offset+4, cvpCodecV2Base64EncodedMonikerBufferSize, name+".Moniker", strconv.Quote(string(moniker)))
+			}
+		}
+		offset += len(raw)
+	}
+}
+
+// inspectSeparatedTextFields adds the text fields, each followed by the separator, returns the offset after them
+// or false after adding a malformed field.
+func inspectSeparatedTextFields(in *inspector, bz []byte, offset int, separator byte, fields []string, values []func(text string) string) (int, bool) {
+	for i, name := range fields {
+		text := takeUntilSeparatorOrEnd(bz, offset, separator)
+		in.add(offset, len(text), name, values[i](string(text)))
+		offset += len(text)
+
+		next := "ValidatorVoteStates"
+		if i+1 < len(fields) {
+			next = fields[i+1]
+		}
+		if !in.separator(bz, offset, separator, next) {
+			return offset, false
+		}
+		offset++
+	}
+	return offset, true
+}
+
+func inspectStreamingNextBlockVotingInformationV1(in *inspector, bz []byte) {
+	in.add(0, len(prefixDataEncodedByCvpCodecV1), "prefix", string(CvpCodecVersionV1))
+
+	percentX100 := func(text string) string {
+		return textNumber(text, 100)
+	}
+	offset, ok := inspectSeparatedTextFields(in, bz, len(prefixDataEncodedByCvpCodecV1), cvpCodecV1Separator[0],
+		[]string{"HeightRoundStep", "Duration", "PreVotedPercent", "PreCommitVotedPercent"},
+		[]func(string) string{
+			strconv.Quote,
+			func(text string) string {
+				return textNumber(text, 1) + " ms"
+			},
+			percentX100,
+			percentX100,
+		},
+	)
+	if !ok {
+		return
+	}
+
+	inspectValidatorVoteStates(in, bz, offset, 3, func(bz []byte) string {
+		return textNumber(string(bz), 1)
+	})
+}
+
+func inspectStreamingNextBlockVotingInformationV2(in *inspector, bz []byte) {
+	in.add(0, len(prefixDataEncodedByCvpCodecV2), "prefix", string(CvpCodecVersionV2))
+
+	offset, ok := inspectSeparatedTextFields(in, bz, len(prefixDataEncodedByCvpCodecV2), cvpCodecV2Separator,
+		[]string{"HeightRoundStep", "Duration"},
+		[]func(string) string{
+			strconv.Quote,
+			func(text string) string {
+				return textNumber(text, 1) + " s"
+			},
+		},
+	)
+	if !ok {
+		return
+	}
+
+	if len(bz) < offset+4 {
+		in.malformed(offset, len(bz)-offset, "PreVotedPercent", "truncated, pre-voted and pre-commit voted percent must be 4 bytes")
+		return
+	}
+	in.add(offset, 2, "PreVotedPercent", percentBuffer(bz[offset:offset+2]))
+	in.add(offset+2, 2, "PreCommitVotedPercent", percentBuffer(bz[offset+2:offset+4]))
+	offset += 4
+	if !in.separator(bz, offset, cvpCodecV2Separator, "ValidatorVoteStates") {
+		return
+	}
+	offset++
+
+	inspectValidatorVoteStates(in, bz, offset, 2, uint16Index)
+}
+
+// inspectValidatorVoteStates adds the fixed-size vote state records till the end,
+// each record is the validator index, 4 bytes pre-voted fingerprint block hash and 1 byte vote flag.
+func inspectValidatorVoteStates(in *inspector, bz []byte, offset, indexBytes int, index func(bz []byte) string) {
+	recordBytes := indexBytes + 4 + 1
+	if offset >= len(bz) {
+		in.malformed(offset, 0, "ValidatorVoteStates", "missing validator vote states")
+		return
+	}
+
+	for i := 0; offset < len(bz); i++ {
+		name := fmt.Sprintf("ValidatorVoteStates[%d]", i)
+		if len(bz)-offset < recordBytes {
+			in.malformed(offset, len(bz)-offset, name, fmt.Sprintf("truncated, validator vote state must be %d bytes", recordBytes))
+			return
+		}
+
+		in.add(offset, indexBytes, name+".ValidatorIndex", index(bz[offset:offset+indexBytes]))
+		offset += indexBytes
+		in.add(offset, 4, name+".PreVotedBlockHash", strconv.Quote(string(bz[offset:offset+4])))
+		offset += 4
+		in.add(offset, 1, name+".VoteFlag", voteFlag(bz[offset]))
+		offset++
+	}
+}
@@ -0,0 +1,207 @@
+package codec
+
+import (
+	"errors"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"strings"
+	"testing"
+	"time"
+)
+
+var inspectTestValidators = types.StreamingLightValidators{
+	{Index: 0, VotingPowerDisplayPercent: 60.5, Moniker: "Validator One"},
+	{Index: 1, VotingPowerDisplayPercent: 39.5},
+}
+
+var inspectTestInformation = &types.StreamingNextBlockVotingInformation{
+	HeightRoundStep:       types.HeightRoundStep{Height: 100, Round: 0, Step: types.RoundStepPrevote},
+	Duration:              3 * time.Second,
+	PreVotedPercent:       60.5,
+	PreCommitVotedPercent: 0,
+	ValidatorVoteStates: []types.StreamingValidatorVoteState{
+		{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true},
+		{ValidatorIndex: 1, PreVotedBlockHash: "----"},
+	},
+}
+
+// checkInspectionCoverage checks the fields are contiguous and cover all the bytes, without malformed field.
+func checkInspectionCoverage(t *testing.T, inspection *Inspection, size int) {
+	t.Helper()
+	offset := 0
+	for _, field := range inspection.Fields {
+		if field.Malformed {
+			t.Errorf("unexpected malformed field %+v", field)
+		}
+		if field.Offset != offset {
+			t.Errorf("field %s offset = %d, want %d", field.Name, field.Offset, offset)
+		}
+		offset = field.Offset + field.Length
+	}
+	if offset != size {
+		t.Errorf("fields cover %d bytes, want %d", offset, size)
+	}
+}
+
+func findInspectedField(inspection *Inspection, name string) (InspectedField, bool) {
+	for _, field := range inspection.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return InspectedField{}, false
+}
+
+func TestInspect(t *testing.T) {
+	for _, version := range []CvpCodecVersion{CvpCodecVersionV1, CvpCodecVersionV2, CvpCodecVersionV3} {
+		t.Run(string(version), func(t *testing.T) {
+			codec, _ := GetRegisteredCvpCodec(version)
+
+			tests := []struct {
+				name       string
+				bz         []byte
+				inspect    func(bz []byte) (*Inspection, error)
+				wantValues map[string]string
+			}{
+				{
+					name:    "light validators",
+					bz:      codec.EncodeStreamingLightValidators(inspectTestValidators),
+					inspect: InspectStreamingLightValidators,
+					wantValues: map[string]string{
+						"Validators[0].Index":                     "0",
+						"Validators[0].VotingPowerDisplayPercent": "60.5",
+						"Validators[1].Index":                     "1",
+					},
+				},
+				{
+					name:    "next block voting information",
+					bz:      codec.EncodeStreamingNextBlockVotingInformation(inspectTestInformation),
+					inspect: InspectStreamingNextBlockVotingInformation,
+					wantValues: map[string]string{
+						"HeightRoundStep":                          `"100/0/4"`,
+						"ValidatorVoteStates[0].PreVotedBlockHash": `"C0FF"`,
+						"ValidatorVoteStates[0].VoteFlag":          "V (pre-voted)",
+						"ValidatorVoteStates[1].ValidatorIndex":    "1",
+						"ValidatorVoteStates[1].VoteFlag":          "X (not voted)",
+					},
+				},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					inspection, err := tt.inspect(tt.bz)
+					if err != nil {
+						t.Fatalf("inspect error = %v", err)
+					}
+					if inspection.Version != version {
+						t.Errorf("version = %s, want %s", inspection.Version, version)
+					}
+					checkInspectionCoverage(t, inspection, len(tt.bz))
+
+					fields := inspection
+					if version == CvpCodecVersionV3 {
+						if inspection.Inner == nil || inspection.Inner.Version != CvpCodecVersionV2 {
+							t.Fatalf("inner inspection = %+v, want v2", inspection.Inner)
+						}
+						bzByV2, _ := gunzipV3(tt.bz[len(prefixDataEncodedByCvpCodecV3):])
+						checkInspectionCoverage(t, inspection.Inner, len(bzByV2))
+						fields = inspection.Inner
+					} else if inspection.Inner != nil {
+						t.Errorf("inner inspection = %+v, want nil", inspection.Inner)
+					}
+
+					for name, want := range tt.wantValues {
+						field, found := findInspectedField(fields, name)
+						if !found {
+							t.Errorf("missing field %s", name)
+						} else if field.Value != want {
+							t.Errorf("field %s value = %s, want %s", name, field.Value, want)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestInspect_Moniker(t *testing.T) {
+	for _, codec := range []CvpCodec{GetCvpCodecV1(), GetCvpCodecV2()} {
+		inspection, err := InspectStreamingLightValidators(codec.EncodeStreamingLightValidators(inspectTestValidators))
+		if err != nil {
+			t.Fatalf("%s: inspect error = %v", codec.GetVersion(), err)
+		}
+		field, found := findInspectedField(inspection, "Validators[0].Moniker")
+		if !found || !strings.HasPrefix(field.Value, `"Validator One`) {
+			t.Errorf("%s: moniker field = %+v", codec.GetVersion(), field)
+		}
+		if _, found := findInspectedField(inspection, "Validators[1].Moniker"); found {
+			t.Errorf("%s: empty moniker must be omitted", codec.GetVersion())
+		}
+	}
+}
+
+func TestInspect_Malformed(t *testing.T) {
+	bzV2 := GetCvpCodecV2().EncodeStreamingNextBlockVotingInformation(inspectTestInformation)
+	bzV1 := GetCvpCodecV1().EncodeStreamingLightValidators(inspectTestValidators)
+
+	tests := []struct {
+		name          string
+		bz            []byte
+		inspect       func(bz []byte) (*Inspection, error)
+		wantMalformed string
+	}{
+		{
+			name:          "truncated vote state",
+			bz:            bzV2[:len(bzV2)-3],
+			inspect:       InspectStreamingNextBlockVotingInformation,
+			wantMalformed: "ValidatorVoteStates[1]",
+		},
+		{
+			name:          "missing vote states",
+			bz:            bzV2[:len(bzV2)-14],
+			inspect:       InspectStreamingNextBlockVotingInformation,
+			wantMalformed: "ValidatorVoteStates",
+		},
+		{
+			name:          "missing separator",
+			bz:            []byte{0x2, '|', '1', '0', '0'},
+			inspect:       InspectStreamingNextBlockVotingInformation,
+			wantMalformed: "Duration",
+		},
+		{
+			name:          "invalid validator length",
+			bz:            bzV1[:len(bzV1)-1],
+			inspect:       InspectStreamingLightValidators,
+			wantMalformed: "Validators[1]",
+		},
+		{
+			name:          "invalid gzip",
+			bz:            []byte{0x3, '|', 0x1f, 0x8b},
+			inspect:       InspectStreamingLightValidators,
+			wantMalformed: "payload",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspection, err := tt.inspect(tt.bz)
+			if err != nil {
+				t.Fatalf("inspect error = %v", err)
+			}
+			last := inspection.Fields[len(inspection.Fields)-1]
+			if !last.Malformed || last.Name != tt.wantMalformed {
+				t.Errorf("last field = %+v, want malformed %s", last, tt.wantMalformed)
+			}
+			if last.Offset+last.Length != len(tt.bz) {
+				t.Errorf("malformed field must cover the rest, got %+v of %d bytes", last, len(tt.bz))
+			}
+		})
+	}
+}
+
+func TestInspect_Unsupported(t *testing.T) {
+	if _, err := InspectStreamingLightValidators([]byte("garbage")); !errors.Is(err, ErrBadPrefix) {
+		t.Errorf("inspect error = %v, want ErrBadPrefix", err)
+	}
+	bz := GetCvpCodecV4().EncodeStreamingLightValidators(inspectTestValidators)
+	if _, err := InspectStreamingLightValidators(bz); err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Errorf("inspect error = %v, want not supported", err)
+	}
+}
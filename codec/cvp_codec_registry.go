@@ -0,0 +1,125 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// cvpCodecRegistration holds a CvpCodec implementation registered via RegisterCvpCodec.
+type cvpCodecRegistration struct {
+	version CvpCodecVersion
+	prefix  []byte
+	codec   CvpCodec
+}
+
+// cvpCodecRegistry holds all the registered CvpCodec implementations,
+// it drives the version detection and the decoding of proxy CvpCodec.
+var cvpCodecRegistry = struct {
+	mu            sync.RWMutex
+	registrations []cvpCodecRegistration
+}{}
+
+func init() {
+	//goland:noinspection GoDeprecation
+	mustRegisterCvpCodec(GetCvpCodecV1(), []byte(prefixDataEncodedByCvpCodecV1))
+	mustRegisterCvpCodec(GetCvpCodecV2(), prefixDataEncodedByCvpCodecV2)
+	mustRegisterCvpCodec(GetCvpCodecV3(), prefixDataEncodedByCvpCodecV3)
+	mustRegisterCvpCodec(GetCvpCodecV4(), prefixDataEncodedByCvpCodecV4)
+	mustRegisterCvpCodec(GetCvpCodecV5(), prefixDataEncodedByCvpCodecV5)
+	mustRegisterCvpCodec(GetCvpCodecV6(), prefixDataEncodedByCvpCodecV6)
+	mustRegisterCvpCodec(GetCvpCodecV7(), prefixDataEncodedByCvpCodecV7)
+	mustRegisterCvpCodec(GetCvpCodecV8(), prefixDataEncodedByCvpCodecV8)
+	mustRegisterCvpCodec(GetCvpCodecV9(), prefixDataEncodedByCvpCodecV9)
+	mustRegisterCvpCodec(GetCvpDeltaCodec(), prefixDataEncodedByCvpCodecDelta)
+}
+
+// RegisterCvpCodec registers a CvpCodec implementation, so its encoded data can be detected by DetectEncodingVersion
+// and decoded by proxy CvpCodec.
+//
+// The prefix is the very first bytes of every data encoded by the codec.
+// Registration is rejected when the version is already registered, or the prefix collides with prefix of
+// another registered codec, means either one of them is prefix of the other.
+func RegisterCvpCodec(codec CvpCodec, prefix []byte) error {
+	if codec == nil {
+		return fmt.Errorf("codec is required")
+	}
+	if _, isProxy := codec.(proxyCvpCodec); isProxy {
+		return fmt.Errorf("can not register proxy CvpCodec")
+	}
+	version := codec.GetVersion()
+	if version == "" || version == CvpCodecVersionUnknown {
+		return fmt.Errorf("invalid codec version: %s", version)
+	}
+	if len(prefix) < 1 {
+		return fmt.Errorf("prefix is required")
+	}
+
+	cvpCodecRegistry.mu.Lock()
+	defer cvpCodecRegistry.mu.Unlock()
+
+	for _, registration := range cvpCodecRegistry.registrations {
+		if registration.version == version {
+			return fmt.Errorf("codec version %s had been registered", version)
+		}
+		if bytes.HasPrefix(prefix, registration.prefix) || bytes.HasPrefix(registration.prefix, prefix) {
+			return fmt.Errorf("prefix %x of codec version %s collides with prefix %x of codec version %s", prefix, version, registration.prefix, registration.version)
+		}
+	}
+
+	cvpCodecRegistry.registrations = append(cvpCodecRegistry.registrations, cvpCodecRegistration{
+		version: version,
+		prefix:  append([]byte{}, prefix...),
+		codec:   codec,
+	})
+	return nil
+}
+
+func mustRegisterCvpCodec(codec CvpCodec, prefix []byte) {
+	if err := RegisterCvpCodec(codec, prefix); err != nil {
+		panic(err)
+	}
+}
+
+// GetRegisteredCvpCodec returns the registered CvpCodec implementation of the given version.
+func GetRegisteredCvpCodec(version CvpCodecVersion) (codec CvpCodec, found bool) {
+	cvpCodecRegistry.mu.RLock()
+	defer cvpCodecRegistry.mu.RUnlock()
+
+	for _, registration := range cvpCodecRegistry.registrations {
+		if registration.version == version {
+			return registration.codec, true
+		}
+	}
+	return nil, false
+}
+
+// GetRegisteredCvpCodecVersions returns versions of all the registered CvpCodec implementations, sorted.
+func GetRegisteredCvpCodecVersions() []CvpCodecVersion {
+	cvpCodecRegistry.mu.RLock()
+	defer cvpCodecRegistry.mu.RUnlock()
+
+	versions := make([]CvpCodecVersion, 0, len(cvpCodecRegistry.registrations))
+	for _, registration := range cvpCodecRegistry.registrations {
+		versions = append(versions, registration.version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+	return versions
+}
+
+// findRegisteredCvpCodecByPrefix returns the registration which prefix matches the very first bytes of the given data.
+func findRegisteredCvpCodecByPrefix(bz []byte) (cvpCodecRegistration, bool) {
+	cvpCodecRegistry.mu.RLock()
+	defer cvpCodecRegistry.mu.RUnlock()
+
+	// prefixes never collide, so at most one registration matches
+	for _, registration := range cvpCodecRegistry.registrations {
+		if bytes.HasPrefix(bz, registration.prefix) {
+			return registration, true
+		}
+	}
+	return cvpCodecRegistration{}, false
+}
@@ -0,0 +1,227 @@
+package codec
+
+import (
+	"bytes"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const cvpCodecVersionExperimental CvpCodecVersion = "experimental"
+
+var prefixDataEncodedByCvpCodecExperimental = []byte{0xE, 0xE, '|'}
+
+var _ CvpCodec = (*cvpCodecExperimental)(nil)
+
+// cvpCodecExperimental is a CvpCodec implementation for testing registry,
+// it encodes the same way as v2 codec, with a different prefix.
+type cvpCodecExperimental struct {
+	CvpCodec
+}
+
+func (c cvpCodecExperimental) GetVersion() CvpCodecVersion {
+	return cvpCodecVersionExperimental
+}
+
+func (c cvpCodecExperimental) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
+	return c.toExperimental(c.CvpCodec.EncodeStreamingLightValidators(validators))
+}
+
+func (c cvpCodecExperimental) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecExperimental) {
+		return nil, newDecodeError(cvpCodecVersionExperimental, ErrBadPrefix, "prefix", 0, "bad prefix")
+	}
+	return c.CvpCodec.DecodeStreamingLightValidators(c.toV2(bz))
+}
+
+func (c cvpCodecExperimental) EncodeStreamingNextBlockVotingInformation(information *types.StreamingNextBlockVotingInformation) []byte {
+	return c.toExperimental(c.CvpCodec.EncodeStreamingNextBlockVotingInformation(information))
+}
+
+func (c cvpCodecExperimental) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecExperimental) {
+		return nil, newDecodeError(cvpCodecVersionExperimental, ErrBadPrefix, "prefix", 0, "bad prefix")
+	}
+	return c.CvpCodec.DecodeStreamingNextBlockVotingInformation(c.toV2(bz))
+}
+
+func (c cvpCodecExperimental) toExperimental(bzByV2 []byte) []byte {
+	return append(append([]byte{}, prefixDataEncodedByCvpCodecExperimental...), bzByV2[len(prefixDataEncodedByCvpCodecV2):]...)
+}
+
+func (c cvpCodecExperimental) toV2(bz []byte) []byte {
+	return append(append([]byte{}, prefixDataEncodedByCvpCodecV2...), bz[len(prefixDataEncodedByCvpCodecExperimental):]...)
+}
+
+// withTestingCvpCodecRegistry restores the registry after the test finished.
+func withTestingCvpCodecRegistry(t *testing.T) {
+	cvpCodecRegistry.mu.Lock()
+	backup := append([]cvpCodecRegistration{}, cvpCodecRegistry.registrations...)
+	cvpCodecRegistry.mu.Unlock()
+
+	t.Cleanup(func() {
+		cvpCodecRegistry.mu.Lock()
+		defer cvpCodecRegistry.mu.Unlock()
+		cvpCodecRegistry.registrations = backup
+	})
+}
+
+func TestRegisterCvpCodec(t *testing.T) {
+	tests := []struct {
+		name            string
+		codec           CvpCodec
+		prefix          []byte
+		wantErr         bool
+		wantErrContains string
+	}{
+		{
+			name:    "can register",
+			codec:   cvpCodecExperimental{CvpCodec: GetCvpCodecV2()},
+			prefix:  prefixDataEncodedByCvpCodecExperimental,
+			wantErr: false,
+		},
+		{
+			name:            "reject nil codec",
+			codec:           nil,
+			prefix:          prefixDataEncodedByCvpCodecExperimental,
+			wantErr:         true,
+			wantErrContains: "codec is required",
+		},
+		{
+			name:            "reject proxy codec",
+			codec:           NewProxyCvpCodec(),
+			prefix:          prefixDataEncodedByCvpCodecExperimental,
+			wantErr:         true,
+			wantErrContains: "can not register proxy CvpCodec",
+		},
+		{
+			name:            "reject empty prefix",
+			codec:           cvpCodecExperimental{CvpCodec: GetCvpCodecV2()},
+			prefix:          nil,
+			wantErr:         true,
+			wantErrContains: "prefix is required",
+		},
+		{
+			name:            "reject duplicated version",
+			codec:           GetCvpCodecV2(),
+			prefix:          prefixDataEncodedByCvpCodecExperimental,
+			wantErr:         true,
+			wantErrContains: "codec version v2 had been registered",
+		},
+		{
+			name:            "reject duplicated prefix",
+			codec:           cvpCodecExperimental{CvpCodec: GetCvpCodecV2()},
+			prefix:          prefixDataEncodedByCvpCodecV2,
+			wantErr:         true,
+			wantErrContains: "collides with prefix",
+		},
+		{
+			name:            "reject prefix which is prefix of registered prefix",
+			codec:           cvpCodecExperimental{CvpCodec: GetCvpCodecV2()},
+			prefix:          []byte{0x2},
+			wantErr:         true,
+			wantErrContains: "collides with prefix",
+		},
+		{
+			name:            "reject prefix which starts with registered prefix",
+			codec:           cvpCodecExperimental{CvpCodec: GetCvpCodecV2()},
+			prefix:          []byte{0x2, cvpCodecV2Separator, 0xE},
+			wantErr:         true,
+			wantErrContains: "collides with prefix",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withTestingCvpCodecRegistry(t)
+
+			err := RegisterCvpCodec(tt.codec, tt.prefix)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("RegisterCvpCodec() expect error but got nil")
+					return
+				}
+				if !strings.Contains(err.Error(), tt.wantErrContains) {
+					t.Errorf("RegisterCvpCodec() error = %v, want contains %v", err, tt.wantErrContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("RegisterCvpCodec() error = %v", err)
+				return
+			}
+			if _, found := GetRegisteredCvpCodec(tt.codec.GetVersion()); !found {
+				t.Errorf("GetRegisteredCvpCodec() not found version %s after registered", tt.codec.GetVersion())
+			}
+		})
+	}
+}
+
+func TestGetRegisteredCvpCodecVersions(t *testing.T) {
+	want := []CvpCodecVersion{CvpCodecVersionDelta, CvpCodecVersionV1, CvpCodecVersionV2, CvpCodecVersionV3, CvpCodecVersionV4, CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}
+	if got := GetRegisteredCvpCodecVersions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetRegisteredCvpCodecVersions() = %v, want %v", got, want)
+	}
+}
+
+func Test_proxyCvpCodec_DecodeRegisteredCvpCodec(t *testing.T) {
+	withTestingCvpCodecRegistry(t)
+
+	experimentalCodec := cvpCodecExperimental{CvpCodec: GetCvpCodecV2()}
+
+	validators := types.StreamingLightValidators{
+		{
+			Index:                     0,
+			VotingPowerDisplayPercent: 10.1,
+			Moniker:                   "Val1",
+		},
+	}
+	information := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
+		PreVotedPercent:       1,
+		PreCommitVotedPercent: 2,
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{
+				ValidatorIndex:    0,
+				PreVotedBlockHash: "ABCD",
+				PreVoted:          true,
+			},
+		},
+	}
+
+	bzValidators := experimentalCodec.EncodeStreamingLightValidators(validators)
+	bzInformation := experimentalCodec.EncodeStreamingNextBlockVotingInformation(information)
+
+	if _, detected := DetectEncodingVersion(bzValidators); detected {
+		t.Errorf("DetectEncodingVersion() detected version of unregistered codec")
+		return
+	}
+	if _, err := cvpProxyCodecImpl.DecodeStreamingLightValidators(bzValidators); !errors.Is(err, ErrBadPrefix) {
+		t.Errorf("DecodeStreamingLightValidators() error = %v, want matches ErrBadPrefix", err)
+		return
+	}
+
+	if err := RegisterCvpCodec(experimentalCodec, prefixDataEncodedByCvpCodecExperimental); err != nil {
+		t.Errorf("RegisterCvpCodec() error = %v", err)
+		return
+	}
+
+	if possible, detected := DetectEncodingVersion(bzInformation); !detected || possible != cvpCodecVersionExperimental {
+		t.Errorf("DetectEncodingVersion() = %v, %v, want %v, true", possible, detected, cvpCodecVersionExperimental)
+	}
+
+	gotValidators, err := cvpProxyCodecImpl.DecodeStreamingLightValidators(bzValidators)
+	if err != nil {
+		t.Errorf("DecodeStreamingLightValidators() error = %v", err)
+	} else if !reflect.DeepEqual(gotValidators, validators) {
+		t.Errorf("DecodeStreamingLightValidators()\ngot = %v\nwant %v", gotValidators, validators)
+	}
+
+	gotInformation, err := cvpProxyCodecImpl.DecodeStreamingNextBlockVotingInformation(bzInformation)
+	if err != nil {
+		t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v", err)
+	} else if !reflect.DeepEqual(gotInformation, information) {
+		t.Errorf("DecodeStreamingNextBlockVotingInformation()\ngot = %v\nwant %v", gotInformation, information)
+	}
+}
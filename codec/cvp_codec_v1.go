@@ -4,10 +4,9 @@ import (
 	"bytes"
 	"encoding/hex"
 	"fmt"
-	"github.com/bcdevtools/cvp-streaming-core/constants"
 	"github.com/bcdevtools/cvp-streaming-core/types"
 	"github.com/bcdevtools/cvp-streaming-core/utils"
-	"github.com/pkg/errors"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -26,6 +25,13 @@ const cvpCodecV1MonikerBufferSize = 20
 
 const cvpCodecV1HexEncodedMonikerBufferSize = 40
 
+var cvpCodecV1Limits = CvpCodecLimits{
+	MaxValidators:                             250,
+	MaxValidatorIndex:                         998,
+	MaxEncodedLightValidatorsBytes:            12251,
+	MaxEncodedNextBlockVotingInformationBytes: 2044,
+}
+
 type cvpCodecV1 struct {
 }
 
@@ -37,8 +43,16 @@ func GetCvpCodecV1() CvpCodec {
 }
 
 func (c cvpCodecV1) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
-	if len(validators) > constants.MAX_VALIDATORS {
-		panic(fmt.Errorf("too many validators: %d/%d", len(validators), constants.MAX_VALIDATORS))
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV1) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	if len(validators) > cvpCodecV1Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV1, "StreamingLightValidators", "too many validators: %d/%d", len(validators), cvpCodecV1Limits.MaxValidators)
 	}
 
 	var b strings.Builder
@@ -50,10 +64,10 @@ func (c cvpCodecV1) EncodeStreamingLightValidators(validators types.StreamingLig
 		}
 
 		if v.Index < 0 {
-			panic(fmt.Errorf("invalid validator index: %d, must not be negative", v.Index))
+			return nil, newEncodeError(CvpCodecVersionV1, "Index", "invalid validator index: %d, must not be negative", v.Index)
 		}
-		if v.Index > 998 {
-			panic(fmt.Errorf("invalid validator index: %d, must be less than 999", v.Index))
+		if v.Index > cvpCodecV1Limits.MaxValidatorIndex {
+			return nil, newEncodeError(CvpCodecVersionV1, "Index", "invalid validator index: %d, must be less than %d", v.Index, cvpCodecV1Limits.MaxValidatorIndex+1)
 		}
 		valIdxStr := strconv.Itoa(v.Index)
 		for len(valIdxStr) < 3 {
@@ -61,11 +75,14 @@ func (c cvpCodecV1) EncodeStreamingLightValidators(validators types.StreamingLig
 		}
 		b.WriteString(valIdxStr)
 
+		if math.IsNaN(v.VotingPowerDisplayPercent) {
+			return nil, newEncodeError(CvpCodecVersionV1, "VotingPowerDisplayPercent", "invalid voting power display percent: NaN")
+		}
 		if v.VotingPowerDisplayPercent < 0 {
-			panic(fmt.Errorf("invalid voting power display percent: %f, must not be negative", v.VotingPowerDisplayPercent))
+			return nil, newEncodeError(CvpCodecVersionV1, "VotingPowerDisplayPercent", "invalid voting power display percent: %f, must not be negative", v.VotingPowerDisplayPercent)
 		}
 		if v.VotingPowerDisplayPercent > 100 {
-			panic(fmt.Errorf("invalid voting power display percent: %f, must not be greater than 100", v.VotingPowerDisplayPercent))
+			return nil, newEncodeError(CvpCodecVersionV1, "VotingPowerDisplayPercent", "invalid voting power display percent: %f, must not be greater than 100", v.VotingPowerDisplayPercent)
 		}
 		valVpStr := strconv.Itoa(int(v.VotingPowerDisplayPercent * 100))
 		for len(valVpStr) < 5 {
@@ -80,62 +97,73 @@ func (c cvpCodecV1) EncodeStreamingLightValidators(validators types.StreamingLig
 		}
 	}
 
-	return []byte(b.String())
+	return []byte(b.String()), nil
 }
 
 func (c cvpCodecV1) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
 	if !bytes.HasPrefix(bz, []byte(prefixDataEncodedByCvpCodecV1)) {
-		return nil, fmt.Errorf("bad encoding prefix")
+		return nil, newDecodeError(CvpCodecVersionV1, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
 	}
 
 	var validators types.StreamingLightValidators
+	offsetByIndex := make(map[int]int)
 
 	spl := strings.Split(string(bz), cvpCodecV1Separator)
 
+	offset := len(prefixDataEncodedByCvpCodecV1)
 	for i := 1; i < len(spl); i++ {
+		if i > 1 {
+			offset += len(spl[i-1]) + len(cvpCodecV1Separator)
+		}
+
 		valRawData := spl[i]
 
 		const lengthOmittingMoniker = 3 /*index*/ + 5                                           /*percent x100*/
 		const lengthWithMoniker = lengthOmittingMoniker + cvpCodecV1HexEncodedMonikerBufferSize /*moniker buffer size to hex*/
 
 		if len(valRawData) == 0 {
-			return nil, fmt.Errorf("invalid empty validator raw data")
+			return nil, newDecodeError(CvpCodecVersionV1, ErrTruncated, "StreamingLightValidator", offset, "invalid empty validator raw data")
 		} else if len(valRawData) == lengthOmittingMoniker {
 			// OK
 		} else if len(valRawData) == lengthWithMoniker {
 			// OK
+		} else if len(valRawData) < lengthWithMoniker {
+			return nil, newDecodeError(CvpCodecVersionV1, ErrTruncated, "StreamingLightValidator", offset, "invalid validator raw data length %d", len(valRawData))
 		} else {
-			return nil, fmt.Errorf("invalid validator raw data length %d", len(valRawData))
+			return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "StreamingLightValidator", offset, "invalid validator raw data length %d", len(valRawData))
 		}
 
 		var validator types.StreamingLightValidator
 
 		validatorIndex, err := strconv.ParseInt(valRawData[:3], 10, 64)
 		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to parse validator index: %s", valRawData[:3]))
+			return nil, wrapDecodeError(CvpCodecVersionV1, ErrInvalidField, "Index", offset, err, fmt.Sprintf("failed to parse validator index: %s", valRawData[:3]))
 		}
-		if validatorIndex < 0 || validatorIndex > 998 {
-			return nil, fmt.Errorf("invalid validator index: %d", validatorIndex)
+		if validatorIndex < 0 || validatorIndex > int64(cvpCodecV1Limits.MaxValidatorIndex) {
+			return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "Index", offset, "invalid validator index: %d", validatorIndex)
 		}
 		validator.Index = int(validatorIndex)
 
 		votingPowerDisplayPercentX100, err := strconv.ParseInt(valRawData[3:8], 10, 64)
 		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to parse voting power display percent x100: %s", valRawData[3:8]))
+			return nil, wrapDecodeError(CvpCodecVersionV1, ErrInvalidField, "VotingPowerDisplayPercent", offset+3, err, fmt.Sprintf("failed to parse voting power display percent x100: %s", valRawData[3:8]))
 		}
 		validator.VotingPowerDisplayPercent = float64(votingPowerDisplayPercentX100) / 100
 		if validator.VotingPowerDisplayPercent < 0 || validator.VotingPowerDisplayPercent > 100 {
-			return nil, fmt.Errorf("invalid voting power display percent: %f", validator.VotingPowerDisplayPercent)
+			return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "VotingPowerDisplayPercent", offset+3, "invalid voting power display percent: %f", validator.VotingPowerDisplayPercent)
 		}
 
 		if len(valRawData) == lengthWithMoniker {
 			monikerBytes, err := hex.DecodeString(valRawData[8:])
 			if err != nil {
-				return nil, errors.Wrap(err, fmt.Sprintf("failed to decode moniker: %s", valRawData[8:]))
+				return nil, wrapDecodeError(CvpCodecVersionV1, ErrInvalidField, "Moniker", offset+8, err, fmt.Sprintf("failed to decode moniker: %s", valRawData[8:]))
 			}
 			validator.Moniker = strings.TrimSpace(sanitizeMoniker(string(monikerBytes)))
 		}
 
+		if _, found := offsetByIndex[validator.Index]; !found {
+			offsetByIndex[validator.Index] = offset
+		}
 		validators = append(validators, validator)
 	}
 
@@ -144,7 +172,7 @@ func (c cvpCodecV1) DecodeStreamingLightValidators(bz []byte) (types.StreamingLi
 	})
 	for i, v := range validators {
 		if v.Index != i {
-			return nil, fmt.Errorf("invalid validator index sequence, %d at %d", v.Index, i)
+			return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "Index", offsetByIndex[v.Index], "invalid validator index sequence, %d at %d", v.Index, i)
 		}
 	}
 
@@ -152,14 +180,25 @@ func (c cvpCodecV1) DecodeStreamingLightValidators(bz []byte) (types.StreamingLi
 }
 
 func (c cvpCodecV1) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
-	if len(inf.ValidatorVoteStates) > constants.MAX_VALIDATORS {
-		panic(fmt.Errorf("too many validators: %d/%d", len(inf.ValidatorVoteStates), constants.MAX_VALIDATORS))
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV1) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	if len(inf.ValidatorVoteStates) > cvpCodecV1Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV1, "ValidatorVoteStates", "too many validators: %d/%d", len(inf.ValidatorVoteStates), cvpCodecV1Limits.MaxValidators)
+	}
+	if err := validateTextHeightRoundStepForEncoding(CvpCodecVersionV1, "HeightRoundStep", inf.HeightRoundStep); err != nil {
+		return nil, err
 	}
 
 	var b strings.Builder
 	b.WriteString(prefixDataEncodedByCvpCodecV1)
 
-	b.WriteString(inf.HeightRoundStep)
+	b.WriteString(inf.HeightRoundStep.String())
 	b.WriteString(cvpCodecV1Separator)
 
 	duration := inf.Duration
@@ -178,10 +217,10 @@ func (c cvpCodecV1) EncodeStreamingNextBlockVotingInformation(inf *types.Streami
 
 	for _, v := range inf.ValidatorVoteStates {
 		if v.ValidatorIndex < 0 {
-			panic(fmt.Errorf("invalid validator index: %d, must not be negative", v.ValidatorIndex))
+			return nil, newEncodeError(CvpCodecVersionV1, "ValidatorIndex", "invalid validator index: %d, must not be negative", v.ValidatorIndex)
 		}
-		if v.ValidatorIndex > 998 {
-			panic(fmt.Errorf("invalid validator index: %d, must be less than 999", v.ValidatorIndex))
+		if v.ValidatorIndex > cvpCodecV1Limits.MaxValidatorIndex {
+			return nil, newEncodeError(CvpCodecVersionV1, "ValidatorIndex", "invalid validator index: %d, must be less than %d", v.ValidatorIndex, cvpCodecV1Limits.MaxValidatorIndex+1)
 		}
 		valIdxStr := strconv.Itoa(v.ValidatorIndex)
 		for len(valIdxStr) < 3 {
@@ -192,7 +231,7 @@ func (c cvpCodecV1) EncodeStreamingNextBlockVotingInformation(inf *types.Streami
 		if len(v.PreVotedBlockHash) == 0 {
 			b.WriteString("----")
 		} else if len(v.PreVotedBlockHash) != 4 {
-			panic(fmt.Errorf("invalid pre-voted fingerprint block hash length: %s, must be 2 bytes", v.PreVotedBlockHash))
+			return nil, newEncodeError(CvpCodecVersionV1, "PreVotedBlockHash", "invalid pre-voted fingerprint block hash length: %s, must be 2 bytes", v.PreVotedBlockHash)
 		} else {
 			b.WriteString(v.PreVotedBlockHash)
 		}
@@ -208,12 +247,12 @@ func (c cvpCodecV1) EncodeStreamingNextBlockVotingInformation(inf *types.Streami
 		}
 	}
 
-	return []byte(b.String())
+	return []byte(b.String()), nil
 }
 
 func (c cvpCodecV1) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
 	if !bytes.HasPrefix(bz, []byte(prefixDataEncodedByCvpCodecV1)) {
-		return nil, fmt.Errorf("bad encoding prefix")
+		return nil, newDecodeError(CvpCodecVersionV1, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
 	}
 
 	data := strings.ToUpper(string(bz))
@@ -221,65 +260,78 @@ func (c cvpCodecV1) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 	var result types.StreamingNextBlockVotingInformation
 
 	spl := strings.Split(data, cvpCodecV1Separator)
-	if len(spl) != 6 {
-		return nil, fmt.Errorf("wrong number of elements")
+	if len(spl) < 6 {
+		return nil, newDecodeError(CvpCodecVersionV1, ErrTruncated, "StreamingNextBlockVotingInformation", len(bz), "wrong number of elements")
+	}
+	if len(spl) > 6 {
+		return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "StreamingNextBlockVotingInformation", 0, "wrong number of elements")
+	}
+
+	// offsets[i] is the beginning offset of spl[i]
+	offsets := make([]int, len(spl))
+	for i := 1; i < len(spl); i++ {
+		offsets[i] = offsets[i-1] + len(spl[i-1]) + len(cvpCodecV1Separator)
 	}
 
-	result.HeightRoundStep = spl[1]
-	if !regexpHeightRoundStep.MatchString(result.HeightRoundStep) {
-		return nil, fmt.Errorf("invalid height round step: %s", result.HeightRoundStep)
+	heightRoundStep, err := parseHeightRoundStepForDecoding(CvpCodecVersionV1, "HeightRoundStep", offsets[1], spl[1])
+	if err != nil {
+		return nil, err
 	}
+	result.HeightRoundStep = heightRoundStep
 
 	durationMs, err := strconv.ParseInt(spl[2], 10, 64)
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("failed to parse duration ms: %s", spl[2]))
+		return nil, wrapDecodeError(CvpCodecVersionV1, ErrInvalidField, "Duration", offsets[2], err, fmt.Sprintf("failed to parse duration ms: %s", spl[2]))
 	}
 	if durationMs < 0 {
-		return nil, fmt.Errorf("negative duration ms: %d", durationMs)
+		return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "Duration", offsets[2], "negative duration ms: %d", durationMs)
 	}
 	result.Duration = time.Duration(durationMs) * time.Millisecond
 
 	preVotedPercentX100, err := strconv.ParseInt(spl[3], 10, 64)
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("failed to parse pre-voted percent x100: %s", spl[3]))
+		return nil, wrapDecodeError(CvpCodecVersionV1, ErrInvalidField, "PreVotedPercent", offsets[3], err, fmt.Sprintf("failed to parse pre-voted percent x100: %s", spl[3]))
 	}
 	result.PreVotedPercent = float64(preVotedPercentX100) / 100
 	if result.PreVotedPercent < 0 || result.PreVotedPercent > 100 {
-		return nil, fmt.Errorf("invalid pre-voted percent: %f", result.PreVotedPercent)
+		return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "PreVotedPercent", offsets[3], "invalid pre-voted percent: %f", result.PreVotedPercent)
 	}
 
 	preCommitVotedPercentX100, err := strconv.ParseInt(spl[4], 10, 64)
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("failed to parse pre-commit voted percent x100: %s", spl[4]))
+		return nil, wrapDecodeError(CvpCodecVersionV1, ErrInvalidField, "PreCommitVotedPercent", offsets[4], err, fmt.Sprintf("failed to parse pre-commit voted percent x100: %s", spl[4]))
 	}
 	result.PreCommitVotedPercent = float64(preCommitVotedPercentX100) / 100
 	if result.PreCommitVotedPercent < 0 || result.PreCommitVotedPercent > 100 {
-		return nil, fmt.Errorf("invalid pre-commit voted percent: %f", result.PreCommitVotedPercent)
+		return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "PreCommitVotedPercent", offsets[4], "invalid pre-commit voted percent: %f", result.PreCommitVotedPercent)
 	}
 
 	validatorVoteStates := make([]types.StreamingValidatorVoteState, 0)
+	offsetByIndex := make(map[int]int)
 	validatorVoteStatesStr := spl[5]
 	if len(validatorVoteStatesStr) < 1 {
-		return nil, fmt.Errorf("missing validator vote states")
+		return nil, newDecodeError(CvpCodecVersionV1, ErrTruncated, "ValidatorVoteStates", offsets[5], "missing validator vote states")
 	}
 	if len(validatorVoteStatesStr)%8 != 0 {
-		return nil, fmt.Errorf("invalid validator vote states length: %d", len(validatorVoteStatesStr))
+		return nil, newDecodeError(CvpCodecVersionV1, ErrTruncated, "ValidatorVoteStates", offsets[5], "invalid validator vote states length: %d", len(validatorVoteStatesStr))
 	}
 	var cursor int
 	for cursor < len(validatorVoteStatesStr) {
+		offset := offsets[5] + cursor
+
 		validatorIndex, err := strconv.ParseInt(validatorVoteStatesStr[cursor:cursor+3], 10, 64)
 		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to parse validator index: %s", validatorVoteStatesStr[cursor:cursor+3]))
+			return nil, wrapDecodeError(CvpCodecVersionV1, ErrInvalidField, "ValidatorIndex", offset, err, fmt.Sprintf("failed to parse validator index: %s", validatorVoteStatesStr[cursor:cursor+3]))
 		}
-		if validatorIndex < 0 || validatorIndex > 998 {
-			return nil, fmt.Errorf("invalid validator index: %d", validatorIndex)
+		if validatorIndex < 0 || validatorIndex > int64(cvpCodecV1Limits.MaxValidatorIndex) {
+			return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "ValidatorIndex", offset, "invalid validator index: %d", validatorIndex)
 		}
 		cursor += 3
 
 		preVotedBlockHash := validatorVoteStatesStr[cursor : cursor+4]
 		if preVotedBlockHash != "----" {
 			if !regexpPreVotedFingerprintBlockHash.MatchString(preVotedBlockHash) {
-				return nil, fmt.Errorf("invalid pre-voted fingerprint block hash: %s, must be 2 bytes", preVotedBlockHash)
+				return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "PreVotedBlockHash", offsets[5]+cursor, "invalid pre-voted fingerprint block hash: %s, must be 2 bytes", preVotedBlockHash)
 			}
 		}
 		cursor += 4
@@ -299,10 +351,13 @@ func (c cvpCodecV1) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 			preVoted = true
 		case 'X':
 		default:
-			return nil, fmt.Errorf("invalid validator vote flag: %s", string(voteFlag))
+			return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "VoteFlag", offsets[5]+cursor, "invalid validator vote flag: %s", string(voteFlag))
 		}
 		cursor++
 
+		if _, found := offsetByIndex[int(validatorIndex)]; !found {
+			offsetByIndex[int(validatorIndex)] = offset
+		}
 		validatorVoteStates = append(validatorVoteStates, types.StreamingValidatorVoteState{
 			ValidatorIndex:    int(validatorIndex),
 			PreVotedBlockHash: preVotedBlockHash,
@@ -316,7 +371,7 @@ func (c cvpCodecV1) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 	})
 	for i, state := range validatorVoteStates {
 		if state.ValidatorIndex != i {
-			return nil, fmt.Errorf("invalid validator index sequence, %d at %d", state.ValidatorIndex, i)
+			return nil, newDecodeError(CvpCodecVersionV1, ErrInvalidField, "ValidatorIndex", offsetByIndex[state.ValidatorIndex], "invalid validator index sequence, %d at %d", state.ValidatorIndex, i)
 		}
 	}
 	result.ValidatorVoteStates = validatorVoteStates
@@ -324,6 +379,10 @@ func (c cvpCodecV1) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 	return &result, nil
 }
 
+func (c cvpCodecV1) GetLimits() CvpCodecLimits {
+	return cvpCodecV1Limits
+}
+
 func (c cvpCodecV1) GetVersion() CvpCodecVersion {
 	return CvpCodecVersionV1
 }
@@ -521,7 +521,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "normal, 4 validators",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -562,7 +562,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "normal, 1 validators",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -582,7 +582,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "can not decode zero validators vote state",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -595,7 +595,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "duration will be corrected to zero if negative",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              -1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -611,7 +611,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			},
 			wantEncodedData: []byte("1|1/2/3|0|100|254|000ABCDC"),
 			wantDecodedOrUseInputAsWantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              0,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -629,7 +629,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "percent will be x100 for saving space of dot",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              2 * time.Second,
 				PreVotedPercent:       1.1,
 				PreCommitVotedPercent: 2.54,
@@ -648,7 +648,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if negative validator index",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -663,7 +663,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if validator index greater than 998",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -679,7 +679,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			name: "panic encode if validator list size larger than cap",
 			inf: func() types.StreamingNextBlockVotingInformation {
 				inf := types.StreamingNextBlockVotingInformation{
-					HeightRoundStep:       "1/2/3",
+					HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 					Duration:              time.Second,
 					PreVotedPercent:       1,
 					PreCommitVotedPercent: 2,
@@ -698,7 +698,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if block hash length is not 0 or 4",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -715,7 +715,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if block hash length is not 0 or 4",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -732,7 +732,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "automatically fill prevoted block hash if empty",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -745,7 +745,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			},
 			wantEncodedData: []byte("1|1/2/3|1000|100|200|000----X"),
 			wantDecodedOrUseInputAsWantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -761,7 +761,7 @@ func Test_cvpCodecV1_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			name: "collision of separator byte with bytes index",
 			inf: func() types.StreamingNextBlockVotingInformation {
 				nextBlockVotingInfo := types.StreamingNextBlockVotingInformation{
-					HeightRoundStep:       "1/2/3",
+					HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 					Duration:              time.Second,
 					PreVotedPercent:       1,
 					PreCommitVotedPercent: 2,
@@ -847,7 +847,7 @@ func Test_cvpCodecV1_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
 			name:             "normal, 4 validators",
 			inputEncodedData: []byte("1|1/2/3|1000|100|254|000ABCDC00100000002ABCDV003----X"),
 			wantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -888,7 +888,7 @@ func Test_cvpCodecV1_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
 			name:             "normal, 1 validator",
 			inputEncodedData: []byte("1|1/2/3|1000|100|254|000ABCDC"),
 			wantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -908,7 +908,7 @@ func Test_cvpCodecV1_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
 			name:             "decode upper case input",
 			inputEncodedData: []byte(strings.ToUpper("1|1/2/3|1000|100|254|000ABCDC")),
 			wantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -928,7 +928,7 @@ func Test_cvpCodecV1_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
 			name:             "decode lower case input",
 			inputEncodedData: []byte(strings.ToLower("1|1/2/3|1000|100|254|000ABCDC")),
 			wantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -4,10 +4,9 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
-	"github.com/bcdevtools/cvp-streaming-core/constants"
 	"github.com/bcdevtools/cvp-streaming-core/types"
 	"github.com/bcdevtools/cvp-streaming-core/utils"
-	"github.com/pkg/errors"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -28,6 +27,13 @@ const cvpCodecV2Base64EncodedMonikerBufferSize = 28
 var collisionSeparator2Bytes = []byte{0x0, cvpCodecV2Separator}
 var collisionSeparator2BytesReplacement = []byte{0xFF, 0xFF}
 
+var cvpCodecV2Limits = CvpCodecLimits{
+	MaxValidators:                             250,
+	MaxValidatorIndex:                         998,
+	MaxEncodedLightValidatorsBytes:            8251,
+	MaxEncodedNextBlockVotingInformationBytes: 1786,
+}
+
 type cvpCodecV2 struct {
 }
 
@@ -40,8 +46,16 @@ func GetCvpCodecV2() CvpCodec {
 }
 
 func (c cvpCodecV2) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
-	if len(validators) > constants.MAX_VALIDATORS {
-		panic(fmt.Errorf("too many validators: %d/%d", len(validators), constants.MAX_VALIDATORS))
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV2) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	if len(validators) > cvpCodecV2Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV2, "StreamingLightValidators", "too many validators: %d/%d", len(validators), cvpCodecV2Limits.MaxValidators)
 	}
 
 	var b bytes.Buffer
@@ -53,10 +67,10 @@ func (c cvpCodecV2) EncodeStreamingLightValidators(validators types.StreamingLig
 		}
 
 		if v.Index < 0 {
-			panic(fmt.Errorf("invalid validator index: %d, must not be negative", v.Index))
+			return nil, newEncodeError(CvpCodecVersionV2, "Index", "invalid validator index: %d, must not be negative", v.Index)
 		}
-		if v.Index > 998 {
-			panic(fmt.Errorf("invalid validator index: %d, must be less than 999", v.Index))
+		if v.Index > cvpCodecV2Limits.MaxValidatorIndex {
+			return nil, newEncodeError(CvpCodecVersionV2, "Index", "invalid validator index: %d, must be less than %d", v.Index, cvpCodecV2Limits.MaxValidatorIndex+1)
 		}
 		bzIndex := toUint16Buffer(v.Index)
 		if bytes.Equal(bzIndex, collisionSeparator2Bytes) {
@@ -64,11 +78,14 @@ func (c cvpCodecV2) EncodeStreamingLightValidators(validators types.StreamingLig
 		}
 		b.Write(bzIndex)
 
+		if math.IsNaN(v.VotingPowerDisplayPercent) {
+			return nil, newEncodeError(CvpCodecVersionV2, "VotingPowerDisplayPercent", "invalid voting power display percent: NaN")
+		}
 		if v.VotingPowerDisplayPercent < 0 {
-			panic(fmt.Errorf("invalid voting power display percent: %f, must not be negative", v.VotingPowerDisplayPercent))
+			return nil, newEncodeError(CvpCodecVersionV2, "VotingPowerDisplayPercent", "invalid voting power display percent: %f, must not be negative", v.VotingPowerDisplayPercent)
 		}
 		if v.VotingPowerDisplayPercent > 100 {
-			panic(fmt.Errorf("invalid voting power display percent: %f, must not be greater than 100", v.VotingPowerDisplayPercent))
+			return nil, newEncodeError(CvpCodecVersionV2, "VotingPowerDisplayPercent", "invalid voting power display percent: %f, must not be greater than 100", v.VotingPowerDisplayPercent)
 		}
 		b.Write(toPercentBuffer(v.VotingPowerDisplayPercent))
 
@@ -79,33 +96,38 @@ func (c cvpCodecV2) EncodeStreamingLightValidators(validators types.StreamingLig
 		}
 	}
 
-	return b.Bytes()
+	return b.Bytes(), nil
 }
 
 func (c cvpCodecV2) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
 	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV2) {
-		return nil, fmt.Errorf("bad encoding prefix")
+		return nil, newDecodeError(CvpCodecVersionV2, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
 	}
 
 	var validators types.StreamingLightValidators
+	offsetByIndex := make(map[int]int)
 
 	cursor := 1 // skipped first byte as version, starts with separator
 	for cursor < len(bz) {
 		cursor++
 
+		offset := cursor
+
 		bzValRawData := takeUntilSeparatorOrEnd(bz, cursor, cvpCodecV2Separator)
 
 		const lengthOmittingMoniker = 2 /*index*/ + 2                                              /*percent*/
 		const lengthWithMoniker = lengthOmittingMoniker + cvpCodecV2Base64EncodedMonikerBufferSize /*moniker*/
 
 		if len(bzValRawData) == 0 {
-			return nil, fmt.Errorf("invalid empty validator raw data")
+			return nil, newDecodeError(CvpCodecVersionV2, ErrTruncated, "StreamingLightValidator", offset, "invalid empty validator raw data")
 		} else if len(bzValRawData) == lengthOmittingMoniker {
 			// OK
 		} else if len(bzValRawData) == lengthWithMoniker {
 			// OK
+		} else if len(bzValRawData) < lengthWithMoniker && offset+len(bzValRawData) == len(bz) {
+			return nil, newDecodeError(CvpCodecVersionV2, ErrTruncated, "StreamingLightValidator", offset, "invalid validator raw data length %d", len(bzValRawData))
 		} else {
-			return nil, fmt.Errorf("invalid validator raw data length %d", len(bzValRawData))
+			return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "StreamingLightValidator", offset, "invalid validator raw data length %d", len(bzValRawData))
 		}
 
 		var validator types.StreamingLightValidator
@@ -115,8 +137,8 @@ func (c cvpCodecV2) DecodeStreamingLightValidators(bz []byte) (types.StreamingLi
 			bzIndex = collisionSeparator2Bytes
 		}
 		validatorIndex := fromUint16Buffer(bzIndex)
-		if validatorIndex < 0 || validatorIndex > 998 {
-			return nil, fmt.Errorf("invalid validator index: %d", validatorIndex)
+		if validatorIndex < 0 || validatorIndex > cvpCodecV2Limits.MaxValidatorIndex {
+			return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "Index", cursor, "invalid validator index: %d", validatorIndex)
 		}
 		validator.Index = validatorIndex
 
@@ -125,7 +147,7 @@ func (c cvpCodecV2) DecodeStreamingLightValidators(bz []byte) (types.StreamingLi
 		bzVotingPowerDisplayPercent := mustTakeNBytesFrom(bz, cursor, 2)
 		validator.VotingPowerDisplayPercent = fromPercentBuffer(bzVotingPowerDisplayPercent)
 		if validator.VotingPowerDisplayPercent < 0 || validator.VotingPowerDisplayPercent > 100 {
-			return nil, fmt.Errorf("invalid voting power display percent: %f", validator.VotingPowerDisplayPercent)
+			return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "VotingPowerDisplayPercent", cursor, "invalid voting power display percent: %f", validator.VotingPowerDisplayPercent)
 		}
 
 		cursor += 2
@@ -134,13 +156,16 @@ func (c cvpCodecV2) DecodeStreamingLightValidators(bz []byte) (types.StreamingLi
 			bzBase64EncodedOfBzMoniker := takeUntilSeparatorOrEnd(bz, cursor, cvpCodecV2Separator)
 			bzMoniker, err := base64.StdEncoding.DecodeString(string(bzBase64EncodedOfBzMoniker))
 			if err != nil {
-				return nil, errors.Wrap(err, fmt.Sprintf("failed to decode base64 encoded moniker: %s", string(bzBase64EncodedOfBzMoniker)))
+				return nil, wrapDecodeError(CvpCodecVersionV2, ErrInvalidField, "Moniker", cursor, err, fmt.Sprintf("failed to decode base64 encoded moniker: %s", string(bzBase64EncodedOfBzMoniker)))
 			}
 			validator.Moniker = strings.TrimSpace(sanitizeMoniker(string(bzMoniker)))
 
 			cursor += len(bzBase64EncodedOfBzMoniker)
 		}
 
+		if _, found := offsetByIndex[validator.Index]; !found {
+			offsetByIndex[validator.Index] = offset
+		}
 		validators = append(validators, validator)
 	}
 
@@ -149,7 +174,7 @@ func (c cvpCodecV2) DecodeStreamingLightValidators(bz []byte) (types.StreamingLi
 	})
 	for i, v := range validators {
 		if v.Index != i {
-			return nil, fmt.Errorf("invalid validator index sequence, %d at %d", v.Index, i)
+			return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "Index", offsetByIndex[v.Index], "invalid validator index sequence, %d at %d", v.Index, i)
 		}
 	}
 
@@ -157,14 +182,31 @@ func (c cvpCodecV2) DecodeStreamingLightValidators(bz []byte) (types.StreamingLi
 }
 
 func (c cvpCodecV2) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
-	if len(inf.ValidatorVoteStates) > constants.MAX_VALIDATORS {
-		panic(fmt.Errorf("too many validators: %d/%d", len(inf.ValidatorVoteStates), constants.MAX_VALIDATORS))
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV2) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	if len(inf.ValidatorVoteStates) > cvpCodecV2Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV2, "ValidatorVoteStates", "too many validators: %d/%d", len(inf.ValidatorVoteStates), cvpCodecV2Limits.MaxValidators)
+	}
+	if err := validateTextHeightRoundStepForEncoding(CvpCodecVersionV2, "HeightRoundStep", inf.HeightRoundStep); err != nil {
+		return nil, err
+	}
+	if err := validatePercentForEncoding(CvpCodecVersionV2, "PreVotedPercent", inf.PreVotedPercent); err != nil {
+		return nil, err
+	}
+	if err := validatePercentForEncoding(CvpCodecVersionV2, "PreCommitVotedPercent", inf.PreCommitVotedPercent); err != nil {
+		return nil, err
 	}
 
 	var b bytes.Buffer
 	b.Write(prefixDataEncodedByCvpCodecV2)
 
-	b.Write([]byte(inf.HeightRoundStep))
+	b.Write([]byte(inf.HeightRoundStep.String()))
 	b.WriteByte(cvpCodecV2Separator)
 
 	duration := inf.Duration
@@ -180,10 +222,10 @@ func (c cvpCodecV2) EncodeStreamingNextBlockVotingInformation(inf *types.Streami
 
 	for _, v := range inf.ValidatorVoteStates {
 		if v.ValidatorIndex < 0 {
-			panic(fmt.Errorf("invalid validator index: %d, must not be negative", v.ValidatorIndex))
+			return nil, newEncodeError(CvpCodecVersionV2, "ValidatorIndex", "invalid validator index: %d, must not be negative", v.ValidatorIndex)
 		}
-		if v.ValidatorIndex > 998 {
-			panic(fmt.Errorf("invalid validator index: %d, must be less than 999", v.ValidatorIndex))
+		if v.ValidatorIndex > cvpCodecV2Limits.MaxValidatorIndex {
+			return nil, newEncodeError(CvpCodecVersionV2, "ValidatorIndex", "invalid validator index: %d, must be less than %d", v.ValidatorIndex, cvpCodecV2Limits.MaxValidatorIndex+1)
 		}
 		bzIndex := toUint16Buffer(v.ValidatorIndex)
 		if bytes.Equal(bzIndex, collisionSeparator2Bytes) {
@@ -191,31 +233,79 @@ func (c cvpCodecV2) EncodeStreamingNextBlockVotingInformation(inf *types.Streami
 		}
 		b.Write(bzIndex)
 
-		if len(v.PreVotedBlockHash) == 0 {
-			b.Write([]byte("----"))
-		} else if len(v.PreVotedBlockHash) != 4 {
-			panic(fmt.Errorf("invalid pre-voted fingerprint block hash length: %s, must be 2 bytes", v.PreVotedBlockHash))
-		} else {
-			b.Write([]byte(v.PreVotedBlockHash))
+		if err := writeValidatorVoteStateBodyV2(&b, v, CvpCodecVersionV2); err != nil {
+			return nil, err
 		}
+	}
 
-		if v.PreCommitVoted {
-			b.WriteByte('C')
-		} else if v.VotedZeroes {
-			b.WriteByte('0')
-		} else if v.PreVoted {
-			b.WriteByte('V')
-		} else {
-			b.WriteByte('X')
+	return b.Bytes(), nil
+}
+
+// writeValidatorVoteStateBodyV2 writes the 5 bytes following the validator index of a v2 vote state record:
+// 4 bytes pre-voted fingerprint block hash and 1 byte vote flag.
+func writeValidatorVoteStateBodyV2(b *bytes.Buffer, v types.StreamingValidatorVoteState, version CvpCodecVersion) error {
+	if len(v.PreVotedBlockHash) == 0 {
+		b.Write([]byte("----"))
+	} else if len(v.PreVotedBlockHash) != 4 {
+		return newEncodeError(version, "PreVotedBlockHash", "invalid pre-voted fingerprint block hash length: %s, must be 2 bytes", v.PreVotedBlockHash)
+	} else {
+		b.Write([]byte(v.PreVotedBlockHash))
+	}
+
+	if v.PreCommitVoted {
+		b.WriteByte('C')
+	} else if v.VotedZeroes {
+		b.WriteByte('0')
+	} else if v.PreVoted {
+		b.WriteByte('V')
+	} else {
+		b.WriteByte('X')
+	}
+
+	return nil
+}
+
+// readValidatorVoteStateBodyV2 is the reverse of writeValidatorVoteStateBodyV2,
+// the input buffer must be exactly 5 bytes, offset is the position of the buffer in the encoded data, for error reporting.
+func readValidatorVoteStateBodyV2(bz []byte, validatorIndex int, version CvpCodecVersion, offset int) (types.StreamingValidatorVoteState, error) {
+	bzPreVotedBlockHash := bz[:4]
+	preVotedBlockHash := string(bzPreVotedBlockHash)
+	if preVotedBlockHash != "----" {
+		if !regexpPreVotedFingerprintBlockHash.MatchString(preVotedBlockHash) {
+			return types.StreamingValidatorVoteState{}, newDecodeError(version, ErrInvalidField, "PreVotedBlockHash", offset, "invalid pre-voted fingerprint block hash: %s, must be 2 bytes", preVotedBlockHash)
 		}
 	}
 
-	return b.Bytes()
+	preCommitVoted := false
+	votedZeroes := false
+	preVoted := false
+	voteFlag := bz[4]
+	switch voteFlag {
+	case 'C':
+		preCommitVoted = true
+		preVoted = true
+	case '0':
+		votedZeroes = true
+		preVoted = true
+	case 'V':
+		preVoted = true
+	case 'X':
+	default:
+		return types.StreamingValidatorVoteState{}, newDecodeError(version, ErrInvalidField, "VoteFlag", offset+4, "invalid validator vote flag: %s", string(voteFlag))
+	}
+
+	return types.StreamingValidatorVoteState{
+		ValidatorIndex:    validatorIndex,
+		PreVotedBlockHash: preVotedBlockHash,
+		PreVoted:          preVoted,
+		VotedZeroes:       votedZeroes,
+		PreCommitVoted:    preCommitVoted,
+	}, nil
 }
 
 func (c cvpCodecV2) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
 	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV2) {
-		return nil, fmt.Errorf("bad encoding prefix")
+		return nil, newDecodeError(CvpCodecVersionV2, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
 	}
 
 	var result types.StreamingNextBlockVotingInformation
@@ -227,17 +317,21 @@ func (c cvpCodecV2) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 		}
 	}
 
-	if countSeparator != 4 {
-		return nil, fmt.Errorf("wrong number of elements")
+	if countSeparator < 4 {
+		return nil, newDecodeError(CvpCodecVersionV2, ErrTruncated, "StreamingNextBlockVotingInformation", len(bz), "wrong number of elements")
+	}
+	if countSeparator > 4 {
+		return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "StreamingNextBlockVotingInformation", 0, "wrong number of elements")
 	}
 
 	cursor := 2 // skipped first byte is version and second byte is separator
 
 	bzHeightRoundStep := takeUntilSeparatorOrEnd(bz, cursor, cvpCodecV2Separator)
-	result.HeightRoundStep = string(bzHeightRoundStep)
-	if !regexpHeightRoundStep.MatchString(result.HeightRoundStep) {
-		return nil, fmt.Errorf("invalid height round step: %s", result.HeightRoundStep)
+	heightRoundStep, err := parseHeightRoundStepForDecoding(CvpCodecVersionV2, "HeightRoundStep", cursor, string(bzHeightRoundStep))
+	if err != nil {
+		return nil, err
 	}
+	result.HeightRoundStep = heightRoundStep
 
 	cursor += len(bzHeightRoundStep) + 1 /*separator*/
 
@@ -245,10 +339,10 @@ func (c cvpCodecV2) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 	durationSecStr := string(bzDurationSec)
 	durationSec, err := strconv.ParseInt(durationSecStr, 10, 64)
 	if err != nil {
-		return nil, errors.Wrap(err, fmt.Sprintf("failed to parse duration sec: %s", durationSecStr))
+		return nil, wrapDecodeError(CvpCodecVersionV2, ErrInvalidField, "Duration", cursor, err, fmt.Sprintf("failed to parse duration sec: %s", durationSecStr))
 	}
 	if durationSec < 0 {
-		return nil, fmt.Errorf("negative duration sec: %d", durationSec)
+		return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "Duration", cursor, "negative duration sec: %d", durationSec)
 	}
 	result.Duration = time.Duration(durationSec) * time.Second
 
@@ -256,36 +350,39 @@ func (c cvpCodecV2) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 
 	bzPreVotedAndPreCommitVotedPercent := takeUntilSeparatorOrEnd(bz, cursor, cvpCodecV2Separator)
 	if len(bzPreVotedAndPreCommitVotedPercent) != 4 {
-		return nil, fmt.Errorf("invalid buffer of pre-voted and pre-commit voted percent length: %d", len(bzPreVotedAndPreCommitVotedPercent))
+		return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "PreVotedPercent", cursor, "invalid buffer of pre-voted and pre-commit voted percent length: %d", len(bzPreVotedAndPreCommitVotedPercent))
 	}
 	bzPreVotedPercent := bzPreVotedAndPreCommitVotedPercent[:2]
 	result.PreVotedPercent = fromPercentBuffer(bzPreVotedPercent)
 	if result.PreVotedPercent < 0 || result.PreVotedPercent > 100 {
-		return nil, fmt.Errorf("invalid pre-voted percent: %f", result.PreVotedPercent)
+		return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "PreVotedPercent", cursor, "invalid pre-voted percent: %f", result.PreVotedPercent)
 	}
 	bzPreCommitVotedPercent := bzPreVotedAndPreCommitVotedPercent[2:]
 	result.PreCommitVotedPercent = fromPercentBuffer(bzPreCommitVotedPercent)
 	if result.PreCommitVotedPercent < 0 || result.PreCommitVotedPercent > 100 {
-		return nil, fmt.Errorf("invalid pre-commit voted percent: %f", result.PreCommitVotedPercent)
+		return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "PreCommitVotedPercent", cursor+2, "invalid pre-commit voted percent: %f", result.PreCommitVotedPercent)
 	}
 	cursor += 4
 
 	cursor += 1 // separator
 
 	if cursor >= len(bz)-1 {
-		return nil, fmt.Errorf("missing validator vote states")
+		return nil, newDecodeError(CvpCodecVersionV2, ErrTruncated, "ValidatorVoteStates", cursor, "missing validator vote states")
 	}
 
+	offsetOfValidatorVoteStates := cursor
 	bzValidatorVoteStates := bz[cursor:]
 	if len(bzValidatorVoteStates)%7 != 0 {
-		return nil, fmt.Errorf("invalid validator vote states length: %d", len(bzValidatorVoteStates))
+		return nil, newDecodeError(CvpCodecVersionV2, ErrTruncated, "ValidatorVoteStates", cursor, "invalid validator vote states length: %d", len(bzValidatorVoteStates))
 	}
 
 	validatorVoteStates := make([]types.StreamingValidatorVoteState, 0)
+	offsetByIndex := make(map[int]int)
 
 	cursor = 0 // reset cursor to work on new buffer
 
 	for cursor < len(bzValidatorVoteStates) {
+		offset := offsetOfValidatorVoteStates + cursor
 		bzValidatorVoteState := bzValidatorVoteStates[cursor : cursor+7]
 
 		bzIndex := bzValidatorVoteState[:2]
@@ -293,43 +390,19 @@ func (c cvpCodecV2) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 			bzIndex = collisionSeparator2Bytes
 		}
 		validatorIndex := fromUint16Buffer(bzIndex)
-		if validatorIndex < 0 || validatorIndex > 998 {
-			return nil, fmt.Errorf("invalid validator index: %d", validatorIndex)
+		if validatorIndex < 0 || validatorIndex > cvpCodecV2Limits.MaxValidatorIndex {
+			return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "ValidatorIndex", offset, "invalid validator index: %d", validatorIndex)
 		}
 
-		bzPreVotedBlockHash := bzValidatorVoteState[2:6]
-		preVotedBlockHash := string(bzPreVotedBlockHash)
-		if preVotedBlockHash != "----" {
-			if !regexpPreVotedFingerprintBlockHash.MatchString(preVotedBlockHash) {
-				return nil, fmt.Errorf("invalid pre-voted fingerprint block hash: %s, must be 2 bytes", preVotedBlockHash)
-			}
+		validatorVoteState, err := readValidatorVoteStateBodyV2(bzValidatorVoteState[2:], validatorIndex, CvpCodecVersionV2, offset+2)
+		if err != nil {
+			return nil, err
 		}
 
-		preCommitVoted := false
-		votedZeroes := false
-		preVoted := false
-		voteFlag := bzValidatorVoteState[6]
-		switch voteFlag {
-		case 'C':
-			preCommitVoted = true
-			preVoted = true
-		case '0':
-			votedZeroes = true
-			preVoted = true
-		case 'V':
-			preVoted = true
-		case 'X':
-		default:
-			return nil, fmt.Errorf("invalid validator vote flag: %s", string(voteFlag))
+		if _, found := offsetByIndex[validatorIndex]; !found {
+			offsetByIndex[validatorIndex] = offset
 		}
-
-		validatorVoteStates = append(validatorVoteStates, types.StreamingValidatorVoteState{
-			ValidatorIndex:    validatorIndex,
-			PreVotedBlockHash: preVotedBlockHash,
-			PreVoted:          preVoted,
-			VotedZeroes:       votedZeroes,
-			PreCommitVoted:    preCommitVoted,
-		})
+		validatorVoteStates = append(validatorVoteStates, validatorVoteState)
 
 		cursor += 7
 	}
@@ -338,7 +411,7 @@ func (c cvpCodecV2) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 	})
 	for i, state := range validatorVoteStates {
 		if state.ValidatorIndex != i {
-			return nil, fmt.Errorf("invalid validator index sequence, %d at %d", state.ValidatorIndex, i)
+			return nil, newDecodeError(CvpCodecVersionV2, ErrInvalidField, "ValidatorIndex", offsetByIndex[state.ValidatorIndex], "invalid validator index sequence, %d at %d", state.ValidatorIndex, i)
 		}
 	}
 	result.ValidatorVoteStates = validatorVoteStates
@@ -346,6 +419,10 @@ func (c cvpCodecV2) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types
 	return &result, nil
 }
 
+func (c cvpCodecV2) GetLimits() CvpCodecLimits {
+	return cvpCodecV2Limits
+}
+
 func (c cvpCodecV2) GetVersion() CvpCodecVersion {
 	return CvpCodecVersionV2
 }
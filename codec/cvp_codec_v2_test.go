@@ -573,7 +573,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "normal, 4 validators",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -623,7 +623,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "normal, 1 validators",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -649,7 +649,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "can not decode zero validators vote state",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -667,7 +667,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "duration will be corrected to zero if negative",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              -1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -689,7 +689,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 				[]byte{0x00, 0x00}, []byte("ABCD"), []byte("C"),
 			),
 			wantDecodedOrUseInputAsWantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              0,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -707,7 +707,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "percent computed correctly",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              2 * time.Second,
 				PreVotedPercent:       99.98,
 				PreCommitVotedPercent: 97.96,
@@ -732,7 +732,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if negative validator index",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -747,7 +747,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if validator index greater than 998",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -763,7 +763,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			name: "panic encode if validator list size larger than cap",
 			inf: func() types.StreamingNextBlockVotingInformation {
 				inf := types.StreamingNextBlockVotingInformation{
-					HeightRoundStep:       "1/2/3",
+					HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 					Duration:              time.Second,
 					PreVotedPercent:       1,
 					PreCommitVotedPercent: 2,
@@ -782,7 +782,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if block hash length is not 0 or 4",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -799,7 +799,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if block hash length is not 0 or 4",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -816,7 +816,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "automatically fill prevoted block hash if empty",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              3 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -835,7 +835,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 				[]byte{0x00, 0x00}, []byte("----"), []byte("X"),
 			),
 			wantDecodedOrUseInputAsWantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              3 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -851,7 +851,7 @@ func Test_cvpCodecV2_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			name: "collision of separator byte with bytes index",
 			inf: func() types.StreamingNextBlockVotingInformation {
 				nextBlockVotingInfo := types.StreamingNextBlockVotingInformation{
-					HeightRoundStep:       "1/2/3",
+					HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 					Duration:              time.Second,
 					PreVotedPercent:       1,
 					PreCommitVotedPercent: 2,
@@ -946,7 +946,7 @@ func Test_cvpCodecV2_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
 				[]byte{0x00, 0x03}, []byte("----"), []byte("X"),
 			),
 			wantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -993,7 +993,7 @@ func Test_cvpCodecV2_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
 				[]byte{0x00, 0x00}, []byte("ABCD"), []byte("C"),
 			),
 			wantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -3,8 +3,6 @@ package codec
 import (
 	"bytes"
 	"compress/gzip"
-	"fmt"
-	"github.com/bcdevtools/cvp-streaming-core/constants"
 	"github.com/bcdevtools/cvp-streaming-core/types"
 	"github.com/pkg/errors"
 	"io"
@@ -18,6 +16,17 @@ const cvpCodecV3Separator byte = '|'
 
 var prefixDataEncodedByCvpCodecV3 = []byte{0x3, cvpCodecV3Separator}
 
+// cvpCodecV3Limits is the same as v2, plus the gzip header and trailer and the deflate overhead.
+var cvpCodecV3Limits = CvpCodecLimits{
+	MaxValidators:                             cvpCodecV2Limits.MaxValidators,
+	MaxValidatorIndex:                         cvpCodecV2Limits.MaxValidatorIndex,
+	MaxEncodedLightValidatorsBytes:            len(prefixDataEncodedByCvpCodecV3) + gzipHeaderAndTrailerBytes + maxDeflatedBytes(cvpCodecV2Limits.MaxEncodedLightValidatorsBytes),
+	MaxEncodedNextBlockVotingInformationBytes: len(prefixDataEncodedByCvpCodecV3) + gzipHeaderAndTrailerBytes + maxDeflatedBytes(cvpCodecV2Limits.MaxEncodedNextBlockVotingInformationBytes),
+}
+
+// gzipHeaderAndTrailerBytes is size of the gzip header, without optional fields, plus the gzip trailer.
+const gzipHeaderAndTrailerBytes = 10 + 8
+
 type cvpCodecV3 struct {
 	v2Codec CvpCodec
 }
@@ -32,93 +41,127 @@ func GetCvpCodecV3() CvpCodec {
 }
 
 func (c cvpCodecV3) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
-	if len(validators) > constants.MAX_VALIDATORS {
-		panic(fmt.Errorf("too many validators: %d/%d", len(validators), constants.MAX_VALIDATORS))
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV3) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	if len(validators) > cvpCodecV3Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV3, "StreamingLightValidators", "too many validators: %d/%d", len(validators), cvpCodecV3Limits.MaxValidators)
 	}
 
-	bzByV2 := c.v2Codec.EncodeStreamingLightValidators(validators)
+	bzByV2, err := c.v2Codec.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		return nil, withEncodeErrorVersion(err, CvpCodecVersionV3)
+	}
 
 	var b bytes.Buffer
 	w := gzip.NewWriter(&b)
-	_, err := w.Write(bzByV2)
+	_, err = w.Write(bzByV2)
 	if err != nil {
-		panic(errors.Wrap(err, "failed to write gzipped content"))
+		return nil, errors.Wrap(err, "failed to write gzipped content")
 	}
 	err = w.Close()
 	if err != nil {
-		panic(errors.Wrap(err, "failed to close gzip writer"))
+		return nil, errors.Wrap(err, "failed to close gzip writer")
 	}
 
-	return append(prefixDataEncodedByCvpCodecV3, b.Bytes()...)
+	return append(prefixDataEncodedByCvpCodecV3, b.Bytes()...), nil
 }
 
 func (c cvpCodecV3) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
 	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV3) {
-		return nil, fmt.Errorf("bad encoding prefix")
+		return nil, newDecodeError(CvpCodecVersionV3, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
 	}
 
-	gzipr, err := gzip.NewReader(bytes.NewReader(bz[2:]))
+	bzByV2, err := gunzipV3(bz[len(prefixDataEncodedByCvpCodecV3):])
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create gzip reader")
+		return nil, err
 	}
 
-	bzByV2, err := io.ReadAll(gzipr)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to read gzipped content")
-	}
+	return c.v2Codec.DecodeStreamingLightValidators(bzByV2)
+}
 
-	err = gzipr.Close()
+func (c cvpCodecV3) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to close gzip reader")
+		panic(err)
 	}
-
-	return c.v2Codec.DecodeStreamingLightValidators(bzByV2)
+	return bz
 }
 
-func (c cvpCodecV3) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
-	if len(inf.ValidatorVoteStates) > constants.MAX_VALIDATORS {
-		panic(fmt.Errorf("too many validators: %d/%d", len(inf.ValidatorVoteStates), constants.MAX_VALIDATORS))
+func (c cvpCodecV3) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	if len(inf.ValidatorVoteStates) > cvpCodecV3Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV3, "ValidatorVoteStates", "too many validators: %d/%d", len(inf.ValidatorVoteStates), cvpCodecV3Limits.MaxValidators)
 	}
 
-	bzByV2 := c.v2Codec.EncodeStreamingNextBlockVotingInformation(inf)
+	bzByV2, err := c.v2Codec.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		return nil, withEncodeErrorVersion(err, CvpCodecVersionV3)
+	}
 
 	var b bytes.Buffer
 	w := gzip.NewWriter(&b)
-	_, err := w.Write(bzByV2)
+	_, err = w.Write(bzByV2)
 	if err != nil {
-		panic(errors.Wrap(err, "failed to write gzipped content"))
+		return nil, errors.Wrap(err, "failed to write gzipped content")
 	}
 	err = w.Close()
 	if err != nil {
-		panic(errors.Wrap(err, "failed to close gzip writer"))
+		return nil, errors.Wrap(err, "failed to close gzip writer")
 	}
 
-	return append(prefixDataEncodedByCvpCodecV3, b.Bytes()...)
+	return append(prefixDataEncodedByCvpCodecV3, b.Bytes()...), nil
 }
 
 func (c cvpCodecV3) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
 	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV3) {
-		return nil, fmt.Errorf("bad encoding prefix")
+		return nil, newDecodeError(CvpCodecVersionV3, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	bzByV2, err := gunzipV3(bz[len(prefixDataEncodedByCvpCodecV3):])
+	if err != nil {
+		return nil, err
 	}
 
-	gzipr, err := gzip.NewReader(bytes.NewReader(bz[2:]))
+	return c.v2Codec.DecodeStreamingNextBlockVotingInformation(bzByV2)
+}
+
+func (c cvpCodecV3) GetLimits() CvpCodecLimits {
+	return cvpCodecV3Limits
+}
+
+func (c cvpCodecV3) GetVersion() CvpCodecVersion {
+	return CvpCodecVersionV3
+}
+
+// gunzipV3 decompresses the gzipped payload which follows the v3 prefix.
+func gunzipV3(bz []byte) ([]byte, error) {
+	gzipr, err := gzip.NewReader(bytes.NewReader(bz))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create gzip reader")
+		return nil, wrapDecodeError(CvpCodecVersionV3, kindOfDecompressionError(err), "payload", len(prefixDataEncodedByCvpCodecV3), err, "failed to create gzip reader")
 	}
 
 	bzByV2, err := io.ReadAll(gzipr)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to read gzipped content")
+		return nil, wrapDecodeError(CvpCodecVersionV3, kindOfDecompressionError(err), "payload", len(prefixDataEncodedByCvpCodecV3), err, "failed to read gzipped content")
 	}
 
 	err = gzipr.Close()
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to close gzip reader")
+		return nil, wrapDecodeError(CvpCodecVersionV3, kindOfDecompressionError(err), "payload", len(prefixDataEncodedByCvpCodecV3), err, "failed to close gzip reader")
 	}
 
-	return c.v2Codec.DecodeStreamingNextBlockVotingInformation(bzByV2)
+	return bzByV2, nil
 }
 
-func (c cvpCodecV3) GetVersion() CvpCodecVersion {
-	return CvpCodecVersionV3
+// kindOfDecompressionError returns ErrTruncated if the compressed content ended unexpectedly, otherwise ErrInvalidField.
+func kindOfDecompressionError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrTruncated
+	}
+	return ErrInvalidField
 }
@@ -359,7 +359,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "normal, 4 validators",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -400,7 +400,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "normal, 1 validators",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -420,7 +420,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "can not decode zero validators vote state",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -433,7 +433,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "duration will be corrected to zero if negative",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              -1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -449,7 +449,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			},
 			wantEncodedData: bufferFromHex("037c1f8b08000000000000ff62aa31d437d237ae31a861646032ab6160707472767106040000ffffe44b1e8916000000"),
 			wantDecodedOrUseInputAsWantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              0,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -467,7 +467,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "percent computed correctly",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              2 * time.Second,
 				PreVotedPercent:       99.98,
 				PreCommitVotedPercent: 97.96,
@@ -486,7 +486,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if negative validator index",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -501,7 +501,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if validator index greater than 998",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -517,7 +517,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			name: "panic encode if validator list size larger than cap",
 			inf: func() types.StreamingNextBlockVotingInformation {
 				inf := types.StreamingNextBlockVotingInformation{
-					HeightRoundStep:       "1/2/3",
+					HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 					Duration:              time.Second,
 					PreVotedPercent:       1,
 					PreCommitVotedPercent: 2,
@@ -536,7 +536,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if block hash length is not 0 or 4",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -553,7 +553,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "panic encode if block hash length is not 0 or 4",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -570,7 +570,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "automatically fill prevoted block hash if empty",
 			inf: types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              3 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -583,7 +583,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			},
 			wantEncodedData: bufferFromHex("037c1f8b08000000000000ff62aa31d437d237ae31ae61646062a86160d0d5d5d58d00040000ffff5655c0eb16000000"),
 			wantDecodedOrUseInputAsWantDecoded: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              3 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2,
@@ -599,7 +599,7 @@ func Test_cvpCodecV3_EncodeAndDecodeStreamingNextBlockVotingInformation(t *testi
 			name: "collision of separator byte with bytes index",
 			inf: func() types.StreamingNextBlockVotingInformation {
 				nextBlockVotingInfo := types.StreamingNextBlockVotingInformation{
-					HeightRoundStep:       "1/2/3",
+					HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 					Duration:              time.Second,
 					PreVotedPercent:       1,
 					PreCommitVotedPercent: 2,
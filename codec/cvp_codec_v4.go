@@ -0,0 +1,162 @@
+package codec
+
+//go:generate go run cvp_codec_v4_dictionary_gen.go
+
+import (
+	"bytes"
+	"compress/flate"
+	_ "embed"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"io"
+)
+
+//goland:noinspection SpellCheckingInspection
+
+var _ CvpCodec = (*cvpCodecV4)(nil)
+
+const cvpCodecV4Separator byte = '|'
+
+var prefixDataEncodedByCvpCodecV4 = []byte{0x4, cvpCodecV4Separator}
+
+// cvpCodecV4Dictionary is the preset dictionary of the deflate stream, built from typical v2 payloads.
+// It is part of the wire format, changing it breaks decoding of the data encoded by the previous dictionary.
+//
+//go:embed cvp_codec_v4_dictionary.bin
+var cvpCodecV4Dictionary []byte
+
+// cvpCodecV4Limits is the same as v2, plus the deflate overhead.
+var cvpCodecV4Limits = CvpCodecLimits{
+	MaxValidators:                             cvpCodecV2Limits.MaxValidators,
+	MaxValidatorIndex:                         cvpCodecV2Limits.MaxValidatorIndex,
+	MaxEncodedLightValidatorsBytes:            len(prefixDataEncodedByCvpCodecV4) + maxDeflatedBytes(cvpCodecV2Limits.MaxEncodedLightValidatorsBytes),
+	MaxEncodedNextBlockVotingInformationBytes: len(prefixDataEncodedByCvpCodecV4) + maxDeflatedBytes(cvpCodecV2Limits.MaxEncodedNextBlockVotingInformationBytes),
+}
+
+type cvpCodecV4 struct {
+	v2Codec CvpCodec
+}
+
+// GetCvpCodecV4 returns new instance of CvpCodec that actually encode data using v2 codec then deflate it
+// using a preset dictionary which ships with the module.
+// Procedures smaller data than v3 codec, especially with small data size,
+// because there is no gzip header and common sequences are available in the dictionary since the very first byte.
+func GetCvpCodecV4() CvpCodec {
+	return cvpCodecV4{
+		v2Codec: GetCvpCodecV2(),
+	}
+}
+
+func (c cvpCodecV4) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV4) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	if len(validators) > cvpCodecV4Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV4, "StreamingLightValidators", "too many validators: %d/%d", len(validators), cvpCodecV4Limits.MaxValidators)
+	}
+
+	bzByV2, err := c.v2Codec.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		return nil, withEncodeErrorVersion(err, CvpCodecVersionV4)
+	}
+
+	return deflateV4(bzByV2)
+}
+
+func (c cvpCodecV4) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV4) {
+		return nil, newDecodeError(CvpCodecVersionV4, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	bzByV2, err := inflateV4(bz[len(prefixDataEncodedByCvpCodecV4):])
+	if err != nil {
+		return nil, err
+	}
+
+	return c.v2Codec.DecodeStreamingLightValidators(bzByV2)
+}
+
+func (c cvpCodecV4) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV4) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	if len(inf.ValidatorVoteStates) > cvpCodecV4Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV4, "ValidatorVoteStates", "too many validators: %d/%d", len(inf.ValidatorVoteStates), cvpCodecV4Limits.MaxValidators)
+	}
+
+	bzByV2, err := c.v2Codec.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		return nil, withEncodeErrorVersion(err, CvpCodecVersionV4)
+	}
+
+	return deflateV4(bzByV2)
+}
+
+func (c cvpCodecV4) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV4) {
+		return nil, newDecodeError(CvpCodecVersionV4, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	bzByV2, err := inflateV4(bz[len(prefixDataEncodedByCvpCodecV4):])
+	if err != nil {
+		return nil, err
+	}
+
+	return c.v2Codec.DecodeStreamingNextBlockVotingInformation(bzByV2)
+}
+
+func (c cvpCodecV4) GetLimits() CvpCodecLimits {
+	return cvpCodecV4Limits
+}
+
+func (c cvpCodecV4) GetVersion() CvpCodecVersion {
+	return CvpCodecVersionV4
+}
+
+// deflateV4 compresses the v2 encoded data using the preset dictionary, and prepends the v4 prefix.
+func deflateV4(bzByV2 []byte) ([]byte, error) {
+	var b bytes.Buffer
+	b.Write(prefixDataEncodedByCvpCodecV4)
+
+	w, err := flate.NewWriterDict(&b, flate.BestCompression, cvpCodecV4Dictionary)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create deflate writer")
+	}
+	_, err = w.Write(bzByV2)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write deflated content")
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to close deflate writer")
+	}
+
+	return b.Bytes(), nil
+}
+
+// inflateV4 decompresses the deflated payload which follows the v4 prefix.
+func inflateV4(bz []byte) ([]byte, error) {
+	r := flate.NewReaderDict(bytes.NewReader(bz), cvpCodecV4Dictionary)
+
+	bzByV2, err := io.ReadAll(r)
+	if err != nil {
+		return nil, wrapDecodeError(CvpCodecVersionV4, kindOfDecompressionError(err), "payload", len(prefixDataEncodedByCvpCodecV4), err, "failed to read deflated content")
+	}
+
+	err = r.Close()
+	if err != nil {
+		return nil, wrapDecodeError(CvpCodecVersionV4, kindOfDecompressionError(err), "payload", len(prefixDataEncodedByCvpCodecV4), err, "failed to close deflate reader")
+	}
+
+	return bzByV2, nil
+}
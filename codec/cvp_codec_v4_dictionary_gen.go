@@ -0,0 +1,92 @@
+//go:build ignore
+
+// This program generates cvp_codec_v4_dictionary.bin, the preset dictionary used by v4 codec.
+//
+// The dictionary is built from v2 encoded payloads of typical light validators and next block voting information,
+// so the common byte sequences are available for back-reference since the very first byte of a v4 payload.
+//
+// Changing the dictionary breaks decoding of data encoded by the previous dictionary,
+// so it must never be re-generated once released.
+//
+// Run via: go generate ./codec
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"os"
+	"time"
+)
+
+const outputFile = "cvp_codec_v4_dictionary.bin"
+
+// flate window is 32KB, the dictionary must be smaller than that.
+const maxDictionarySize = 16 * 1024
+
+//goland:noinspection SpellCheckingInspection
+var monikerWords = []string{
+	"Validator", "Staking", "Stake", "Node", "Nodes", "Labs", "Capital", "Ventures", "Finance", "Network",
+	"Pool", "DAO", "Cosmos", "Chain", "Crypto", "Digital", "Global", "Security", "Infra", "Cloud",
+	"Everstake", "Figment", "Chorus One", "Allnodes", "Polkachu", "Informal", "Coinbase", "Binance", "Kraken", "Upbit",
+	"StakeLab", "Stakin", "P2P.ORG", "Imperator", "Lavender.Five", "NodesGuru", "Cosmostation", "DSRV", "Forbole", "Stakecito",
+	"🚀", "✅", "🔥", "⚛️", "🌟",
+}
+
+func main() {
+	var dict bytes.Buffer
+
+	v2 := codec.GetCvpCodecV2()
+
+	// light validators, monikers are the major part
+	var validators types.StreamingLightValidators
+	for i, word := range monikerWords {
+		validators = append(validators, types.StreamingLightValidator{
+			Index:                     i,
+			VotingPowerDisplayPercent: float64(len(monikerWords)-i) / 10,
+			Moniker:                   word,
+		})
+	}
+	dict.Write(v2.EncodeStreamingLightValidators(validators))
+
+	// next block voting information, in different stages of a round.
+	// The most common sequences, the earliest stage which contains the most nil votes, go last,
+	// as nearer back-references are cheaper.
+	for _, stage := range []struct {
+		hash    string
+		preVote bool
+		commit  bool
+	}{
+		{hash: "C0FF", preVote: true, commit: true},
+		{hash: "C0FF", preVote: true, commit: false},
+		{hash: "", preVote: false, commit: false},
+	} {
+		inf := types.StreamingNextBlockVotingInformation{
+			HeightRoundStep:       types.MustParseHeightRoundStep("12345678/0/6"),
+			Duration:              3 * time.Second,
+			PreVotedPercent:       66.67,
+			PreCommitVotedPercent: 0,
+		}
+		for i := 0; i < 200; i++ {
+			inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, types.StreamingValidatorVoteState{
+				ValidatorIndex:    i,
+				PreVotedBlockHash: stage.hash,
+				PreVoted:          stage.preVote,
+				PreCommitVoted:    stage.commit,
+			})
+		}
+		dict.Write(v2.EncodeStreamingNextBlockVotingInformation(&inf))
+	}
+
+	bz := dict.Bytes()
+	if len(bz) > maxDictionarySize {
+		bz = bz[len(bz)-maxDictionarySize:]
+	}
+
+	if err := os.WriteFile(outputFile, bz, 0o644); err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("generated %s: %d bytes\n", outputFile, len(bz))
+}
@@ -0,0 +1,220 @@
+package codec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cvpV4CodecImpl = GetCvpCodecV4()
+
+func Test_cvpCodecV4_Dictionary(t *testing.T) {
+	// The dictionary is part of the wire format,
+	// data encoded by the previous dictionary can not be decoded after the dictionary changed.
+	//goland:noinspection SpellCheckingInspection
+	const wantSha256 = "375014235221384f5bda31df8733234b72c31f1ac9b7168ed3f7e24e7bfbc391"
+
+	if len(cvpCodecV4Dictionary) < 1 {
+		t.Errorf("dictionary is empty")
+		return
+	}
+	if len(cvpCodecV4Dictionary) > 32*1024 {
+		t.Errorf("dictionary size = %d, must not be larger than deflate window size", len(cvpCodecV4Dictionary))
+	}
+	hash := sha256.Sum256(cvpCodecV4Dictionary)
+	if got := hex.EncodeToString(hash[:]); got != wantSha256 {
+		t.Errorf("dictionary had been changed, sha256 = %s, want %s", got, wantSha256)
+	}
+}
+
+func Test_cvpCodecV4_EncodeDecodeStreamingLightValidators(t *testing.T) {
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name       string
+		validators types.StreamingLightValidators
+	}{
+		{
+			name: "normal, 1 validator",
+			validators: []types.StreamingLightValidator{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10.11,
+					Moniker:                   "Val1",
+				},
+			},
+		},
+		{
+			name: "normal, 2 validators",
+			validators: []types.StreamingLightValidator{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10.11,
+					Moniker:                   "Everstake",
+				},
+				{
+					Index:                     1,
+					VotingPowerDisplayPercent: 01.02,
+					Moniker:                   "Chorus One",
+				},
+			},
+		},
+		{
+			name: "collision of separator byte and bytes index",
+			validators: func() types.StreamingLightValidators {
+				var result types.StreamingLightValidators
+				for i := 0; i < 250; i++ {
+					result = append(result, types.StreamingLightValidator{
+						Index:                     i,
+						VotingPowerDisplayPercent: 99,
+						Moniker:                   fmt.Sprintf("Val%d", i+1),
+					})
+				}
+				return result
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEncoded := cvpV4CodecImpl.EncodeStreamingLightValidators(tt.validators)
+
+			gotDecoded, err := cvpV4CodecImpl.DecodeStreamingLightValidators(gotEncoded)
+			if err != nil {
+				t.Errorf("DecodeStreamingLightValidators() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(gotDecoded, tt.validators) {
+				t.Errorf("DecodeStreamingLightValidators()\ngot = %v,\nwant %v", gotDecoded, tt.validators)
+			}
+
+			if encodedV3 := cvpV3CodecImpl.EncodeStreamingLightValidators(tt.validators); len(gotEncoded) >= len(encodedV3) {
+				t.Errorf("expect encoded by v4 (%d bytes) smaller than v3 (%d bytes)", len(gotEncoded), len(encodedV3))
+			}
+		})
+	}
+}
+
+func Test_cvpCodecV4_EncodeDecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	newInformation := func(size int, hash string, preVoted, preCommitVoted bool) *types.StreamingNextBlockVotingInformation {
+		inf := &types.StreamingNextBlockVotingInformation{
+			HeightRoundStep:       types.MustParseHeightRoundStep("19999999/0/6"),
+			Duration:              2 * time.Second,
+			PreVotedPercent:       70.5,
+			PreCommitVotedPercent: 30.25,
+		}
+		for i := 0; i < size; i++ {
+			inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, types.StreamingValidatorVoteState{
+				ValidatorIndex:    i,
+				PreVotedBlockHash: hash,
+				PreVoted:          preVoted,
+				PreCommitVoted:    preCommitVoted,
+			})
+		}
+		return inf
+	}
+
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name string
+		inf  *types.StreamingNextBlockVotingInformation
+	}{
+		{
+			name: "1 validator not voted",
+			inf:  newInformation(1, "----", false, false),
+		},
+		{
+			name: "8 validators pre-voted",
+			inf:  newInformation(8, "ABCD", true, false),
+		},
+		{
+			name: "180 validators pre-commit voted",
+			inf:  newInformation(180, "ABCD", true, true),
+		},
+		{
+			name: "collision of separator byte and bytes index",
+			inf:  newInformation(250, "----", false, false),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEncoded := cvpV4CodecImpl.EncodeStreamingNextBlockVotingInformation(tt.inf)
+
+			gotDecoded, err := cvpV4CodecImpl.DecodeStreamingNextBlockVotingInformation(gotEncoded)
+			if err != nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+			wantDecoded, err := cvpV2CodecImpl.DecodeStreamingNextBlockVotingInformation(cvpV2CodecImpl.EncodeStreamingNextBlockVotingInformation(tt.inf))
+			if err != nil {
+				t.Errorf("failed to prepare expected output: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(gotDecoded, wantDecoded) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation()\ngot = %v,\nwant %v", gotDecoded, wantDecoded)
+			}
+
+			if encodedV3 := cvpV3CodecImpl.EncodeStreamingNextBlockVotingInformation(tt.inf); len(gotEncoded) >= len(encodedV3) {
+				t.Errorf("expect encoded by v4 (%d bytes) smaller than v3 (%d bytes)", len(gotEncoded), len(encodedV3))
+			}
+		})
+	}
+}
+
+func Test_cvpCodecV4_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	// The codec v4 is use v2 underlying and deflate so not much to test here.
+
+	encoded := cvpV4CodecImpl.EncodeStreamingNextBlockVotingInformation(sampleNextBlockVotingInformationForDelta(10))
+
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name                  string
+		inputEncodedData      []byte
+		wantErrKind           error
+		wantErrDecodeContains string
+	}{
+		{
+			name:                  "bad prefix",
+			inputEncodedData:      cvpV3CodecImpl.EncodeStreamingNextBlockVotingInformation(sampleNextBlockVotingInformationForDelta(10)),
+			wantErrKind:           ErrBadPrefix,
+			wantErrDecodeContains: "bad encoding prefix",
+		},
+		{
+			name:                  "empty payload",
+			inputEncodedData:      prefixDataEncodedByCvpCodecV4,
+			wantErrKind:           ErrTruncated,
+			wantErrDecodeContains: "failed to read deflated content",
+		},
+		{
+			name:                  "truncated payload",
+			inputEncodedData:      encoded[:len(encoded)-2],
+			wantErrKind:           ErrTruncated,
+			wantErrDecodeContains: "failed to read deflated content",
+		},
+		{
+			name:                  "corrupted payload",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV4, []byte{0xFF, 0xFF, 0xFF, 0xFF}),
+			wantErrKind:           ErrInvalidField,
+			wantErrDecodeContains: "failed to read deflated content",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cvpV4CodecImpl.DecodeStreamingNextBlockVotingInformation(tt.inputEncodedData)
+			if err == nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() expect error but got nil")
+				return
+			}
+			if !errors.Is(err, tt.wantErrKind) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, want matches %v", err, tt.wantErrKind)
+			}
+			if !strings.Contains(err.Error(), tt.wantErrDecodeContains) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, wantErr contains %v", err, tt.wantErrDecodeContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+//goland:noinspection SpellCheckingInspection
+
+var _ CvpCodec = (*cvpCodecV5)(nil)
+
+const cvpCodecV5Separator byte = '|'
+
+var prefixDataEncodedByCvpCodecV5 = []byte{0x5, cvpCodecV5Separator}
+
+const cvpCodecV5MonikerBufferSize = 20
+
+// cvpCodecV5MaxHeightRoundStepLength is the maximum length of the text form of HeightRoundStep can be encoded.
+const cvpCodecV5MaxHeightRoundStepLength = types.MaxHeightRoundStepTextLength
+
+// cvpCodecV5LightValidatorRecordSize is size of a light validator record: 2 bytes index + 2 bytes percent + moniker.
+const cvpCodecV5LightValidatorRecordSize = 2 + 2 + cvpCodecV5MonikerBufferSize
+
+// cvpCodecV5VoteStateRecordSize is size of a vote state record: 2 bytes index + 4 bytes hash + 1 byte flag.
+const cvpCodecV5VoteStateRecordSize = 2 + 4 + 1
+
+var cvpCodecV5Limits = CvpCodecLimits{
+	MaxValidators:                             math.MaxUint16,
+	MaxValidatorIndex:                         math.MaxUint16 - 1,
+	MaxEncodedLightValidatorsBytes:            len(prefixDataEncodedByCvpCodecV5) + 2 /*count*/ + math.MaxUint16*cvpCodecV5LightValidatorRecordSize,
+	MaxEncodedNextBlockVotingInformationBytes: len(prefixDataEncodedByCvpCodecV5) + 1 + cvpCodecV5MaxHeightRoundStepLength /*height round step*/ + 4 /*duration*/ + 2 + 2 /*percents*/ + 2 /*count*/ + math.MaxUint16*cvpCodecV5VoteStateRecordSize,
+}
+
+type cvpCodecV5 struct {
+}
+
+// GetCvpCodecV5 returns new instance of v5 implementation of CvpCodec.
+//
+// V5 is a binary format, fields are fixed-size or length-prefixed instead of separated,
+// validator index and count are encoded as uint16, so it supports up to 65535 validators.
+func GetCvpCodecV5() CvpCodec {
+	return cvpCodecV5{}
+}
+
+func (c cvpCodecV5) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV5) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	if len(validators) > cvpCodecV5Limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV5, "StreamingLightValidators", "too many validators: %d/%d", len(validators), cvpCodecV5Limits.MaxValidators)
+	}
+
+	var b bytes.Buffer
+	b.Write(prefixDataEncodedByCvpCodecV5)
+	b.Write(toUint16Buffer(len(validators)))
+
+	for _, v := range validators {
+		if err := writeLightValidatorIndexAndPercentV5(&b, v, CvpCodecVersionV5); err != nil {
+			return nil, err
+		}
+
+		b.Write(utils.TruncateStringUntilBufferLessThanXBytesOrFillWithSpaceSuffix(v.Moniker, cvpCodecV5MonikerBufferSize))
+	}
+
+	return b.Bytes(), nil
+}
+
+// writeLightValidatorIndexAndPercentV5 writes the first 4 bytes of a v5 light validator record:
+// 2 bytes index and 2 bytes voting power display percent.
+func writeLightValidatorIndexAndPercentV5(b *bytes.Buffer, v types.StreamingLightValidator, version CvpCodecVersion) error {
+	if v.Index < 0 {
+		return newEncodeError(version, "Index", "invalid validator index: %d, must not be negative", v.Index)
+	}
+	if v.Index > cvpCodecV5Limits.MaxValidatorIndex {
+		return newEncodeError(version, "Index", "invalid validator index: %d, must be less than %d", v.Index, cvpCodecV5Limits.MaxValidatorIndex+1)
+	}
+	b.Write(toUint16Buffer(v.Index))
+
+	if math.IsNaN(v.VotingPowerDisplayPercent) {
+		return newEncodeError(version, "VotingPowerDisplayPercent", "invalid voting power display percent: NaN")
+	}
+	if v.VotingPowerDisplayPercent < 0 {
+		return newEncodeError(version, "VotingPowerDisplayPercent", "invalid voting power display percent: %f, must not be negative", v.VotingPowerDisplayPercent)
+	}
+	if v.VotingPowerDisplayPercent > 100 {
+		return newEncodeError(version, "VotingPowerDisplayPercent", "invalid voting power display percent: %f, must not be greater than 100", v.VotingPowerDisplayPercent)
+	}
+	b.Write(toPercentBuffer(v.VotingPowerDisplayPercent))
+
+	return nil
+}
+
+func (c cvpCodecV5) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV5) {
+		return nil, newDecodeError(CvpCodecVersionV5, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	cursor := len(prefixDataEncodedByCvpCodecV5)
+
+	bzCount, ok := tryTakeNBytesFrom(bz, cursor, 2)
+	if !ok {
+		return nil, newDecodeError(CvpCodecVersionV5, ErrTruncated, "StreamingLightValidators", cursor, "missing number of validators")
+	}
+	count := fromUint16Buffer(bzCount)
+	if count < 1 {
+		return nil, newDecodeError(CvpCodecVersionV5, ErrInvalidField, "StreamingLightValidators", cursor, "invalid empty validator raw data")
+	}
+	cursor += 2
+
+	if want := cursor + count*cvpCodecV5LightValidatorRecordSize; len(bz) < want {
+		return nil, newDecodeError(CvpCodecVersionV5, ErrTruncated, "StreamingLightValidator", cursor, "invalid validators raw data length %d, require %d", len(bz), want)
+	} else if len(bz) > want {
+		return nil, newDecodeError(CvpCodecVersionV5, ErrInvalidField, "StreamingLightValidators", want, "unexpected trailing %d bytes", len(bz)-want)
+	}
+
+	validators := make(types.StreamingLightValidators, 0, count)
+	offsetByIndex := make(map[int]int)
+	for i := 0; i < count; i++ {
+		offset := cursor
+
+		validator, err := readLightValidatorIndexAndPercentV5(bz, CvpCodecVersionV5, cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor += 4
+
+		bzMoniker := mustTakeNBytesFrom(bz, cursor, cvpCodecV5MonikerBufferSize)
+		validator.Moniker = strings.TrimSpace(sanitizeMoniker(string(bzMoniker)))
+		cursor += cvpCodecV5MonikerBufferSize
+
+		if _, found := offsetByIndex[validator.Index]; !found {
+			offsetByIndex[validator.Index] = offset
+		}
+		validators = append(validators, validator)
+	}
+
+	if err := sortAndValidateLightValidatorIndexSequence(validators, CvpCodecVersionV5, offsetByIndex); err != nil {
+		return nil, err
+	}
+
+	return validators, nil
+}
+
+// sortAndValidateLightValidatorIndexSequence sorts the validators by index
+// then ensures the indexes are sequence 0..n-1.
+func sortAndValidateLightValidatorIndexSequence(validators types.StreamingLightValidators, version CvpCodecVersion, offsetByIndex map[int]int) error {
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].Index < validators[j].Index
+	})
+	for i, v := range validators {
+		if v.Index != i {
+			return newDecodeError(version, ErrInvalidField, "Index", offsetByIndex[v.Index], "invalid validator index sequence, %d at %d", v.Index, i)
+		}
+	}
+	return nil
+}
+
+// readLightValidatorIndexAndPercentV5 is the reverse of writeLightValidatorIndexAndPercentV5,
+// offset is the position of the record in the encoded data.
+func readLightValidatorIndexAndPercentV5(bz []byte, version CvpCodecVersion, offset int) (types.StreamingLightValidator, error) {
+	var validator types.StreamingLightValidator
+
+	validator.Index = fromUint16Buffer(mustTakeNBytesFrom(bz, offset, 2))
+	if validator.Index > cvpCodecV5Limits.MaxValidatorIndex {
+		return validator, newDecodeError(version, ErrInvalidField, "Index", offset, "invalid validator index: %d", validator.Index)
+	}
+
+	validator.VotingPowerDisplayPercent = fromPercentBuffer(mustTakeNBytesFrom(bz, offset+2, 2))
+	if validator.VotingPowerDisplayPercent < 0 || validator.VotingPowerDisplayPercent > 100 {
+		return validator, newDecodeError(version, ErrInvalidField, "VotingPowerDisplayPercent", offset+2, "invalid voting power display percent: %f", validator.VotingPowerDisplayPercent)
+	}
+
+	return validator, nil
+}
+
+func (c cvpCodecV5) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV5) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	return encodeStreamingNextBlockVotingInformationV5(inf, CvpCodecVersionV5, prefixDataEncodedByCvpCodecV5)
+}
+
+func (c cvpCodecV5) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	return decodeStreamingNextBlockVotingInformationV5(bz, CvpCodecVersionV5, prefixDataEncodedByCvpCodecV5)
+}
+
+// nextBlockVotingInformationFormatV5 describes the parts of v5 next block voting information format
+// which the later codec versions can replace while keeping the rest of v5 format:
+// the height round step and the part following the 2 bytes validator index of a vote state record.
+type nextBlockVotingInformationFormatV5 struct {
+	validateHeightRoundStep func(version CvpCodecVersion, field string, hrs types.HeightRoundStep) error
+	writeHeightRoundStep    func(b *bytes.Buffer, hrs types.HeightRoundStep)
+	readHeightRoundStep     func(bz []byte, version CvpCodecVersion, offset int) (types.HeightRoundStep, int, error)
+
+	voteStateBodySize  int
+	writeVoteStateBody func(b *bytes.Buffer, v types.StreamingValidatorVoteState, version CvpCodecVersion) error
+	readVoteStateBody  func(bz []byte, validatorIndex int, version CvpCodecVersion, offset int) (types.StreamingValidatorVoteState, error)
+}
+
+// nextBlockVotingInformationFormatOfV5 is the format of v5: text height round step and v2 vote state record body.
+var nextBlockVotingInformationFormatOfV5 = nextBlockVotingInformationFormatV5{
+	validateHeightRoundStep: validateHeightRoundStepForEncodingV5,
+	writeHeightRoundStep:    writeHeightRoundStepV5,
+	readHeightRoundStep:     readHeightRoundStepV5,
+	voteStateBodySize:       cvpCodecV5VoteStateRecordSize - 2,
+	writeVoteStateBody:      writeValidatorVoteStateBodyV2,
+	readVoteStateBody:       readValidatorVoteStateBodyV2,
+}
+
+// encodeStreamingNextBlockVotingInformationV5 encodes the next block voting information in v5 format,
+// which is shared with the later codec versions those only differ in light validators format.
+func encodeStreamingNextBlockVotingInformationV5(inf *types.StreamingNextBlockVotingInformation, version CvpCodecVersion, prefix []byte) ([]byte, error) {
+	return encodeStreamingNextBlockVotingInformationWithFormatV5(inf, version, prefix, nextBlockVotingInformationFormatOfV5)
+}
+
+// encodeStreamingNextBlockVotingInformationWithFormatV5 is the same as encodeStreamingNextBlockVotingInformationV5
+// but the height round step and the vote state records are written using the given format.
+func encodeStreamingNextBlockVotingInformationWithFormatV5(inf *types.StreamingNextBlockVotingInformation, version CvpCodecVersion, prefix []byte, format nextBlockVotingInformationFormatV5) ([]byte, error) {
+	if len(inf.ValidatorVoteStates) > cvpCodecV5Limits.MaxValidators {
+		return nil, newEncodeError(version, "ValidatorVoteStates", "too many validators: %d/%d", len(inf.ValidatorVoteStates), cvpCodecV5Limits.MaxValidators)
+	}
+	if err := format.validateHeightRoundStep(version, "HeightRoundStep", inf.HeightRoundStep); err != nil {
+		return nil, err
+	}
+	if err := validatePercentForEncoding(version, "PreVotedPercent", inf.PreVotedPercent); err != nil {
+		return nil, err
+	}
+	if err := validatePercentForEncoding(version, "PreCommitVotedPercent", inf.PreCommitVotedPercent); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.Write(prefix)
+
+	format.writeHeightRoundStep(&b, inf.HeightRoundStep)
+
+	durationSec := int64(inf.Duration.Seconds())
+	if durationSec < 0 {
+		durationSec = 0
+	} else if durationSec > math.MaxUint32 {
+		durationSec = math.MaxUint32
+	}
+	bzDuration := make([]byte, 4)
+	binary.BigEndian.PutUint32(bzDuration, uint32(durationSec))
+	b.Write(bzDuration)
+
+	b.Write(toPercentBuffer(inf.PreVotedPercent))
+	b.Write(toPercentBuffer(inf.PreCommitVotedPercent))
+
+	b.Write(toUint16Buffer(len(inf.ValidatorVoteStates)))
+
+	for _, v := range inf.ValidatorVoteStates {
+		if v.ValidatorIndex < 0 {
+			return nil, newEncodeError(version, "ValidatorIndex", "invalid validator index: %d, must not be negative", v.ValidatorIndex)
+		}
+		if v.ValidatorIndex > cvpCodecV5Limits.MaxValidatorIndex {
+			return nil, newEncodeError(version, "ValidatorIndex", "invalid validator index: %d, must be less than %d", v.ValidatorIndex, cvpCodecV5Limits.MaxValidatorIndex+1)
+		}
+		b.Write(toUint16Buffer(v.ValidatorIndex))
+
+		if err := format.writeVoteStateBody(&b, v, version); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// decodeStreamingNextBlockVotingInformationV5 is the reverse of encodeStreamingNextBlockVotingInformationV5.
+func decodeStreamingNextBlockVotingInformationV5(bz []byte, version CvpCodecVersion, prefix []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	return decodeStreamingNextBlockVotingInformationWithFormatV5(bz, version, prefix, nextBlockVotingInformationFormatOfV5)
+}
+
+// decodeStreamingNextBlockVotingInformationWithFormatV5 is the reverse of encodeStreamingNextBlockVotingInformationWithFormatV5.
+func decodeStreamingNextBlockVotingInformationWithFormatV5(bz []byte, version CvpCodecVersion, prefix []byte, format nextBlockVotingInformationFormatV5) (*types.StreamingNextBlockVotingInformation, error) {
+	if !bytes.HasPrefix(bz, prefix) {
+		return nil, newDecodeError(version, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	var result types.StreamingNextBlockVotingInformation
+
+	cursor := len(prefix)
+
+	var err error
+	result.HeightRoundStep, cursor, err = format.readHeightRoundStep(bz, version, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	bzDuration, ok := tryTakeNBytesFrom(bz, cursor, 4)
+	if !ok {
+		return nil, newDecodeError(version, ErrTruncated, "Duration", cursor, "missing duration")
+	}
+	result.Duration = time.Duration(binary.BigEndian.Uint32(bzDuration)) * time.Second
+	cursor += 4
+
+	bzPreVotedAndPreCommitVotedPercent, ok := tryTakeNBytesFrom(bz, cursor, 4)
+	if !ok {
+		return nil, newDecodeError(version, ErrTruncated, "PreVotedPercent", cursor, "missing pre-voted and pre-commit voted percent")
+	}
+	result.PreVotedPercent = fromPercentBuffer(bzPreVotedAndPreCommitVotedPercent[:2])
+	if result.PreVotedPercent < 0 || result.PreVotedPercent > 100 {
+		return nil, newDecodeError(version, ErrInvalidField, "PreVotedPercent", cursor, "invalid pre-voted percent: %f", result.PreVotedPercent)
+	}
+	result.PreCommitVotedPercent = fromPercentBuffer(bzPreVotedAndPreCommitVotedPercent[2:])
+	if result.PreCommitVotedPercent < 0 || result.PreCommitVotedPercent > 100 {
+		return nil, newDecodeError(version, ErrInvalidField, "PreCommitVotedPercent", cursor+2, "invalid pre-commit voted percent: %f", result.PreCommitVotedPercent)
+	}
+	cursor += 4
+
+	bzCount, ok := tryTakeNBytesFrom(bz, cursor, 2)
+	if !ok {
+		return nil, newDecodeError(version, ErrTruncated, "ValidatorVoteStates", cursor, "missing number of validator vote states")
+	}
+	count := fromUint16Buffer(bzCount)
+	if count < 1 {
+		return nil, newDecodeError(version, ErrInvalidField, "ValidatorVoteStates", cursor, "missing validator vote states")
+	}
+	cursor += 2
+
+	recordSize := 2 + format.voteStateBodySize
+	if want := cursor + count*recordSize; len(bz) < want {
+		return nil, newDecodeError(version, ErrTruncated, "ValidatorVoteStates", cursor, "invalid validator vote states length: %d, require %d", len(bz)-cursor, want-cursor)
+	} else if len(bz) > want {
+		return nil, newDecodeError(version, ErrInvalidField, "ValidatorVoteStates", want, "unexpected trailing %d bytes", len(bz)-want)
+	}
+
+	validatorVoteStates := make([]types.StreamingValidatorVoteState, 0, count)
+	offsetByIndex := make(map[int]int)
+	for i := 0; i < count; i++ {
+		validatorIndex := fromUint16Buffer(mustTakeNBytesFrom(bz, cursor, 2))
+		if validatorIndex > cvpCodecV5Limits.MaxValidatorIndex {
+			return nil, newDecodeError(version, ErrInvalidField, "ValidatorIndex", cursor, "invalid validator index: %d", validatorIndex)
+		}
+
+		validatorVoteState, err := format.readVoteStateBody(mustTakeNBytesFrom(bz, cursor+2, format.voteStateBodySize), validatorIndex, version, cursor+2)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, found := offsetByIndex[validatorIndex]; !found {
+			offsetByIndex[validatorIndex] = cursor
+		}
+		validatorVoteStates = append(validatorVoteStates, validatorVoteState)
+
+		cursor += recordSize
+	}
+	sort.Slice(validatorVoteStates, func(i, j int) bool {
+		return validatorVoteStates[i].ValidatorIndex < validatorVoteStates[j].ValidatorIndex
+	})
+	for i, state := range validatorVoteStates {
+		if state.ValidatorIndex != i {
+			return nil, newDecodeError(version, ErrInvalidField, "ValidatorIndex", offsetByIndex[state.ValidatorIndex], "invalid validator index sequence, %d at %d", state.ValidatorIndex, i)
+		}
+	}
+	result.ValidatorVoteStates = validatorVoteStates
+
+	return &result, nil
+}
+
+// validateHeightRoundStepForEncodingV5 validates the text form, which must fit the 1 byte length.
+func validateHeightRoundStepForEncodingV5(version CvpCodecVersion, field string, hrs types.HeightRoundStep) error {
+	if err := validateTextHeightRoundStepForEncoding(version, field, hrs); err != nil {
+		return err
+	}
+	if str := hrs.String(); len(str) > math.MaxUint8 {
+		return newEncodeError(version, field, "height round step %s exceeds %d bytes", str, math.MaxUint8)
+	}
+	return nil
+}
+
+// writeHeightRoundStepV5 writes the text form of the height round step, prefixed by 1 byte length.
+func writeHeightRoundStepV5(b *bytes.Buffer, hrs types.HeightRoundStep) {
+	str := hrs.String()
+	b.WriteByte(byte(len(str)))
+	b.WriteString(str)
+}
+
+// readHeightRoundStepV5 is the reverse of writeHeightRoundStepV5, returns the height round step
+// and the offset right after it.
+func readHeightRoundStepV5(bz []byte, version CvpCodecVersion, offset int) (types.HeightRoundStep, int, error) {
+	bzHeightRoundStepLength, ok := tryTakeNBytesFrom(bz, offset, 1)
+	if !ok {
+		return types.HeightRoundStep{}, offset, newDecodeError(version, ErrTruncated, "HeightRoundStep", offset, "missing height round step")
+	}
+	offset++
+
+	heightRoundStepLength := int(bzHeightRoundStepLength[0])
+	if heightRoundStepLength < 1 {
+		return types.HeightRoundStep{}, offset, newDecodeError(version, ErrInvalidField, "HeightRoundStep", offset, "invalid empty height round step")
+	}
+	bzHeightRoundStep, ok := tryTakeNBytesFrom(bz, offset, heightRoundStepLength)
+	if !ok {
+		return types.HeightRoundStep{}, offset, newDecodeError(version, ErrTruncated, "HeightRoundStep", offset, "missing height round step")
+	}
+	hrs, err := parseHeightRoundStepForDecoding(version, "HeightRoundStep", offset, string(bzHeightRoundStep))
+	if err != nil {
+		return types.HeightRoundStep{}, offset, err
+	}
+
+	return hrs, offset + heightRoundStepLength, nil
+}
+
+func (c cvpCodecV5) GetLimits() CvpCodecLimits {
+	return cvpCodecV5Limits
+}
+
+func (c cvpCodecV5) GetVersion() CvpCodecVersion {
+	return CvpCodecVersionV5
+}
@@ -0,0 +1,315 @@
+package codec
+
+import (
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cvpV5CodecImpl = GetCvpCodecV5()
+
+func Test_cvpCodecV5_EncodeDecodeStreamingLightValidators(t *testing.T) {
+	newValidators := func(size int) types.StreamingLightValidators {
+		validators := make(types.StreamingLightValidators, size)
+		for i := range validators {
+			validators[i] = types.StreamingLightValidator{
+				Index:                     i,
+				VotingPowerDisplayPercent: 0.01,
+				Moniker:                   fmt.Sprintf("Val%d", i+1),
+			}
+		}
+		return validators
+	}
+
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name                               string
+		validators                         types.StreamingLightValidators
+		wantErrEncode                      bool
+		wantErrEncodeContains              string
+		wantDecodedOrUseInputAsWantDecoded types.StreamingLightValidators // if missing, use input as expect
+	}{
+		{
+			name:       "more than 250 validators",
+			validators: newValidators(1000),
+		},
+		{
+			name:       "maximum number of validators",
+			validators: newValidators(math.MaxUint16),
+		},
+		{
+			name:                  "too many validators",
+			validators:            newValidators(math.MaxUint16 + 1),
+			wantErrEncode:         true,
+			wantErrEncodeContains: "too many validators: 65536/65535",
+		},
+		{
+			name: "not accept validator index greater than 65534",
+			validators: []types.StreamingLightValidator{
+				{
+					Index:                     math.MaxUint16,
+					VotingPowerDisplayPercent: 1,
+				},
+			},
+			wantErrEncode:         true,
+			wantErrEncodeContains: "must be less than 65535",
+		},
+		{
+			name: "sort by index",
+			validators: []types.StreamingLightValidator{
+				{
+					Index:                     1,
+					VotingPowerDisplayPercent: 1,
+					Moniker:                   "Val2",
+				},
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 2,
+					Moniker:                   "Val1",
+				},
+			},
+			wantDecodedOrUseInputAsWantDecoded: []types.StreamingLightValidator{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 2,
+					Moniker:                   "Val1",
+				},
+				{
+					Index:                     1,
+					VotingPowerDisplayPercent: 1,
+					Moniker:                   "Val2",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEncoded, err := cvpV5CodecImpl.TryEncodeStreamingLightValidators(tt.validators)
+			if tt.wantErrEncode {
+				if err == nil {
+					t.Errorf("TryEncodeStreamingLightValidators() expect error but got nil")
+				} else if !strings.Contains(err.Error(), tt.wantErrEncodeContains) {
+					t.Errorf("TryEncodeStreamingLightValidators() error = %v, wantErr contains %v", err, tt.wantErrEncodeContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("TryEncodeStreamingLightValidators() error = %v", err)
+				return
+			}
+
+			if len(gotEncoded) > cvpV5CodecImpl.GetLimits().MaxEncodedLightValidatorsBytes {
+				t.Errorf("encoded size %d exceeds limit %d", len(gotEncoded), cvpV5CodecImpl.GetLimits().MaxEncodedLightValidatorsBytes)
+			}
+
+			gotDecoded, err := cvpV5CodecImpl.DecodeStreamingLightValidators(gotEncoded)
+			if err != nil {
+				t.Errorf("DecodeStreamingLightValidators() error = %v", err)
+				return
+			}
+			if tt.wantDecodedOrUseInputAsWantDecoded == nil {
+				tt.wantDecodedOrUseInputAsWantDecoded = tt.validators
+			}
+			if !reflect.DeepEqual(gotDecoded, tt.wantDecodedOrUseInputAsWantDecoded) {
+				t.Errorf("DecodeStreamingLightValidators()\ngot = %v,\nwant %v", gotDecoded, tt.wantDecodedOrUseInputAsWantDecoded)
+			}
+		})
+	}
+}
+
+func Test_cvpCodecV5_EncodeDecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	newInformation := func(size int) *types.StreamingNextBlockVotingInformation {
+		inf := &types.StreamingNextBlockVotingInformation{
+			HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
+			Duration:              3 * time.Second,
+			PreVotedPercent:       50,
+			PreCommitVotedPercent: 25.5,
+		}
+		for i := 0; i < size; i++ {
+			inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, types.StreamingValidatorVoteState{
+				ValidatorIndex:    i,
+				PreVotedBlockHash: "C0FF",
+				PreVoted:          true,
+			})
+		}
+		return inf
+	}
+
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name                  string
+		inf                   *types.StreamingNextBlockVotingInformation
+		wantErrEncode         bool
+		wantErrEncodeContains string
+	}{
+		{
+			name: "more than 250 validators",
+			inf:  newInformation(1000),
+		},
+		{
+			name: "maximum number of validators",
+			inf:  newInformation(math.MaxUint16),
+		},
+		{
+			name:                  "too many validators",
+			inf:                   newInformation(math.MaxUint16 + 1),
+			wantErrEncode:         true,
+			wantErrEncodeContains: "too many validators: 65536/65535",
+		},
+		{
+			name: "not accept validator index greater than 65534",
+			inf: func() *types.StreamingNextBlockVotingInformation {
+				inf := newInformation(1)
+				inf.ValidatorVoteStates[0].ValidatorIndex = math.MaxUint16
+				return inf
+			}(),
+			wantErrEncode:         true,
+			wantErrEncodeContains: "must be less than 65535",
+		},
+		{
+			name: "longest height round step",
+			inf: func() *types.StreamingNextBlockVotingInformation {
+				inf := newInformation(1)
+				inf.HeightRoundStep = types.HeightRoundStep{Height: math.MaxInt64, Round: math.MaxInt32, Step: types.RoundStepCommit}
+				return inf
+			}(),
+		},
+		{
+			name: "not accept invalid height round step",
+			inf: func() *types.StreamingNextBlockVotingInformation {
+				inf := newInformation(1)
+				inf.HeightRoundStep = types.HeightRoundStep{Height: 1, Round: -1, Step: types.RoundStepPrevote}
+				return inf
+			}(),
+			wantErrEncode:         true,
+			wantErrEncodeContains: "invalid height round step 1/-1/4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEncoded, err := cvpV5CodecImpl.TryEncodeStreamingNextBlockVotingInformation(tt.inf)
+			if tt.wantErrEncode {
+				if err == nil {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() expect error but got nil")
+				} else if !strings.Contains(err.Error(), tt.wantErrEncodeContains) {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %v, wantErr contains %v", err, tt.wantErrEncodeContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+
+			gotDecoded, err := cvpV5CodecImpl.DecodeStreamingNextBlockVotingInformation(gotEncoded)
+			if err != nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(gotDecoded, tt.inf) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation()\ngot = %v,\nwant %v", gotDecoded, tt.inf)
+			}
+		})
+	}
+}
+
+func Test_cvpCodecV5_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name                  string
+		inputEncodedData      []byte
+		wantErrKind           error
+		wantErrField          string
+		wantErrDecodeContains string
+	}{
+		{
+			name:                  "bad prefix",
+			inputEncodedData:      []byte{0x2, '|'},
+			wantErrKind:           ErrBadPrefix,
+			wantErrField:          "prefix",
+			wantErrDecodeContains: "bad encoding prefix",
+		},
+		{
+			name:                  "missing height round step",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV5, []byte{5}, []byte("1/2")),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "HeightRoundStep",
+			wantErrDecodeContains: "missing height round step",
+		},
+		{
+			name:                  "invalid height round step",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV5, []byte{5}, []byte("1/2|3")),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "HeightRoundStep",
+			wantErrDecodeContains: "invalid height round step",
+		},
+		{
+			name: "truncated vote states",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecV5,
+				[]byte{5}, []byte("1/2/3"),
+				[]byte{0, 0, 0, 1},
+				[]byte{1, 0}, []byte{2, 0},
+				[]byte{0, 2},
+				[]byte{0, 0}, []byte("C0FF"), []byte("V"),
+			),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "ValidatorVoteStates",
+			wantErrDecodeContains: "invalid validator vote states length",
+		},
+		{
+			name: "trailing bytes",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecV5,
+				[]byte{5}, []byte("1/2/3"),
+				[]byte{0, 0, 0, 1},
+				[]byte{1, 0}, []byte{2, 0},
+				[]byte{0, 1},
+				[]byte{0, 0}, []byte("C0FF"), []byte("V"),
+				[]byte{0},
+			),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "ValidatorVoteStates",
+			wantErrDecodeContains: "unexpected trailing 1 bytes",
+		},
+		{
+			name: "invalid validator index sequence",
+			inputEncodedData: mergeBuffers(
+				prefixDataEncodedByCvpCodecV5,
+				[]byte{5}, []byte("1/2/3"),
+				[]byte{0, 0, 0, 1},
+				[]byte{1, 0}, []byte{2, 0},
+				[]byte{0, 2},
+				[]byte{0, 0}, []byte("C0FF"), []byte("V"),
+				[]byte{0x1, 0x0}, []byte("C0FF"), []byte("V"),
+			),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "ValidatorIndex",
+			wantErrDecodeContains: "invalid validator index sequence, 256 at 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cvpV5CodecImpl.DecodeStreamingNextBlockVotingInformation(tt.inputEncodedData)
+			if err == nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() expect error but got nil")
+				return
+			}
+			if !errors.Is(err, tt.wantErrKind) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, want matches %v", err, tt.wantErrKind)
+			}
+			var decodeErr *DecodeError
+			if errors.As(err, &decodeErr) && decodeErr.Field != tt.wantErrField {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error field = %s, want %s", decodeErr.Field, tt.wantErrField)
+			}
+			if !strings.Contains(err.Error(), tt.wantErrDecodeContains) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, wantErr contains %v", err, tt.wantErrDecodeContains)
+			}
+		})
+	}
+}
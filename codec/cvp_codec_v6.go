@@ -0,0 +1,196 @@
+package codec
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+//goland:noinspection SpellCheckingInspection
+
+var _ CvpCodec = (*cvpCodecV6)(nil)
+
+const cvpCodecV6Separator byte = '|'
+
+var prefixDataEncodedByCvpCodecV6 = []byte{0x6, cvpCodecV6Separator}
+
+// CvpCodecV6DefaultMaxMonikerBytes is the default maximum moniker size of v6 codec,
+// matches the maximum moniker length of Cosmos SDK.
+const CvpCodecV6DefaultMaxMonikerBytes = 70
+
+// cvpCodecV6WireMaxMonikerBytes is the maximum moniker size can be carried by the 1 byte length prefix.
+const cvpCodecV6WireMaxMonikerBytes = math.MaxUint8
+
+type cvpCodecV6 struct {
+	maxMonikerBytes int
+}
+
+// GetCvpCodecV6 returns new instance of v6 implementation of CvpCodec,
+// with maximum moniker size is CvpCodecV6DefaultMaxMonikerBytes.
+//
+// V6 is the same as v5, except monikers are length-prefixed instead of truncated or padded to 20 bytes,
+// so the decoded moniker equals to the input moniker after sanitization,
+// as long as the input is valid UTF-8 and not longer than the maximum moniker size.
+// Longer monikers are truncated at UTF-8 rune boundary.
+func GetCvpCodecV6() CvpCodec {
+	return GetCvpCodecV6WithMaxMonikerBytes(CvpCodecV6DefaultMaxMonikerBytes)
+}
+
+// GetCvpCodecV6WithMaxMonikerBytes is the same as GetCvpCodecV6 but with custom maximum moniker size for encoding.
+// The maximum moniker size must be in range 1 to 255.
+//
+// Decoding accepts moniker up to 255 bytes, regardless the configured maximum moniker size.
+func GetCvpCodecV6WithMaxMonikerBytes(maxMonikerBytes int) CvpCodec {
+	if maxMonikerBytes < 1 || maxMonikerBytes > cvpCodecV6WireMaxMonikerBytes {
+		panic(fmt.Errorf("invalid max moniker bytes: %d, must be in range 1 to %d", maxMonikerBytes, cvpCodecV6WireMaxMonikerBytes))
+	}
+	return cvpCodecV6{
+		maxMonikerBytes: maxMonikerBytes,
+	}
+}
+
+func (c cvpCodecV6) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV6) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	limits := c.GetLimits()
+	if len(validators) > limits.MaxValidators {
+		return nil, newEncodeError(CvpCodecVersionV6, "StreamingLightValidators", "too many validators: %d/%d", len(validators), limits.MaxValidators)
+	}
+
+	var b bytes.Buffer
+	b.Write(prefixDataEncodedByCvpCodecV6)
+	b.Write(toUint16Buffer(len(validators)))
+
+	for _, v := range validators {
+		if err := writeLightValidatorIndexAndPercentV5(&b, v, CvpCodecVersionV6); err != nil {
+			return nil, err
+		}
+
+		writeMonikerV6(&b, v.Moniker, c.maxMonikerBytes)
+	}
+
+	return b.Bytes(), nil
+}
+
+func (c cvpCodecV6) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	if !bytes.HasPrefix(bz, prefixDataEncodedByCvpCodecV6) {
+		return nil, newDecodeError(CvpCodecVersionV6, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	cursor := len(prefixDataEncodedByCvpCodecV6)
+
+	bzCount, ok := tryTakeNBytesFrom(bz, cursor, 2)
+	if !ok {
+		return nil, newDecodeError(CvpCodecVersionV6, ErrTruncated, "StreamingLightValidators", cursor, "missing number of validators")
+	}
+	count := fromUint16Buffer(bzCount)
+	if count < 1 {
+		return nil, newDecodeError(CvpCodecVersionV6, ErrInvalidField, "StreamingLightValidators", cursor, "invalid empty validator raw data")
+	}
+	cursor += 2
+
+	validators := make(types.StreamingLightValidators, 0, count)
+	offsetByIndex := make(map[int]int)
+	for i := 0; i < count; i++ {
+		offset := cursor
+
+		if _, ok := tryTakeNBytesFrom(bz, cursor, 4+1); !ok {
+			return nil, newDecodeError(CvpCodecVersionV6, ErrTruncated, "StreamingLightValidator", offset, "missing validator %d/%d", i+1, count)
+		}
+
+		validator, err := readLightValidatorIndexAndPercentV5(bz, CvpCodecVersionV6, cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor += 4
+
+		validator.Moniker, cursor, err = readMonikerV6(bz, CvpCodecVersionV6, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, found := offsetByIndex[validator.Index]; !found {
+			offsetByIndex[validator.Index] = offset
+		}
+		validators = append(validators, validator)
+	}
+
+	if cursor < len(bz) {
+		return nil, newDecodeError(CvpCodecVersionV6, ErrInvalidField, "StreamingLightValidators", cursor, "unexpected trailing %d bytes", len(bz)-cursor)
+	}
+
+	if err := sortAndValidateLightValidatorIndexSequence(validators, CvpCodecVersionV6, offsetByIndex); err != nil {
+		return nil, err
+	}
+
+	return validators, nil
+}
+
+func (c cvpCodecV6) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV6) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	return encodeStreamingNextBlockVotingInformationV5(inf, CvpCodecVersionV6, prefixDataEncodedByCvpCodecV6)
+}
+
+func (c cvpCodecV6) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	return decodeStreamingNextBlockVotingInformationV5(bz, CvpCodecVersionV6, prefixDataEncodedByCvpCodecV6)
+}
+
+// GetLimits returns the same limits as v5,
+// except the maximum size of the encoded light validators depends on the configured maximum moniker size.
+func (c cvpCodecV6) GetLimits() CvpCodecLimits {
+	limits := cvpCodecV5Limits
+	limits.MaxEncodedLightValidatorsBytes = len(prefixDataEncodedByCvpCodecV6) + 2 /*count*/ + limits.MaxValidators*(2+2+1+c.maxMonikerBytes)
+	return limits
+}
+
+func (c cvpCodecV6) GetVersion() CvpCodecVersion {
+	return CvpCodecVersionV6
+}
+
+// writeMonikerV6 writes the moniker, sanitized and truncated to maxMonikerBytes, prefixed by 1 byte length.
+func writeMonikerV6(b *bytes.Buffer, moniker string, maxMonikerBytes int) {
+	moniker = strings.ToValidUTF8(moniker, string(utf8.RuneError))
+	moniker = utils.TruncateStringUntilBufferLessThanXBytes(sanitizeMoniker(moniker), maxMonikerBytes)
+	b.WriteByte(byte(len(moniker)))
+	b.WriteString(moniker)
+}
+
+// readMonikerV6 reads the length-prefixed moniker at the given offset, returns the moniker and the offset right after it.
+func readMonikerV6(bz []byte, version CvpCodecVersion, offset int) (string, int, error) {
+	if offset >= len(bz) {
+		return "", offset, newDecodeError(version, ErrTruncated, "Moniker", offset, "missing moniker length")
+	}
+	monikerLength := int(bz[offset])
+	offset++
+
+	if monikerLength < 1 {
+		return "", offset, nil
+	}
+
+	bzMoniker, ok := tryTakeNBytesFrom(bz, offset, monikerLength)
+	if !ok {
+		return "", offset, newDecodeError(version, ErrTruncated, "Moniker", offset, "invalid moniker length %d, only %d bytes left", monikerLength, len(bz)-offset)
+	}
+	if !utf8.Valid(bzMoniker) {
+		return "", offset, newDecodeError(version, ErrInvalidField, "Moniker", offset, "invalid UTF-8 moniker")
+	}
+
+	return sanitizeMoniker(string(bzMoniker)), offset + monikerLength, nil
+}
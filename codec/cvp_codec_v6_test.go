@@ -0,0 +1,240 @@
+package codec
+
+import (
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var cvpV6CodecImpl = GetCvpCodecV6()
+
+func Test_cvpCodecV6_EncodeDecodeStreamingLightValidators(t *testing.T) {
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name                               string
+		codec                              CvpCodec // if missing, use default v6 codec
+		moniker                            string
+		wantDecodedOrUseInputAsWantDecoded string // if missing, use input as expect
+	}{
+		{
+			name:    "moniker longer than 20 bytes",
+			moniker: "123456789012345678901234567890",
+		},
+		{
+			name:    "moniker of 70 bytes",
+			moniker: strings.Repeat("1234567890", 7),
+		},
+		{
+			name:                               "truncate moniker longer than 70 bytes",
+			moniker:                            strings.Repeat("1234567890", 8),
+			wantDecodedOrUseInputAsWantDecoded: strings.Repeat("1234567890", 7),
+		},
+		{
+			name:                               "truncate moniker at UTF-8 rune boundary",
+			moniker:                            strings.Repeat("1", 68) + "✅", // 68 + 3 bytes
+			wantDecodedOrUseInputAsWantDecoded: strings.Repeat("1", 68),
+		},
+		{
+			name:    "keep leading and trailing spaces",
+			moniker: "  Val1  ",
+		},
+		{
+			name:    "empty moniker",
+			moniker: "",
+		},
+		{
+			name:                               "sanitize moniker",
+			moniker:                            `<he'llo">`,
+			wantDecodedOrUseInputAsWantDecoded: "(he`llo`)",
+		},
+		{
+			name:                               "replace invalid UTF-8",
+			moniker:                            "Val\xff1",
+			wantDecodedOrUseInputAsWantDecoded: "Val�1",
+		},
+		{
+			name:    "custom maximum moniker size",
+			codec:   GetCvpCodecV6WithMaxMonikerBytes(255),
+			moniker: strings.Repeat("1234567890", 25) + "12345",
+		},
+		{
+			name:                               "truncate moniker by custom maximum moniker size",
+			codec:                              GetCvpCodecV6WithMaxMonikerBytes(5),
+			moniker:                            "1234567890",
+			wantDecodedOrUseInputAsWantDecoded: "12345",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := tt.codec
+			if codec == nil {
+				codec = cvpV6CodecImpl
+			}
+
+			validators := types.StreamingLightValidators{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10.01,
+					Moniker:                   tt.moniker,
+				},
+			}
+			gotEncoded, err := codec.TryEncodeStreamingLightValidators(validators)
+			if err != nil {
+				t.Errorf("TryEncodeStreamingLightValidators() error = %v", err)
+				return
+			}
+
+			if len(gotEncoded) > codec.GetLimits().MaxEncodedLightValidatorsBytes {
+				t.Errorf("encoded size %d exceeds limit %d", len(gotEncoded), codec.GetLimits().MaxEncodedLightValidatorsBytes)
+			}
+
+			gotDecoded, err := codec.DecodeStreamingLightValidators(gotEncoded)
+			if err != nil {
+				t.Errorf("DecodeStreamingLightValidators() error = %v", err)
+				return
+			}
+			wantMoniker := tt.wantDecodedOrUseInputAsWantDecoded
+			if wantMoniker == "" {
+				wantMoniker = tt.moniker
+			}
+			wantDecoded := types.StreamingLightValidators{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10.01,
+					Moniker:                   wantMoniker,
+				},
+			}
+			if !reflect.DeepEqual(gotDecoded, wantDecoded) {
+				t.Errorf("DecodeStreamingLightValidators()\ngot = %v,\nwant %v", gotDecoded, wantDecoded)
+			}
+		})
+	}
+
+	t.Run("decode moniker longer than configured maximum moniker size", func(t *testing.T) {
+		moniker := strings.Repeat("1234567890", 10)
+		bz := GetCvpCodecV6WithMaxMonikerBytes(255).EncodeStreamingLightValidators(types.StreamingLightValidators{
+			{
+				Index:                     0,
+				VotingPowerDisplayPercent: 1,
+				Moniker:                   moniker,
+			},
+		})
+		gotDecoded, err := cvpV6CodecImpl.DecodeStreamingLightValidators(bz)
+		if err != nil {
+			t.Errorf("DecodeStreamingLightValidators() error = %v", err)
+			return
+		}
+		if gotDecoded[0].Moniker != moniker {
+			t.Errorf("DecodeStreamingLightValidators() moniker = %s, want %s", gotDecoded[0].Moniker, moniker)
+		}
+	})
+}
+
+func TestGetCvpCodecV6WithMaxMonikerBytes(t *testing.T) {
+	tests := []struct {
+		maxMonikerBytes int
+		wantPanic       bool
+	}{
+		{maxMonikerBytes: -1, wantPanic: true},
+		{maxMonikerBytes: 0, wantPanic: true},
+		{maxMonikerBytes: 1},
+		{maxMonikerBytes: CvpCodecV6DefaultMaxMonikerBytes},
+		{maxMonikerBytes: 255},
+		{maxMonikerBytes: 256, wantPanic: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.maxMonikerBytes), func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if tt.wantPanic && r == nil {
+					t.Errorf("GetCvpCodecV6WithMaxMonikerBytes(%d) did not panic", tt.maxMonikerBytes)
+				} else if !tt.wantPanic && r != nil {
+					t.Errorf("GetCvpCodecV6WithMaxMonikerBytes(%d) panic = %v", tt.maxMonikerBytes, r)
+				}
+			}()
+			_ = GetCvpCodecV6WithMaxMonikerBytes(tt.maxMonikerBytes)
+		})
+	}
+}
+
+func Test_cvpCodecV6_DecodeStreamingLightValidators(t *testing.T) {
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name                  string
+		inputEncodedData      []byte
+		wantErrKind           error
+		wantErrField          string
+		wantErrOffset         int
+		wantErrDecodeContains string
+	}{
+		{
+			name:                  "bad prefix",
+			inputEncodedData:      []byte{0x5, '|', 0, 1},
+			wantErrKind:           ErrBadPrefix,
+			wantErrField:          "prefix",
+			wantErrOffset:         0,
+			wantErrDecodeContains: "bad encoding prefix",
+		},
+		{
+			name:                  "missing validator",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV6, []byte{0, 2}, []byte{0, 0, 0, 1, 0}),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "StreamingLightValidator",
+			wantErrOffset:         9,
+			wantErrDecodeContains: "missing validator 2/2",
+		},
+		{
+			name:                  "truncated moniker",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV6, []byte{0, 1}, []byte{0, 0, 0, 1, 5}, []byte("Val")),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "Moniker",
+			wantErrOffset:         9,
+			wantErrDecodeContains: "invalid moniker length 5, only 3 bytes left",
+		},
+		{
+			name:                  "invalid UTF-8 moniker",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV6, []byte{0, 1}, []byte{0, 0, 0, 1, 2}, []byte{0xff, 0xfe}),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "Moniker",
+			wantErrOffset:         9,
+			wantErrDecodeContains: "invalid UTF-8 moniker",
+		},
+		{
+			name:                  "trailing bytes",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV6, []byte{0, 1}, []byte{0, 0, 0, 1, 1}, []byte("V"), []byte{0}),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "StreamingLightValidators",
+			wantErrOffset:         10,
+			wantErrDecodeContains: "unexpected trailing 1 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cvpV6CodecImpl.DecodeStreamingLightValidators(tt.inputEncodedData)
+			if err == nil {
+				t.Errorf("DecodeStreamingLightValidators() expect error but got nil")
+				return
+			}
+			if !errors.Is(err, tt.wantErrKind) {
+				t.Errorf("DecodeStreamingLightValidators() error = %v, want matches %v", err, tt.wantErrKind)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Errorf("DecodeStreamingLightValidators() error = %T, want *DecodeError", err)
+				return
+			}
+			if decodeErr.Field != tt.wantErrField {
+				t.Errorf("DecodeStreamingLightValidators() error field = %s, want %s", decodeErr.Field, tt.wantErrField)
+			}
+			if decodeErr.Offset != tt.wantErrOffset {
+				t.Errorf("DecodeStreamingLightValidators() error offset = %d, want %d", decodeErr.Offset, tt.wantErrOffset)
+			}
+			if !strings.Contains(err.Error(), tt.wantErrDecodeContains) {
+				t.Errorf("DecodeStreamingLightValidators() error = %v, wantErr contains %v", err, tt.wantErrDecodeContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"strings"
+)
+
+//goland:noinspection SpellCheckingInspection
+
+var _ CvpCodec = (*cvpCodecV7)(nil)
+
+const cvpCodecV7Separator byte = '|'
+
+var prefixDataEncodedByCvpCodecV7 = []byte{0x7, cvpCodecV7Separator}
+
+const (
+	cvpCodecV7FlagOperatorAddress  byte = 1 << 0
+	cvpCodecV7FlagConsensusAddress byte = 1 << 1
+)
+
+// cvpCodecV7CustomHrp is the hrp table index indicates the human-readable part is written inline.
+const cvpCodecV7CustomHrp byte = 0
+
+// cvpCodecV7OperatorAddressHrpTable holds the well-known human-readable parts of validator operator addresses,
+// so they are encoded using 1 byte table index instead of the full string.
+// It is part of the wire format, new entries must be appended only.
+//
+//goland:noinspection SpellCheckingInspection
+var cvpCodecV7OperatorAddressHrpTable = []string{
+	cvpCodecV7CustomHrp: "", // written inline
+	"cosmosvaloper",
+	"osmovaloper",
+	"junovaloper",
+	"evmosvaloper",
+	"injvaloper",
+	"celestiavaloper",
+	"dydxvaloper",
+	"akashvaloper",
+	"starsvaloper",
+	"kavavaloper",
+	"secretvaloper",
+	"terravaloper",
+	"axelarvaloper",
+	"stridevaloper",
+	"neutronvaloper",
+	"dymvaloper",
+	"sagavaloper",
+	"seivaloper",
+	"archwayvaloper",
+	"persistencevaloper",
+}
+
+// cvpCodecV7MaxOperatorAddressBytes is the maximum size of an encoded operator address:
+// 1 byte hrp table index, 1 byte hrp length + 51 bytes inline hrp, 1 byte data length + 20 bytes data.
+// It is the longest hrp which fits into the 90 characters of bech32 string, along with 20 bytes data.
+const cvpCodecV7MaxOperatorAddressBytes = 1 + 1 + 51 + 1 + 20
+
+// cvpCodecV7Limits is the same as v6 with default maximum moniker size,
+// plus the flags and the optional addresses of each validator.
+var cvpCodecV7Limits = CvpCodecLimits{
+	MaxValidators:                             cvpCodecV5Limits.MaxValidators,
+	MaxValidatorIndex:                         cvpCodecV5Limits.MaxValidatorIndex,
+	MaxEncodedLightValidatorsBytes:            len(prefixDataEncodedByCvpCodecV7) + 2 /*count*/ + cvpCodecV5Limits.MaxValidators*(2+2+1+CvpCodecV6DefaultMaxMonikerBytes+1+cvpCodecV7MaxOperatorAddressBytes+types.ConsensusAddressBytes),
+	MaxEncodedNextBlockVotingInformationBytes: cvpCodecV5Limits.MaxEncodedNextBlockVotingInformationBytes,
+}
+
+type cvpCodecV7 struct {
+}
+
+// GetCvpCodecV7 returns new instance of v7 implementation of CvpCodec.
+//
+// V7 is the same as v6 with default maximum moniker size,
+// plus the optional operator address and consensus address of each light validator.
+// Operator address is encoded as the raw bytes along with the hrp table index,
+// consensus address is encoded as the raw bytes and decoded as upper case hex.
+func GetCvpCodecV7() CvpCodec {
+	return cvpCodecV7{}
+}
+
+func (c cvpCodecV7) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV7) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	return encodeStreamingLightValidatorsV7(validators, CvpCodecVersionV7, prefixDataEncodedByCvpCodecV7)
+}
+
+// encodeStreamingLightValidatorsV7 encodes the light validators using v7 format, with the given version and prefix,
+// so it can be reused by the later codecs which only differ from v7 by the next block voting information.
+func encodeStreamingLightValidatorsV7(validators types.StreamingLightValidators, version CvpCodecVersion, prefix []byte) ([]byte, error) {
+	if len(validators) > cvpCodecV7Limits.MaxValidators {
+		return nil, newEncodeError(version, "StreamingLightValidators", "too many validators: %d/%d", len(validators), cvpCodecV7Limits.MaxValidators)
+	}
+
+	var b bytes.Buffer
+	b.Write(prefix)
+	b.Write(toUint16Buffer(len(validators)))
+
+	for _, v := range validators {
+		if err := writeLightValidatorIndexAndPercentV5(&b, v, version); err != nil {
+			return nil, err
+		}
+
+		writeMonikerV6(&b, v.Moniker, CvpCodecV6DefaultMaxMonikerBytes)
+
+		var flags byte
+		if v.OperatorAddress != "" {
+			flags |= cvpCodecV7FlagOperatorAddress
+		}
+		if v.ConsensusAddress != "" {
+			flags |= cvpCodecV7FlagConsensusAddress
+		}
+		b.WriteByte(flags)
+
+		if v.OperatorAddress != "" {
+			if err := writeOperatorAddressV7(&b, v.OperatorAddress, version); err != nil {
+				return nil, err
+			}
+		}
+
+		if v.ConsensusAddress != "" {
+			if err := types.ValidateConsensusAddress(v.ConsensusAddress); err != nil {
+				return nil, newEncodeError(version, "ConsensusAddress", "invalid consensus address %s: %v", v.ConsensusAddress, err)
+			}
+			bzConsensusAddress, _ := hex.DecodeString(v.ConsensusAddress)
+			b.Write(bzConsensusAddress)
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func (c cvpCodecV7) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	return decodeStreamingLightValidatorsV7(bz, CvpCodecVersionV7, prefixDataEncodedByCvpCodecV7)
+}
+
+// decodeStreamingLightValidatorsV7 is the reverse of encodeStreamingLightValidatorsV7.
+func decodeStreamingLightValidatorsV7(bz []byte, version CvpCodecVersion, prefix []byte) (types.StreamingLightValidators, error) {
+	if !bytes.HasPrefix(bz, prefix) {
+		return nil, newDecodeError(version, ErrBadPrefix, "prefix", 0, "bad encoding prefix")
+	}
+
+	cursor := len(prefix)
+
+	bzCount, ok := tryTakeNBytesFrom(bz, cursor, 2)
+	if !ok {
+		return nil, newDecodeError(version, ErrTruncated, "StreamingLightValidators", cursor, "missing number of validators")
+	}
+	count := fromUint16Buffer(bzCount)
+	if count < 1 {
+		return nil, newDecodeError(version, ErrInvalidField, "StreamingLightValidators", cursor, "invalid empty validator raw data")
+	}
+	cursor += 2
+
+	validators := make(types.StreamingLightValidators, 0, count)
+	offsetByIndex := make(map[int]int)
+	for i := 0; i < count; i++ {
+		offset := cursor
+
+		if _, ok := tryTakeNBytesFrom(bz, cursor, 4+1); !ok {
+			return nil, newDecodeError(version, ErrTruncated, "StreamingLightValidator", offset, "missing validator %d/%d", i+1, count)
+		}
+
+		validator, err := readLightValidatorIndexAndPercentV5(bz, version, cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursor += 4
+
+		validator.Moniker, cursor, err = readMonikerV6(bz, version, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		if cursor >= len(bz) {
+			return nil, newDecodeError(version, ErrTruncated, "flags", cursor, "missing address flags")
+		}
+		flags := bz[cursor]
+		if flags&^(cvpCodecV7FlagOperatorAddress|cvpCodecV7FlagConsensusAddress) != 0 {
+			return nil, newDecodeError(version, ErrInvalidField, "flags", cursor, "unknown address flags 0x%02x", flags)
+		}
+		cursor++
+
+		if flags&cvpCodecV7FlagOperatorAddress != 0 {
+			validator.OperatorAddress, cursor, err = readOperatorAddressV7(bz, version, cursor)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if flags&cvpCodecV7FlagConsensusAddress != 0 {
+			bzConsensusAddress, ok := tryTakeNBytesFrom(bz, cursor, types.ConsensusAddressBytes)
+			if !ok {
+				return nil, newDecodeError(version, ErrTruncated, "ConsensusAddress", cursor, "missing consensus address")
+			}
+			validator.ConsensusAddress = strings.ToUpper(hex.EncodeToString(bzConsensusAddress))
+			cursor += types.ConsensusAddressBytes
+		}
+
+		if _, found := offsetByIndex[validator.Index]; !found {
+			offsetByIndex[validator.Index] = offset
+		}
+		validators = append(validators, validator)
+	}
+
+	if cursor < len(bz) {
+		return nil, newDecodeError(version, ErrInvalidField, "StreamingLightValidators", cursor, "unexpected trailing %d bytes", len(bz)-cursor)
+	}
+
+	if err := sortAndValidateLightValidatorIndexSequence(validators, version, offsetByIndex); err != nil {
+		return nil, err
+	}
+
+	return validators, nil
+}
+
+func (c cvpCodecV7) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV7) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	return encodeStreamingNextBlockVotingInformationV5(inf, CvpCodecVersionV7, prefixDataEncodedByCvpCodecV7)
+}
+
+func (c cvpCodecV7) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	return decodeStreamingNextBlockVotingInformationV5(bz, CvpCodecVersionV7, prefixDataEncodedByCvpCodecV7)
+}
+
+func (c cvpCodecV7) GetLimits() CvpCodecLimits {
+	return cvpCodecV7Limits
+}
+
+func (c cvpCodecV7) GetVersion() CvpCodecVersion {
+	return CvpCodecVersionV7
+}
+
+// writeOperatorAddressV7 writes the hrp table index, the inline hrp if not well-known,
+// then the raw bytes of the operator address prefixed by 1 byte length.
+func writeOperatorAddressV7(b *bytes.Buffer, operatorAddress string, version CvpCodecVersion) error {
+	if err := types.ValidateOperatorAddress(operatorAddress); err != nil {
+		return newEncodeError(version, "OperatorAddress", "invalid operator address %s: %v", operatorAddress, err)
+	}
+
+	hrp, data, _ := utils.Bech32Decode(operatorAddress)
+
+	hrpIndex := cvpCodecV7CustomHrp
+	for i, knownHrp := range cvpCodecV7OperatorAddressHrpTable {
+		if byte(i) != cvpCodecV7CustomHrp && knownHrp == hrp {
+			hrpIndex = byte(i)
+			break
+		}
+	}
+
+	b.WriteByte(hrpIndex)
+	if hrpIndex == cvpCodecV7CustomHrp {
+		b.WriteByte(byte(len(hrp)))
+		b.WriteString(hrp)
+	}
+	b.WriteByte(byte(len(data)))
+	b.Write(data)
+
+	return nil
+}
+
+// readOperatorAddressV7 reads the operator address at the given offset, returns the bech32 operator address
+// and the offset right after it.
+func readOperatorAddressV7(bz []byte, version CvpCodecVersion, offset int) (string, int, error) {
+	beginOffset := offset
+
+	if offset >= len(bz) {
+		return "", offset, newDecodeError(version, ErrTruncated, "OperatorAddress", offset, "missing hrp table index")
+	}
+	hrpIndex := bz[offset]
+	if int(hrpIndex) >= len(cvpCodecV7OperatorAddressHrpTable) {
+		return "", offset, newDecodeError(version, ErrInvalidField, "OperatorAddress", offset, "unknown hrp table index %d", hrpIndex)
+	}
+	offset++
+
+	hrp := cvpCodecV7OperatorAddressHrpTable[hrpIndex]
+	if hrpIndex == cvpCodecV7CustomHrp {
+		bzHrp, ok := tryTakeLengthPrefixedBytesV7(bz, offset)
+		if !ok {
+			return "", offset, newDecodeError(version, ErrTruncated, "OperatorAddress", offset, "missing hrp")
+		}
+		hrp = string(bzHrp)
+		offset += 1 + len(bzHrp)
+	}
+
+	data, ok := tryTakeLengthPrefixedBytesV7(bz, offset)
+	if !ok {
+		return "", offset, newDecodeError(version, ErrTruncated, "OperatorAddress", offset, "missing address bytes")
+	}
+	offset += 1 + len(data)
+
+	operatorAddress, err := utils.Bech32Encode(hrp, data)
+	if err == nil {
+		err = types.ValidateOperatorAddress(operatorAddress)
+	}
+	if err != nil {
+		return "", beginOffset, wrapDecodeError(version, ErrInvalidField, "OperatorAddress", beginOffset, err, "invalid operator address")
+	}
+
+	return operatorAddress, offset, nil
+}
+
+// tryTakeLengthPrefixedBytesV7 takes the non-empty bytes prefixed by 1 byte length at the given offset.
+func tryTakeLengthPrefixedBytesV7(bz []byte, offset int) ([]byte, bool) {
+	if offset >= len(bz) {
+		return nil, false
+	}
+	size := int(bz[offset])
+	if size < 1 {
+		return nil, false
+	}
+	return tryTakeNBytesFrom(bz, offset+1, size)
+}
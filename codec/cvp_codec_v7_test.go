@@ -0,0 +1,291 @@
+package codec
+
+import (
+	"bytes"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var cvpV7CodecImpl = GetCvpCodecV7()
+
+func mustBech32(hrp string, data []byte) string {
+	address, err := utils.Bech32Encode(hrp, data)
+	if err != nil {
+		panic(err)
+	}
+	return address
+}
+
+//goland:noinspection SpellCheckingInspection
+func Test_cvpCodecV7_EncodeDecodeStreamingLightValidators(t *testing.T) {
+	tests := []struct {
+		name                               string
+		validators                         types.StreamingLightValidators
+		wantErrEncodeField                 string
+		wantDecodedOrUseInputAsWantDecoded types.StreamingLightValidators // if missing, use input as expect
+		wantEncodedSize                    int                            // if positive, check size of encoded data
+	}{
+		{
+			name: "without addresses",
+			validators: types.StreamingLightValidators{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10,
+					Moniker:                   "Val1",
+				},
+			},
+			wantEncodedSize: 2 + 2 + 4 + 1 + 4 + 1,
+		},
+		{
+			name: "well-known hrp",
+			validators: types.StreamingLightValidators{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10,
+					Moniker:                   "Val1",
+					OperatorAddress:           mustBech32("cosmosvaloper", bytes.Repeat([]byte{0xAB}, 20)),
+					ConsensusAddress:          "0123456789ABCDEF0123456789ABCDEF01234567",
+				},
+			},
+			wantEncodedSize: 2 + 2 + 4 + 1 + 4 + 1 + (1 + 1 + 20) + 20,
+		},
+		{
+			name: "custom hrp",
+			validators: types.StreamingLightValidators{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10,
+					Moniker:                   "Val1",
+					OperatorAddress:           mustBech32("customvaloper", bytes.Repeat([]byte{0xAB}, 32)),
+				},
+			},
+			wantEncodedSize: 2 + 2 + 4 + 1 + 4 + 1 + (1 + 1 + 13 + 1 + 32),
+		},
+		{
+			name: "lower case consensus address is decoded as upper case",
+			validators: types.StreamingLightValidators{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10,
+					ConsensusAddress:          "0123456789abcdef0123456789abcdef01234567",
+				},
+			},
+			wantDecodedOrUseInputAsWantDecoded: types.StreamingLightValidators{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10,
+					ConsensusAddress:          "0123456789ABCDEF0123456789ABCDEF01234567",
+				},
+			},
+		},
+		{
+			name: "multiple validators, duplicated moniker",
+			validators: types.StreamingLightValidators{
+				{
+					Index:                     0,
+					VotingPowerDisplayPercent: 10,
+					Moniker:                   "Val",
+					OperatorAddress:           mustBech32("osmovaloper", bytes.Repeat([]byte{0x01}, 20)),
+				},
+				{
+					Index:                     1,
+					VotingPowerDisplayPercent: 5,
+					Moniker:                   "Val",
+					OperatorAddress:           mustBech32("osmovaloper", bytes.Repeat([]byte{0x02}, 20)),
+				},
+			},
+		},
+		{
+			name: "not accept invalid operator address",
+			validators: types.StreamingLightValidators{
+				{
+					Index:           0,
+					OperatorAddress: mustBech32("cosmos", bytes.Repeat([]byte{0x01}, 20)),
+				},
+			},
+			wantErrEncodeField: "OperatorAddress",
+		},
+		{
+			name: "not accept invalid consensus address",
+			validators: types.StreamingLightValidators{
+				{
+					Index:            0,
+					ConsensusAddress: "0123",
+				},
+			},
+			wantErrEncodeField: "ConsensusAddress",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEncoded, err := cvpV7CodecImpl.TryEncodeStreamingLightValidators(tt.validators)
+			if tt.wantErrEncodeField != "" {
+				var encodeErr *EncodeError
+				if !errors.As(err, &encodeErr) {
+					t.Errorf("TryEncodeStreamingLightValidators() error = %v, want *EncodeError", err)
+				} else if encodeErr.Field != tt.wantErrEncodeField {
+					t.Errorf("TryEncodeStreamingLightValidators() error field = %s, want %s", encodeErr.Field, tt.wantErrEncodeField)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("TryEncodeStreamingLightValidators() error = %v", err)
+				return
+			}
+
+			if tt.wantEncodedSize > 0 && len(gotEncoded) != tt.wantEncodedSize {
+				t.Errorf("encoded size = %d, want %d", len(gotEncoded), tt.wantEncodedSize)
+			}
+
+			gotDecoded, err := cvpV7CodecImpl.DecodeStreamingLightValidators(gotEncoded)
+			if err != nil {
+				t.Errorf("DecodeStreamingLightValidators() error = %v", err)
+				return
+			}
+			if tt.wantDecodedOrUseInputAsWantDecoded == nil {
+				tt.wantDecodedOrUseInputAsWantDecoded = tt.validators
+			}
+			if !reflect.DeepEqual(gotDecoded, tt.wantDecodedOrUseInputAsWantDecoded) {
+				t.Errorf("DecodeStreamingLightValidators()\ngot = %v,\nwant %v", gotDecoded, tt.wantDecodedOrUseInputAsWantDecoded)
+			}
+		})
+	}
+}
+
+//goland:noinspection SpellCheckingInspection
+func Test_cvpCodecV7_MaxOperatorAddressBytes(t *testing.T) {
+	longestHrp := strings.Repeat("x", 51-len("valoper")) + "valoper"
+	validators := types.StreamingLightValidators{
+		{
+			Index:            0,
+			OperatorAddress:  mustBech32(longestHrp, bytes.Repeat([]byte{0x01}, 20)),
+			ConsensusAddress: "0123456789ABCDEF0123456789ABCDEF01234567",
+			Moniker:          strings.Repeat("x", CvpCodecV6DefaultMaxMonikerBytes),
+		},
+	}
+	bz, err := cvpV7CodecImpl.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		t.Errorf("TryEncodeStreamingLightValidators() error = %v", err)
+		return
+	}
+	wantSize := len(prefixDataEncodedByCvpCodecV7) + 2 + 2 + 2 + 1 + CvpCodecV6DefaultMaxMonikerBytes + 1 + cvpCodecV7MaxOperatorAddressBytes + types.ConsensusAddressBytes
+	if len(bz) != wantSize {
+		t.Errorf("encoded size = %d, want %d", len(bz), wantSize)
+	}
+}
+
+//goland:noinspection SpellCheckingInspection
+func Test_cvpCodecV7_DecodeStreamingLightValidators(t *testing.T) {
+	record := mergeBuffers([]byte{0, 0, 0, 1}, []byte{1}, []byte("V"))
+	tests := []struct {
+		name                  string
+		inputEncodedData      []byte
+		wantErrKind           error
+		wantErrField          string
+		wantErrOffset         int
+		wantErrDecodeContains string
+	}{
+		{
+			name:                  "bad prefix",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV6, []byte{0, 1}, record, []byte{0}),
+			wantErrKind:           ErrBadPrefix,
+			wantErrField:          "prefix",
+			wantErrOffset:         0,
+			wantErrDecodeContains: "bad encoding prefix",
+		},
+		{
+			name:                  "missing flags",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, []byte{0, 1}, record),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "flags",
+			wantErrOffset:         10,
+			wantErrDecodeContains: "missing address flags",
+		},
+		{
+			name:                  "unknown flags",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, []byte{0, 1}, record, []byte{0x4}),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "flags",
+			wantErrOffset:         10,
+			wantErrDecodeContains: "unknown address flags 0x04",
+		},
+		{
+			name:                  "unknown hrp table index",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, []byte{0, 1}, record, []byte{0x1}, []byte{0xFF}),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "OperatorAddress",
+			wantErrOffset:         11,
+			wantErrDecodeContains: "unknown hrp table index 255",
+		},
+		{
+			name:                  "missing operator address bytes",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, []byte{0, 1}, record, []byte{0x1}, []byte{0x1, 20}, bytes.Repeat([]byte{1}, 19)),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "OperatorAddress",
+			wantErrOffset:         12,
+			wantErrDecodeContains: "missing address bytes",
+		},
+		{
+			name:                  "invalid operator address length",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, []byte{0, 1}, record, []byte{0x1}, []byte{0x1, 19}, bytes.Repeat([]byte{1}, 19)),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "OperatorAddress",
+			wantErrOffset:         11,
+			wantErrDecodeContains: "invalid address length 19",
+		},
+		{
+			name:                  "custom hrp is not valoper",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, []byte{0, 1}, record, []byte{0x1}, []byte{0x0, 6}, []byte("cosmos"), []byte{20}, bytes.Repeat([]byte{1}, 20)),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "OperatorAddress",
+			wantErrOffset:         11,
+			wantErrDecodeContains: "not a validator operator address",
+		},
+		{
+			name:                  "missing consensus address",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, []byte{0, 1}, record, []byte{0x2}, bytes.Repeat([]byte{1}, 19)),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "ConsensusAddress",
+			wantErrOffset:         11,
+			wantErrDecodeContains: "missing consensus address",
+		},
+		{
+			name:                  "trailing bytes",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, []byte{0, 1}, record, []byte{0x2}, bytes.Repeat([]byte{1}, 21)),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "StreamingLightValidators",
+			wantErrOffset:         31,
+			wantErrDecodeContains: "unexpected trailing 1 bytes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cvpV7CodecImpl.DecodeStreamingLightValidators(tt.inputEncodedData)
+			if err == nil {
+				t.Errorf("DecodeStreamingLightValidators() expect error but got nil")
+				return
+			}
+			if !errors.Is(err, tt.wantErrKind) {
+				t.Errorf("DecodeStreamingLightValidators() error = %v, want matches %v", err, tt.wantErrKind)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Errorf("DecodeStreamingLightValidators() error = %T, want *DecodeError", err)
+				return
+			}
+			if decodeErr.Field != tt.wantErrField {
+				t.Errorf("DecodeStreamingLightValidators() error field = %s, want %s", decodeErr.Field, tt.wantErrField)
+			}
+			if decodeErr.Offset != tt.wantErrOffset {
+				t.Errorf("DecodeStreamingLightValidators() error offset = %d, want %d", decodeErr.Offset, tt.wantErrOffset)
+			}
+			if !strings.Contains(err.Error(), tt.wantErrDecodeContains) {
+				t.Errorf("DecodeStreamingLightValidators() error = %v, wantErr contains %v", err, tt.wantErrDecodeContains)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package codec
+
+import (
+	"bytes"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"math"
+)
+
+//goland:noinspection SpellCheckingInspection
+
+var _ CvpCodec = (*cvpCodecV8)(nil)
+
+const cvpCodecV8Separator byte = '|'
+
+var prefixDataEncodedByCvpCodecV8 = []byte{0x8, cvpCodecV8Separator}
+
+// cvpCodecV8VoteStateRecordSize is size of a vote state record:
+// 2 bytes index + 4 bytes pre-voted hash + 1 byte flag + 4 bytes pre-committed hash.
+const cvpCodecV8VoteStateRecordSize = 2 + 4 + 1 + 4
+
+// cvpCodecV8Limits is the same as v7 for light validators,
+// next block voting information is the same as v5 but with larger vote state records.
+var cvpCodecV8Limits = CvpCodecLimits{
+	MaxValidators:                             cvpCodecV7Limits.MaxValidators,
+	MaxValidatorIndex:                         cvpCodecV7Limits.MaxValidatorIndex,
+	MaxEncodedLightValidatorsBytes:            cvpCodecV7Limits.MaxEncodedLightValidatorsBytes,
+	MaxEncodedNextBlockVotingInformationBytes: len(prefixDataEncodedByCvpCodecV8) + 1 + cvpCodecV5MaxHeightRoundStepLength /*height round step*/ + 4 /*duration*/ + 2 + 2 /*percents*/ + 2 /*count*/ + math.MaxUint16*cvpCodecV8VoteStateRecordSize,
+}
+
+// nextBlockVotingInformationFormatOfV8 is the format of v8: the same as v5,
+// but the vote state record body is v2 body followed by the pre-committed fingerprint block hash.
+var nextBlockVotingInformationFormatOfV8 = nextBlockVotingInformationFormatV5{
+	validateHeightRoundStep: validateHeightRoundStepForEncodingV5,
+	writeHeightRoundStep:    writeHeightRoundStepV5,
+	readHeightRoundStep:     readHeightRoundStepV5,
+	voteStateBodySize:       cvpCodecV8VoteStateRecordSize - 2,
+	writeVoteStateBody:      writeValidatorVoteStateBodyV8,
+	readVoteStateBody:       readValidatorVoteStateBodyV8,
+}
+
+type cvpCodecV8 struct {
+}
+
+// GetCvpCodecV8 returns new instance of v8 implementation of CvpCodec.
+//
+// V8 is the same as v7, plus the pre-committed fingerprint block hash of each validator vote state.
+func GetCvpCodecV8() CvpCodec {
+	return cvpCodecV8{}
+}
+
+func (c cvpCodecV8) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV8) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	return encodeStreamingLightValidatorsV7(validators, CvpCodecVersionV8, prefixDataEncodedByCvpCodecV8)
+}
+
+func (c cvpCodecV8) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	return decodeStreamingLightValidatorsV7(bz, CvpCodecVersionV8, prefixDataEncodedByCvpCodecV8)
+}
+
+func (c cvpCodecV8) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV8) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	return encodeStreamingNextBlockVotingInformationWithFormatV5(inf, CvpCodecVersionV8, prefixDataEncodedByCvpCodecV8, nextBlockVotingInformationFormatOfV8)
+}
+
+func (c cvpCodecV8) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	return decodeStreamingNextBlockVotingInformationWithFormatV5(bz, CvpCodecVersionV8, prefixDataEncodedByCvpCodecV8, nextBlockVotingInformationFormatOfV8)
+}
+
+func (c cvpCodecV8) GetLimits() CvpCodecLimits {
+	return cvpCodecV8Limits
+}
+
+func (c cvpCodecV8) GetVersion() CvpCodecVersion {
+	return CvpCodecVersionV8
+}
+
+// writeValidatorVoteStateBodyV8 writes the v2 vote state body, followed by 4 bytes pre-committed fingerprint block hash,
+// which is "----" when the hash is empty.
+func writeValidatorVoteStateBodyV8(b *bytes.Buffer, v types.StreamingValidatorVoteState, version CvpCodecVersion) error {
+	if err := writeValidatorVoteStateBodyV2(b, v, version); err != nil {
+		return err
+	}
+
+	if len(v.PreCommittedBlockHash) == 0 {
+		b.Write([]byte("----"))
+	} else if !regexpPreVotedFingerprintBlockHash.MatchString(v.PreCommittedBlockHash) {
+		return newEncodeError(version, "PreCommittedBlockHash", "invalid pre-committed fingerprint block hash: %s, must be 2 bytes", v.PreCommittedBlockHash)
+	} else {
+		b.Write([]byte(v.PreCommittedBlockHash))
+	}
+
+	return nil
+}
+
+// readValidatorVoteStateBodyV8 is the reverse of writeValidatorVoteStateBodyV8,
+// the input buffer must be exactly 9 bytes, offset is the position of the buffer in the encoded data, for error reporting.
+func readValidatorVoteStateBodyV8(bz []byte, validatorIndex int, version CvpCodecVersion, offset int) (types.StreamingValidatorVoteState, error) {
+	validatorVoteState, err := readValidatorVoteStateBodyV2(bz[:5], validatorIndex, version, offset)
+	if err != nil {
+		return types.StreamingValidatorVoteState{}, err
+	}
+
+	preCommittedBlockHash := string(bz[5:9])
+	if preCommittedBlockHash != "----" {
+		if !regexpPreVotedFingerprintBlockHash.MatchString(preCommittedBlockHash) {
+			return types.StreamingValidatorVoteState{}, newDecodeError(version, ErrInvalidField, "PreCommittedBlockHash", offset+5, "invalid pre-committed fingerprint block hash: %s, must be 2 bytes", preCommittedBlockHash)
+		}
+		validatorVoteState.PreCommittedBlockHash = preCommittedBlockHash
+	}
+
+	return validatorVoteState, nil
+}
@@ -0,0 +1,220 @@
+package codec
+
+import (
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cvpV8CodecImpl = GetCvpCodecV8()
+
+func Test_cvpCodecV8_EncodeDecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	newInformation := func(preCommittedBlockHash string) *types.StreamingNextBlockVotingInformation {
+		return &types.StreamingNextBlockVotingInformation{
+			HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
+			Duration:              3 * time.Second,
+			PreVotedPercent:       50,
+			PreCommitVotedPercent: 25.5,
+			ValidatorVoteStates: []types.StreamingValidatorVoteState{
+				{
+					ValidatorIndex:        0,
+					PreVotedBlockHash:     "C0FF",
+					PreVoted:              true,
+					PreCommitVoted:        true,
+					PreCommittedBlockHash: preCommittedBlockHash,
+				},
+			},
+		}
+	}
+
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name               string
+		inf                *types.StreamingNextBlockVotingInformation
+		wantErrEncodeField string
+		wantEncodedSize    int // if positive, check size of encoded data
+	}{
+		{
+			name:            "pre-committed block hash",
+			inf:             newInformation("C0FF"),
+			wantEncodedSize: 2 + 1 + 5 + 4 + 4 + 2 + cvpCodecV8VoteStateRecordSize,
+		},
+		{
+			name: "split pre-commits",
+			inf: func() *types.StreamingNextBlockVotingInformation {
+				inf := newInformation("C0FF")
+				inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, types.StreamingValidatorVoteState{
+					ValidatorIndex:        1,
+					PreVotedBlockHash:     "C0FF",
+					PreVoted:              true,
+					PreCommitVoted:        true,
+					PreCommittedBlockHash: "beef",
+				})
+				return inf
+			}(),
+		},
+		{
+			name: "empty pre-committed block hash",
+			inf:  newInformation(""),
+		},
+		{
+			name:               "not accept pre-committed block hash length is not 0 or 4",
+			inf:                newInformation("C0F"),
+			wantErrEncodeField: "PreCommittedBlockHash",
+		},
+		{
+			name:               "not accept non-hex pre-committed block hash",
+			inf:                newInformation("C0FG"),
+			wantErrEncodeField: "PreCommittedBlockHash",
+		},
+		{
+			name:               "not accept placeholder as pre-committed block hash",
+			inf:                newInformation("----"),
+			wantErrEncodeField: "PreCommittedBlockHash",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEncoded, err := cvpV8CodecImpl.TryEncodeStreamingNextBlockVotingInformation(tt.inf)
+			if tt.wantErrEncodeField != "" {
+				var encodeErr *EncodeError
+				if !errors.As(err, &encodeErr) {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %v, want *EncodeError", err)
+				} else if encodeErr.Field != tt.wantErrEncodeField {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error field = %s, want %s", encodeErr.Field, tt.wantErrEncodeField)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+
+			if tt.wantEncodedSize > 0 && len(gotEncoded) != tt.wantEncodedSize {
+				t.Errorf("encoded size = %d, want %d", len(gotEncoded), tt.wantEncodedSize)
+			}
+
+			gotDecoded, err := cvpV8CodecImpl.DecodeStreamingNextBlockVotingInformation(gotEncoded)
+			if err != nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(gotDecoded, tt.inf) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation()\ngot = %v,\nwant %v", gotDecoded, tt.inf)
+			}
+		})
+	}
+}
+
+func Test_cvpCodecV8_PreCommittedBlockHashNotEncodedByOlderVersions(t *testing.T) {
+	inf := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.MustParseHeightRoundStep("1/2/3"),
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{
+				ValidatorIndex:        0,
+				PreVotedBlockHash:     "C0FF",
+				PreVoted:              true,
+				PreCommitVoted:        true,
+				PreCommittedBlockHash: "C0FF",
+			},
+		},
+	}
+
+	for _, codec := range []CvpCodec{cvpV1CodecImpl, cvpV2CodecImpl, cvpV3CodecImpl, cvpV4CodecImpl, cvpV5CodecImpl, cvpV6CodecImpl, cvpV7CodecImpl, cvpV8CodecImpl, cvpV9CodecImpl, cvpDeltaCodecImpl} {
+		t.Run(string(codec.GetVersion()), func(t *testing.T) {
+			gotDecoded, err := cvpProxyCodecImpl.DecodeStreamingNextBlockVotingInformation(codec.EncodeStreamingNextBlockVotingInformation(inf))
+			if err != nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+
+			want := ""
+			if codec.GetVersion() == CvpCodecVersionV8 || codec.GetVersion() == CvpCodecVersionV9 {
+				want = "C0FF"
+			}
+			if got := gotDecoded.ValidatorVoteStates[0].PreCommittedBlockHash; got != want {
+				t.Errorf("PreCommittedBlockHash = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+//goland:noinspection SpellCheckingInspection
+func Test_cvpCodecV8_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	header := mergeBuffers(
+		prefixDataEncodedByCvpCodecV8,
+		[]byte{5}, []byte("1/2/3"),
+		[]byte{0, 0, 0, 1},
+		[]byte{1, 0}, []byte{2, 0},
+	)
+	tests := []struct {
+		name                  string
+		inputEncodedData      []byte
+		wantErrKind           error
+		wantErrField          string
+		wantErrOffset         int
+		wantErrDecodeContains string
+	}{
+		{
+			name:                  "bad prefix",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV7, header[2:], []byte{0, 1}, []byte{0, 0}, []byte("C0FF"), []byte("C"), []byte("C0FF")),
+			wantErrKind:           ErrBadPrefix,
+			wantErrField:          "prefix",
+			wantErrOffset:         0,
+			wantErrDecodeContains: "bad encoding prefix",
+		},
+		{
+			name:                  "v5 vote state record size is truncated",
+			inputEncodedData:      mergeBuffers(header, []byte{0, 1}, []byte{0, 0}, []byte("C0FF"), []byte("C")),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "ValidatorVoteStates",
+			wantErrOffset:         18,
+			wantErrDecodeContains: "invalid validator vote states length",
+		},
+		{
+			name:                  "invalid pre-committed block hash",
+			inputEncodedData:      mergeBuffers(header, []byte{0, 1}, []byte{0, 0}, []byte("C0FF"), []byte("C"), []byte("C0FG")),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "PreCommittedBlockHash",
+			wantErrOffset:         25,
+			wantErrDecodeContains: "invalid pre-committed fingerprint block hash: C0FG",
+		},
+		{
+			name:                  "invalid vote flag",
+			inputEncodedData:      mergeBuffers(header, []byte{0, 1}, []byte{0, 0}, []byte("C0FF"), []byte("?"), []byte("C0FF")),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "VoteFlag",
+			wantErrOffset:         24,
+			wantErrDecodeContains: "invalid validator vote flag",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cvpV8CodecImpl.DecodeStreamingNextBlockVotingInformation(tt.inputEncodedData)
+			if err == nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() expect error but got nil")
+				return
+			}
+			if !errors.Is(err, tt.wantErrKind) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, want matches %v", err, tt.wantErrKind)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %T, want *DecodeError", err)
+				return
+			}
+			if decodeErr.Field != tt.wantErrField {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error field = %s, want %s", decodeErr.Field, tt.wantErrField)
+			}
+			if decodeErr.Offset != tt.wantErrOffset {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error offset = %d, want %d", decodeErr.Offset, tt.wantErrOffset)
+			}
+			if !strings.Contains(err.Error(), tt.wantErrDecodeContains) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, wantErr contains %v", err, tt.wantErrDecodeContains)
+			}
+		})
+	}
+}
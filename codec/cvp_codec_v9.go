@@ -0,0 +1,136 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"math"
+)
+
+//goland:noinspection SpellCheckingInspection
+
+var _ CvpCodec = (*cvpCodecV9)(nil)
+
+const cvpCodecV9Separator byte = '|'
+
+var prefixDataEncodedByCvpCodecV9 = []byte{0x9, cvpCodecV9Separator}
+
+// cvpCodecV9MaxHeightRoundStepBytes is the maximum size of an encoded height round step:
+// 9 bytes uvarint of non-negative int64 height, 5 bytes uvarint of non-negative int32 round and 1 byte step.
+const cvpCodecV9MaxHeightRoundStepBytes = 9 + 5 + 1
+
+// cvpCodecV9Limits is the same as v8, except the height round step which is encoded as binary.
+var cvpCodecV9Limits = CvpCodecLimits{
+	MaxValidators:                             cvpCodecV8Limits.MaxValidators,
+	MaxValidatorIndex:                         cvpCodecV8Limits.MaxValidatorIndex,
+	MaxEncodedLightValidatorsBytes:            cvpCodecV8Limits.MaxEncodedLightValidatorsBytes,
+	MaxEncodedNextBlockVotingInformationBytes: len(prefixDataEncodedByCvpCodecV9) + cvpCodecV9MaxHeightRoundStepBytes + 4 /*duration*/ + 2 + 2 /*percents*/ + 2 /*count*/ + math.MaxUint16*cvpCodecV8VoteStateRecordSize,
+}
+
+// nextBlockVotingInformationFormatOfV9 is the format of v9: the same as v8, but the height round step is binary.
+var nextBlockVotingInformationFormatOfV9 = nextBlockVotingInformationFormatV5{
+	validateHeightRoundStep: validateHeightRoundStepForEncoding,
+	writeHeightRoundStep:    writeHeightRoundStepV9,
+	readHeightRoundStep:     readHeightRoundStepV9,
+	voteStateBodySize:       cvpCodecV8VoteStateRecordSize - 2,
+	writeVoteStateBody:      writeValidatorVoteStateBodyV8,
+	readVoteStateBody:       readValidatorVoteStateBodyV8,
+}
+
+type cvpCodecV9 struct {
+}
+
+// GetCvpCodecV9 returns new instance of v9 implementation of CvpCodec.
+//
+// V9 is the same as v8, except the height round step of next block voting information
+// is encoded as uvarint height, uvarint round and 1 byte step instead of text.
+func GetCvpCodecV9() CvpCodec {
+	return cvpCodecV9{}
+}
+
+func (c cvpCodecV9) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
+	bz, err := c.TryEncodeStreamingLightValidators(validators)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV9) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	return encodeStreamingLightValidatorsV7(validators, CvpCodecVersionV9, prefixDataEncodedByCvpCodecV9)
+}
+
+func (c cvpCodecV9) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	return decodeStreamingLightValidatorsV7(bz, CvpCodecVersionV9, prefixDataEncodedByCvpCodecV9)
+}
+
+func (c cvpCodecV9) EncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) []byte {
+	bz, err := c.TryEncodeStreamingNextBlockVotingInformation(inf)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+func (c cvpCodecV9) TryEncodeStreamingNextBlockVotingInformation(inf *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	return encodeStreamingNextBlockVotingInformationWithFormatV5(inf, CvpCodecVersionV9, prefixDataEncodedByCvpCodecV9, nextBlockVotingInformationFormatOfV9)
+}
+
+func (c cvpCodecV9) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
+	return decodeStreamingNextBlockVotingInformationWithFormatV5(bz, CvpCodecVersionV9, prefixDataEncodedByCvpCodecV9, nextBlockVotingInformationFormatOfV9)
+}
+
+func (c cvpCodecV9) GetLimits() CvpCodecLimits {
+	return cvpCodecV9Limits
+}
+
+func (c cvpCodecV9) GetVersion() CvpCodecVersion {
+	return CvpCodecVersionV9
+}
+
+// writeHeightRoundStepV9 writes the height and round as uvarint, then 1 byte step.
+// The height round step is assumed to be valid.
+func writeHeightRoundStepV9(b *bytes.Buffer, hrs types.HeightRoundStep) {
+	b.Write(binary.AppendUvarint(nil, uint64(hrs.Height)))
+	b.Write(binary.AppendUvarint(nil, uint64(hrs.Round)))
+	b.WriteByte(byte(hrs.Step))
+}
+
+// readHeightRoundStepV9 is the reverse of writeHeightRoundStepV9, returns the height round step
+// and the offset right after it.
+func readHeightRoundStepV9(bz []byte, version CvpCodecVersion, offset int) (types.HeightRoundStep, int, error) {
+	beginOffset := offset
+
+	height, n := binary.Uvarint(bz[offset:])
+	if n == 0 {
+		return types.HeightRoundStep{}, offset, newDecodeError(version, ErrTruncated, "HeightRoundStep", offset, "missing height")
+	}
+	if n < 0 || height > math.MaxInt64 {
+		return types.HeightRoundStep{}, offset, newDecodeError(version, ErrInvalidField, "HeightRoundStep", offset, "height overflow")
+	}
+	offset += n
+
+	round, n := binary.Uvarint(bz[offset:])
+	if n == 0 {
+		return types.HeightRoundStep{}, offset, newDecodeError(version, ErrTruncated, "HeightRoundStep", offset, "missing round")
+	}
+	if n < 0 || round > math.MaxInt32 {
+		return types.HeightRoundStep{}, offset, newDecodeError(version, ErrInvalidField, "HeightRoundStep", offset, "round overflow")
+	}
+	offset += n
+
+	if offset >= len(bz) {
+		return types.HeightRoundStep{}, offset, newDecodeError(version, ErrTruncated, "HeightRoundStep", offset, "missing step")
+	}
+	hrs := types.HeightRoundStep{
+		Height: int64(height),
+		Round:  int32(round),
+		Step:   types.RoundStepType(bz[offset]),
+	}
+	if err := hrs.Validate(); err != nil {
+		return types.HeightRoundStep{}, beginOffset, wrapDecodeError(version, ErrInvalidField, "HeightRoundStep", beginOffset, err, "invalid height round step")
+	}
+	offset++
+
+	return hrs, offset, nil
+}
@@ -0,0 +1,199 @@
+package codec
+
+import (
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var cvpV9CodecImpl = GetCvpCodecV9()
+
+func Test_cvpCodecV9_EncodeDecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	newInformation := func(hrs types.HeightRoundStep) *types.StreamingNextBlockVotingInformation {
+		return &types.StreamingNextBlockVotingInformation{
+			HeightRoundStep:       hrs,
+			Duration:              3 * time.Second,
+			PreVotedPercent:       50,
+			PreCommitVotedPercent: 25.5,
+			ValidatorVoteStates: []types.StreamingValidatorVoteState{
+				{
+					ValidatorIndex:        0,
+					PreVotedBlockHash:     "C0FF",
+					PreVoted:              true,
+					PreCommitVoted:        true,
+					PreCommittedBlockHash: "C0FF",
+				},
+			},
+		}
+	}
+
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name               string
+		inf                *types.StreamingNextBlockVotingInformation
+		wantErrEncodeField string
+		wantEncodedSize    int // if positive, check size of encoded data
+	}{
+		{
+			name:            "small height round step",
+			inf:             newInformation(types.HeightRoundStep{Height: 100, Round: 0, Step: types.RoundStepPropose}),
+			wantEncodedSize: 2 + (1 + 1 + 1) + 4 + 4 + 2 + cvpCodecV8VoteStateRecordSize,
+		},
+		{
+			name:            "typical height round step",
+			inf:             newInformation(types.HeightRoundStep{Height: 19_999_999, Round: 1, Step: types.RoundStepPrecommit}),
+			wantEncodedSize: 2 + (4 + 1 + 1) + 4 + 4 + 2 + cvpCodecV8VoteStateRecordSize,
+		},
+		{
+			name:            "largest height round step",
+			inf:             newInformation(types.HeightRoundStep{Height: math.MaxInt64, Round: math.MaxInt32, Step: types.RoundStepCommit}),
+			wantEncodedSize: 2 + cvpCodecV9MaxHeightRoundStepBytes + 4 + 4 + 2 + cvpCodecV8VoteStateRecordSize,
+		},
+		{
+			name:               "not accept negative height",
+			inf:                newInformation(types.HeightRoundStep{Height: -1, Round: 0, Step: types.RoundStepPropose}),
+			wantErrEncodeField: "HeightRoundStep",
+		},
+		{
+			name:               "not accept unknown step",
+			inf:                newInformation(types.HeightRoundStep{Height: 1, Round: 0, Step: 0}),
+			wantErrEncodeField: "HeightRoundStep",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotEncoded, err := cvpV9CodecImpl.TryEncodeStreamingNextBlockVotingInformation(tt.inf)
+			if tt.wantErrEncodeField != "" {
+				var encodeErr *EncodeError
+				if !errors.As(err, &encodeErr) {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %v, want *EncodeError", err)
+				} else if encodeErr.Field != tt.wantErrEncodeField {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error field = %s, want %s", encodeErr.Field, tt.wantErrEncodeField)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+
+			if tt.wantEncodedSize > 0 && len(gotEncoded) != tt.wantEncodedSize {
+				t.Errorf("encoded size = %d, want %d", len(gotEncoded), tt.wantEncodedSize)
+			}
+
+			gotDecoded, err := cvpV9CodecImpl.DecodeStreamingNextBlockVotingInformation(gotEncoded)
+			if err != nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(gotDecoded, tt.inf) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation()\ngot = %v,\nwant %v", gotDecoded, tt.inf)
+			}
+		})
+	}
+}
+
+//goland:noinspection SpellCheckingInspection
+func Test_cvpCodecV9_DecodeStreamingNextBlockVotingInformation(t *testing.T) {
+	tail := mergeBuffers(
+		[]byte{0, 0, 0, 1},
+		[]byte{1, 0}, []byte{2, 0},
+		[]byte{0, 1},
+		[]byte{0, 0}, []byte("C0FF"), []byte("C"), []byte("C0FF"),
+	)
+	tests := []struct {
+		name                  string
+		inputEncodedData      []byte
+		wantErrKind           error
+		wantErrField          string
+		wantErrOffset         int
+		wantErrDecodeContains string
+	}{
+		{
+			name:                  "text height round step of v8",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV8, []byte{5}, []byte("1/2/3"), tail),
+			wantErrKind:           ErrBadPrefix,
+			wantErrField:          "prefix",
+			wantErrOffset:         0,
+			wantErrDecodeContains: "bad encoding prefix",
+		},
+		{
+			name:                  "missing height",
+			inputEncodedData:      prefixDataEncodedByCvpCodecV9,
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "HeightRoundStep",
+			wantErrOffset:         2,
+			wantErrDecodeContains: "missing height",
+		},
+		{
+			name:                  "height overflow",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV9, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01}, []byte{0}, []byte{3}, tail),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "HeightRoundStep",
+			wantErrOffset:         2,
+			wantErrDecodeContains: "height overflow",
+		},
+		{
+			name:                  "missing round",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV9, []byte{100}),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "HeightRoundStep",
+			wantErrOffset:         3,
+			wantErrDecodeContains: "missing round",
+		},
+		{
+			name:                  "round overflow",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV9, []byte{100}, []byte{0x80, 0x80, 0x80, 0x80, 0x08}, []byte{3}, tail),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "HeightRoundStep",
+			wantErrOffset:         3,
+			wantErrDecodeContains: "round overflow",
+		},
+		{
+			name:                  "missing step",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV9, []byte{100}, []byte{0}),
+			wantErrKind:           ErrTruncated,
+			wantErrField:          "HeightRoundStep",
+			wantErrOffset:         4,
+			wantErrDecodeContains: "missing step",
+		},
+		{
+			name:                  "unknown step",
+			inputEncodedData:      mergeBuffers(prefixDataEncodedByCvpCodecV9, []byte{100}, []byte{0}, []byte{9}, tail),
+			wantErrKind:           ErrInvalidField,
+			wantErrField:          "HeightRoundStep",
+			wantErrOffset:         2,
+			wantErrDecodeContains: "invalid step 9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cvpV9CodecImpl.DecodeStreamingNextBlockVotingInformation(tt.inputEncodedData)
+			if err == nil {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() expect error but got nil")
+				return
+			}
+			if !errors.Is(err, tt.wantErrKind) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, want matches %v", err, tt.wantErrKind)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %T, want *DecodeError", err)
+				return
+			}
+			if decodeErr.Field != tt.wantErrField {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error field = %s, want %s", decodeErr.Field, tt.wantErrField)
+			}
+			if decodeErr.Offset != tt.wantErrOffset {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error offset = %d, want %d", decodeErr.Offset, tt.wantErrOffset)
+			}
+			if !strings.Contains(err.Error(), tt.wantErrDecodeContains) {
+				t.Errorf("DecodeStreamingNextBlockVotingInformation() error = %v, wantErr contains %v", err, tt.wantErrDecodeContains)
+			}
+		})
+	}
+}
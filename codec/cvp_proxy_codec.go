@@ -13,8 +13,14 @@ var _ CvpCodec = (*proxyCvpCodec)(nil)
 // The proxy automatically detect version of encoded data and forward to the corresponding implementation for decoding.
 //
 // When invoking encode functions, it forward to default CvpCodec.
+//
+// Delta frames built on top of a base frame can not be decoded by the proxy,
+// use CvpDeltaCodec.ApplyStreamingNextBlockVotingInformationDelta instead.
 type proxyCvpCodec struct {
 	cvpCodecImpl CvpCodec
+
+	// allowedVersions restricts versions accepted for decoding, nil means all registered versions are accepted.
+	allowedVersions map[CvpCodecVersion]bool
 }
 
 // NewProxyCvpCodec returns new instance of proxy CvpCodec.
@@ -42,51 +48,117 @@ func WrapCvpCodecInProxy(inner CvpCodec) CvpCodec {
 	}
 }
 
+// WrapCvpCodecInProxyWithAllowedVersions wraps a CvpCodec into a proxy CvpCodec
+// which only decodes data encoded by one of the allowed versions,
+// data encoded by other versions are rejected with error matches ErrVersionNotAllowed.
+//
+// When invoking encode functions, it forward to the provided version, which must be one of the allowed versions.
+func WrapCvpCodecInProxyWithAllowedVersions(inner CvpCodec, allowedVersions ...CvpCodecVersion) CvpCodec {
+	if _, ok := inner.(proxyCvpCodec); ok {
+		panic(fmt.Errorf("can not wrap proxy CvpCodec into another proxy CvpCodec"))
+	}
+	if len(allowedVersions) < 1 {
+		panic(fmt.Errorf("require at least one allowed version"))
+	}
+	allowed := make(map[CvpCodecVersion]bool, len(allowedVersions))
+	for _, version := range allowedVersions {
+		if _, found := GetRegisteredCvpCodec(version); !found {
+			panic(fmt.Errorf("codec version %s had not been registered", version))
+		}
+		allowed[version] = true
+	}
+	if !allowed[inner.GetVersion()] {
+		panic(fmt.Errorf("version %s of the wrapped CvpCodec is not allowed", inner.GetVersion()))
+	}
+	return proxyCvpCodec{
+		cvpCodecImpl:    inner,
+		allowedVersions: allowed,
+	}
+}
+
+// findDecoder returns the registered CvpCodec which is able to decode the given data.
+func (p proxyCvpCodec) findDecoder(bz []byte) (CvpCodec, error) {
+	registration, found := findRegisteredCvpCodecByPrefix(bz)
+	if !found {
+		return nil, newDecodeError(CvpCodecVersionUnknown, ErrBadPrefix, "prefix", 0, "unable to detect encoder version")
+	}
+	if p.allowedVersions != nil && !p.allowedVersions[registration.version] {
+		return nil, newDecodeError(registration.version, ErrVersionNotAllowed, "prefix", 0, "encoder version %s is not allowed", registration.version)
+	}
+	return registration.codec, nil
+}
+
 func (p proxyCvpCodec) EncodeStreamingLightValidators(validators types.StreamingLightValidators) []byte {
 	return p.cvpCodecImpl.EncodeStreamingLightValidators(validators)
 }
 
+func (p proxyCvpCodec) TryEncodeStreamingLightValidators(validators types.StreamingLightValidators) ([]byte, error) {
+	return p.cvpCodecImpl.TryEncodeStreamingLightValidators(validators)
+}
+
 func (p proxyCvpCodec) DecodeStreamingLightValidators(bz []byte) (types.StreamingLightValidators, error) {
-	possibleVersion, detected := DetectEncodingVersion(bz)
-	if detected {
-		switch possibleVersion {
-		case CvpCodecVersionV3:
-			return GetCvpCodecV3().DecodeStreamingLightValidators(bz)
-		case CvpCodecVersionV2:
-			return GetCvpCodecV2().DecodeStreamingLightValidators(bz)
-		case CvpCodecVersionV1:
-			//goland:noinspection GoDeprecation
-			return GetCvpCodecV1().DecodeStreamingLightValidators(bz)
-		}
+	decoder, err := p.findDecoder(bz)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, fmt.Errorf("unable to detect encoder version")
+	return decoder.DecodeStreamingLightValidators(bz)
 }
 
 func (p proxyCvpCodec) EncodeStreamingNextBlockVotingInformation(information *types.StreamingNextBlockVotingInformation) []byte {
 	return p.cvpCodecImpl.EncodeStreamingNextBlockVotingInformation(information)
 }
 
-var regexpHeightRoundStep = regexp.MustCompile(`^\d+/\d+/\d+$`)
+func (p proxyCvpCodec) TryEncodeStreamingNextBlockVotingInformation(information *types.StreamingNextBlockVotingInformation) ([]byte, error) {
+	return p.cvpCodecImpl.TryEncodeStreamingNextBlockVotingInformation(information)
+}
+
 var regexpPreVotedFingerprintBlockHash = regexp.MustCompile(`^[a-fA-F\d]{4}$`)
 
 func (p proxyCvpCodec) DecodeStreamingNextBlockVotingInformation(bz []byte) (*types.StreamingNextBlockVotingInformation, error) {
-	possibleVersion, detected := DetectEncodingVersion(bz)
-	if detected {
-		switch possibleVersion {
-		case CvpCodecVersionV3:
-			return GetCvpCodecV3().DecodeStreamingNextBlockVotingInformation(bz)
-		case CvpCodecVersionV2:
-			return GetCvpCodecV2().DecodeStreamingNextBlockVotingInformation(bz)
-		case CvpCodecVersionV1:
-			//goland:noinspection GoDeprecation
-			return GetCvpCodecV1().DecodeStreamingNextBlockVotingInformation(bz)
-		}
+	decoder, err := p.findDecoder(bz)
+	if err != nil {
+		return nil, err
 	}
+	return decoder.DecodeStreamingNextBlockVotingInformation(bz)
+}
 
-	return nil, fmt.Errorf("unable to detect encoder version")
+func (p proxyCvpCodec) GetLimits() CvpCodecLimits {
+	return p.cvpCodecImpl.GetLimits()
 }
 
 func (p proxyCvpCodec) GetVersion() CvpCodecVersion {
 	return p.cvpCodecImpl.GetVersion()
 }
+
+// GetDecodingLimits returns the limits of the data the CvpCodec is able to decode.
+//
+// For proxy CvpCodec, it is the largest of each limit over the versions accepted for decoding,
+// unlike GetLimits which returns the limits of the encoder version.
+// For other CvpCodec, it is the same as GetLimits.
+func GetDecodingLimits(cvpCodec CvpCodec) CvpCodecLimits {
+	proxy, ok := cvpCodec.(proxyCvpCodec)
+	if !ok {
+		return cvpCodec.GetLimits()
+	}
+
+	limits := proxy.cvpCodecImpl.GetLimits()
+	for _, version := range GetRegisteredCvpCodecVersions() {
+		if proxy.allowedVersions != nil && !proxy.allowedVersions[version] {
+			continue
+		}
+		registered, _ := GetRegisteredCvpCodec(version)
+		decoderLimits := registered.GetLimits()
+		limits.MaxValidators = maxInt(limits.MaxValidators, decoderLimits.MaxValidators)
+		limits.MaxValidatorIndex = maxInt(limits.MaxValidatorIndex, decoderLimits.MaxValidatorIndex)
+		limits.MaxEncodedLightValidatorsBytes = maxInt(limits.MaxEncodedLightValidatorsBytes, decoderLimits.MaxEncodedLightValidatorsBytes)
+		limits.MaxEncodedNextBlockVotingInformationBytes = maxInt(limits.MaxEncodedNextBlockVotingInformationBytes, decoderLimits.MaxEncodedNextBlockVotingInformationBytes)
+	}
+	return limits
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
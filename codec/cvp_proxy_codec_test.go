@@ -2,6 +2,7 @@ package codec
 
 import (
 	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -16,6 +17,13 @@ func Test_proxyCvpCodec_WrapCvpCodecInProxy(t *testing.T) {
 		_ = WrapCvpCodecInProxy(GetCvpCodecV1())
 		_ = WrapCvpCodecInProxy(GetCvpCodecV2())
 		_ = WrapCvpCodecInProxy(GetCvpCodecV3())
+		_ = WrapCvpCodecInProxy(GetCvpCodecV4())
+		_ = WrapCvpCodecInProxy(GetCvpCodecV5())
+		_ = WrapCvpCodecInProxy(GetCvpCodecV6())
+		_ = WrapCvpCodecInProxy(GetCvpCodecV7())
+		_ = WrapCvpCodecInProxy(GetCvpCodecV8())
+		_ = WrapCvpCodecInProxy(GetCvpCodecV9())
+		_ = WrapCvpCodecInProxy(GetCvpDeltaCodec())
 	})
 	t.Run("can not wrap proxy codec", func(t *testing.T) {
 		defer func() {
@@ -96,6 +104,13 @@ func Test_proxyCvpCodec_EncodeDecodeStreamingLightValidators(t *testing.T) {
 				testDetect(cvpV1CodecImpl)
 				testDetect(cvpV2CodecImpl)
 				testDetect(cvpV3CodecImpl)
+				testDetect(cvpV4CodecImpl)
+				testDetect(cvpV5CodecImpl)
+				testDetect(cvpV6CodecImpl)
+				testDetect(cvpV7CodecImpl)
+				testDetect(cvpV8CodecImpl)
+				testDetect(cvpV9CodecImpl)
+				testDetect(cvpDeltaCodecImpl)
 			} else {
 				t.Errorf("DecodeStreamingLightValidators()\ngotDecoded = %v\nwant %v", gotDecoded, tt.want)
 			}
@@ -123,7 +138,7 @@ func Test_proxyCvpCodec_EncodeDecodeStreamingNextBlockVotingInformation(t *testi
 		{
 			name: "can codec",
 			input: &types.StreamingNextBlockVotingInformation{
-				HeightRoundStep:       "1/2/3",
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
 				Duration:              1 * time.Second,
 				PreVotedPercent:       1,
 				PreCommitVotedPercent: 2.54,
@@ -179,6 +194,13 @@ func Test_proxyCvpCodec_EncodeDecodeStreamingNextBlockVotingInformation(t *testi
 				testDetect(cvpV1CodecImpl)
 				testDetect(cvpV2CodecImpl)
 				testDetect(cvpV3CodecImpl)
+				testDetect(cvpV4CodecImpl)
+				testDetect(cvpV5CodecImpl)
+				testDetect(cvpV6CodecImpl)
+				testDetect(cvpV7CodecImpl)
+				testDetect(cvpV8CodecImpl)
+				testDetect(cvpV9CodecImpl)
+				testDetect(cvpDeltaCodecImpl)
 			} else {
 				t.Errorf("DecodeStreamingNextBlockVotingInformation()\ngotDecoded = %v\nwant %v", gotDecoded, tt.input)
 			}
@@ -196,3 +218,109 @@ func Test_proxyCvpCodec_EncodeDecodeStreamingNextBlockVotingInformation(t *testi
 		}
 	})
 }
+
+func Test_proxyCvpCodec_WrapCvpCodecInProxyWithAllowedVersions(t *testing.T) {
+	t.Run("can not wrap with invalid allowed versions", func(t *testing.T) {
+		tests := []struct {
+			name            string
+			inner           CvpCodec
+			allowedVersions []CvpCodecVersion
+		}{
+			{
+				name:            "wrap proxy codec",
+				inner:           NewProxyCvpCodec(),
+				allowedVersions: []CvpCodecVersion{CvpCodecVersionV3},
+			},
+			{
+				name:            "empty allowed versions",
+				inner:           GetCvpCodecV3(),
+				allowedVersions: nil,
+			},
+			{
+				name:            "unregistered version",
+				inner:           GetCvpCodecV3(),
+				allowedVersions: []CvpCodecVersion{CvpCodecVersionV3, "v999"},
+			},
+			{
+				name:            "version of the inner codec is not allowed",
+				inner:           GetCvpCodecV3(),
+				allowedVersions: []CvpCodecVersion{CvpCodecVersionV2},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("WrapCvpCodecInProxyWithAllowedVersions() did not panic")
+					}
+				}()
+				_ = WrapCvpCodecInProxyWithAllowedVersions(tt.inner, tt.allowedVersions...)
+			})
+		}
+	})
+
+	t.Run("reject not allowed versions", func(t *testing.T) {
+		proxy := WrapCvpCodecInProxyWithAllowedVersions(GetCvpCodecV3(), CvpCodecVersionV2, CvpCodecVersionV3)
+
+		validators := types.StreamingLightValidators{
+			{
+				Index:                     0,
+				VotingPowerDisplayPercent: 1,
+				Moniker:                   "Val1",
+			},
+		}
+		information := &types.StreamingNextBlockVotingInformation{
+			HeightRoundStep: types.MustParseHeightRoundStep("1/2/3"),
+			ValidatorVoteStates: []types.StreamingValidatorVoteState{
+				{
+					ValidatorIndex: 0,
+				},
+			},
+		}
+
+		for _, codec := range []CvpCodec{cvpV1CodecImpl, cvpV2CodecImpl, cvpV3CodecImpl, cvpV4CodecImpl, cvpV5CodecImpl, cvpV6CodecImpl, cvpV7CodecImpl, cvpV8CodecImpl, cvpV9CodecImpl, cvpDeltaCodecImpl} {
+			wantAllowed := codec.GetVersion() == CvpCodecVersionV2 || codec.GetVersion() == CvpCodecVersionV3
+
+			_, errValidators := proxy.DecodeStreamingLightValidators(codec.EncodeStreamingLightValidators(validators))
+			_, errInformation := proxy.DecodeStreamingNextBlockVotingInformation(codec.EncodeStreamingNextBlockVotingInformation(information))
+			for _, err := range []error{errValidators, errInformation} {
+				if wantAllowed {
+					if err != nil {
+						t.Errorf("expect %s is allowed but got error = %v", codec.GetVersion(), err)
+					}
+					continue
+				}
+				if !errors.Is(err, ErrVersionNotAllowed) {
+					t.Errorf("expect %s is not allowed but got error = %v", codec.GetVersion(), err)
+				}
+			}
+		}
+	})
+}
+
+func Test_GetDecodingLimits(t *testing.T) {
+	if got, want := GetDecodingLimits(cvpV3CodecImpl), cvpV3CodecImpl.GetLimits(); got != want {
+		t.Errorf("GetDecodingLimits() of non-proxy = %v, want %v", got, want)
+	}
+
+	got := GetDecodingLimits(cvpProxyCodecImpl)
+	if got.MaxValidators != cvpV9CodecImpl.GetLimits().MaxValidators {
+		t.Errorf("GetDecodingLimits() of proxy MaxValidators = %d, want limit of v5+ %d", got.MaxValidators, cvpV9CodecImpl.GetLimits().MaxValidators)
+	}
+	for _, version := range GetRegisteredCvpCodecVersions() {
+		registered, _ := GetRegisteredCvpCodec(version)
+		limits := registered.GetLimits()
+		if limits.MaxValidators > got.MaxValidators ||
+			limits.MaxValidatorIndex > got.MaxValidatorIndex ||
+			limits.MaxEncodedLightValidatorsBytes > got.MaxEncodedLightValidatorsBytes ||
+			limits.MaxEncodedNextBlockVotingInformationBytes > got.MaxEncodedNextBlockVotingInformationBytes {
+			t.Errorf("GetDecodingLimits() of proxy = %v, lower than limits of %s %v", got, version, limits)
+		}
+	}
+
+	allowed := WrapCvpCodecInProxyWithAllowedVersions(GetCvpCodecV3(), CvpCodecVersionV1, CvpCodecVersionV3)
+	want := cvpV1CodecImpl.GetLimits() // v1 is larger than v3 in all the limits
+	if got := GetDecodingLimits(allowed); got != want {
+		t.Errorf("GetDecodingLimits() of proxy with allowed versions = %v, want %v", got, want)
+	}
+}
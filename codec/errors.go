@@ -0,0 +1,173 @@
+package codec
+
+import (
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"math"
+)
+
+// ErrInvalidEncodeInput is matched, via errors.Is, by every EncodeError.
+var ErrInvalidEncodeInput = errors.New("invalid input to encode")
+
+var (
+	// ErrBadPrefix is matched, via errors.Is, by DecodeError when the encoded data
+	// does not start with prefix of the codec, or version of the encoded data can not be detected.
+	ErrBadPrefix = errors.New("bad encoding prefix")
+
+	// ErrTruncated is matched, via errors.Is, by DecodeError when the encoded data ends before
+	// all the required fields are read, which usually means the payload was cut off during transfer.
+	ErrTruncated = errors.New("truncated encoded data")
+
+	// ErrInvalidField is matched, via errors.Is, by DecodeError when a field of the encoded data
+	// is malformed or has an invalid value.
+	ErrInvalidField = errors.New("invalid field")
+
+	// ErrBaseFrameMismatch is matched, via errors.Is, by DecodeError when a delta frame
+	// can not be applied on top of the provided base frame.
+	ErrBaseFrameMismatch = errors.New("base frame mismatch")
+
+	// ErrVersionNotAllowed is matched, via errors.Is, by DecodeError when a proxy CvpCodec
+	// restricted by WrapCvpCodecInProxyWithAllowedVersions receives data encoded by a version which is not allowed.
+	ErrVersionNotAllowed = errors.New("encoder version not allowed")
+)
+
+var _ error = (*EncodeError)(nil)
+var _ error = (*DecodeError)(nil)
+
+// EncodeError is returned by TryEncode* methods when the input can not be encoded by the CvpCodec.
+//
+// The Encode* methods panic with this error.
+type EncodeError struct {
+	// Version is the version of the codec which rejected the input.
+	Version CvpCodecVersion
+
+	// Field is name of the rejected field of the input, like Index or PreVotedBlockHash.
+	Field string
+
+	// Message is the human-readable reason.
+	Message string
+}
+
+func newEncodeError(version CvpCodecVersion, field string, format string, args ...any) *EncodeError {
+	return &EncodeError{
+		Version: version,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *EncodeError) Error() string {
+	return e.Message
+}
+
+func (e *EncodeError) Unwrap() error {
+	return ErrInvalidEncodeInput
+}
+
+// withEncodeErrorVersion re-tags the EncodeError returned by an underlying codec
+// with version of the wrapping codec, other errors are returned as is.
+func withEncodeErrorVersion(err error, version CvpCodecVersion) error {
+	if encodeErr, ok := err.(*EncodeError); ok {
+		return &EncodeError{
+			Version: version,
+			Field:   encodeErr.Field,
+			Message: encodeErr.Message,
+		}
+	}
+	return err
+}
+
+func validateHeightRoundStepForEncoding(version CvpCodecVersion, field string, hrs types.HeightRoundStep) error {
+	if err := hrs.Validate(); err != nil {
+		return newEncodeError(version, field, "invalid height round step %s: %v", hrs, err)
+	}
+	return nil
+}
+
+// validateTextHeightRoundStepForEncoding is validateHeightRoundStepForEncoding of the versions encoding the text form,
+// which accept any step as the decoders do, see types.HeightRoundStep.ValidateLegacy.
+func validateTextHeightRoundStepForEncoding(version CvpCodecVersion, field string, hrs types.HeightRoundStep) error {
+	if err := hrs.ValidateLegacy(); err != nil {
+		return newEncodeError(version, field, "invalid height round step %s: %v", hrs, err)
+	}
+	return nil
+}
+
+// parseHeightRoundStepForDecoding parses the text form of HeightRoundStep,
+// offset is the position of the text in the encoded data, for error reporting.
+//
+// The step is not validated, payloads encoded before HeightRoundStep was structured might carry any digits as the step.
+func parseHeightRoundStepForDecoding(version CvpCodecVersion, field string, offset int, str string) (types.HeightRoundStep, error) {
+	hrs, err := types.ParseLegacyHeightRoundStep(str)
+	if err != nil {
+		return types.HeightRoundStep{}, wrapDecodeError(version, ErrInvalidField, field, offset, err, fmt.Sprintf("invalid height round step: %s", str))
+	}
+	return hrs, nil
+}
+
+func validatePercentForEncoding(version CvpCodecVersion, field string, percent float64) error {
+	if math.IsNaN(percent) {
+		return newEncodeError(version, field, "invalid percent: NaN")
+	}
+	if percent < 0 || percent > 100 {
+		return newEncodeError(version, field, "overflow percent: %f", percent)
+	}
+	return nil
+}
+
+// DecodeError is returned by Decode* methods of every CvpCodec implementation when failed to decode.
+//
+// Use errors.Is with ErrBadPrefix, ErrTruncated or ErrInvalidField to check the kind of failure,
+// and errors.As to inspect the details.
+type DecodeError struct {
+	// Kind is one of ErrBadPrefix, ErrTruncated or ErrInvalidField,
+	// or ErrBaseFrameMismatch when applying delta frame,
+	// or ErrVersionNotAllowed when decoding via a restricted proxy CvpCodec.
+	Kind error
+
+	// Version is the version of the codec which failed to decode.
+	// For v3, failures of the inner v2 payload are reported with version v2.
+	Version CvpCodecVersion
+
+	// Field is name of the field failed to decode, like Index or PreVotedBlockHash.
+	Field string
+
+	// Offset is the byte offset of the field within the input of the codec reported by Version.
+	Offset int
+
+	// Message is the human-readable reason.
+	Message string
+
+	// Cause is the underlying error, if any, like parsing number error.
+	Cause error
+}
+
+func newDecodeError(version CvpCodecVersion, kind error, field string, offset int, format string, args ...any) *DecodeError {
+	return &DecodeError{
+		Kind:    kind,
+		Version: version,
+		Field:   field,
+		Offset:  offset,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func wrapDecodeError(version CvpCodecVersion, kind error, field string, offset int, cause error, message string) *DecodeError {
+	return &DecodeError{
+		Kind:    kind,
+		Version: version,
+		Field:   field,
+		Offset:  offset,
+		Message: fmt.Sprintf("%s: %s", message, cause.Error()),
+		Cause:   cause,
+	}
+}
+
+func (e *DecodeError) Error() string {
+	return e.Message
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Kind
+}
@@ -0,0 +1,399 @@
+package codec
+
+import (
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/constants"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"math"
+	"testing"
+)
+
+func Test_cvpCodecAllVersions_TryEncodeStreamingLightValidators(t *testing.T) {
+	tests := []struct {
+		name         string
+		validators   types.StreamingLightValidators
+		wantField    string
+		skipVersions []CvpCodecVersion
+	}{
+		{
+			name:       "negative index",
+			validators: types.StreamingLightValidators{{Index: -1, VotingPowerDisplayPercent: 1}},
+			wantField:  "Index",
+		},
+		{
+			name:         "index greater than 998",
+			validators:   types.StreamingLightValidators{{Index: 999, VotingPowerDisplayPercent: 1}},
+			wantField:    "Index",
+			skipVersions: []CvpCodecVersion{CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // wide-index codecs
+		},
+		{
+			name:       "negative voting power display percent",
+			validators: types.StreamingLightValidators{{Index: 0, VotingPowerDisplayPercent: -1}},
+			wantField:  "VotingPowerDisplayPercent",
+		},
+		{
+			name:       "voting power display percent greater than 100",
+			validators: types.StreamingLightValidators{{Index: 0, VotingPowerDisplayPercent: 100.01}},
+			wantField:  "VotingPowerDisplayPercent",
+		},
+		{
+			name:       "NaN voting power display percent",
+			validators: types.StreamingLightValidators{{Index: 0, VotingPowerDisplayPercent: math.NaN()}},
+			wantField:  "VotingPowerDisplayPercent",
+		},
+		{
+			name: "too many validators",
+			validators: func() types.StreamingLightValidators {
+				validators := make(types.StreamingLightValidators, constants.MAX_VALIDATORS+1)
+				for i := range validators {
+					validators[i].Index = i
+				}
+				return validators
+			}(),
+			wantField:    "StreamingLightValidators",
+			skipVersions: []CvpCodecVersion{CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // wide-index codecs
+		},
+	}
+	for _, tt := range tests {
+		for _, codec := range []CvpCodec{cvpV1CodecImpl, cvpV2CodecImpl, cvpV3CodecImpl, cvpV4CodecImpl, cvpV5CodecImpl, cvpV6CodecImpl, cvpV7CodecImpl, cvpV8CodecImpl, cvpV9CodecImpl, cvpDeltaCodecImpl, cvpProxyCodecImpl} {
+			skip := false
+			for _, skipVersion := range tt.skipVersions {
+				if skipVersion == codec.GetVersion() {
+					skip = true
+				}
+			}
+			if skip {
+				continue
+			}
+
+			t.Run(fmt.Sprintf("%s_%s", tt.name, codec.GetVersion()), func(t *testing.T) {
+				bz, err := codec.TryEncodeStreamingLightValidators(tt.validators)
+				if err == nil {
+					t.Errorf("TryEncodeStreamingLightValidators() expect error but got %v", bz)
+					return
+				}
+				if !errors.Is(err, ErrInvalidEncodeInput) {
+					t.Errorf("TryEncodeStreamingLightValidators() error = %v, want matches ErrInvalidEncodeInput", err)
+				}
+				var encodeErr *EncodeError
+				if !errors.As(err, &encodeErr) {
+					t.Errorf("TryEncodeStreamingLightValidators() error = %T, want *EncodeError", err)
+					return
+				}
+				if encodeErr.Field != tt.wantField {
+					t.Errorf("TryEncodeStreamingLightValidators() error field = %s, want %s", encodeErr.Field, tt.wantField)
+				}
+				if encodeErr.Version != codec.GetVersion() {
+					t.Errorf("TryEncodeStreamingLightValidators() error version = %s, want %s", encodeErr.Version, codec.GetVersion())
+				}
+
+				defer func() {
+					r := recover()
+					if r == nil {
+						t.Errorf("EncodeStreamingLightValidators() did not panic")
+					} else if r.(error).Error() != err.Error() {
+						t.Errorf("EncodeStreamingLightValidators() panic = %v, want %v", r, err)
+					}
+				}()
+				_ = codec.EncodeStreamingLightValidators(tt.validators)
+			})
+		}
+	}
+}
+
+func Test_cvpCodecAllVersions_TryEncodeStreamingNextBlockVotingInformation(t *testing.T) {
+	tests := []struct {
+		name         string
+		inf          types.StreamingNextBlockVotingInformation
+		wantField    string
+		skipVersions []CvpCodecVersion
+	}{
+		{
+			name: "negative index",
+			inf: types.StreamingNextBlockVotingInformation{
+				HeightRoundStep:     types.MustParseHeightRoundStep("1/2/3"),
+				ValidatorVoteStates: []types.StreamingValidatorVoteState{{ValidatorIndex: -1}},
+			},
+			wantField: "ValidatorIndex",
+		},
+		{
+			name: "index greater than 998",
+			inf: types.StreamingNextBlockVotingInformation{
+				HeightRoundStep:     types.MustParseHeightRoundStep("1/2/3"),
+				ValidatorVoteStates: []types.StreamingValidatorVoteState{{ValidatorIndex: 999}},
+			},
+			wantField:    "ValidatorIndex",
+			skipVersions: []CvpCodecVersion{CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // wide-index codecs
+		},
+		{
+			name: "bad pre-voted block hash",
+			inf: types.StreamingNextBlockVotingInformation{
+				HeightRoundStep:     types.MustParseHeightRoundStep("1/2/3"),
+				ValidatorVoteStates: []types.StreamingValidatorVoteState{{ValidatorIndex: 0, PreVotedBlockHash: "ABC"}},
+			},
+			wantField: "PreVotedBlockHash",
+		},
+		{
+			name: "pre-voted percent greater than 100",
+			inf: types.StreamingNextBlockVotingInformation{
+				HeightRoundStep:     types.MustParseHeightRoundStep("1/2/3"),
+				PreVotedPercent:     101,
+				ValidatorVoteStates: []types.StreamingValidatorVoteState{{ValidatorIndex: 0}},
+			},
+			wantField:    "PreVotedPercent",
+			skipVersions: []CvpCodecVersion{CvpCodecVersionV1}, // v1 does not validate
+		},
+		{
+			name: "NaN pre-commit voted percent",
+			inf: types.StreamingNextBlockVotingInformation{
+				HeightRoundStep:       types.MustParseHeightRoundStep("1/2/3"),
+				PreCommitVotedPercent: math.NaN(),
+				ValidatorVoteStates:   []types.StreamingValidatorVoteState{{ValidatorIndex: 0}},
+			},
+			wantField:    "PreCommitVotedPercent",
+			skipVersions: []CvpCodecVersion{CvpCodecVersionV1}, // v1 does not validate
+		},
+		{
+			name: "too many validators",
+			inf: func() types.StreamingNextBlockVotingInformation {
+				inf := types.StreamingNextBlockVotingInformation{
+					HeightRoundStep:     types.MustParseHeightRoundStep("1/2/3"),
+					ValidatorVoteStates: make([]types.StreamingValidatorVoteState, constants.MAX_VALIDATORS+1),
+				}
+				for i := range inf.ValidatorVoteStates {
+					inf.ValidatorVoteStates[i].ValidatorIndex = i
+				}
+				return inf
+			}(),
+			wantField:    "ValidatorVoteStates",
+			skipVersions: []CvpCodecVersion{CvpCodecVersionV5, CvpCodecVersionV6, CvpCodecVersionV7, CvpCodecVersionV8, CvpCodecVersionV9}, // wide-index codecs
+		},
+	}
+	for _, tt := range tests {
+		for _, codec := range []CvpCodec{cvpV1CodecImpl, cvpV2CodecImpl, cvpV3CodecImpl, cvpV4CodecImpl, cvpV5CodecImpl, cvpV6CodecImpl, cvpV7CodecImpl, cvpV8CodecImpl, cvpV9CodecImpl, cvpDeltaCodecImpl, cvpProxyCodecImpl} {
+			skip := false
+			for _, skipVersion := range tt.skipVersions {
+				if skipVersion == codec.GetVersion() {
+					skip = true
+				}
+			}
+			if skip {
+				continue
+			}
+
+			t.Run(fmt.Sprintf("%s_%s", tt.name, codec.GetVersion()), func(t *testing.T) {
+				bz, err := codec.TryEncodeStreamingNextBlockVotingInformation(&tt.inf)
+				if err == nil {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() expect error but got %v", bz)
+					return
+				}
+				if !errors.Is(err, ErrInvalidEncodeInput) {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %v, want matches ErrInvalidEncodeInput", err)
+				}
+				var encodeErr *EncodeError
+				if !errors.As(err, &encodeErr) {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error = %T, want *EncodeError", err)
+					return
+				}
+				if encodeErr.Field != tt.wantField {
+					t.Errorf("TryEncodeStreamingNextBlockVotingInformation() error field = %s, want %s", encodeErr.Field, tt.wantField)
+				}
+
+				defer func() {
+					if r := recover(); r == nil {
+						t.Errorf("EncodeStreamingNextBlockVotingInformation() did not panic")
+					}
+				}()
+				_ = codec.EncodeStreamingNextBlockVotingInformation(&tt.inf)
+			})
+		}
+	}
+}
+
+func Test_cvpCodecAllVersions_DecodeError(t *testing.T) {
+	v3Encoded := cvpV3CodecImpl.EncodeStreamingNextBlockVotingInformation(sampleNextBlockVotingInformationForDelta(10))
+
+	//goland:noinspection SpellCheckingInspection
+	tests := []struct {
+		name        string
+		decode      func() error
+		wantKind    error
+		wantVersion CvpCodecVersion
+		wantField   string
+		wantOffset  int
+	}{
+		{
+			name: "v1 light validators, truncated validator",
+			decode: func() error {
+				_, err := cvpV1CodecImpl.DecodeStreamingLightValidators([]byte("1|00001000|0010"))
+				return err
+			},
+			wantKind:    ErrTruncated,
+			wantVersion: CvpCodecVersionV1,
+			wantField:   "StreamingLightValidator",
+			wantOffset:  11,
+		},
+		{
+			name: "v1 light validators, invalid percent",
+			decode: func() error {
+				_, err := cvpV1CodecImpl.DecodeStreamingLightValidators([]byte("1|000A1000"))
+				return err
+			},
+			wantKind:    ErrInvalidField,
+			wantVersion: CvpCodecVersionV1,
+			wantField:   "VotingPowerDisplayPercent",
+			wantOffset:  5,
+		},
+		{
+			name: "v1 light validators, invalid index sequence",
+			decode: func() error {
+				_, err := cvpV1CodecImpl.DecodeStreamingLightValidators([]byte("1|00001000|00201000"))
+				return err
+			},
+			wantKind:    ErrInvalidField,
+			wantVersion: CvpCodecVersionV1,
+			wantField:   "Index",
+			wantOffset:  11,
+		},
+		{
+			name: "v1 next block voting information, truncated",
+			decode: func() error {
+				_, err := cvpV1CodecImpl.DecodeStreamingNextBlockVotingInformation([]byte("1|1/2/3|1000"))
+				return err
+			},
+			wantKind:    ErrTruncated,
+			wantVersion: CvpCodecVersionV1,
+			wantField:   "StreamingNextBlockVotingInformation",
+			wantOffset:  12,
+		},
+		{
+			name: "v1 next block voting information, invalid vote flag",
+			decode: func() error {
+				_, err := cvpV1CodecImpl.DecodeStreamingNextBlockVotingInformation([]byte("1|1/2/3|1000|100|254|000ABCDC001ABCDZ"))
+				return err
+			},
+			wantKind:    ErrInvalidField,
+			wantVersion: CvpCodecVersionV1,
+			wantField:   "VoteFlag",
+			wantOffset:  36,
+		},
+		{
+			name: "v2 light validators, invalid index sequence",
+			decode: func() error {
+				_, err := cvpV2CodecImpl.DecodeStreamingLightValidators(mergeBuffers(
+					prefixDataEncodedByCvpCodecV2,
+					[]byte{0x0, 0x0}, []byte{0x0a, 0x0a},
+					[]byte{cvpCodecV2Separator},
+					[]byte{0x0, 0x2}, []byte{0x0a, 0x0a},
+				))
+				return err
+			},
+			wantKind:    ErrInvalidField,
+			wantVersion: CvpCodecVersionV2,
+			wantField:   "Index",
+			wantOffset:  7,
+		},
+		{
+			name: "v2 next block voting information, invalid pre-voted block hash",
+			decode: func() error {
+				_, err := cvpV2CodecImpl.DecodeStreamingNextBlockVotingInformation(mergeBuffers(
+					prefixDataEncodedByCvpCodecV2,
+					[]byte("1/2/3"), []byte{cvpCodecV2Separator},
+					[]byte("1"), []byte{cvpCodecV2Separator},
+					[]byte{0x01, 0x00}, []byte{0x02, 0x36}, []byte{cvpCodecV2Separator},
+					[]byte{0x00, 0x00}, []byte("GGGG"), []byte("C"),
+				))
+				return err
+			},
+			wantKind:    ErrInvalidField,
+			wantVersion: CvpCodecVersionV2,
+			wantField:   "PreVotedBlockHash",
+			wantOffset:  17,
+		},
+		{
+			name: "v2 next block voting information, truncated vote states",
+			decode: func() error {
+				bz := cvpV2CodecImpl.EncodeStreamingNextBlockVotingInformation(sampleNextBlockVotingInformationForDelta(2))
+				_, err := cvpV2CodecImpl.DecodeStreamingNextBlockVotingInformation(bz[:len(bz)-1])
+				return err
+			},
+			wantKind:    ErrTruncated,
+			wantVersion: CvpCodecVersionV2,
+			wantField:   "ValidatorVoteStates",
+			wantOffset:  17,
+		},
+		{
+			name: "v3 next block voting information, truncated",
+			decode: func() error {
+				_, err := cvpV3CodecImpl.DecodeStreamingNextBlockVotingInformation(v3Encoded[:len(v3Encoded)-4])
+				return err
+			},
+			wantKind:    ErrTruncated,
+			wantVersion: CvpCodecVersionV3,
+			wantField:   "payload",
+			wantOffset:  2,
+		},
+		{
+			name: "v3 bad prefix",
+			decode: func() error {
+				_, err := cvpV3CodecImpl.DecodeStreamingLightValidators([]byte("1|00001000"))
+				return err
+			},
+			wantKind:    ErrBadPrefix,
+			wantVersion: CvpCodecVersionV3,
+			wantField:   "prefix",
+			wantOffset:  0,
+		},
+		{
+			name: "proxy unable to detect encoder version",
+			decode: func() error {
+				_, err := cvpProxyCodecImpl.DecodeStreamingNextBlockVotingInformation([]byte("invalid data"))
+				return err
+			},
+			wantKind:    ErrBadPrefix,
+			wantVersion: CvpCodecVersionUnknown,
+			wantField:   "prefix",
+			wantOffset:  0,
+		},
+		{
+			name: "delta base frame mismatch",
+			decode: func() error {
+				base := sampleNextBlockVotingInformationForDelta(2)
+				bz := cvpDeltaCodecImpl.EncodeStreamingNextBlockVotingInformationDelta(base, base)
+				_, err := cvpDeltaCodecImpl.ApplyStreamingNextBlockVotingInformationDelta(sampleNextBlockVotingInformationForDelta(3), bz)
+				return err
+			},
+			wantKind:    ErrBaseFrameMismatch,
+			wantVersion: CvpCodecVersionDelta,
+			wantField:   "Checksum",
+			wantOffset:  25,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.decode()
+			if err == nil {
+				t.Errorf("expect error but got nil")
+				return
+			}
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("error = %v, want matches %v", err, tt.wantKind)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Errorf("error = %T, want *DecodeError", err)
+				return
+			}
+			if decodeErr.Version != tt.wantVersion {
+				t.Errorf("error version = %s, want %s", decodeErr.Version, tt.wantVersion)
+			}
+			if decodeErr.Field != tt.wantField {
+				t.Errorf("error field = %s, want %s", decodeErr.Field, tt.wantField)
+			}
+			if decodeErr.Offset != tt.wantOffset {
+				t.Errorf("error offset = %d, want %d", decodeErr.Offset, tt.wantOffset)
+			}
+		})
+	}
+}
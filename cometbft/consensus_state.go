@@ -0,0 +1,189 @@
+package cometbft
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"strings"
+	"time"
+)
+
+// ConsensusState is the part of CometBFT consensus state needed to build the streaming types.
+type ConsensusState struct {
+	HeightRoundStep types.HeightRoundStep
+	StartTime       time.Time
+	Validators      Validators
+
+	// Prevotes and Precommits of the current round, indexed by validator index,
+	// element is nil if the validator has not voted yet.
+	Prevotes   []*Vote
+	Precommits []*Vote
+}
+
+type roundVotesJson struct {
+	Round      jsonInt64 `json:"round"`
+	Prevotes   []string  `json:"prevotes"`
+	Precommits []string  `json:"precommits"`
+}
+
+type dumpConsensusStateResultJson struct {
+	RoundState *struct {
+		Height     jsonInt64 `json:"height"`
+		Round      jsonInt64 `json:"round"`
+		Step       jsonInt64 `json:"step"`
+		StartTime  time.Time `json:"start_time"`
+		Validators struct {
+			Validators []validatorJson `json:"validators"`
+		} `json:"validators"`
+		Votes []roundVotesJson `json:"votes"`
+	} `json:"round_state"`
+}
+
+// ParseDumpConsensusState parses the response of CometBFT /dump_consensus_state RPC into ConsensusState.
+//
+// Only votes of the current round are kept, votes must be in the same order as the validator set.
+// Both the JSON-RPC envelope and the bare result are accepted.
+func ParseDumpConsensusState(bz []byte) (*ConsensusState, error) {
+	bzResult, err := unwrapRpcResult(bz)
+	if err != nil {
+		return nil, err
+	}
+
+	var result dumpConsensusStateResultJson
+	if err := json.Unmarshal(bzResult, &result); err != nil {
+		return nil, fmt.Errorf("invalid dump consensus state response: %v", err)
+	}
+	roundState := result.RoundState
+	if roundState == nil {
+		return nil, fmt.Errorf("missing round state")
+	}
+
+	if roundState.Round < 0 || roundState.Round > 1<<31-1 || roundState.Step < 0 || roundState.Step > 0xFF {
+		return nil, fmt.Errorf("invalid round %d or step %d", roundState.Round, roundState.Step)
+	}
+	hrs := types.HeightRoundStep{
+		Height: int64(roundState.Height),
+		Round:  int32(roundState.Round),
+		Step:   types.RoundStepType(roundState.Step),
+	}
+	if err := hrs.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid height round step: %v", err)
+	}
+
+	cs := &ConsensusState{
+		HeightRoundStep: hrs,
+		StartTime:       roundState.StartTime,
+	}
+
+	if len(roundState.Validators.Validators) < 1 {
+		return nil, fmt.Errorf("empty validator set")
+	}
+	for _, v := range roundState.Validators.Validators {
+		validator, err := toValidator(v)
+		if err != nil {
+			return nil, err
+		}
+		cs.Validators = append(cs.Validators, validator)
+	}
+
+	cs.Prevotes = make([]*Vote, len(cs.Validators))
+	cs.Precommits = make([]*Vote, len(cs.Validators))
+	for _, roundVotes := range roundState.Votes {
+		if int64(roundVotes.Round) != int64(hrs.Round) {
+			continue
+		}
+
+		if err := cs.parseVotes(roundVotes.Prevotes, "Prevote", cs.Prevotes); err != nil {
+			return nil, fmt.Errorf("invalid prevotes: %v", err)
+		}
+		if err := cs.parseVotes(roundVotes.Precommits, "Precommit", cs.Precommits); err != nil {
+			return nil, fmt.Errorf("invalid precommits: %v", err)
+		}
+		break
+	}
+
+	return cs, nil
+}
+
+// parseVotes parses the votes of the current round into the output slice,
+// each vote must be placed at the position of the validator index and belong to the validator at that position.
+func (cs *ConsensusState) parseVotes(votes []string, typeName string, out []*Vote) error {
+	if len(votes) != len(cs.Validators) {
+		return fmt.Errorf("got %d votes, want %d", len(votes), len(cs.Validators))
+	}
+
+	for i, str := range votes {
+		vote, err := parseVote(str, typeName)
+		if err != nil {
+			return err
+		}
+		if vote == nil {
+			continue
+		}
+
+		if vote.ValidatorIndex != i {
+			return fmt.Errorf("vote of validator index %d at position %d", vote.ValidatorIndex, i)
+		}
+		if !strings.HasPrefix(cs.Validators[i].Address, vote.ValidatorAddressFingerprint) {
+			return fmt.Errorf("vote of validator %s mismatch validator address %s", vote.ValidatorAddressFingerprint, cs.Validators[i].Address)
+		}
+		if vote.Height != cs.HeightRoundStep.Height || vote.Round != cs.HeightRoundStep.Round {
+			return fmt.Errorf("vote of %d/%d mismatch consensus state %s", vote.Height, vote.Round, cs.HeightRoundStep)
+		}
+
+		out[i] = vote
+	}
+
+	return nil
+}
+
+// ToStreamingNextBlockVotingInformation builds the next block voting information from the consensus state.
+//
+// Duration is the time elapsed since the start time of the height until the given now, not less than zero.
+// Percents are the voting power of the validators which have voted, in any block hash or nil,
+// over the total voting power, rounded to 2 decimal places.
+func (cs *ConsensusState) ToStreamingNextBlockVotingInformation(now time.Time) (*types.StreamingNextBlockVotingInformation, error) {
+	totalVotingPower := cs.Validators.TotalVotingPower()
+	if totalVotingPower < 1 {
+		return nil, fmt.Errorf("invalid total voting power %d", totalVotingPower)
+	}
+
+	duration := now.Sub(cs.StartTime)
+	if duration < 0 {
+		duration = 0
+	}
+
+	inf := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep:     cs.HeightRoundStep,
+		Duration:            duration,
+		ValidatorVoteStates: make([]types.StreamingValidatorVoteState, 0, len(cs.Validators)),
+	}
+
+	var preVotedPower, preCommitVotedPower int64
+	for i, validator := range cs.Validators {
+		voteState := types.StreamingValidatorVoteState{
+			ValidatorIndex:    i,
+			PreVotedBlockHash: "----",
+		}
+
+		if prevote := cs.Prevotes[i]; prevote != nil {
+			voteState.PreVoted = true
+			voteState.PreVotedBlockHash = prevote.StreamingBlockHash()
+			voteState.VotedZeroes = prevote.IsVotedZeroes()
+			preVotedPower += validator.VotingPower
+		}
+
+		if precommit := cs.Precommits[i]; precommit != nil {
+			voteState.PreCommitVoted = true
+			voteState.PreCommittedBlockHash = precommit.StreamingBlockHash()
+			preCommitVotedPower += validator.VotingPower
+		}
+
+		inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, voteState)
+	}
+
+	inf.PreVotedPercent = percentOf(preVotedPower, totalVotingPower)
+	inf.PreCommitVotedPercent = percentOf(preCommitVotedPower, totalVotingPower)
+
+	return inf, nil
+}
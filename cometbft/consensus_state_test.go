@@ -0,0 +1,118 @@
+package cometbft
+
+import (
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDumpConsensusState(t *testing.T) {
+	bz := readFixture(t, "dump_consensus_state.json")
+
+	cs, err := ParseDumpConsensusState(bz)
+	require.NoError(t, err)
+	require.Equal(t, types.MustParseHeightRoundStep("1234567/1/6"), cs.HeightRoundStep)
+	require.Len(t, cs.Validators, 5)
+	require.Len(t, cs.Prevotes, 5)
+	require.Len(t, cs.Precommits, 5)
+
+	now := cs.StartTime.Add(2500 * time.Millisecond)
+	inf, err := cs.ToStreamingNextBlockVotingInformation(now)
+	require.NoError(t, err)
+	requireGolden(t, "next_block_voting_information.golden.json", inf)
+
+	t.Run("deterministic", func(t *testing.T) {
+		cs2, err := ParseDumpConsensusState(bz)
+		require.NoError(t, err)
+		inf2, err := cs2.ToStreamingNextBlockVotingInformation(now)
+		require.NoError(t, err)
+		require.Equal(t, inf, inf2)
+	})
+
+	t.Run("encodable by codecs", func(t *testing.T) {
+		for _, version := range codec.GetRegisteredCvpCodecVersions() {
+			cvpCodec, found := codec.GetRegisteredCvpCodec(version)
+			require.True(t, found)
+			_, err := cvpCodec.TryEncodeStreamingNextBlockVotingInformation(inf)
+			require.NoError(t, err, "version %d", version)
+		}
+	})
+
+	t.Run("duration not negative", func(t *testing.T) {
+		inf, err := cs.ToStreamingNextBlockVotingInformation(cs.StartTime.Add(-time.Second))
+		require.NoError(t, err)
+		require.Zero(t, inf.Duration)
+	})
+}
+
+func TestParseDumpConsensusState_Errors(t *testing.T) {
+	fixture := string(readFixture(t, "dump_consensus_state.json"))
+
+	tests := []struct {
+		name            string
+		input           string
+		wantErrContains string
+	}{
+		{
+			name:            "RPC error",
+			input:           `{"jsonrpc":"2.0","id":-1,"error":{"code":-32601,"message":"Method not found"}}`,
+			wantErrContains: "RPC error -32601",
+		},
+		{
+			name:            "missing round state",
+			input:           `{"peers":[]}`,
+			wantErrContains: "missing round state",
+		},
+		{
+			name:            "invalid step",
+			input:           strings.Replace(fixture, `"step": 6`, `"step": 9`, 1),
+			wantErrContains: "invalid height round step",
+		},
+		{
+			name:            "empty validator set",
+			input:           `{"round_state":{"height":"1","round":0,"step":1,"validators":{"validators":[]}}}`,
+			wantErrContains: "empty validator set",
+		},
+		{
+			name:            "vote at wrong position",
+			input:           strings.Replace(fixture, `Vote{4:4E5D6C7B8A99`, `Vote{3:4E5D6C7B8A99`, 1),
+			wantErrContains: "vote of validator index 3 at position 4",
+		},
+		{
+			name:            "vote of another validator",
+			input:           strings.Replace(fixture, `Vote{4:4E5D6C7B8A99`, `Vote{4:3D4C5B6A7988`, 1),
+			wantErrContains: "mismatch validator address",
+		},
+		{
+			name:            "vote of another height",
+			input:           strings.Replace(fixture, `1234567/01/SIGNED_MSG_TYPE_PREVOTE(Prevote) B1C2`, `1234566/01/SIGNED_MSG_TYPE_PREVOTE(Prevote) B1C2`, 1),
+			wantErrContains: "mismatch consensus state",
+		},
+		{
+			name:            "precommit in prevotes",
+			input:           strings.Replace(fixture, `SIGNED_MSG_TYPE_PREVOTE(Prevote) B1C2`, `SIGNED_MSG_TYPE_PRECOMMIT(Precommit) B1C2`, 1),
+			wantErrContains: "invalid prevotes: invalid vote type",
+		},
+		{
+			name:            "malformed vote",
+			input:           strings.Replace(fixture, "\"nil-Vote\",\n            \"Vote{2:", "\"Vote{}\",\n            \"Vote{2:", 1),
+			wantErrContains: "invalid vote format",
+		},
+		{
+			name:            "missing votes",
+			input:           strings.Replace(fixture, "\"nil-Vote\",\n            \"nil-Vote\"\n          ],\n          \"precommits_bit_array\": \"BA{5:x_x__}", "\"nil-Vote\"\n          ],\n          \"precommits_bit_array\": \"BA{5:x_x__}", 1),
+			wantErrContains: "invalid precommits: got 4 votes, want 5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NotEqual(t, fixture, tt.input, "test input must be modified from the fixture")
+			_, err := ParseDumpConsensusState([]byte(tt.input))
+			require.Error(t, err)
+			require.ErrorContains(t, err, tt.wantErrContains)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package cometbft
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// readFixture returns content of the file in testdata.
+func readFixture(t *testing.T, name string) []byte {
+	bz, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return bz
+}
+
+// requireGolden compares JSON of the given value with the golden file in testdata,
+// or overwrites the golden file when running with -update.
+func requireGolden(t *testing.T, name string, got any) {
+	bz, err := json.MarshalIndent(got, "", "  ")
+	require.NoError(t, err)
+	bz = append(bz, '\n')
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, bz, 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run with -update to create golden file")
+	require.Equal(t, string(want), string(bz))
+}
@@ -0,0 +1,53 @@
+package cometbft
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// rpcResponse is the JSON-RPC envelope of CometBFT RPC responses.
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+// unwrapRpcResult returns the "result" of the JSON-RPC envelope,
+// or the input as is if it is the bare result without the envelope.
+func unwrapRpcResult(bz []byte) (json.RawMessage, error) {
+	var envelope rpcResponse
+	if err := json.Unmarshal(bz, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	if envelope.Error != nil {
+		return nil, fmt.Errorf("RPC error %d: %s %s", envelope.Error.Code, envelope.Error.Message, envelope.Error.Data)
+	}
+	if len(envelope.Result) > 0 && !bytes.Equal(envelope.Result, []byte("null")) {
+		return envelope.Result, nil
+	}
+	return bz, nil
+}
+
+// jsonInt64 is an int64 which can be unmarshalled from either JSON number or JSON string,
+// CometBFT encodes 64 bits integers as string but the smaller ones as number.
+type jsonInt64 int64
+
+func (i *jsonInt64) UnmarshalJSON(bz []byte) error {
+	str := string(bz)
+	if len(bz) > 1 && bz[0] == '"' {
+		if err := json.Unmarshal(bz, &str); err != nil {
+			return err
+		}
+	}
+	num, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", string(bz))
+	}
+	*i = jsonInt64(num)
+	return nil
+}
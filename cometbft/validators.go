@@ -0,0 +1,132 @@
+package cometbft
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"math"
+	"strings"
+)
+
+// Validator is a member of the CometBFT validator set.
+type Validator struct {
+	// Address is the consensus address, upper case hex.
+	Address     string
+	VotingPower int64
+}
+
+// Validators is the CometBFT validator set, sorted the same way as CometBFT does,
+// so position of each validator is the validator index used in votes.
+type Validators []Validator
+
+type validatorJson struct {
+	Address     string    `json:"address"`
+	VotingPower jsonInt64 `json:"voting_power"`
+}
+
+type validatorsResultJson struct {
+	BlockHeight jsonInt64       `json:"block_height"`
+	Validators  []validatorJson `json:"validators"`
+	Total       jsonInt64       `json:"total"`
+}
+
+// ParseValidators parses the response of CometBFT /validators RPC into Validators.
+//
+// The RPC is paginated, all the pages must be provided in order,
+// they must be of the same block height and contain exactly the total number of validators.
+// Both the JSON-RPC envelope and the bare result are accepted.
+func ParseValidators(pages ...[]byte) (Validators, error) {
+	if len(pages) < 1 {
+		return nil, fmt.Errorf("require at least one page")
+	}
+
+	var validators Validators
+	var blockHeight, total jsonInt64
+	for i, page := range pages {
+		bzResult, err := unwrapRpcResult(page)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %v", i+1, err)
+		}
+
+		var result validatorsResultJson
+		if err := json.Unmarshal(bzResult, &result); err != nil {
+			return nil, fmt.Errorf("page %d: invalid validators response: %v", i+1, err)
+		}
+
+		if i == 0 {
+			blockHeight, total = result.BlockHeight, result.Total
+		} else if result.BlockHeight != blockHeight {
+			return nil, fmt.Errorf("page %d: block height %d mismatch with %d of the first page", i+1, result.BlockHeight, blockHeight)
+		}
+
+		for _, v := range result.Validators {
+			validator, err := toValidator(v)
+			if err != nil {
+				return nil, fmt.Errorf("page %d: %v", i+1, err)
+			}
+			validators = append(validators, validator)
+		}
+	}
+
+	if len(validators) != int(total) {
+		return nil, fmt.Errorf("got %d validators, want total %d", len(validators), total)
+	}
+
+	return validators, nil
+}
+
+func toValidator(v validatorJson) (Validator, error) {
+	if err := types.ValidateConsensusAddress(v.Address); err != nil {
+		return Validator{}, fmt.Errorf("invalid validator address %s: %v", v.Address, err)
+	}
+	if v.VotingPower < 0 {
+		return Validator{}, fmt.Errorf("invalid voting power %d of validator %s", v.VotingPower, v.Address)
+	}
+	return Validator{
+		Address:     strings.ToUpper(v.Address),
+		VotingPower: int64(v.VotingPower),
+	}, nil
+}
+
+// TotalVotingPower returns the sum of voting power of all the validators.
+func (vs Validators) TotalVotingPower() int64 {
+	var total int64
+	for _, v := range vs {
+		total += v.VotingPower
+	}
+	return total
+}
+
+// ToStreamingLightValidators converts the validator set into light validators.
+//
+// Monikers are looked up by upper case hex consensus address, validators without moniker are named by the address.
+func (vs Validators) ToStreamingLightValidators(monikerByConsensusAddress map[string]string) (types.StreamingLightValidators, error) {
+	if len(vs) < 1 {
+		return nil, fmt.Errorf("empty validator set")
+	}
+	totalVotingPower := vs.TotalVotingPower()
+	if totalVotingPower < 1 {
+		return nil, fmt.Errorf("invalid total voting power %d", totalVotingPower)
+	}
+
+	validators := make(types.StreamingLightValidators, 0, len(vs))
+	for i, v := range vs {
+		moniker, found := monikerByConsensusAddress[v.Address]
+		if !found {
+			moniker = v.Address
+		}
+		validators = append(validators, types.StreamingLightValidator{
+			Index:                     i,
+			VotingPowerDisplayPercent: percentOf(v.VotingPower, totalVotingPower),
+			Moniker:                   moniker,
+			ConsensusAddress:          v.Address,
+		})
+	}
+
+	return validators, nil
+}
+
+// percentOf returns the percent of part in total, rounded to 2 decimal places which is the precision of codecs.
+func percentOf(part, total int64) float64 {
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
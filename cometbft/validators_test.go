@@ -0,0 +1,113 @@
+package cometbft
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseValidators(t *testing.T) {
+	page1 := readFixture(t, "validators_page_1.json")
+	page2 := readFixture(t, "validators_page_2.json")
+
+	t.Run("all pages", func(t *testing.T) {
+		validators, err := ParseValidators(page1, page2)
+		require.NoError(t, err)
+		require.Len(t, validators, 5)
+		require.Equal(t, int64(10000), validators.TotalVotingPower())
+
+		lightValidators, err := validators.ToStreamingLightValidators(map[string]string{
+			"0A1B2C3D4E5F60718293A4B5C6D7E8F901234567": "Validator One",
+			"2C3B4A5968778695A4B3C2D1E0F1E2D3C4B5A697": "Validator Three",
+		})
+		require.NoError(t, err)
+		requireGolden(t, "validators.golden.json", lightValidators)
+	})
+
+	t.Run("bare result without envelope", func(t *testing.T) {
+		validators, err := ParseValidators([]byte(`{"block_height":"1","validators":[{"address":"0a1b2c3d4e5f60718293a4b5c6d7e8f901234567","voting_power":"1"}],"count":"1","total":"1"}`))
+		require.NoError(t, err)
+		require.Equal(t, Validators{{Address: "0A1B2C3D4E5F60718293A4B5C6D7E8F901234567", VotingPower: 1}}, validators)
+	})
+
+	tests := []struct {
+		name            string
+		pages           [][]byte
+		wantErrContains string
+	}{
+		{
+			name:            "no page",
+			pages:           nil,
+			wantErrContains: "require at least one page",
+		},
+		{
+			name:            "missing page",
+			pages:           [][]byte{page1},
+			wantErrContains: "got 3 validators, want total 5",
+		},
+		{
+			name:            "page of different height",
+			pages:           [][]byte{page1, []byte(`{"block_height":"2","validators":[],"total":"5"}`)},
+			wantErrContains: "page 2: block height 2 mismatch with 1234567",
+		},
+		{
+			name:            "RPC error",
+			pages:           [][]byte{[]byte(`{"jsonrpc":"2.0","id":-1,"error":{"code":-32603,"message":"Internal error","data":"height 1 must be less than or equal to the current blockchain height 0"}}`)},
+			wantErrContains: "RPC error -32603",
+		},
+		{
+			name:            "invalid address",
+			pages:           [][]byte{[]byte(`{"block_height":"1","validators":[{"address":"0A1B","voting_power":"1"}],"total":"1"}`)},
+			wantErrContains: "invalid validator address 0A1B",
+		},
+		{
+			name:            "negative voting power",
+			pages:           [][]byte{[]byte(`{"block_height":"1","validators":[{"address":"0A1B2C3D4E5F60718293A4B5C6D7E8F901234567","voting_power":"-1"}],"total":"1"}`)},
+			wantErrContains: "invalid voting power -1",
+		},
+		{
+			name:            "invalid voting power",
+			pages:           [][]byte{[]byte(`{"block_height":"1","validators":[{"address":"0A1B2C3D4E5F60718293A4B5C6D7E8F901234567","voting_power":"x"}],"total":"1"}`)},
+			wantErrContains: "invalid integer",
+		},
+		{
+			name:            "not JSON",
+			pages:           [][]byte{[]byte(`<html>`)},
+			wantErrContains: "invalid JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseValidators(tt.pages...)
+			require.Error(t, err)
+			require.ErrorContains(t, err, tt.wantErrContains)
+		})
+	}
+}
+
+func TestValidators_ToStreamingLightValidators(t *testing.T) {
+	t.Run("percent rounded to 2 decimal places", func(t *testing.T) {
+		validators := Validators{
+			{Address: "0A1B2C3D4E5F60718293A4B5C6D7E8F901234567", VotingPower: 1},
+			{Address: "1F2E3D4C5B6A79880716253443526170A1B2C3D4", VotingPower: 1},
+			{Address: "2C3B4A5968778695A4B3C2D1E0F1E2D3C4B5A697", VotingPower: 1},
+		}
+		lightValidators, err := validators.ToStreamingLightValidators(nil)
+		require.NoError(t, err)
+		for i, v := range lightValidators {
+			require.Equal(t, i, v.Index)
+			require.Equal(t, 33.33, v.VotingPowerDisplayPercent)
+			require.Equal(t, validators[i].Address, v.Moniker)
+			require.Equal(t, validators[i].Address, v.ConsensusAddress)
+		}
+	})
+
+	t.Run("reject empty validator set", func(t *testing.T) {
+		_, err := Validators{}.ToStreamingLightValidators(nil)
+		require.Error(t, err)
+	})
+
+	t.Run("reject zero total voting power", func(t *testing.T) {
+		_, err := Validators{{Address: "0A1B2C3D4E5F60718293A4B5C6D7E8F901234567"}}.ToStreamingLightValidators(nil)
+		require.Error(t, err)
+	})
+}
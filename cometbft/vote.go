@@ -0,0 +1,110 @@
+package cometbft
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// nilVote is how CometBFT renders the vote of a validator which has not voted yet.
+const nilVote = "nil-Vote"
+
+// fingerprintLength is the length of fingerprints rendered by CometBFT, hex of the first 6 bytes.
+const fingerprintLength = 12
+
+// zeroesFingerprint is the fingerprint of the empty block hash, which is rendered for votes for nil.
+const zeroesFingerprint = "000000000000"
+
+// Vote is a prevote or precommit, as rendered by CometBFT in the consensus state.
+type Vote struct {
+	ValidatorIndex int
+	// ValidatorAddressFingerprint is the fingerprint of the validator consensus address, upper case hex.
+	ValidatorAddressFingerprint string
+	Height                      int64
+	Round                       int32
+	// BlockHashFingerprint is the fingerprint of the voted block hash, upper case hex,
+	// all zeroes when voted for nil.
+	BlockHashFingerprint string
+}
+
+// IsVotedZeroes returns true if the vote is for nil, the block hash is empty.
+func (v Vote) IsVotedZeroes() bool {
+	return v.BlockHashFingerprint == zeroesFingerprint
+}
+
+// StreamingBlockHash returns the first 2 bytes of the block hash fingerprint, upper case hex,
+// which is the fingerprint format used by the streaming types.
+func (v Vote) StreamingBlockHash() string {
+	return v.BlockHashFingerprint[:4]
+}
+
+// parseVote parses the string form of a vote, as rendered by CometBFT:
+//
+//	Vote{0:ABCDEF123456 100/00/SIGNED_MSG_TYPE_PREVOTE(Prevote) 0123456789AB 0123456789AB @ 2024-01-01T00:00:00.000000000Z}
+//
+// since CometBFT v0.38, the vote extension fingerprint is rendered after the signature fingerprint.
+// Returns nil if the validator has not voted yet.
+func parseVote(str string, wantTypeName string) (*Vote, error) {
+	if str == nilVote {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(str, "Vote{") || !strings.HasSuffix(str, "}") {
+		return nil, fmt.Errorf("invalid vote format: %s", str)
+	}
+
+	fields := strings.Fields(str[len("Vote{") : len(str)-1])
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("invalid vote format, not enough fields: %s", str)
+	}
+
+	var vote Vote
+
+	spl := strings.Split(fields[0], ":")
+	if len(spl) != 2 {
+		return nil, fmt.Errorf("invalid vote validator: %s", fields[0])
+	}
+	validatorIndex, err := strconv.ParseUint(spl[0], 10, 31)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vote validator index: %s", spl[0])
+	}
+	vote.ValidatorIndex = int(validatorIndex)
+	if !isFingerprint(spl[1]) {
+		return nil, fmt.Errorf("invalid vote validator address fingerprint: %s", spl[1])
+	}
+	vote.ValidatorAddressFingerprint = strings.ToUpper(spl[1])
+
+	spl = strings.Split(fields[1], "/")
+	if len(spl) != 3 {
+		return nil, fmt.Errorf("invalid vote height/round/type: %s", fields[1])
+	}
+	height, err := strconv.ParseInt(spl[0], 10, 64)
+	if err != nil || height < 0 {
+		return nil, fmt.Errorf("invalid vote height: %s", spl[0])
+	}
+	vote.Height = height
+	round, err := strconv.ParseInt(spl[1], 10, 32)
+	if err != nil || round < 0 {
+		return nil, fmt.Errorf("invalid vote round: %s", spl[1])
+	}
+	vote.Round = int32(round)
+	if !strings.HasSuffix(spl[2], "("+wantTypeName+")") {
+		return nil, fmt.Errorf("invalid vote type %s, want %s", spl[2], wantTypeName)
+	}
+
+	if !isFingerprint(fields[2]) {
+		return nil, fmt.Errorf("invalid vote block hash fingerprint: %s", fields[2])
+	}
+	vote.BlockHashFingerprint = strings.ToUpper(fields[2])
+
+	return &vote, nil
+}
+
+func isFingerprint(str string) bool {
+	if len(str) != fingerprintLength {
+		return false
+	}
+	_, err := hex.DecodeString(str)
+	return err == nil
+}
@@ -0,0 +1,82 @@
+package cometbft
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_parseVote(t *testing.T) {
+	tests := []struct {
+		name     string
+		str      string
+		typeName string
+		want     *Vote
+		wantErr  bool
+	}{
+		{
+			name:     "not voted",
+			str:      "nil-Vote",
+			typeName: "Prevote",
+			want:     nil,
+		},
+		{
+			name:     "with vote extension",
+			str:      "Vote{3:3d4c5b6a7988 100/02/SIGNED_MSG_TYPE_PRECOMMIT(Precommit) c0ffee112233 6A7B8C9D0E1F 000000000000 @ 2024-03-01T10:00:01.123456789Z}",
+			typeName: "Precommit",
+			want: &Vote{
+				ValidatorIndex:              3,
+				ValidatorAddressFingerprint: "3D4C5B6A7988",
+				Height:                      100,
+				Round:                       2,
+				BlockHashFingerprint:        "C0FFEE112233",
+			},
+		},
+		{
+			name:     "without vote extension",
+			str:      "Vote{0:0A1B2C3D4E5F 100/00/SIGNED_MSG_TYPE_PREVOTE(Prevote) 000000000000 6A7B8C9D0E1F @ 2024-03-01T10:00:01.123456789Z}",
+			typeName: "Prevote",
+			want: &Vote{
+				ValidatorIndex:              0,
+				ValidatorAddressFingerprint: "0A1B2C3D4E5F",
+				Height:                      100,
+				Round:                       0,
+				BlockHashFingerprint:        zeroesFingerprint,
+			},
+		},
+		{
+			name:     "wrong type",
+			str:      "Vote{0:0A1B2C3D4E5F 100/00/SIGNED_MSG_TYPE_PREVOTE(Prevote) 000000000000 6A7B8C9D0E1F @ 2024-03-01T10:00:01.123456789Z}",
+			typeName: "Precommit",
+			wantErr:  true,
+		},
+		{
+			name:     "negative index",
+			str:      "Vote{-1:0A1B2C3D4E5F 100/00/SIGNED_MSG_TYPE_PREVOTE(Prevote) 000000000000 6A7B8C9D0E1F @ 2024-03-01T10:00:01.123456789Z}",
+			typeName: "Prevote",
+			wantErr:  true,
+		},
+		{
+			name:     "short block hash fingerprint",
+			str:      "Vote{0:0A1B2C3D4E5F 100/00/SIGNED_MSG_TYPE_PREVOTE(Prevote) C0FF 6A7B8C9D0E1F @ 2024-03-01T10:00:01.123456789Z}",
+			typeName: "Prevote",
+			wantErr:  true,
+		},
+		{
+			name:     "not a vote",
+			str:      "Proposal{}",
+			typeName: "Prevote",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVote(tt.str, tt.typeName)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -8,13 +8,37 @@ const (
 	STREAMING_PATH_REGISTER_PRE_VOTE          = "register-session/pre-vote/:chainId"
 	STREAMING_PATH_RESUME_PRE_VOTE            = "resume-session/pre-vote/:sessionId"
 	STREAMING_PATH_BROADCAST_PRE_VOTE         = "broadcast/pre-vote/:sessionId"
+	STREAMING_PATH_BROADCAST_PRE_VOTE_WS      = "broadcast-ws/pre-vote/:sessionId" // WebSocket, authenticated once at the handshake
+	STREAMING_PATH_REVOKE_PRE_VOTE            = "revoke-session/pre-vote/:sessionId"
 	STREAMING_PATH_VIEW_PRE_VOTE              = "pvtop/:sessionId"
 	STREAMING_PATH_VIEW_PRE_VOTE_FETCH_UPDATE = "pvtop/:sessionId/update"
+	STREAMING_PATH_VIEW_PRE_VOTE_SUBSCRIBE    = "pvtop/:sessionId/events" // Server-Sent Events, each broadcast frame is pushed as base64
 
-	STREAMING_CONTENT_TYPE       = "application/octet-stream"
-	STREAMING_HEADER_SESSION_KEY = "X-Session-Key"
+	STREAMING_CONTENT_TYPE              = "application/octet-stream"
+	STREAMING_CONTENT_TYPE_EVENT_STREAM = "text/event-stream"
+	STREAMING_HEADER_SESSION_KEY        = "X-Session-Key" // raw session key, prefer the signature headers below
+
+	// signature of the method, the URL path and the body of the request, see types.BroadcastSignature
+	STREAMING_HEADER_SIGNATURE           = "X-Signature"
+	STREAMING_HEADER_SIGNATURE_TIMESTAMP = "X-Signature-Timestamp"
+	STREAMING_HEADER_SIGNATURE_NONCE     = "X-Signature-Nonce"
+
+	STREAMING_EVENT_UPDATE = "update" // SSE event name of the broadcast frames
+)
+
+// Kinds of the binary WebSocket broadcast frames, each frame is 8 bytes big-endian sequence, 1 byte kind
+// and the encoded payload. The server acknowledges each frame by a JSON text message.
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	STREAMING_WS_FRAME_KIND_NEXT_BLOCK_VOTING_INFO byte = 0x01
+	STREAMING_WS_FRAME_KIND_LIGHT_VALIDATORS       byte = 0x02
+
+	STREAMING_WS_FRAME_HEADER_SIZE = 8 + 1
 )
 
+// Deprecated: the limits are different per codec version, use CvpCodec.GetLimits instead.
+//
 //goland:noinspection GoSnakeCaseUsage
 const (
 	MAX_VALIDATORS                             = 250
@@ -0,0 +1,154 @@
+// Package cliinput reads the encoded payloads given to the command-line tools, as raw bytes or hex or base64 text.
+package cliinput
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"io"
+	"os"
+	"strings"
+)
+
+// Input formats of the payloads.
+const (
+	// FormatAuto tries hex, then base64, then raw, picking the first with detectable encoding version.
+	FormatAuto   = "auto"
+	FormatRaw    = "raw"
+	FormatHex    = "hex"
+	FormatBase64 = "base64"
+)
+
+// ValidateFormat returns an error if the format is not one of the input formats.
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatAuto, FormatRaw, FormatHex, FormatBase64:
+		return nil
+	default:
+		return fmt.Errorf("invalid input format %q", format)
+	}
+}
+
+// ReadSource reads the file, or stdin if the source is "-".
+func ReadSource(source string, stdin io.Reader) ([]byte, error) {
+	if source == "-" {
+		bz, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %v", err)
+		}
+		return bz, nil
+	}
+	return os.ReadFile(source)
+}
+
+// Decode returns the payload of the input in the format, and the format actually decoded if auto.
+func Decode(input []byte, format string) (payload []byte, decodedFormat string, err error) {
+	payloads, decodedFormat, err := decode(input, format, false)
+	if err != nil {
+		return nil, "", err
+	}
+	return payloads[0], decodedFormat, nil
+}
+
+// DecodeLines is Decode for multiple payloads: each non-empty line of hex or base64 text is a payload,
+// while the raw input is a single payload.
+func DecodeLines(input []byte, format string) (payloads [][]byte, decodedFormat string, err error) {
+	return decode(input, format, true)
+}
+
+func decode(input []byte, format string, lines bool) ([][]byte, string, error) {
+	decodeText := func(decode func([]byte) ([]byte, error)) ([][]byte, error) {
+		if !lines {
+			payload, err := decode(input)
+			if err != nil {
+				return nil, err
+			}
+			return [][]byte{payload}, nil
+		}
+
+		var payloads [][]byte
+		for i, line := range bytes.Split(input, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			payload, err := decode(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			payloads = append(payloads, payload)
+		}
+		return payloads, nil
+	}
+
+	var payloads [][]byte
+	var err error
+	switch format {
+	case FormatRaw:
+		payloads = [][]byte{input}
+	case FormatHex:
+		payloads, err = decodeText(decodeHex)
+	case FormatBase64:
+		payloads, err = decodeText(decodeBase64)
+	default:
+		for _, candidate := range []struct {
+			format string
+			decode func([]byte) ([]byte, error)
+		}{
+			{format: FormatHex, decode: decodeHex},
+			{format: FormatBase64, decode: decodeBase64},
+		} {
+			if payloads, err := decodeText(candidate.decode); err == nil && allDetectable(payloads) {
+				return payloads, candidate.format, nil
+			}
+		}
+		payloads, format = [][]byte{input}, FormatRaw
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(payloads) == 0 {
+		return nil, "", fmt.Errorf("empty payload")
+	}
+	for _, payload := range payloads {
+		if len(payload) == 0 {
+			return nil, "", fmt.Errorf("empty payload")
+		}
+	}
+	return payloads, format, nil
+}
+
+func allDetectable(payloads [][]byte) bool {
+	for _, payload := range payloads {
+		if _, detected := codec.DetectEncodingVersion(payload); !detected {
+			return false
+		}
+	}
+	return len(payloads) > 0
+}
+
+// decodeHex decodes the hex text, whitespaces and the 0x prefix are ignored.
+func decodeHex(input []byte) ([]byte, error) {
+	text := strings.Join(strings.Fields(string(input)), "")
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "0x"), "0X")
+	bz, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %v", err)
+	}
+	return bz, nil
+}
+
+// decodeBase64 decodes the standard or URL base64 text, padded or not, whitespaces are ignored.
+func decodeBase64(input []byte) ([]byte, error) {
+	text := strings.Join(strings.Fields(string(input)), "")
+	var err error
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		var bz []byte
+		if bz, err = encoding.DecodeString(text); err == nil {
+			return bz, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64: %v", err)
+}
@@ -0,0 +1,137 @@
+package cliinput
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testPayload = codec.GetCvpCodecV2().EncodeStreamingLightValidators(types.StreamingLightValidators{
+	{Index: 0, VotingPowerDisplayPercent: 100, Moniker: "Validator"},
+})
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      []byte
+		format     string
+		wantFormat string
+		wantErr    string
+	}{
+		{
+			name:       "auto raw",
+			input:      testPayload,
+			format:     FormatAuto,
+			wantFormat: FormatRaw,
+		},
+		{
+			name:       "auto hex with prefix and spaces",
+			input:      []byte("0x" + hex.EncodeToString(testPayload[:3]) + " \n" + hex.EncodeToString(testPayload[3:]) + "\n"),
+			format:     FormatAuto,
+			wantFormat: FormatHex,
+		},
+		{
+			name:       "auto base64",
+			input:      []byte(base64.StdEncoding.EncodeToString(testPayload) + "\n"),
+			format:     FormatAuto,
+			wantFormat: FormatBase64,
+		},
+		{
+			name:       "base64 url without padding",
+			input:      []byte(base64.RawURLEncoding.EncodeToString(testPayload)),
+			format:     FormatBase64,
+			wantFormat: FormatBase64,
+		},
+		{
+			name:    "invalid hex",
+			input:   []byte("xyz"),
+			format:  FormatHex,
+			wantErr: "invalid hex",
+		},
+		{
+			name:    "invalid base64",
+			input:   []byte("!!"),
+			format:  FormatBase64,
+			wantErr: "invalid base64",
+		},
+		{
+			name:    "empty",
+			format:  FormatAuto,
+			wantErr: "empty payload",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, format, err := Decode(tt.input, tt.format)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFormat, format)
+			require.Equal(t, testPayload, payload)
+		})
+	}
+}
+
+func TestDecodeLines(t *testing.T) {
+	hexLine := hex.EncodeToString(testPayload)
+
+	t.Run("hex lines", func(t *testing.T) {
+		payloads, format, err := DecodeLines([]byte(hexLine+"\n\n"+hexLine+"\n"), FormatAuto)
+		require.NoError(t, err)
+		require.Equal(t, FormatHex, format)
+		require.Equal(t, [][]byte{testPayload, testPayload}, payloads)
+	})
+
+	t.Run("base64 lines", func(t *testing.T) {
+		line := base64.StdEncoding.EncodeToString(testPayload)
+		payloads, format, err := DecodeLines([]byte(line+"\r\n"+line), FormatBase64)
+		require.NoError(t, err)
+		require.Equal(t, FormatBase64, format)
+		require.Len(t, payloads, 2)
+	})
+
+	t.Run("raw is a single payload", func(t *testing.T) {
+		payloads, format, err := DecodeLines(testPayload, FormatAuto)
+		require.NoError(t, err)
+		require.Equal(t, FormatRaw, format)
+		require.Equal(t, [][]byte{testPayload}, payloads)
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		_, _, err := DecodeLines([]byte(hexLine+"\nxyz\n"), FormatHex)
+		require.ErrorContains(t, err, "line 2: invalid hex")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, _, err := DecodeLines([]byte("\n \n"), FormatHex)
+		require.ErrorContains(t, err, "empty payload")
+	})
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{FormatAuto, FormatRaw, FormatHex, FormatBase64} {
+		require.NoError(t, ValidateFormat(format))
+	}
+	require.ErrorContains(t, ValidateFormat("json"), `invalid input format "json"`)
+}
+
+func TestReadSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.bin")
+	require.NoError(t, os.WriteFile(path, testPayload, 0o600))
+
+	bz, err := ReadSource(path, nil)
+	require.NoError(t, err)
+	require.Equal(t, testPayload, bz)
+
+	bz, err = ReadSource("-", strings.NewReader("stdin"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("stdin"), bz)
+}
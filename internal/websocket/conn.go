@@ -0,0 +1,286 @@
+// Package websocket is a minimal RFC 6455 WebSocket implementation, just enough for the streaming transport:
+// no extensions and no sub-protocols, fragmented messages are re-assembled.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Opcodes of the frames, RFC 6455 section 5.2.
+const (
+	OpContinuation byte = 0x0
+	OpText         byte = 0x1
+	OpBinary       byte = 0x2
+	OpClose        byte = 0x8
+	OpPing         byte = 0x9
+	OpPong         byte = 0xA
+)
+
+// Close status codes, RFC 6455 section 7.4.1.
+const (
+	CloseNormalClosure   = 1000
+	CloseProtocolError   = 1002
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// CloseError is returned by ReadMessage when the peer closed the connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket closed %d %s", e.Code, e.Reason)
+}
+
+// ErrMessageTooBig is returned by ReadMessage when the message exceeds the read limit.
+var ErrMessageTooBig = fmt.Errorf("websocket message too big")
+
+// Conn is a WebSocket connection.
+// ReadMessage must not be called concurrently, WriteMessage and Close are safe for concurrent use.
+type Conn struct {
+	netConn  net.Conn
+	reader   *bufio.Reader
+	isClient bool // client masks the frames it sends
+
+	readLimit   int
+	idleTimeout time.Duration
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+func newConn(netConn net.Conn, reader *bufio.Reader, isClient bool) *Conn {
+	return &Conn{
+		netConn:   netConn,
+		reader:    reader,
+		isClient:  isClient,
+		readLimit: 1 << 20,
+	}
+}
+
+// SetReadLimit sets the maximum size of a message read from the peer.
+func (c *Conn) SetReadLimit(limit int) {
+	c.readLimit = limit
+}
+
+// SetIdleTimeout makes reading fail when no frame, including control frames, is received within the timeout.
+// Zero disables the timeout.
+func (c *Conn) SetIdleTimeout(timeout time.Duration) {
+	c.idleTimeout = timeout
+}
+
+// ReadMessage reads the next text or binary message, fragmented messages are re-assembled.
+// Ping is answered and pong is ignored. When the peer closes the connection, the close is answered
+// and CloseError is returned.
+func (c *Conn) ReadMessage() (opcode byte, payload []byte, err error) {
+	message := make([]byte, 0)
+	messageOpcode := byte(0)
+	for {
+		fin, op, data, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case OpPing:
+			if err := c.writeFrame(OpPong, data); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			closeErr := &CloseError{Code: CloseNormalClosure}
+			if len(data) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(data))
+				closeErr.Reason = string(data[2:])
+			}
+			_ = c.closeWith(closeErr.Code, "")
+			return 0, nil, closeErr
+		case OpText, OpBinary:
+			if messageOpcode != 0 {
+				_ = c.closeWith(CloseProtocolError, "expect continuation frame")
+				return 0, nil, fmt.Errorf("websocket: expect continuation frame")
+			}
+			messageOpcode = op
+		case OpContinuation:
+			if messageOpcode == 0 {
+				_ = c.closeWith(CloseProtocolError, "unexpected continuation frame")
+				return 0, nil, fmt.Errorf("websocket: unexpected continuation frame")
+			}
+		default:
+			_ = c.closeWith(CloseProtocolError, "unknown opcode")
+			return 0, nil, fmt.Errorf("websocket: unknown opcode %d", op)
+		}
+
+		if len(message)+len(data) > c.readLimit {
+			_ = c.closeWith(CloseMessageTooBig, "")
+			return 0, nil, ErrMessageTooBig
+		}
+		message = append(message, data...)
+
+		if fin {
+			return messageOpcode, message, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	if c.idleTimeout > 0 {
+		if err = c.netConn.SetReadDeadline(time.Now().Add(c.idleTimeout)); err != nil {
+			return
+		}
+	}
+
+	var header [2]byte
+	if _, err = io.ReadFull(c.reader, header[:]); err != nil {
+		return
+	}
+
+	fin = header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		_ = c.closeWith(CloseProtocolError, "reserved bits")
+		err = fmt.Errorf("websocket: reserved bits are set")
+		return
+	}
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	if masked == c.isClient {
+		// RFC 6455 section 5.1, client frames must be masked and server frames must not be
+		_ = c.closeWith(CloseProtocolError, "bad masking")
+		err = fmt.Errorf("websocket: bad masking")
+		return
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if opcode >= OpClose && (length > 125 || !fin) {
+		_ = c.closeWith(CloseProtocolError, "bad control frame")
+		err = fmt.Errorf("websocket: bad control frame")
+		return
+	}
+	if length > uint64(c.readLimit) {
+		_ = c.closeWith(CloseMessageTooBig, "")
+		err = ErrMessageTooBig
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+
+	return
+}
+
+// WriteMessage writes a single frame message.
+func (c *Conn) WriteMessage(opcode byte, payload []byte) error {
+	return c.writeFrame(opcode, payload)
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|opcode)
+
+	maskBit := byte(0)
+	if c.isClient {
+		maskBit = 0x80
+	}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+
+	if c.isClient {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		begin := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(mask, frame[begin:])
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	_, err := c.netConn.Write(frame)
+	return err
+}
+
+// Close sends the normal closure and closes the underlying connection.
+func (c *Conn) Close() error {
+	return c.closeWith(CloseNormalClosure, "")
+}
+
+// CloseWithStatus sends the close frame with the given status and closes the underlying connection.
+func (c *Conn) CloseWithStatus(code int, reason string) error {
+	return c.closeWith(code, reason)
+}
+
+func (c *Conn) closeWith(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason...)
+	_ = c.netConn.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = c.writeFrame(OpClose, payload)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.netConn.Close()
+}
+
+func maskBytes(mask [4]byte, bz []byte) {
+	for i := range bz {
+		bz[i] ^= mask[i%4]
+	}
+}
@@ -0,0 +1,307 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestComputeAccept(t *testing.T) {
+	// sample of RFC 6455 section 1.3
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", computeAccept("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func TestDialUpgrade_Echo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") != "1" {
+			http.Error(w, "missing header", http.StatusUnauthorized)
+			return
+		}
+		conn, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		conn.SetReadLimit(70000)
+		for {
+			opcode, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(opcode, message); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	t.Run("handshake rejected", func(t *testing.T) {
+		_, err := Dial(ctx, url, nil)
+		var handshakeErr *HandshakeError
+		require.True(t, errors.As(err, &handshakeErr))
+		require.Equal(t, http.StatusUnauthorized, handshakeErr.Response.StatusCode)
+		require.Equal(t, "missing header", handshakeErr.Body)
+	})
+
+	conn, err := Dial(ctx, url, http.Header{"X-Test": []string{"1"}})
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	for _, size := range []int{0, 125, 126, 0xFFFF, 0xFFFF + 1} {
+		payload := bytes.Repeat([]byte{0xAB}, size)
+		require.NoError(t, conn.WriteMessage(OpBinary, payload))
+		opcode, message, err := conn.ReadMessage()
+		require.NoError(t, err)
+		require.Equal(t, OpBinary, opcode)
+		require.Equal(t, payload, message, "size %d", size)
+	}
+
+	require.NoError(t, conn.WriteMessage(OpPing, []byte("ping")))
+	require.NoError(t, conn.WriteMessage(OpText, []byte("after ping")))
+	opcode, message, err := conn.ReadMessage()
+	require.NoError(t, err, "pong must be skipped")
+	require.Equal(t, OpText, opcode)
+	require.Equal(t, "after ping", string(message))
+
+	require.NoError(t, conn.WriteMessage(OpBinary, make([]byte, 70001)))
+	_, _, err = conn.ReadMessage()
+	var closeErr *CloseError
+	require.True(t, errors.As(err, &closeErr))
+	require.Equal(t, CloseMessageTooBig, closeErr.Code)
+}
+
+func TestConn_ReadMessage_Fragmented(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	defer func() {
+		_ = clientSide.Close()
+	}()
+	server := newConn(serverSide, bufio.NewReader(serverSide), false)
+
+	go func() {
+		mask := [4]byte{1, 2, 3, 4}
+		writeMaskedFrame := func(header byte, payload string) {
+			bz := []byte(payload)
+			maskBytes(mask, bz)
+			_, _ = clientSide.Write(append([]byte{header, 0x80 | byte(len(bz)), mask[0], mask[1], mask[2], mask[3]}, bz...))
+		}
+		writeMaskedFrame(OpText, "hel")         // not final
+		writeMaskedFrame(0x80|OpPing, "")       // control frame interleaved
+		writeMaskedFrame(OpContinuation, "lo ") // not final
+		writeMaskedFrame(0x80|OpContinuation, "world")
+	}()
+
+	go func() {
+		// drain the pong
+		buf := make([]byte, 16)
+		_, _ = clientSide.Read(buf)
+	}()
+
+	opcode, message, err := server.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, OpText, opcode)
+	require.Equal(t, "hello world", string(message))
+}
+
+func TestConn_ReadMessage_RejectUnmaskedClientFrame(t *testing.T) {
+	clientSide, serverSide := net.Pipe()
+	defer func() {
+		_ = clientSide.Close()
+	}()
+	server := newConn(serverSide, bufio.NewReader(serverSide), false)
+
+	go func() {
+		_, _ = clientSide.Write([]byte{0x80 | OpBinary, 1, 0xFF})
+		buf := make([]byte, 16)
+		_, _ = clientSide.Read(buf) // drain the close frame
+	}()
+
+	_, _, err := server.ReadMessage()
+	require.ErrorContains(t, err, "bad masking")
+}
+
+// maskedFrame builds a frame as sent by clients, header is the first byte: FIN, reserved bits and opcode.
+func maskedFrame(header byte, payload []byte) []byte {
+	mask := [4]byte{1, 2, 3, 4}
+	bz := append([]byte{}, payload...)
+	maskBytes(mask, bz)
+	return append([]byte{header, 0x80 | byte(len(bz)), mask[0], mask[1], mask[2], mask[3]}, bz...)
+}
+
+type sentFrame struct {
+	opcode  byte
+	payload []byte
+}
+
+// readServerFrames parses the unmasked frames, sent by the server, until the end of the data.
+func readServerFrames(t *testing.T, bz []byte) []sentFrame {
+	var frames []sentFrame
+	for len(bz) > 0 {
+		require.GreaterOrEqual(t, len(bz), 2)
+		require.NotZero(t, bz[0]&0x80, "server frames must be final")
+		length := int(bz[1] & 0x7F)
+		require.Less(t, length, 126, "control frames only")
+		require.GreaterOrEqual(t, len(bz), 2+length)
+		frames = append(frames, sentFrame{opcode: bz[0] & 0x0F, payload: bz[2 : 2+length]})
+		bz = bz[2+length:]
+	}
+	return frames
+}
+
+func TestConn_ReadMessage_ControlAndFragmentedFrames(t *testing.T) {
+	closePayload := func(code int, reason string) []byte {
+		return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+	}
+
+	tests := []struct {
+		name           string
+		frames         [][]byte
+		readLimit      int
+		wantOpcode     byte
+		wantMessage    string
+		wantErr        error
+		wantErrContain string
+		wantSent       []sentFrame
+	}{
+		{
+			name: "ping answered by pong of the same payload, between fragments",
+			frames: [][]byte{
+				maskedFrame(OpBinary, []byte("ab")),
+				maskedFrame(0x80|OpPing, []byte("p1")),
+				maskedFrame(0x80|OpPong, []byte("ignored")),
+				maskedFrame(0x80|OpContinuation, []byte("cd")),
+			},
+			wantOpcode:  OpBinary,
+			wantMessage: "abcd",
+			wantSent:    []sentFrame{{opcode: OpPong, payload: []byte("p1")}},
+		},
+		{
+			name: "empty fragments",
+			frames: [][]byte{
+				maskedFrame(OpText, nil),
+				maskedFrame(OpContinuation, []byte("x")),
+				maskedFrame(0x80|OpContinuation, nil),
+			},
+			wantOpcode:  OpText,
+			wantMessage: "x",
+		},
+		{
+			name:           "close answered with the same code",
+			frames:         [][]byte{maskedFrame(0x80|OpClose, closePayload(ClosePolicyViolation, "bye"))},
+			wantErr:        &CloseError{Code: ClosePolicyViolation, Reason: "bye"},
+			wantErrContain: "websocket closed 1008 bye",
+			wantSent:       []sentFrame{{opcode: OpClose, payload: closePayload(ClosePolicyViolation, "")}},
+		},
+		{
+			name:           "close without status",
+			frames:         [][]byte{maskedFrame(0x80|OpClose, nil)},
+			wantErrContain: "websocket closed 1000",
+			wantSent:       []sentFrame{{opcode: OpClose, payload: closePayload(CloseNormalClosure, "")}},
+		},
+		{
+			name:           "fragmented control frame",
+			frames:         [][]byte{maskedFrame(OpPing, []byte("p"))},
+			wantErrContain: "bad control frame",
+			wantSent:       []sentFrame{{opcode: OpClose, payload: closePayload(CloseProtocolError, "bad control frame")}},
+		},
+		{
+			name:           "control frame too long",
+			frames:         [][]byte{maskedFrame(0x80|OpPing, bytes.Repeat([]byte{1}, 126))},
+			wantErrContain: "bad control frame",
+			wantSent:       []sentFrame{{opcode: OpClose, payload: closePayload(CloseProtocolError, "bad control frame")}},
+		},
+		{
+			name:           "continuation without beginning",
+			frames:         [][]byte{maskedFrame(0x80|OpContinuation, []byte("x"))},
+			wantErrContain: "unexpected continuation frame",
+			wantSent:       []sentFrame{{opcode: OpClose, payload: closePayload(CloseProtocolError, "unexpected continuation frame")}},
+		},
+		{
+			name: "new message before the previous is final",
+			frames: [][]byte{
+				maskedFrame(OpText, []byte("a")),
+				maskedFrame(0x80|OpText, []byte("b")),
+			},
+			wantErrContain: "expect continuation frame",
+			wantSent:       []sentFrame{{opcode: OpClose, payload: closePayload(CloseProtocolError, "expect continuation frame")}},
+		},
+		{
+			name: "fragments exceed the read limit",
+			frames: [][]byte{
+				maskedFrame(OpBinary, []byte("abc")),
+				maskedFrame(0x80|OpContinuation, []byte("def")),
+			},
+			readLimit: 5,
+			wantErr:   ErrMessageTooBig,
+			wantSent:  []sentFrame{{opcode: OpClose, payload: closePayload(CloseMessageTooBig, "")}},
+		},
+		{
+			name:           "reserved bits",
+			frames:         [][]byte{maskedFrame(0x80|0x40|OpText, []byte("a"))},
+			wantErrContain: "reserved bits",
+			wantSent:       []sentFrame{{opcode: OpClose, payload: closePayload(CloseProtocolError, "reserved bits")}},
+		},
+		{
+			name:           "unknown opcode",
+			frames:         [][]byte{maskedFrame(0x80|0x3, []byte("a"))},
+			wantErrContain: "unknown opcode 3",
+			wantSent:       []sentFrame{{opcode: OpClose, payload: closePayload(CloseProtocolError, "unknown opcode")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientSide, serverSide := net.Pipe()
+			defer func() {
+				_ = clientSide.Close()
+			}()
+			server := newConn(serverSide, bufio.NewReader(serverSide), false)
+			if tt.readLimit > 0 {
+				server.SetReadLimit(tt.readLimit)
+			}
+
+			go func() {
+				for _, frame := range tt.frames {
+					if _, err := clientSide.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+			sent := make(chan []byte)
+			go func() {
+				bz, _ := io.ReadAll(clientSide)
+				sent <- bz
+			}()
+
+			opcode, message, err := server.ReadMessage()
+			_ = serverSide.Close()
+			if tt.wantErr != nil || tt.wantErrContain != "" {
+				require.Error(t, err)
+				if tt.wantErr != nil {
+					require.Equal(t, tt.wantErr, err)
+				}
+				require.ErrorContains(t, err, tt.wantErrContain)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantOpcode, opcode)
+				require.Equal(t, tt.wantMessage, string(message))
+			}
+
+			require.Equal(t, tt.wantSent, readServerFrames(t, <-sent))
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// acceptGuid is the GUID concatenated to the key to compute the accept value, RFC 6455 section 1.3.
+const acceptGuid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+func computeAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGuid))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// IsUpgradeRequest returns true if the request asks for upgrading to WebSocket.
+func IsUpgradeRequest(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") && headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// CheckSameOrigin returns true if the request has no Origin header, as sent by non-browser clients,
+// or the host of the Origin is the host of the request.
+func CheckSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// Upgrade upgrades the HTTP server connection to WebSocket,
+// responds 400 Bad Request and returns error if the request is not a valid WebSocket handshake.
+//
+// Browsers send WebSocket handshakes cross-origin, checkOrigin must return true for the request to be upgraded,
+// otherwise 403 Forbidden is responded. Nil checkOrigin is CheckSameOrigin.
+func Upgrade(w http.ResponseWriter, r *http.Request, checkOrigin func(r *http.Request) bool) (*Conn, error) {
+	if r.Method != http.MethodGet || !IsUpgradeRequest(r) {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return nil, fmt.Errorf("websocket: not an upgrade request")
+	}
+	if checkOrigin == nil {
+		checkOrigin = CheckSameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, fmt.Errorf("websocket: origin %s not allowed", r.Header.Get("Origin"))
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusBadRequest)
+		return nil, fmt.Errorf("websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if bz, err := base64.StdEncoding.DecodeString(key); err != nil || len(bz) != 16 {
+		http.Error(w, "invalid websocket key", http.StatusBadRequest)
+		return nil, fmt.Errorf("websocket: invalid key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("websocket: response writer does not support hijacking")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + computeAccept(key) + "\r\n\r\n"
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+
+	return newConn(netConn, rw.Reader, false), nil
+}
+
+// HandshakeError is returned by Dial when the server does not switch protocols,
+// the response is available for inspecting, with the body already read.
+type HandshakeError struct {
+	Response *http.Response
+	Body     string
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("websocket: handshake failed with status %d", e.Response.StatusCode)
+}
+
+// Dial opens a WebSocket connection to the ws:// or wss:// URL with the additional request headers.
+func Dial(ctx context.Context, rawUrl string, header http.Header) (*Conn, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	var useTls bool
+	switch u.Scheme {
+	case "ws":
+	case "wss":
+		useTls = true
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %s", u.Scheme)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		if useTls {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if useTls {
+		tlsConn := tls.Client(netConn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = netConn.Close()
+			return nil, err
+		}
+		netConn = tlsConn
+	}
+
+	// abort the handshake when the context is done
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = netConn.Close()
+		case <-stop:
+		}
+	}()
+
+	conn, err := handshake(netConn, u, header)
+	if err != nil {
+		_ = netConn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+func handshake(netConn net.Conn, u *url.URL, header http.Header) (*Conn, error) {
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        &url.URL{Path: u.Path, RawQuery: u.RawQuery},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(netConn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(netConn)
+	res, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		_ = res.Body.Close()
+		return nil, &HandshakeError{Response: res, Body: strings.TrimSpace(string(body))}
+	}
+	if res.Header.Get("Sec-WebSocket-Accept") != computeAccept(key) {
+		return nil, fmt.Errorf("websocket: invalid accept")
+	}
+
+	return newConn(netConn, reader, true), nil
+}
+
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
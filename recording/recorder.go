@@ -0,0 +1,206 @@
+// Package recording records the encoded frames of pre-vote streaming sessions to files with their receiving time,
+// and replays them at the original or scaled speed, like for post-mortems of chain halts.
+package recording
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/client"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// recordingMagic is the header of the recording file, the last byte is the format version.
+// The header is followed by the session id length (1) and the session id, then the timestamped frames.
+var recordingMagic = []byte("CVPREC\x00\x01")
+
+// Recorder writes the encoded frames of a session to a recording, each frame is timestamped by the recording time.
+//
+// Frames are recorded as is, in the version they were encoded, so the recording is exactly what was received.
+// Each frame is written by a single write, a partially written frame at the end, after a crash, is skipped when replaying.
+//
+// Recorder is safe for concurrent use.
+type Recorder struct {
+	sessionId types.PreVoteStreamingSessionId
+	now       func() time.Time
+
+	mu     sync.Mutex
+	fw     *codec.FrameWriter
+	file   *os.File // nil if not created by CreateRecording
+	closed bool
+}
+
+// NewRecorder writes the recording header of the session to the writer and returns a Recorder writing to it.
+func NewRecorder(w io.Writer, sessionId types.PreVoteStreamingSessionId) (*Recorder, error) {
+	if err := sessionId.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid session id: %v", err)
+	}
+
+	header := make([]byte, 0, len(recordingMagic)+1+len(sessionId))
+	header = append(header, recordingMagic...)
+	header = append(header, byte(len(sessionId)))
+	header = append(header, sessionId...)
+	if _, err := w.Write(header); err != nil {
+		return nil, errors.Wrap(err, "failed to write recording header")
+	}
+
+	return &Recorder{
+		sessionId: sessionId,
+		now:       time.Now,
+		fw:        codec.NewFrameWriter(w, nil),
+	}, nil
+}
+
+// CreateRecording creates the recording file at the path, truncating it if exists,
+// and returns a Recorder writing to it. The file is closed by Recorder.Close.
+func CreateRecording(path string, sessionId types.PreVoteStreamingSessionId) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create recording file")
+	}
+
+	recorder, err := NewRecorder(file, sessionId)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	recorder.file = file
+	return recorder, nil
+}
+
+// SessionId returns the session id of the recording.
+func (r *Recorder) SessionId() types.PreVoteStreamingSessionId {
+	return r.sessionId
+}
+
+// RecordLightValidators records the encoded light validators, encoded by any registered version.
+func (r *Recorder) RecordLightValidators(encoded []byte) error {
+	return r.record(codec.FrameKindLightValidators, encoded)
+}
+
+// RecordNextBlockVotingInformation records the encoded next block voting information, encoded by any registered version.
+func (r *Recorder) RecordNextBlockVotingInformation(encoded []byte) error {
+	return r.record(codec.FrameKindNextBlockVotingInformation, encoded)
+}
+
+func (r *Recorder) record(kind codec.FrameKind, encoded []byte) error {
+	version, detected := codec.DetectEncodingVersion(encoded)
+	if !detected {
+		return fmt.Errorf("failed to detect the encoding version of the %s", kind)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return os.ErrClosed
+	}
+	if err := r.fw.WriteTimestampedFrame(kind, version, r.now(), encoded); err != nil {
+		return errors.Wrapf(err, "failed to record %s", kind)
+	}
+	return nil
+}
+
+// RecordSubscription subscribes the Server-Sent Events of the session of the recorder, see client.Client.SubscribeEncoded,
+// and records each pushed next block voting information as viewers received it.
+// The light validators are not pushed by the streaming server, record them by RecordLightValidators.
+//
+// It blocks until the context is done, the stream is closed by the server or recording fails.
+func (r *Recorder) RecordSubscription(ctx context.Context, c *client.Client) error {
+	return c.SubscribeEncoded(ctx, r.sessionId, r.RecordNextBlockVotingInformation)
+}
+
+// Close closes the recording file if created by CreateRecording, the recorder is no longer usable.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Sync()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Recording reads the frames of a recording written by Recorder.
+//
+// Recording is not safe for concurrent use.
+type Recording struct {
+	sessionId types.PreVoteStreamingSessionId
+	fr        *codec.FrameReader
+	file      *os.File // nil if not opened by OpenRecording
+}
+
+// NewRecording reads the recording header from the reader and returns a Recording reading the frames from it.
+// The frames are decoded by the proxy codec, so recordings of all the registered versions, like v1 and v2, are readable.
+func NewRecording(r io.Reader) (*Recording, error) {
+	header := make([]byte, len(recordingMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, errors.Wrap(err, "failed to read recording header")
+	}
+	if !bytes.Equal(header[:len(recordingMagic)], recordingMagic) {
+		return nil, fmt.Errorf("not a recording")
+	}
+
+	sessionId := make([]byte, header[len(recordingMagic)])
+	if _, err := io.ReadFull(r, sessionId); err != nil {
+		return nil, errors.Wrap(err, "failed to read recording header")
+	}
+	if err := types.PreVoteStreamingSessionId(sessionId).ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid session id of the recording: %v", err)
+	}
+
+	return &Recording{
+		sessionId: types.PreVoteStreamingSessionId(sessionId),
+		fr:        codec.NewFrameReader(r, nil),
+	}, nil
+}
+
+// OpenRecording opens the recording file at the path. The file is closed by Recording.Close.
+func OpenRecording(path string) (*Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open recording file")
+	}
+
+	recording, err := NewRecording(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	recording.file = file
+	return recording, nil
+}
+
+// SessionId returns the session id of the recording.
+func (r *Recording) SessionId() types.PreVoteStreamingSessionId {
+	return r.sessionId
+}
+
+// Next returns the next frame, decoded and timestamped by the recording time, or io.EOF at the end of the recording.
+// Returns codec.CorruptFrameError if corrupted data was skipped, reading can continue.
+func (r *Recording) Next() (*codec.Frame, error) {
+	return r.fr.ReadFrame()
+}
+
+// Close closes the recording file if opened by OpenRecording.
+func (r *Recording) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,163 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"github.com/bcdevtools/cvp-streaming-core/client"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/server"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testSessionId types.PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+
+var testValidators = types.StreamingLightValidators{
+	{Index: 0, VotingPowerDisplayPercent: 60, Moniker: "Validator One"},
+	{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator Two"},
+}
+
+func newTestInformation(step types.RoundStepType) *types.StreamingNextBlockVotingInformation {
+	return &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.HeightRoundStep{Height: 100, Round: 0, Step: step},
+		Duration:        time.Second,
+		PreVotedPercent: 60,
+		ValidatorVoteStates: []types.StreamingValidatorVoteState{
+			{ValidatorIndex: 0, PreVotedBlockHash: "C0FF", PreVoted: true},
+			{ValidatorIndex: 1, PreVotedBlockHash: "----"},
+		},
+	}
+}
+
+// writeTestRecording records the validators then the voting information of each step, one second apart,
+// encoded by the codec, starting at the given time.
+func writeTestRecording(t *testing.T, path string, cvpCodec codec.CvpCodec, start time.Time, steps ...types.RoundStepType) {
+	recorder, err := CreateRecording(path, testSessionId)
+	require.NoError(t, err)
+
+	now := start
+	recorder.now = func() time.Time {
+		return now
+	}
+
+	require.NoError(t, recorder.RecordLightValidators(cvpCodec.EncodeStreamingLightValidators(testValidators)))
+	for _, step := range steps {
+		now = now.Add(time.Second)
+		require.NoError(t, recorder.RecordNextBlockVotingInformation(cvpCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(step))))
+	}
+	require.NoError(t, recorder.Close())
+	require.ErrorIs(t, recorder.RecordLightValidators(cvpCodec.EncodeStreamingLightValidators(testValidators)), os.ErrClosed)
+}
+
+func TestRecorder_RoundTrip(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, version := range []codec.CvpCodecVersion{codec.CvpCodecVersionV1, codec.CvpCodecVersionV2, codec.NewProxyCvpCodec().GetVersion()} {
+		t.Run(string(version), func(t *testing.T) {
+			cvpCodec, found := codec.GetRegisteredCvpCodec(version)
+			require.True(t, found)
+
+			path := filepath.Join(t.TempDir(), "session.rec")
+			writeTestRecording(t, path, cvpCodec, start, types.RoundStepPrevote, types.RoundStepPrecommit)
+
+			recording, err := OpenRecording(path)
+			require.NoError(t, err)
+			defer func() {
+				_ = recording.Close()
+			}()
+			require.Equal(t, testSessionId, recording.SessionId())
+
+			frame, err := recording.Next()
+			require.NoError(t, err)
+			require.Equal(t, codec.FrameKindLightValidators, frame.Kind)
+			require.Equal(t, version, frame.Version, "frames must be recorded as is")
+			require.Equal(t, testValidators, frame.LightValidators)
+			require.True(t, start.Equal(frame.Timestamp))
+
+			for i, step := range []types.RoundStepType{types.RoundStepPrevote, types.RoundStepPrecommit} {
+				frame, err = recording.Next()
+				require.NoError(t, err)
+				require.Equal(t, codec.FrameKindNextBlockVotingInformation, frame.Kind)
+				require.Equal(t, newTestInformation(step), frame.NextBlockVotingInformation)
+				require.True(t, start.Add(time.Duration(i+1)*time.Second).Equal(frame.Timestamp))
+			}
+
+			_, err = recording.Next()
+			require.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestRecorder_InvalidInput(t *testing.T) {
+	_, err := NewRecorder(&bytes.Buffer{}, "invalid")
+	require.ErrorContains(t, err, "invalid session id")
+
+	var buf bytes.Buffer
+	recorder, err := NewRecorder(&buf, testSessionId)
+	require.NoError(t, err)
+	headerBytes := buf.Len()
+	require.ErrorContains(t, recorder.RecordNextBlockVotingInformation([]byte("not encoded")), "failed to detect the encoding version")
+	require.Equal(t, headerBytes, buf.Len(), "nothing must be recorded")
+}
+
+func TestNewRecording_NotRecording(t *testing.T) {
+	_, err := NewRecording(bytes.NewReader([]byte("not a recording")))
+	require.ErrorContains(t, err, "not a recording")
+
+	_, err = NewRecording(bytes.NewReader(recordingMagic[:4]))
+	require.ErrorContains(t, err, "failed to read recording header")
+}
+
+func TestRecorder_RecordSubscription(t *testing.T) {
+	srv := httptest.NewServer(server.NewServer(server.Config{}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	broadcaster := client.NewClient(client.Config{BaseUrl: srv.URL})
+	registration, err := broadcaster.Register(ctx, "cosmoshub-4", testValidators)
+	require.NoError(t, err)
+	require.NoError(t, broadcaster.BroadcastVotingInfo(ctx, newTestInformation(types.RoundStepPrevote)))
+
+	path := filepath.Join(t.TempDir(), "session.rec")
+	recorder, err := CreateRecording(path, registration.SessionId)
+	require.NoError(t, err)
+	headerBytes := len(recordingMagic) + 1 + len(registration.SessionId)
+
+	subscribeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- recorder.RecordSubscription(subscribeCtx, client.NewClient(client.Config{BaseUrl: srv.URL}))
+	}()
+
+	// the latest frame is pushed on subscribing
+	require.Eventually(t, func() bool {
+		stat, err := os.Stat(path)
+		return err == nil && stat.Size() > int64(headerBytes)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("recording did not end after cancellation")
+	}
+	require.NoError(t, recorder.Close())
+
+	recording, err := OpenRecording(path)
+	require.NoError(t, err)
+	defer func() {
+		_ = recording.Close()
+	}()
+	require.Equal(t, registration.SessionId, recording.SessionId())
+	frame, err := recording.Next()
+	require.NoError(t, err)
+	require.Equal(t, newTestInformation(types.RoundStepPrevote), frame.NextBlockVotingInformation)
+}
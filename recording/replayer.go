@@ -0,0 +1,194 @@
+package recording
+
+import (
+	"context"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/client"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"io"
+	"time"
+)
+
+// Consumer receives the frames re-emitted by Replayer.
+type Consumer interface {
+	// Consume receives the frame, decoded and timestamped by the recording time.
+	// Returning an error stops the replay.
+	Consume(ctx context.Context, frame *codec.Frame) error
+}
+
+// ConsumerFunc is a function implementing Consumer.
+type ConsumerFunc func(ctx context.Context, frame *codec.Frame) error
+
+func (f ConsumerFunc) Consume(ctx context.Context, frame *codec.Frame) error {
+	return f(ctx, frame)
+}
+
+// Replayer re-emits the frames of recordings into consumers, keeping the recorded intervals between frames.
+type Replayer struct {
+	speed          float64
+	now            func() time.Time
+	sleep          func(ctx context.Context, d time.Duration) error
+	onSkippedFrame func(err error)
+}
+
+// NewReplayer returns a Replayer scaling the recorded intervals between frames by the speed:
+// 1 is the original speed, 2 replays twice as fast, zero or negative replays without waiting.
+func NewReplayer(speed float64) *Replayer {
+	return &Replayer{
+		speed: speed,
+		now:   time.Now,
+		sleep: sleepContext,
+	}
+}
+
+// OnSkippedFrame sets the function receiving the error of each frame skipped by Replay,
+// either codec.CorruptFrameError or codec.DecodeError.
+func (rp *Replayer) OnSkippedFrame(report func(err error)) {
+	rp.onSkippedFrame = report
+}
+
+// Replay re-emits the frames of the recording into the consumer, each frame is due at the scaled interval
+// from the first frame, so slow consumers do not accumulate the delay.
+//
+// Corrupted data, like the partially written frame at the end of the recording of a crashed recorder,
+// and intact frames which can not be decoded, like of versions no longer registered, are skipped, see OnSkippedFrame.
+// Returns nil at the end of the recording, otherwise the error of reading, the consumer or the context.
+func (rp *Replayer) Replay(ctx context.Context, recording *Recording, consumer Consumer) error {
+	var start, firstTimestamp time.Time
+	for {
+		frame, err := recording.Next()
+		if err == io.EOF {
+			return nil
+		}
+		var decodeErr *codec.DecodeError
+		if errors.Is(err, codec.ErrCorruptFrame) || errors.As(err, &decodeErr) {
+			if rp.onSkippedFrame != nil {
+				rp.onSkippedFrame(err)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if rp.speed > 0 && !frame.Timestamp.IsZero() {
+			if firstTimestamp.IsZero() {
+				start, firstTimestamp = rp.now(), frame.Timestamp
+			} else {
+				due := start.Add(time.Duration(float64(frame.Timestamp.Sub(firstTimestamp)) / rp.speed))
+				if err := rp.sleep(ctx, due.Sub(rp.now())); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := consumer.Consume(ctx, frame); err != nil {
+			return err
+		}
+	}
+}
+
+// sleepContext waits for the duration or the context is done, returns the error of the context if done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var _ Consumer = (*BroadcastConsumer)(nil)
+
+// BroadcastConsumer is a Consumer broadcasting the replayed frames through the client, like into a local streaming server.
+//
+// The frames are re-encoded by the codec of the client, so recordings of old versions can be replayed into
+// servers no longer accepting them. If the client has no session, the first light validators register a new session
+// of the chain, see client.Client.Session for the new session id.
+//
+// Recordings of Recorder.RecordSubscription hold no light validators, so the session is registered by the first frame
+// of either kind. Until light validators are replayed, placeholder validators named by their index and without voting power
+// are broadcast, as many as the vote states of the replayed next block voting information refer to.
+type BroadcastConsumer struct {
+	client  *client.Client
+	chainId string
+
+	placeholderValidators int // number of the broadcast placeholder validators, zero once light validators are replayed
+}
+
+// NewBroadcastConsumer returns a BroadcastConsumer broadcasting through the client,
+// the chain id is used for registering, like the chain of Recording.SessionId.
+func NewBroadcastConsumer(c *client.Client, chainId string) *BroadcastConsumer {
+	return &BroadcastConsumer{
+		client:  c,
+		chainId: chainId,
+	}
+}
+
+func (bc *BroadcastConsumer) Consume(ctx context.Context, frame *codec.Frame) error {
+	switch frame.Kind {
+	case codec.FrameKindLightValidators:
+		bc.placeholderValidators = 0
+		return bc.broadcastValidators(ctx, frame.LightValidators)
+	case codec.FrameKindNextBlockVotingInformation:
+		if err := bc.ensurePlaceholderValidators(ctx, frame.NextBlockVotingInformation); err != nil {
+			return err
+		}
+		return bc.client.BroadcastVotingInfo(ctx, frame.NextBlockVotingInformation)
+	default:
+		return nil
+	}
+}
+
+// broadcastValidators registers the session by the validators if the client has no session yet.
+func (bc *BroadcastConsumer) broadcastValidators(ctx context.Context, validators types.StreamingLightValidators) error {
+	if sessionId, _ := bc.client.Session(); sessionId == "" {
+		_, err := bc.client.Register(ctx, bc.chainId, validators)
+		return err
+	}
+	return bc.client.BroadcastValidators(ctx, validators)
+}
+
+// ensurePlaceholderValidators broadcasts placeholder validators if no light validators had been replayed
+// and the vote states refer to validators not yet broadcast.
+func (bc *BroadcastConsumer) ensurePlaceholderValidators(ctx context.Context, inf *types.StreamingNextBlockVotingInformation) error {
+	sessionId, _ := bc.client.Session()
+	if sessionId != "" && bc.placeholderValidators == 0 {
+		return nil // light validators had been replayed
+	}
+
+	count := 1 // the server does not accept an empty validator set
+	for _, voteState := range inf.ValidatorVoteStates {
+		if voteState.ValidatorIndex >= count {
+			count = voteState.ValidatorIndex + 1
+		}
+	}
+	if sessionId != "" && count <= bc.placeholderValidators {
+		return nil
+	}
+
+	validators := make(types.StreamingLightValidators, count)
+	for i := range validators {
+		validators[i] = types.StreamingLightValidator{
+			Index:   i,
+			Moniker: fmt.Sprintf("Validator %d", i),
+		}
+	}
+	if err := bc.broadcastValidators(ctx, validators); err != nil {
+		return errors.Wrap(err, "failed to broadcast placeholder validators")
+	}
+	bc.placeholderValidators = count
+	return nil
+}
@@ -0,0 +1,240 @@
+package recording
+
+import (
+	"context"
+	"github.com/bcdevtools/cvp-streaming-core/client"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/server"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestReplayer returns a Replayer which sleeps by advancing its clock, returns the slept durations.
+func newTestReplayer(speed float64) (*Replayer, *[]time.Duration) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept []time.Duration
+
+	replayer := NewReplayer(speed)
+	replayer.now = func() time.Time {
+		return now
+	}
+	replayer.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		now = now.Add(d)
+		return ctx.Err()
+	}
+	return replayer, &slept
+}
+
+func TestReplayer_Speed(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "session.rec")
+	writeTestRecording(t, path, codec.GetCvpCodecV2(), start, types.RoundStepPrevote, types.RoundStepPrecommit)
+
+	tests := []struct {
+		name      string
+		speed     float64
+		wantSlept []time.Duration
+	}{
+		{
+			name:      "original speed",
+			speed:     1,
+			wantSlept: []time.Duration{time.Second, time.Second},
+		},
+		{
+			name:      "four times as fast",
+			speed:     4,
+			wantSlept: []time.Duration{250 * time.Millisecond, 250 * time.Millisecond},
+		},
+		{
+			name:      "half speed",
+			speed:     0.5,
+			wantSlept: []time.Duration{2 * time.Second, 2 * time.Second},
+		},
+		{
+			name:  "without waiting",
+			speed: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recording, err := OpenRecording(path)
+			require.NoError(t, err)
+			defer func() {
+				_ = recording.Close()
+			}()
+
+			replayer, slept := newTestReplayer(tt.speed)
+			var kinds []codec.FrameKind
+			err = replayer.Replay(context.Background(), recording, ConsumerFunc(func(_ context.Context, frame *codec.Frame) error {
+				kinds = append(kinds, frame.Kind)
+				return nil
+			}))
+			require.NoError(t, err)
+			require.Equal(t, []codec.FrameKind{codec.FrameKindLightValidators, codec.FrameKindNextBlockVotingInformation, codec.FrameKindNextBlockVotingInformation}, kinds)
+			require.Equal(t, tt.wantSlept, *slept)
+		})
+	}
+}
+
+func TestReplayer_Cancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.rec")
+	writeTestRecording(t, path, codec.GetCvpCodecV2(), time.Now(), types.RoundStepPrevote)
+
+	recording, err := OpenRecording(path)
+	require.NoError(t, err)
+	defer func() {
+		_ = recording.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var consumed int
+	err = NewReplayer(1).Replay(ctx, recording, ConsumerFunc(func(context.Context, *codec.Frame) error {
+		consumed++
+		cancel()
+		return nil
+	}))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, consumed, "frames must not be consumed after cancellation")
+}
+
+func TestReplayer_TruncatedRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.rec")
+	writeTestRecording(t, path, codec.GetCvpCodecV2(), time.Now(), types.RoundStepPrevote, types.RoundStepPrecommit)
+
+	// the recorder crashed while writing the last frame
+	bz, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, bz[:len(bz)-5], 0o600))
+
+	recording, err := OpenRecording(path)
+	require.NoError(t, err)
+	defer func() {
+		_ = recording.Close()
+	}()
+
+	var steps []types.RoundStepType
+	err = NewReplayer(0).Replay(context.Background(), recording, ConsumerFunc(func(_ context.Context, frame *codec.Frame) error {
+		if frame.Kind == codec.FrameKindNextBlockVotingInformation {
+			steps = append(steps, frame.NextBlockVotingInformation.HeightRoundStep.Step)
+		}
+		return nil
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []types.RoundStepType{types.RoundStepPrevote}, steps)
+}
+
+func TestReplayer_SkippedFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.rec")
+	recorder, err := CreateRecording(path, testSessionId)
+	require.NoError(t, err)
+	cvpCodec := codec.GetCvpCodecV2()
+	require.NoError(t, recorder.RecordLightValidators(cvpCodec.EncodeStreamingLightValidators(testValidators)))
+	// intact frame, but the payload is cut after the version prefix
+	undecodable := cvpCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(types.RoundStepPrevote))
+	require.NoError(t, recorder.RecordNextBlockVotingInformation(undecodable[:3]))
+	require.NoError(t, recorder.RecordNextBlockVotingInformation(cvpCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(types.RoundStepPrecommit))))
+	require.NoError(t, recorder.Close())
+
+	// the recorder crashed while writing the last frame
+	bz, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(bz, bz[len(bz)-20:len(bz)-5]...), 0o600))
+
+	recording, err := OpenRecording(path)
+	require.NoError(t, err)
+	defer func() {
+		_ = recording.Close()
+	}()
+
+	replayer := NewReplayer(0)
+	var skipped []error
+	replayer.OnSkippedFrame(func(err error) {
+		skipped = append(skipped, err)
+	})
+	var steps []types.RoundStepType
+	err = replayer.Replay(context.Background(), recording, ConsumerFunc(func(_ context.Context, frame *codec.Frame) error {
+		if frame.Kind == codec.FrameKindNextBlockVotingInformation {
+			steps = append(steps, frame.NextBlockVotingInformation.HeightRoundStep.Step)
+		}
+		return nil
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []types.RoundStepType{types.RoundStepPrecommit}, steps, "frames after the undecodable one must be replayed")
+
+	require.Len(t, skipped, 2)
+	var decodeErr *codec.DecodeError
+	require.ErrorAs(t, skipped[0], &decodeErr)
+	require.ErrorIs(t, skipped[1], codec.ErrCorruptFrame)
+}
+
+func TestBroadcastConsumer(t *testing.T) {
+	srv := httptest.NewServer(server.NewServer(server.Config{}))
+	defer srv.Close()
+
+	// old recordings are replayed into the server, re-encoded by the codec of the client
+	for _, cvpCodec := range []codec.CvpCodec{codec.GetCvpCodecV1(), codec.GetCvpCodecV2()} {
+		t.Run(string(cvpCodec.GetVersion()), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "session.rec")
+			writeTestRecording(t, path, cvpCodec, time.Now(), types.RoundStepPrevote, types.RoundStepPrecommit)
+
+			recording, err := OpenRecording(path)
+			require.NoError(t, err)
+			defer func() {
+				_ = recording.Close()
+			}()
+
+			ctx := context.Background()
+			c := client.NewClient(client.Config{BaseUrl: srv.URL})
+			err = NewReplayer(0).Replay(ctx, recording, NewBroadcastConsumer(c, recording.SessionId().ChainId()))
+			require.NoError(t, err)
+
+			sessionId, _ := c.Session()
+			require.True(t, sessionId.ForChainId("cosmoshub-4"))
+			require.NotEqual(t, recording.SessionId(), sessionId, "a new session must be registered")
+
+			inf, err := c.FetchUpdate(ctx, sessionId)
+			require.NoError(t, err)
+			require.Equal(t, newTestInformation(types.RoundStepPrecommit), inf)
+		})
+	}
+}
+
+func TestBroadcastConsumer_SubscriptionRecording(t *testing.T) {
+	srv := httptest.NewServer(server.NewServer(server.Config{}))
+	defer srv.Close()
+
+	// like recorded by RecordSubscription, without light validators
+	path := filepath.Join(t.TempDir(), "session.rec")
+	recorder, err := CreateRecording(path, testSessionId)
+	require.NoError(t, err)
+	cvpCodec := codec.GetCvpCodecV2()
+	firstInformation := newTestInformation(types.RoundStepPrevote)
+	firstInformation.ValidatorVoteStates = firstInformation.ValidatorVoteStates[:1]
+	require.NoError(t, recorder.RecordNextBlockVotingInformation(cvpCodec.EncodeStreamingNextBlockVotingInformation(firstInformation)))
+	require.NoError(t, recorder.RecordNextBlockVotingInformation(cvpCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(types.RoundStepPrecommit))))
+	require.NoError(t, recorder.Close())
+
+	recording, err := OpenRecording(path)
+	require.NoError(t, err)
+	defer func() {
+		_ = recording.Close()
+	}()
+
+	ctx := context.Background()
+	c := client.NewClient(client.Config{BaseUrl: srv.URL})
+	err = NewReplayer(0).Replay(ctx, recording, NewBroadcastConsumer(c, recording.SessionId().ChainId()))
+	require.NoError(t, err)
+
+	sessionId, _ := c.Session()
+	require.True(t, sessionId.ForChainId("cosmoshub-4"))
+
+	inf, err := c.FetchUpdate(ctx, sessionId)
+	require.NoError(t, err)
+	require.Equal(t, newTestInformation(types.RoundStepPrecommit), inf)
+}
@@ -0,0 +1,398 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileSessionStoreMagic is the header of the session log file, the last byte is the format version.
+var fileSessionStoreMagic = []byte("CVPSLOG\x01")
+
+// fileSessionRecordHeaderSize is the size of the record header: 4 bytes length and 4 bytes CRC-32 of the payload.
+const fileSessionRecordHeaderSize = 8
+
+// fileSessionStoreMaxRecordBytes limits the size of a record, larger length means the file is corrupted.
+const fileSessionStoreMaxRecordBytes = 16 << 20
+
+// fileSessionStoreMinCompactionRecords is the minimum number of records in the log before compacting automatically.
+const fileSessionStoreMinCompactionRecords = 1024
+
+// fileSessionRecord is the JSON payload of a record, the latest record of a session replaces the previous ones.
+type fileSessionRecord struct {
+	Session                  types.PreVoteStreamingSession        `json:"session"`
+	SessionKeyHash           types.PreVoteStreamingSessionKeyHash `json:"session-key-hash"`
+	BroadcastVerificationKey types.BroadcastVerificationKey       `json:"broadcast-verification-key"`
+	LastBroadcastSequence    uint64                               `json:"last-broadcast-seq,omitempty"`
+
+	// EncodedLightValidators is omitted if not changed since the previous record of the session.
+	EncodedLightValidators            []byte `json:"light-validators,omitempty"`
+	EncodedNextBlockVotingInformation []byte `json:"next-block-voting-info,omitempty"`
+}
+
+var _ SessionStore = (*FileSessionStore)(nil)
+
+// FileSessionStore is a SessionStore persisting sessions to an append-only log file, so sessions survive restarts.
+//
+// Sessions are kept in memory, each Put appends a record of the session to the file,
+// including the latest encoded next block voting information and the encoded light validators if changed.
+// Records are written without fsync, so they survive crashes of the process but the latest records
+// may be lost on power failure. A partially written record at the end of the file is discarded when opening,
+// a corrupted record followed by other records fails opening rather than dropping them.
+//
+// The log is compacted, rewritten with only the latest record of each non-expired session,
+// when it holds more than twice the records it held after the previous compaction.
+// Expired sessions are not returned by Get and Update, and are dropped from memory by the compaction.
+// A failed automatic compaction does not fail the Put which stored the record, it is retried by the next Put,
+// call Compact to get the error.
+type FileSessionStore struct {
+	path  string
+	codec codec.CvpCodec
+	now   func() time.Time
+
+	mu               sync.RWMutex
+	file             *os.File
+	sessions         map[types.PreVoteStreamingSessionId]PreVoteStreamingSession
+	records          int // number of records in the log file
+	compactedRecords int // number of records in the log file after the latest compaction or load
+}
+
+// OpenFileSessionStore opens the session log file at the path, creating it if not exists,
+// and loads the sessions. The codec decodes the persisted frames.
+func OpenFileSessionStore(path string, cvpCodec codec.CvpCodec) (*FileSessionStore, error) {
+	s := &FileSessionStore{
+		path:     path,
+		codec:    cvpCodec,
+		now:      time.Now,
+		sessions: make(map[types.PreVoteStreamingSessionId]PreVoteStreamingSession),
+	}
+
+	// an interrupted compaction leaves the temporary file, the log file is still intact
+	_ = os.Remove(s.compactionPath())
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open session log file")
+	}
+
+	if err := s.load(file); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	s.file = file
+
+	return s, nil
+}
+
+// load replays the log file, truncates the partially written record at the end if any,
+// and leaves the file offset at the end.
+func (s *FileSessionStore) load(file *os.File) error {
+	bz, err := io.ReadAll(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to read session log file")
+	}
+
+	if len(bz) == 0 {
+		if _, err := file.Write(fileSessionStoreMagic); err != nil {
+			return errors.Wrap(err, "failed to write session log file header")
+		}
+		return nil
+	}
+	if !bytes.HasPrefix(bz, fileSessionStoreMagic) {
+		return fmt.Errorf("%s is not a session log file", s.path)
+	}
+
+	offset := len(fileSessionStoreMagic)
+	for offset < len(bz) {
+		payload, size, err := readFileSessionRecord(bz[offset:])
+		if err == errFileSessionRecordIncomplete || (err == errFileSessionRecordCorrupted && offset+size == len(bz)) {
+			// the process crashed while appending the last record, it had not been acknowledged
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "invalid session record at offset %d", offset)
+		}
+
+		var record fileSessionRecord
+		if err := json.Unmarshal(payload, &record); err != nil {
+			return errors.Wrapf(err, "invalid session record at offset %d", offset)
+		}
+		if err := s.apply(record); err != nil {
+			return errors.Wrapf(err, "invalid session record at offset %d", offset)
+		}
+
+		offset += size
+		s.records++
+	}
+
+	if offset < len(bz) {
+		if err := file.Truncate(int64(offset)); err != nil {
+			return errors.Wrap(err, "failed to truncate the partially written session record")
+		}
+	}
+	if _, err := file.Seek(int64(offset), io.SeekStart); err != nil {
+		return errors.Wrap(err, "failed to seek session log file")
+	}
+
+	s.compactedRecords = len(s.sessions)
+	return nil
+}
+
+var (
+	// errFileSessionRecordIncomplete is returned by readFileSessionRecord if the record is cut by the end of the file.
+	errFileSessionRecordIncomplete = fmt.Errorf("incomplete session record")
+
+	// errFileSessionRecordCorrupted is returned by readFileSessionRecord if the record is damaged.
+	errFileSessionRecordCorrupted = fmt.Errorf("corrupted session record")
+)
+
+// readFileSessionRecord returns the payload and the total size of the record at the beginning of bz.
+// The size is also returned along with errFileSessionRecordCorrupted if the checksum mismatches.
+func readFileSessionRecord(bz []byte) (payload []byte, size int, err error) {
+	if len(bz) < fileSessionRecordHeaderSize {
+		return nil, 0, errFileSessionRecordIncomplete
+	}
+	length := binary.BigEndian.Uint32(bz[0:4])
+	checksum := binary.BigEndian.Uint32(bz[4:8])
+	if length > fileSessionStoreMaxRecordBytes {
+		return nil, 0, errFileSessionRecordCorrupted
+	}
+	if len(bz)-fileSessionRecordHeaderSize < int(length) {
+		return nil, 0, errFileSessionRecordIncomplete
+	}
+
+	size = fileSessionRecordHeaderSize + int(length)
+	payload = bz[fileSessionRecordHeaderSize:size]
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, size, errFileSessionRecordCorrupted
+	}
+	return payload, size, nil
+}
+
+// apply replaces the in-memory session by the record, decoding the persisted frames.
+func (s *FileSessionStore) apply(record fileSessionRecord) error {
+	session := PreVoteStreamingSession{
+		PreVoteStreamingSession:  record.Session,
+		SessionKeyHash:           record.SessionKeyHash,
+		BroadcastVerificationKey: record.BroadcastVerificationKey,
+		LastBroadcastSequence:    record.LastBroadcastSequence,
+	}
+
+	if len(record.EncodedLightValidators) > 0 {
+		validators, err := s.codec.DecodeStreamingLightValidators(record.EncodedLightValidators)
+		if err != nil {
+			return errors.Wrap(err, "failed to decode light validators")
+		}
+		session.EncodedLightValidators = record.EncodedLightValidators
+		session.Validators = validators
+	} else if existing, found := s.sessions[session.SessionId]; found {
+		session.EncodedLightValidators = existing.EncodedLightValidators
+		session.Validators = existing.Validators
+	}
+
+	if len(record.EncodedNextBlockVotingInformation) > 0 {
+		inf, err := s.codec.DecodeStreamingNextBlockVotingInformation(record.EncodedNextBlockVotingInformation)
+		if err != nil {
+			return errors.Wrap(err, "failed to decode next block voting information")
+		}
+		session.EncodedNextBlockVotingInformation = record.EncodedNextBlockVotingInformation
+		session.NextBlockVotingInformation = inf
+	}
+
+	s.sessions[session.SessionId] = session
+	return nil
+}
+
+func (s *FileSessionStore) Get(sessionId types.PreVoteStreamingSessionId) (PreVoteStreamingSession, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, found := s.sessions[sessionId]
+	if !found || session.IsExpired(s.now()) {
+		return PreVoteStreamingSession{}, false
+	}
+	return session, true
+}
+
+// Put appends the record of the session to the log file, then replaces the in-memory session.
+func (s *FileSessionStore) Put(session PreVoteStreamingSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	return s.put(session)
+}
+
+// Update applies the update to a copy of the in-memory session, then stores it the same as Put.
+func (s *FileSessionStore) Update(sessionId types.PreVoteStreamingSessionId, update func(session *PreVoteStreamingSession) error) (PreVoteStreamingSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return PreVoteStreamingSession{}, os.ErrClosed
+	}
+
+	session, found := s.sessions[sessionId]
+	if !found || session.IsExpired(s.now()) {
+		return PreVoteStreamingSession{}, ErrSessionNotFound
+	}
+	if err := update(&session); err != nil {
+		return PreVoteStreamingSession{}, err
+	}
+
+	if err := s.put(session); err != nil {
+		return PreVoteStreamingSession{}, err
+	}
+	return session, nil
+}
+
+// put appends the record of the session and replaces the in-memory session, mu must be held.
+func (s *FileSessionStore) put(session PreVoteStreamingSession) error {
+	record := newFileSessionRecord(session)
+	if existing, found := s.sessions[session.SessionId]; found && bytes.Equal(existing.EncodedLightValidators, session.EncodedLightValidators) {
+		record.EncodedLightValidators = nil
+	}
+	if err := s.append(record); err != nil {
+		return err
+	}
+
+	s.sessions[session.SessionId] = session
+
+	if s.records >= fileSessionStoreMinCompactionRecords && s.records > 2*s.compactedRecords {
+		// the record is stored already, a failed compaction leaves the log intact and is retried by the next put
+		_ = s.compact()
+	}
+	return nil
+}
+
+// append writes the record at the end of the log file, mu must be held.
+// A failed write is rolled back, so no partial record is followed by other records.
+func (s *FileSessionStore) append(record fileSessionRecord) error {
+	offset, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return errors.Wrap(err, "failed to seek session log file")
+	}
+
+	if err := writeFileSessionRecord(s.file, record); err != nil {
+		if rollbackErr := s.file.Truncate(offset); rollbackErr == nil {
+			_, _ = s.file.Seek(offset, io.SeekStart)
+		}
+		return err
+	}
+
+	s.records++
+	return nil
+}
+
+// Compact rewrites the log file with only the latest record of each non-expired session.
+func (s *FileSessionStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return os.ErrClosed
+	}
+	return s.compact()
+}
+
+// compact writes the live sessions to a temporary file and atomically replaces the log file by it, mu must be held.
+func (s *FileSessionStore) compact() error {
+	now := s.now()
+	for sessionId, session := range s.sessions {
+		if session.IsExpired(now) {
+			delete(s.sessions, sessionId)
+		}
+	}
+
+	tmpPath := s.compactionPath()
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "failed to create compaction file")
+	}
+	abort := func(err error) error {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if _, err := tmp.Write(fileSessionStoreMagic); err != nil {
+		return abort(errors.Wrap(err, "failed to write compaction file"))
+	}
+	for _, session := range s.sessions {
+		if err := writeFileSessionRecord(tmp, newFileSessionRecord(session)); err != nil {
+			return abort(err)
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return abort(errors.Wrap(err, "failed to sync compaction file"))
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return abort(errors.Wrap(err, "failed to replace session log file"))
+	}
+
+	_ = s.file.Close()
+	s.file = tmp
+	s.records = len(s.sessions)
+	s.compactedRecords = s.records
+	return nil
+}
+
+// Close syncs and closes the log file, the store is no longer usable.
+func (s *FileSessionStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	return err
+}
+
+func (s *FileSessionStore) compactionPath() string {
+	return s.path + ".compact"
+}
+
+func newFileSessionRecord(session PreVoteStreamingSession) fileSessionRecord {
+	return fileSessionRecord{
+		Session:                           session.PreVoteStreamingSession,
+		SessionKeyHash:                    session.SessionKeyHash,
+		BroadcastVerificationKey:          session.BroadcastVerificationKey,
+		LastBroadcastSequence:             session.LastBroadcastSequence,
+		EncodedLightValidators:            session.EncodedLightValidators,
+		EncodedNextBlockVotingInformation: session.EncodedNextBlockVotingInformation,
+	}
+}
+
+// writeFileSessionRecord appends the record in a single write, so a crash leaves at most one partial record.
+func writeFileSessionRecord(w io.Writer, record fileSessionRecord) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal session record")
+	}
+
+	bz := make([]byte, fileSessionRecordHeaderSize, fileSessionRecordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(bz[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(bz[4:8], crc32.ChecksumIEEE(payload))
+	bz = append(bz, payload...)
+
+	if _, err := w.Write(bz); err != nil {
+		return errors.Wrap(err, "failed to write session record")
+	}
+	return nil
+}
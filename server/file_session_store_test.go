@@ -0,0 +1,319 @@
+package server
+
+import (
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testFileSessionKey types.PreVoteStreamingSessionKey = "2222222222222222222222222222222222222222222222222222222222222222"
+
+func newTestFileSession(sessionId types.PreVoteStreamingSessionId, now time.Time) PreVoteStreamingSession {
+	inf := newTestInformation(0, 1)
+	sessionKeyHash, _ := testFileSessionKey.Hash()
+	verificationKey, _ := testFileSessionKey.BroadcastVerificationKey()
+	return PreVoteStreamingSession{
+		PreVoteStreamingSession:           types.StartPreVoteStreamingSession(sessionId, time.Hour, 0, now),
+		SessionKeyHash:                    sessionKeyHash,
+		BroadcastVerificationKey:          verificationKey,
+		EncodedLightValidators:            testCodec.EncodeStreamingLightValidators(testValidators),
+		Validators:                        testValidators,
+		EncodedNextBlockVotingInformation: testCodec.EncodeStreamingNextBlockVotingInformation(inf),
+		NextBlockVotingInformation:        inf,
+		LastBroadcastSequence:             7,
+	}
+}
+
+func openTestFileSessionStore(t *testing.T, path string, now time.Time) *FileSessionStore {
+	store, err := OpenFileSessionStore(path, testCodec)
+	require.NoError(t, err)
+	store.now = func() time.Time {
+		return now
+	}
+	return store
+}
+
+func TestFileSessionStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const sessionId types.PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+
+	store := openTestFileSessionStore(t, path, now)
+	session := newTestFileSession(sessionId, now)
+	require.NoError(t, store.Put(session))
+
+	// validators unchanged, the record omits them
+	session.LastBroadcastSequence++
+	require.NoError(t, session.RecordBroadcast(now))
+	require.NoError(t, store.Put(session))
+	require.NoError(t, store.Close())
+	require.ErrorIs(t, store.Put(session), os.ErrClosed)
+
+	reopened := openTestFileSessionStore(t, path, now)
+	defer func() {
+		_ = reopened.Close()
+	}()
+	got, found := reopened.Get(sessionId)
+	require.True(t, found)
+	require.Equal(t, session.SessionKeyHash, got.SessionKeyHash)
+	require.Equal(t, session.BroadcastVerificationKey, got.BroadcastVerificationKey)
+	require.Equal(t, session.LastBroadcastSequence, got.LastBroadcastSequence)
+	require.Equal(t, session.EncodedLightValidators, got.EncodedLightValidators)
+	require.Equal(t, session.Validators, got.Validators)
+	require.Equal(t, session.EncodedNextBlockVotingInformation, got.EncodedNextBlockVotingInformation)
+	require.Equal(t, session.NextBlockVotingInformation, got.NextBlockVotingInformation)
+	require.True(t, session.CreatedAt.Equal(got.CreatedAt))
+	require.True(t, session.LastBroadcastAt.Equal(got.LastBroadcastAt))
+	require.Equal(t, session.Ttl, got.Ttl)
+
+	_, found = reopened.Get("cosmoshub-4_0000000000000000000000000000000000000000000000000000000000000000")
+	require.False(t, found)
+}
+
+func TestFileSessionStore_CrashRecovery(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const sessionId types.PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+	const otherSessionId types.PreVoteStreamingSessionId = "cosmoshub-4_2222222222222222222222222222222222222222222222222222222222222222"
+
+	tests := []struct {
+		name string
+		// corrupt corrupts the log file, which ends with the record of the other session after the intact size
+		corrupt func(bz []byte, intactSize int) []byte
+	}{
+		{
+			name: "partial record header",
+			corrupt: func(bz []byte, intactSize int) []byte {
+				return append(bz[:intactSize], 0, 0, 1)
+			},
+		},
+		{
+			name: "partial record payload",
+			corrupt: func(bz []byte, intactSize int) []byte {
+				return append(bz[:intactSize], 0, 0, 0, 100, 1, 2, 3, 4, '{')
+			},
+		},
+		{
+			name: "checksum mismatch of the last record",
+			corrupt: func(bz []byte, _ int) []byte {
+				bz[len(bz)-2] ^= 0xFF
+				return bz
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sessions.log")
+
+			store := openTestFileSessionStore(t, path, now)
+			require.NoError(t, store.Put(newTestFileSession(sessionId, now)))
+			require.NoError(t, store.Close())
+			bz, err := os.ReadFile(path)
+			require.NoError(t, err)
+			intactSize := len(bz)
+
+			store = openTestFileSessionStore(t, path, now)
+			require.NoError(t, store.Put(newTestFileSession(otherSessionId, now)))
+			require.NoError(t, store.Close())
+			bz, err = os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, tt.corrupt(bz, intactSize), 0o600))
+
+			store = openTestFileSessionStore(t, path, now)
+			_, found := store.Get(sessionId)
+			require.True(t, found, "records before the corruption must be recovered")
+			_, found = store.Get(otherSessionId)
+			require.False(t, found, "corrupted record must be discarded")
+
+			stat, err := os.Stat(path)
+			require.NoError(t, err)
+			require.Equal(t, int64(intactSize), stat.Size(), "corrupted tail must be truncated")
+
+			// new records are appended after the recovered ones
+			require.NoError(t, store.Put(newTestFileSession(otherSessionId, now)))
+			require.NoError(t, store.Close())
+
+			store = openTestFileSessionStore(t, path, now)
+			defer func() {
+				_ = store.Close()
+			}()
+			_, found = store.Get(sessionId)
+			require.True(t, found)
+			_, found = store.Get(otherSessionId)
+			require.True(t, found)
+		})
+	}
+}
+
+func TestFileSessionStore_CorruptedRecordBeforeOthers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const sessionId types.PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+	const otherSessionId types.PreVoteStreamingSessionId = "cosmoshub-4_2222222222222222222222222222222222222222222222222222222222222222"
+
+	store := openTestFileSessionStore(t, path, now)
+	require.NoError(t, store.Put(newTestFileSession(sessionId, now)))
+	require.NoError(t, store.Put(newTestFileSession(otherSessionId, now)))
+	require.NoError(t, store.Close())
+
+	// damage the payload of the first record, the record of the other session follows it
+	bz, err := os.ReadFile(path)
+	require.NoError(t, err)
+	bz[len(fileSessionStoreMagic)+fileSessionRecordHeaderSize+1] ^= 0xFF
+	require.NoError(t, os.WriteFile(path, bz, 0o600))
+
+	_, err = OpenFileSessionStore(path, testCodec)
+	require.ErrorIs(t, err, errFileSessionRecordCorrupted)
+	require.ErrorContains(t, err, fmt.Sprintf("offset %d", len(fileSessionStoreMagic)))
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, bz, got, "records after the corruption must not be truncated")
+}
+
+func TestFileSessionStore_Compaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const sessionId types.PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+	const expiringSessionId types.PreVoteStreamingSessionId = "cosmoshub-4_2222222222222222222222222222222222222222222222222222222222222222"
+
+	store := openTestFileSessionStore(t, path, now)
+	session := newTestFileSession(sessionId, now)
+	for i := 0; i < 100; i++ {
+		session.LastBroadcastSequence++
+		require.NoError(t, store.Put(session))
+	}
+	expiring := newTestFileSession(expiringSessionId, now)
+	expiring.Ttl = time.Minute
+	require.NoError(t, store.Put(expiring))
+	require.Equal(t, 101, store.records)
+
+	sizeBefore, err := os.Stat(path)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	store.now = func() time.Time {
+		return now
+	}
+	require.NoError(t, store.Compact())
+	require.Equal(t, 1, store.records, "expired session must be dropped")
+
+	sizeAfter, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Less(t, sizeAfter.Size(), sizeBefore.Size())
+	_, err = os.Stat(store.compactionPath())
+	require.True(t, os.IsNotExist(err))
+
+	// the compacted file is still appendable
+	session.LastBroadcastSequence++
+	require.NoError(t, store.Put(session))
+	require.NoError(t, store.Close())
+
+	reopened := openTestFileSessionStore(t, path, now)
+	defer func() {
+		_ = reopened.Close()
+	}()
+	got, found := reopened.Get(sessionId)
+	require.True(t, found)
+	require.Equal(t, session.LastBroadcastSequence, got.LastBroadcastSequence)
+	require.Equal(t, session.Validators, got.Validators)
+	_, found = reopened.Get(expiringSessionId)
+	require.False(t, found)
+	require.Equal(t, 2, reopened.records)
+}
+
+func TestFileSessionStore_AutoCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := openTestFileSessionStore(t, path, now)
+	defer func() {
+		_ = store.Close()
+	}()
+	session := newTestFileSession("cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111", now)
+	for i := 0; i < fileSessionStoreMinCompactionRecords; i++ {
+		require.NoError(t, store.Put(session))
+	}
+	require.Equal(t, 1, store.records)
+}
+
+func TestFileSessionStore_AutoCompactionDropsExpiredSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := openTestFileSessionStore(t, path, now)
+	defer func() {
+		_ = store.Close()
+	}()
+	for i := 1; i < fileSessionStoreMinCompactionRecords; i++ {
+		expiring := newTestFileSession(types.PreVoteStreamingSessionId(fmt.Sprintf("cosmoshub-4_%064d", i)), now)
+		expiring.Ttl = time.Minute
+		require.NoError(t, store.Put(expiring))
+	}
+
+	now = now.Add(2 * time.Minute)
+	store.now = func() time.Time {
+		return now
+	}
+	session := newTestFileSession(types.PreVoteStreamingSessionId(fmt.Sprintf("cosmoshub-4_%064d", 0)), now)
+	require.NoError(t, store.Put(session))
+	require.Equal(t, 1, store.records)
+	require.Len(t, store.sessions, 1, "expired sessions must be dropped by the compaction")
+}
+
+func TestFileSessionStore_FailedAutoCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := openTestFileSessionStore(t, path, now)
+	defer func() {
+		_ = store.Close()
+	}()
+	// the compaction file can not be created
+	require.NoError(t, os.Mkdir(store.compactionPath(), 0o700))
+
+	session := newTestFileSession("cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111", now)
+	for i := 0; i < fileSessionStoreMinCompactionRecords; i++ {
+		session.LastBroadcastSequence++
+		require.NoError(t, store.Put(session), "the record is stored even if the compaction fails")
+	}
+	require.Equal(t, fileSessionStoreMinCompactionRecords, store.records)
+	got, found := store.Get(session.SessionId)
+	require.True(t, found)
+	require.Equal(t, session.LastBroadcastSequence, got.LastBroadcastSequence)
+
+	require.Error(t, store.Compact())
+}
+
+func TestFileSessionStore_InterruptedCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const sessionId types.PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+
+	store := openTestFileSessionStore(t, path, now)
+	require.NoError(t, store.Put(newTestFileSession(sessionId, now)))
+	require.NoError(t, store.Close())
+
+	// crashed before the compaction file replaced the log file
+	require.NoError(t, os.WriteFile(store.compactionPath(), []byte("partial"), 0o600))
+
+	store = openTestFileSessionStore(t, path, now)
+	defer func() {
+		_ = store.Close()
+	}()
+	_, found := store.Get(sessionId)
+	require.True(t, found)
+	_, err := os.Stat(store.compactionPath())
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestOpenFileSessionStore_NotSessionLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	require.NoError(t, os.WriteFile(path, []byte("not a session log"), 0o600))
+
+	_, err := OpenFileSessionStore(path, testCodec)
+	require.ErrorContains(t, err, "not a session log file")
+}
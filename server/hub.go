@@ -0,0 +1,75 @@
+package server
+
+import (
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"sync"
+)
+
+// hub fans out the broadcast encoded frames to the subscribed viewers of each session.
+//
+// Each subscriber only needs the latest frame, so a slow subscriber never blocks the publisher:
+// when the subscriber has not consumed the previous frame yet, the previous frame is replaced.
+type hub struct {
+	mu          sync.Mutex
+	subscribers map[types.PreVoteStreamingSessionId]map[*subscription]struct{}
+}
+
+type subscription struct {
+	frames chan []byte
+}
+
+func newHub() *hub {
+	return &hub{
+		subscribers: make(map[types.PreVoteStreamingSessionId]map[*subscription]struct{}),
+	}
+}
+
+// subscribe registers a new subscriber of the session,
+// the returned unsubscribe function must be called when the subscriber leaves.
+func (h *hub) subscribe(sessionId types.PreVoteStreamingSessionId) (sub *subscription, unsubscribe func()) {
+	sub = &subscription{
+		frames: make(chan []byte, 1),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, found := h.subscribers[sessionId]
+	if !found {
+		subs = make(map[*subscription]struct{})
+		h.subscribers[sessionId] = subs
+	}
+	subs[sub] = struct{}{}
+
+	return sub, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(subs, sub)
+		if len(subs) == 0 && len(h.subscribers[sessionId]) == 0 {
+			delete(h.subscribers, sessionId)
+		}
+	}
+}
+
+// publish pushes the frame to all the subscribers of the session, without blocking.
+func (h *hub) publish(sessionId types.PreVoteStreamingSessionId, frame []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[sessionId] {
+		// drop the stale frame which had not been consumed, if any, then push the new one
+		select {
+		case <-sub.frames:
+		default:
+		}
+		sub.frames <- frame
+	}
+}
+
+// subscriberCount returns the number of subscribers of the session.
+func (h *hub) subscriberCount(sessionId types.PreVoteStreamingSessionId) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers[sessionId])
+}
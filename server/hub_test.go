@@ -0,0 +1,41 @@
+package server
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHub(t *testing.T) {
+	h := newHub()
+
+	sub1, unsubscribe1 := h.subscribe("a")
+	sub2, unsubscribe2 := h.subscribe("a")
+	subOther, unsubscribeOther := h.subscribe("b")
+	defer unsubscribeOther()
+	require.Equal(t, 2, h.subscriberCount("a"))
+
+	h.publish("a", []byte("1"))
+	require.Equal(t, []byte("1"), <-sub1.frames)
+
+	// sub2 has not consumed the first frame, only the latest frame is kept
+	h.publish("a", []byte("2"))
+	require.Equal(t, []byte("2"), <-sub1.frames)
+	require.Equal(t, []byte("2"), <-sub2.frames)
+	require.Len(t, sub2.frames, 0)
+	require.Len(t, subOther.frames, 0, "must not receive frames of other sessions")
+
+	unsubscribe1()
+	require.Equal(t, 1, h.subscriberCount("a"))
+	unsubscribe2()
+	require.Equal(t, 0, h.subscriberCount("a"))
+
+	h.publish("a", []byte("3"))
+	require.Len(t, sub1.frames, 0, "must not receive frames after unsubscribed")
+
+	sub3, unsubscribe3 := h.subscribe("a")
+	defer unsubscribe3()
+	unsubscribe1()
+	require.Equal(t, 1, h.subscriberCount("a"), "calling stale unsubscribe must not affect new subscribers")
+	h.publish("a", []byte("4"))
+	require.Equal(t, []byte("4"), <-sub3.frames)
+}
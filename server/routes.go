@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// route is a handler of the requests matching the method and the path pattern,
+// pattern segments begin with ':' capture the path parameters, like the patterns in constants.
+type route struct {
+	method   string
+	segments []string
+	handler  func(w http.ResponseWriter, r *http.Request, params map[string]string)
+}
+
+func newRoute(method, pattern string, handler func(w http.ResponseWriter, r *http.Request, params map[string]string)) route {
+	return route{
+		method:   method,
+		segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+		handler:  handler,
+	}
+}
+
+// match returns the path parameters if the path matches the route pattern.
+func (rt route) match(path string) (params map[string]string, matched bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params = make(map[string]string)
+	for i, segment := range rt.segments {
+		if strings.HasPrefix(segment, ":") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[segment[1:]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
@@ -0,0 +1,562 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/constants"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config is the configuration of the streaming server.
+type Config struct {
+	// MaxEncodedLightValidatorsBytes is the maximum size of the request body of registration and resuming,
+	// default is constants.MAX_ENCODED_LIGHT_VALIDATORS_BYTES,
+	// or the limit of the versions the Codec decodes if the Codec is set, see codec.GetDecodingLimits.
+	MaxEncodedLightValidatorsBytes int
+
+	// MaxEncodedNextBlockVotingInformationBytes is the maximum size of the request body of broadcasting,
+	// default is constants.MAX_ENCODED_NEXT_BLOCK_PRE_VOTE_INFO_BYTES,
+	// or the limit of the versions the Codec decodes if the Codec is set, see codec.GetDecodingLimits.
+	MaxEncodedNextBlockVotingInformationBytes int
+
+	// MaxValidators is the maximum number of validators of a session,
+	// default is constants.MAX_VALIDATORS,
+	// or the limit of the versions the Codec decodes if the Codec is set, see codec.GetDecodingLimits.
+	MaxValidators int
+
+	// Codec decodes the request bodies, default is the proxy codec which accepts all the registered versions.
+	// Setting it, even to the proxy codec, raises the default limits to those of the versions it decodes,
+	// which allows registering sessions with MB-sized bodies from unauthenticated clients.
+	Codec codec.CvpCodec
+
+	// Store keeps the sessions, default is the in-memory store. See OpenFileSessionStore for a durable store.
+	Store SessionStore
+
+	// SessionTtl is the maximum inactive duration of sessions, default is 1 hour.
+	SessionTtl time.Duration
+
+	// ChainRegistry restricts the chains which sessions can be registered for, and provides the chain names
+	// of the view responses. Default is nil, accepting any chain.
+	ChainRegistry *types.ChainRegistry
+
+	// MaxResumeCount is the maximum number of resuming a session, including replacing the validators
+	// through the resume route, zero means unlimited.
+	MaxResumeCount int
+
+	// KeepAliveInterval is the interval of sending comments to keep the Server-Sent Events connections alive,
+	// default is 15 seconds.
+	KeepAliveInterval time.Duration
+
+	// WebSocketIdleTimeout closes the WebSocket broadcast connections which receive nothing, including pings,
+	// within the timeout, default is 1 minute.
+	WebSocketIdleTimeout time.Duration
+
+	// WebSocketAllowedOrigins are the origins, like "https://example.com", allowed to open the WebSocket broadcast
+	// connections in addition to the same host. Handshakes without Origin header, sent by non-browser broadcasters,
+	// are always allowed.
+	WebSocketAllowedOrigins []string
+
+	// BroadcastSignatureReplayWindow is the maximum difference between the signing time of broadcast requests
+	// and the server time, default is 30 seconds. See types.BroadcastSignatureVerifier.
+	BroadcastSignatureReplayWindow time.Duration
+
+	// RequireBroadcastSignature rejects the broadcast requests authenticated by the raw session key header,
+	// only the signature headers are accepted.
+	RequireBroadcastSignature bool
+}
+
+// Server is a reference implementation of the ConsVP streaming server, serving the routes defined in constants.
+//
+//   - POST register-session/pre-vote/:chainId: body is the encoded light validators,
+//     responds types.PreVoteStreamingSessionRegistrationResponse.
+//   - POST resume-session/pre-vote/:sessionId: requires authentication, body is optional encoded light validators
+//     to replace the existing, responds types.PreVoteStreamingSessionRegistrationResponse without the session key.
+//   - POST broadcast/pre-vote/:sessionId: requires authentication, body is the encoded next block voting information.
+//   - GET broadcast-ws/pre-vote/:sessionId: requires authentication, upgrades to WebSocket
+//     for streaming the broadcast frames, see handleBroadcastWebSocket.
+//   - POST revoke-session/pre-vote/:sessionId: requires authentication, revokes the session.
+//   - GET pvtop/:sessionId: responds types.PreVoteStreamingSessionViewResponse.
+//   - GET pvtop/:sessionId/update: responds the latest encoded next block voting information,
+//     or 204 No Content if nothing had been broadcast.
+//   - GET pvtop/:sessionId/events: Server-Sent Events, pushes the latest and then each newly broadcast
+//     encoded next block voting information as base64 data of the "update" event.
+//
+// Requests are authenticated by the signature headers, signing the method, the URL path and the body
+// of the request, see types.SignBroadcast.
+// Unless Config.RequireBroadcastSignature, the raw session key header is also accepted.
+//
+// Sessions of the routes above respond 410 Gone once revoked, and 404 Not Found once expired.
+// Resuming more than Config.MaxResumeCount times responds 403 Forbidden.
+type Server struct {
+	config   Config
+	routes   []route
+	hub      *hub
+	verifier *types.BroadcastSignatureVerifier
+	now      func() time.Time
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// NewServer returns a new Server, zero fields of the config are filled with defaults.
+func NewServer(config Config) *Server {
+	limits := codec.CvpCodecLimits{
+		MaxValidators:                             constants.MAX_VALIDATORS,
+		MaxEncodedLightValidatorsBytes:            constants.MAX_ENCODED_LIGHT_VALIDATORS_BYTES,
+		MaxEncodedNextBlockVotingInformationBytes: constants.MAX_ENCODED_NEXT_BLOCK_PRE_VOTE_INFO_BYTES,
+	}
+	if config.Codec == nil {
+		config.Codec = codec.NewProxyCvpCodec()
+	} else {
+		// the operator chose the accepted versions, so accept their payloads
+		limits = codec.GetDecodingLimits(config.Codec)
+	}
+	if config.MaxEncodedLightValidatorsBytes < 1 {
+		config.MaxEncodedLightValidatorsBytes = limits.MaxEncodedLightValidatorsBytes
+	}
+	if config.MaxEncodedNextBlockVotingInformationBytes < 1 {
+		config.MaxEncodedNextBlockVotingInformationBytes = limits.MaxEncodedNextBlockVotingInformationBytes
+	}
+	if config.MaxValidators < 1 {
+		config.MaxValidators = limits.MaxValidators
+	}
+	if config.Store == nil {
+		config.Store = NewInMemorySessionStore()
+	}
+	if config.SessionTtl <= 0 {
+		config.SessionTtl = time.Hour
+	}
+	if config.KeepAliveInterval <= 0 {
+		config.KeepAliveInterval = 15 * time.Second
+	}
+	if config.WebSocketIdleTimeout <= 0 {
+		config.WebSocketIdleTimeout = time.Minute
+	}
+	if config.BroadcastSignatureReplayWindow <= 0 {
+		config.BroadcastSignatureReplayWindow = 30 * time.Second
+	}
+
+	s := &Server{
+		config:   config,
+		hub:      newHub(),
+		verifier: types.NewBroadcastSignatureVerifier(config.BroadcastSignatureReplayWindow),
+		now:      time.Now,
+	}
+	s.routes = []route{
+		newRoute(http.MethodPost, constants.STREAMING_PATH_REGISTER_PRE_VOTE, s.handleRegister),
+		newRoute(http.MethodPost, constants.STREAMING_PATH_RESUME_PRE_VOTE, s.handleResume),
+		newRoute(http.MethodPost, constants.STREAMING_PATH_BROADCAST_PRE_VOTE, s.handleBroadcast),
+		newRoute(http.MethodGet, constants.STREAMING_PATH_BROADCAST_PRE_VOTE_WS, s.handleBroadcastWebSocket),
+		newRoute(http.MethodPost, constants.STREAMING_PATH_REVOKE_PRE_VOTE, s.handleRevoke),
+		newRoute(http.MethodGet, constants.STREAMING_PATH_VIEW_PRE_VOTE, s.handleView),
+		newRoute(http.MethodGet, constants.STREAMING_PATH_VIEW_PRE_VOTE_FETCH_UPDATE, s.handleFetchUpdate),
+		newRoute(http.MethodGet, constants.STREAMING_PATH_VIEW_PRE_VOTE_SUBSCRIBE, s.handleSubscribe),
+	}
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pathMatched := false
+	for _, rt := range s.routes {
+		params, matched := rt.match(r.URL.Path)
+		if !matched {
+			continue
+		}
+		pathMatched = true
+		if rt.method != r.Method {
+			continue
+		}
+		rt.handler(w, r, params)
+		return
+	}
+
+	if pathMatched {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	chainId := params["chainId"]
+
+	bz, ok := s.readBody(w, r, s.config.MaxEncodedLightValidatorsBytes)
+	if !ok {
+		return
+	}
+
+	validators, err := s.decodeLightValidators(bz)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessionId, sessionKey, err := s.config.ChainRegistry.NewPreVoteStreamingSession(chainId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sessionKeyHash, err := sessionKey.Hash()
+	if err != nil {
+		http.Error(w, "failed to hash session key", http.StatusInternalServerError)
+		return
+	}
+	verificationKey, err := sessionKey.BroadcastVerificationKey()
+	if err != nil {
+		http.Error(w, "failed to derive broadcast verification key", http.StatusInternalServerError)
+		return
+	}
+
+	err = s.config.Store.Put(PreVoteStreamingSession{
+		PreVoteStreamingSession:  types.StartPreVoteStreamingSession(sessionId, s.config.SessionTtl, s.config.MaxResumeCount, s.now()),
+		SessionKeyHash:           sessionKeyHash,
+		BroadcastVerificationKey: verificationKey,
+		EncodedLightValidators:   bz,
+		Validators:               validators,
+	})
+	if err != nil {
+		http.Error(w, "failed to store session", http.StatusInternalServerError)
+		return
+	}
+
+	writeJson(w, types.PreVoteStreamingSessionRegistrationResponse{
+		SessionId:  sessionId,
+		SessionKey: sessionKey,
+	})
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	bz, ok := s.readBody(w, r, s.config.MaxEncodedLightValidatorsBytes)
+	if !ok {
+		return
+	}
+
+	session, ok := s.authorizedSession(w, r, params, bz)
+	if !ok {
+		return
+	}
+
+	session, ok = s.updateSession(w, session.SessionId, func(session *PreVoteStreamingSession) error {
+		if err := session.Resume(s.now()); err != nil {
+			return err
+		}
+		if len(bz) > 0 {
+			return s.replaceLightValidators(session, bz)
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+
+	// the session key is not kept, the broadcaster keeps using its own
+	writeJson(w, types.PreVoteStreamingSessionRegistrationResponse{
+		SessionId: session.SessionId,
+	})
+}
+
+func (s *Server) handleBroadcast(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	bz, ok := s.readBody(w, r, s.config.MaxEncodedNextBlockVotingInformationBytes)
+	if !ok {
+		return
+	}
+
+	session, ok := s.authorizedSession(w, r, params, bz)
+	if !ok {
+		return
+	}
+
+	session, ok = s.updateSession(w, session.SessionId, func(session *PreVoteStreamingSession) error {
+		if err := s.acceptNextBlockVotingInformation(session, bz); err != nil {
+			return err
+		}
+		return session.RecordBroadcast(s.now())
+	})
+	if !ok {
+		return
+	}
+	s.hub.publish(session.SessionId, bz)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	session, ok := s.authorizedSession(w, r, params, nil)
+	if !ok {
+		return
+	}
+
+	_, ok = s.updateSession(w, session.SessionId, func(session *PreVoteStreamingSession) error {
+		return session.Revoke(s.now())
+	})
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleView(w http.ResponseWriter, _ *http.Request, params map[string]string) {
+	session, ok := s.session(w, params)
+	if !ok {
+		return
+	}
+
+	writeJson(w, types.PreVoteStreamingSessionViewResponse{
+		ChainId:                    session.ChainId,
+		ChainName:                  s.config.ChainRegistry.DisplayName(session.ChainId),
+		Validators:                 session.Validators,
+		NextBlockVotingInformation: session.NextBlockVotingInformation,
+	})
+}
+
+func (s *Server) handleFetchUpdate(w http.ResponseWriter, _ *http.Request, params map[string]string) {
+	session, ok := s.session(w, params)
+	if !ok {
+		return
+	}
+
+	if len(session.EncodedNextBlockVotingInformation) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", constants.STREAMING_CONTENT_TYPE)
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(session.EncodedNextBlockVotingInformation)
+}
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	session, ok := s.session(w, params)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sub, unsubscribe := s.hub.subscribe(session.SessionId)
+	defer unsubscribe()
+
+	// re-read after subscribed, so no frame broadcast in between is missed
+	if latest, found := s.config.Store.Get(session.SessionId); found {
+		session = latest
+	}
+
+	w.Header().Set("Content-Type", constants.STREAMING_CONTENT_TYPE_EVENT_STREAM)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if len(session.EncodedNextBlockVotingInformation) > 0 {
+		if writeEvent(w, constants.STREAMING_EVENT_UPDATE, session.EncodedNextBlockVotingInformation) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(s.config.KeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case frame := <-sub.frames:
+			if writeEvent(w, constants.STREAMING_EVENT_UPDATE, frame) != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// session returns the session of the session id in path, responds 404 Not Found if not exists
+// or 410 Gone if revoked.
+func (s *Server) session(w http.ResponseWriter, params map[string]string) (PreVoteStreamingSession, bool) {
+	sessionId := types.PreVoteStreamingSessionId(params["sessionId"])
+	if err := sessionId.ValidateBasic(); err != nil {
+		http.Error(w, fmt.Sprintf("invalid session id: %v", err), http.StatusBadRequest)
+		return PreVoteStreamingSession{}, false
+	}
+
+	session, found := s.config.Store.Get(sessionId)
+	if !found {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return PreVoteStreamingSession{}, false
+	}
+	if session.Revoked {
+		http.Error(w, types.ErrPreVoteStreamingSessionRevoked.Error(), http.StatusGone)
+		return PreVoteStreamingSession{}, false
+	}
+
+	return session, true
+}
+
+// updateSession atomically applies the update to the session, see SessionStore.Update,
+// responds the error and returns false if the session no longer exists, had been revoked or the update failed.
+//
+// Errors of the update respond 400 Bad Request, except the lifecycle errors of types.PreVoteStreamingSession.
+func (s *Server) updateSession(w http.ResponseWriter, sessionId types.PreVoteStreamingSessionId, update func(session *PreVoteStreamingSession) error) (PreVoteStreamingSession, bool) {
+	var rejection error
+	session, err := s.config.Store.Update(sessionId, func(session *PreVoteStreamingSession) error {
+		if session.Revoked {
+			rejection = types.ErrPreVoteStreamingSessionRevoked
+		} else {
+			rejection = update(session)
+		}
+		return rejection
+	})
+	if err == nil {
+		return session, true
+	}
+
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		http.Error(w, "session not found", http.StatusNotFound)
+	case rejection == nil:
+		http.Error(w, "failed to store session", http.StatusInternalServerError)
+	case errors.Is(rejection, types.ErrPreVoteStreamingSessionRevoked), errors.Is(rejection, types.ErrPreVoteStreamingSessionExpired):
+		http.Error(w, rejection.Error(), http.StatusGone)
+	case errors.Is(rejection, types.ErrPreVoteStreamingSessionResumeLimitReached):
+		http.Error(w, rejection.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, rejection.Error(), http.StatusBadRequest)
+	}
+	return PreVoteStreamingSession{}, false
+}
+
+// authorizedSession is the same as session, but also requires the request is authenticated,
+// responds 401 Unauthorized otherwise.
+//
+// The request is authenticated by the signature headers signing the method, the URL path and the body,
+// or by the raw session key header unless Config.RequireBroadcastSignature.
+func (s *Server) authorizedSession(w http.ResponseWriter, r *http.Request, params map[string]string, body []byte) (PreVoteStreamingSession, bool) {
+	session, ok := s.session(w, params)
+	if !ok {
+		return PreVoteStreamingSession{}, false
+	}
+
+	if signature := r.Header.Get(constants.STREAMING_HEADER_SIGNATURE); signature != "" {
+		sig, err := types.ParseBroadcastSignature(
+			r.Header.Get(constants.STREAMING_HEADER_SIGNATURE_TIMESTAMP),
+			r.Header.Get(constants.STREAMING_HEADER_SIGNATURE_NONCE),
+			signature,
+		)
+		if err == nil {
+			err = s.verifier.Verify(session.SessionId, session.BroadcastVerificationKey, r.Method, r.URL.Path, sig, body, s.now())
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return PreVoteStreamingSession{}, false
+		}
+		return session, true
+	}
+
+	if s.config.RequireBroadcastSignature {
+		http.Error(w, "missing broadcast signature", http.StatusUnauthorized)
+		return PreVoteStreamingSession{}, false
+	}
+
+	sessionKey := types.PreVoteStreamingSessionKey(r.Header.Get(constants.STREAMING_HEADER_SESSION_KEY))
+	if !session.SessionKeyHash.Verify(sessionKey) {
+		http.Error(w, "invalid session key", http.StatusUnauthorized)
+		return PreVoteStreamingSession{}, false
+	}
+
+	return session, true
+}
+
+// readBody reads the octet-stream request body, responds 413 Request Entity Too Large if longer than the limit.
+func (s *Server) readBody(w http.ResponseWriter, r *http.Request, maxBytes int) ([]byte, bool) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, constants.STREAMING_CONTENT_TYPE) {
+		http.Error(w, fmt.Sprintf("unsupported content type %s", contentType), http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+
+	bz, err := io.ReadAll(io.LimitReader(r.Body, int64(maxBytes)+1))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+	if len(bz) > maxBytes {
+		http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxBytes), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+
+	return bz, true
+}
+
+// replaceLightValidators replaces the validators of the session by the encoded light validators,
+// the latest information is dropped because it may refer validators which are no longer in the set.
+func (s *Server) replaceLightValidators(session *PreVoteStreamingSession, bz []byte) error {
+	validators, err := s.decodeLightValidators(bz)
+	if err != nil {
+		return err
+	}
+	session.EncodedLightValidators = bz
+	session.Validators = validators
+	session.EncodedNextBlockVotingInformation = nil
+	session.NextBlockVotingInformation = nil
+	return nil
+}
+
+// acceptNextBlockVotingInformation validates the encoded next block voting information against the validators
+// of the session and keeps it as the latest information of the session.
+func (s *Server) acceptNextBlockVotingInformation(session *PreVoteStreamingSession, bz []byte) error {
+	inf, err := s.config.Codec.DecodeStreamingNextBlockVotingInformation(bz)
+	if err != nil {
+		return fmt.Errorf("invalid next block voting information: %v", err)
+	}
+	for _, voteState := range inf.ValidatorVoteStates {
+		if voteState.ValidatorIndex >= len(session.Validators) {
+			return fmt.Errorf("vote state of unknown validator index %d", voteState.ValidatorIndex)
+		}
+	}
+	session.EncodedNextBlockVotingInformation = bz
+	session.NextBlockVotingInformation = inf
+	return nil
+}
+
+func (s *Server) decodeLightValidators(bz []byte) (types.StreamingLightValidators, error) {
+	validators, err := s.config.Codec.DecodeStreamingLightValidators(bz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid light validators: %v", err)
+	}
+	if len(validators) < 1 {
+		return nil, fmt.Errorf("empty validator set")
+	}
+	if len(validators) > s.config.MaxValidators {
+		return nil, fmt.Errorf("too many validators %d, maximum %d", len(validators), s.config.MaxValidators)
+	}
+	return validators, nil
+}
+
+// writeEvent writes a Server-Sent Event, the binary data is encoded as base64 so it fits in a single data line.
+func writeEvent(w io.Writer, event string, data []byte) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, base64.StdEncoding.EncodeToString(data))
+	return err
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	bz, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(bz)
+}
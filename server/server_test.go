@@ -0,0 +1,556 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/codec"
+	"github.com/bcdevtools/cvp-streaming-core/constants"
+	"github.com/bcdevtools/cvp-streaming-core/internal/websocket"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testCodec = codec.GetCvpCodecV2()
+
+var testValidators = types.StreamingLightValidators{
+	{Index: 0, VotingPowerDisplayPercent: 60, Moniker: "Validator One"},
+	{Index: 1, VotingPowerDisplayPercent: 40, Moniker: "Validator Two"},
+}
+
+func newTestInformation(validatorIndexes ...int) *types.StreamingNextBlockVotingInformation {
+	inf := &types.StreamingNextBlockVotingInformation{
+		HeightRoundStep: types.MustParseHeightRoundStep("100/0/4"),
+		Duration:        time.Second,
+		PreVotedPercent: 60,
+	}
+	for _, validatorIndex := range validatorIndexes {
+		inf.ValidatorVoteStates = append(inf.ValidatorVoteStates, types.StreamingValidatorVoteState{
+			ValidatorIndex:    validatorIndex,
+			PreVotedBlockHash: "C0FF",
+			PreVoted:          true,
+		})
+	}
+	return inf
+}
+
+func doRequest(t *testing.T, method, url string, sessionKey types.PreVoteStreamingSessionKey, body []byte) (int, []byte) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	if body != nil {
+		req.Header.Set("Content-Type", constants.STREAMING_CONTENT_TYPE)
+	}
+	if sessionKey != "" {
+		req.Header.Set(constants.STREAMING_HEADER_SESSION_KEY, string(sessionKey))
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	bz, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, bz
+}
+
+func registerTestSession(t *testing.T, baseUrl string) types.PreVoteStreamingSessionRegistrationResponse {
+	status, bz := doRequest(t, http.MethodPost, utils.GetRemoteUrlRegisterPreVoteStreamingSession(baseUrl, "cosmoshub-4"), "", testCodec.EncodeStreamingLightValidators(testValidators))
+	require.Equal(t, http.StatusOK, status, string(bz))
+
+	var registration types.PreVoteStreamingSessionRegistrationResponse
+	require.NoError(t, json.Unmarshal(bz, &registration))
+	require.NoError(t, registration.SessionId.ValidateBasic())
+	require.NoError(t, registration.SessionKey.ValidateBasic())
+	require.True(t, registration.SessionId.ForChainId("cosmoshub-4"))
+	return registration
+}
+
+func TestServer_StreamingSession(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{}))
+	defer srv.Close()
+	baseUrl := srv.URL
+
+	registration := registerTestSession(t, baseUrl)
+	sessionId := string(registration.SessionId)
+
+	t.Run("nothing to fetch before broadcasting", func(t *testing.T) {
+		status, _ := doRequest(t, http.MethodGet, utils.GetUrlFetchPreVoteStreamingSessionUpdate(baseUrl, sessionId), "", nil)
+		require.Equal(t, http.StatusNoContent, status)
+	})
+
+	encodedInf := testCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(0, 1))
+
+	t.Run("broadcast", func(t *testing.T) {
+		status, bz := doRequest(t, http.MethodPost, utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, sessionId), registration.SessionKey, encodedInf)
+		require.Equal(t, http.StatusOK, status, string(bz))
+	})
+
+	t.Run("fetch update", func(t *testing.T) {
+		status, bz := doRequest(t, http.MethodGet, utils.GetUrlFetchPreVoteStreamingSessionUpdate(baseUrl, sessionId), "", nil)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, encodedInf, bz)
+	})
+
+	t.Run("view", func(t *testing.T) {
+		status, bz := doRequest(t, http.MethodGet, utils.GetPublicUrlViewPreVoteStreamingSession(baseUrl, sessionId), "", nil)
+		require.Equal(t, http.StatusOK, status)
+
+		var view types.PreVoteStreamingSessionViewResponse
+		require.NoError(t, json.Unmarshal(bz, &view))
+		require.Equal(t, "cosmoshub-4", view.ChainId)
+		require.Equal(t, "cosmoshub-4", view.ChainName, "chain id is the name if no registry")
+		require.Equal(t, testValidators, view.Validators)
+		require.Equal(t, newTestInformation(0, 1), view.NextBlockVotingInformation)
+	})
+
+	t.Run("resume without replacing validators", func(t *testing.T) {
+		status, bz := doRequest(t, http.MethodPost, utils.GetRemoteUrlResumePreVoteStreamingSession(baseUrl, sessionId), registration.SessionKey, nil)
+		require.Equal(t, http.StatusOK, status, string(bz))
+
+		var resumed types.PreVoteStreamingSessionRegistrationResponse
+		require.NoError(t, json.Unmarshal(bz, &resumed))
+		require.Equal(t, registration.SessionId, resumed.SessionId)
+		require.Empty(t, resumed.SessionKey, "the session key is not kept by the server")
+
+		status, _ = doRequest(t, http.MethodGet, utils.GetUrlFetchPreVoteStreamingSessionUpdate(baseUrl, sessionId), "", nil)
+		require.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("resume with new validators", func(t *testing.T) {
+		newValidators := testValidators[:1]
+		status, bz := doRequest(t, http.MethodPost, utils.GetRemoteUrlResumePreVoteStreamingSession(baseUrl, sessionId), registration.SessionKey, testCodec.EncodeStreamingLightValidators(newValidators))
+		require.Equal(t, http.StatusOK, status, string(bz))
+
+		status, _ = doRequest(t, http.MethodGet, utils.GetUrlFetchPreVoteStreamingSessionUpdate(baseUrl, sessionId), "", nil)
+		require.Equal(t, http.StatusNoContent, status, "information of the previous validator set must be dropped")
+
+		status, bz = doRequest(t, http.MethodPost, utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, sessionId), registration.SessionKey, encodedInf)
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Contains(t, string(bz), "unknown validator index 1")
+	})
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{}))
+	defer srv.Close()
+	baseUrl := srv.URL
+
+	registration := registerTestSession(t, baseUrl)
+	sessionId := string(registration.SessionId)
+	unknownSessionId := "cosmoshub-4_0000000000000000000000000000000000000000000000000000000000000000"
+	encodedInf := testCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(0))
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		sessionKey types.PreVoteStreamingSessionKey
+		body       []byte
+		wantStatus int
+	}{
+		{
+			name:       "register invalid chain id",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlRegisterPreVoteStreamingSession(baseUrl, "x"),
+			body:       testCodec.EncodeStreamingLightValidators(testValidators),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "register with invalid body",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlRegisterPreVoteStreamingSession(baseUrl, "cosmoshub-4"),
+			body:       []byte("invalid"),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "register with body too large",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlRegisterPreVoteStreamingSession(baseUrl, "cosmoshub-4"),
+			body:       make([]byte, constants.MAX_ENCODED_LIGHT_VALIDATORS_BYTES+1),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "register with wrong method",
+			method:     http.MethodGet,
+			url:        utils.GetRemoteUrlRegisterPreVoteStreamingSession(baseUrl, "cosmoshub-4"),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "broadcast without session key",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, sessionId),
+			body:       encodedInf,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "broadcast with wrong session key",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, sessionId),
+			sessionKey: "0000000000000000000000000000000000000000000000000000000000000000",
+			body:       encodedInf,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "broadcast to unknown session",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, unknownSessionId),
+			sessionKey: registration.SessionKey,
+			body:       encodedInf,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "broadcast with body too large",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, sessionId),
+			sessionKey: registration.SessionKey,
+			body:       make([]byte, constants.MAX_ENCODED_NEXT_BLOCK_PRE_VOTE_INFO_BYTES+1),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "broadcast light validators",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, sessionId),
+			sessionKey: registration.SessionKey,
+			body:       testCodec.EncodeStreamingLightValidators(testValidators),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "resume with wrong session key",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlResumePreVoteStreamingSession(baseUrl, sessionId),
+			sessionKey: "0000000000000000000000000000000000000000000000000000000000000000",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "view invalid session id",
+			method:     http.MethodGet,
+			url:        utils.GetPublicUrlViewPreVoteStreamingSession(baseUrl, "invalid"),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "fetch update of unknown session",
+			method:     http.MethodGet,
+			url:        utils.GetUrlFetchPreVoteStreamingSessionUpdate(baseUrl, unknownSessionId),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "websocket broadcast without session key",
+			method:     http.MethodGet,
+			url:        baseUrl + "/broadcast-ws/pre-vote/" + sessionId,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "websocket broadcast without upgrading",
+			method:     http.MethodGet,
+			url:        baseUrl + "/broadcast-ws/pre-vote/" + sessionId,
+			sessionKey: registration.SessionKey,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "subscribe unknown session",
+			method:     http.MethodGet,
+			url:        utils.GetUrlSubscribePreVoteStreamingSessionUpdates(baseUrl, unknownSessionId),
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "revoke without session key",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlRevokePreVoteStreamingSession(baseUrl, sessionId),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "revoke unknown session",
+			method:     http.MethodPost,
+			url:        utils.GetRemoteUrlRevokePreVoteStreamingSession(baseUrl, unknownSessionId),
+			sessionKey: registration.SessionKey,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unknown path",
+			method:     http.MethodGet,
+			url:        baseUrl + "/unknown",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, bz := doRequest(t, tt.method, tt.url, tt.sessionKey, tt.body)
+			require.Equal(t, tt.wantStatus, status, string(bz))
+		})
+	}
+}
+
+func TestServer_DefaultLimits(t *testing.T) {
+	// more than the limit of the v1 to v4 codecs
+	validators := make(types.StreamingLightValidators, 1000)
+	validatorIndexes := make([]int, len(validators))
+	for i := range validators {
+		validators[i] = types.StreamingLightValidator{Index: i, VotingPowerDisplayPercent: 0.1, Moniker: fmt.Sprintf("Validator %d", i)}
+		validatorIndexes[i] = i
+	}
+	v9Codec := codec.GetCvpCodecV9()
+	encodedValidators := v9Codec.EncodeStreamingLightValidators(validators)
+
+	t.Run("default", func(t *testing.T) {
+		srv := httptest.NewServer(NewServer(Config{}))
+		defer srv.Close()
+
+		status, _ := doRequest(t, http.MethodPost, utils.GetRemoteUrlRegisterPreVoteStreamingSession(srv.URL, "cosmoshub-4"), "", encodedValidators)
+		require.Equal(t, http.StatusRequestEntityTooLarge, status, "unauthenticated registration must be limited by default")
+	})
+
+	srv := httptest.NewServer(NewServer(Config{Codec: codec.NewProxyCvpCodec()}))
+	defer srv.Close()
+	baseUrl := srv.URL
+
+	status, bz := doRequest(t, http.MethodPost, utils.GetRemoteUrlRegisterPreVoteStreamingSession(baseUrl, "cosmoshub-4"), "", encodedValidators)
+	require.Equal(t, http.StatusOK, status, string(bz))
+
+	var registration types.PreVoteStreamingSessionRegistrationResponse
+	require.NoError(t, json.Unmarshal(bz, &registration))
+
+	status, bz = doRequest(t, http.MethodPost, utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, string(registration.SessionId)), registration.SessionKey, v9Codec.EncodeStreamingNextBlockVotingInformation(newTestInformation(validatorIndexes...)))
+	require.Equal(t, http.StatusOK, status, string(bz))
+}
+
+func TestServer_WebSocketOrigin(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{WebSocketAllowedOrigins: []string{"https://allowed.example"}}))
+	defer srv.Close()
+	baseUrl := srv.URL
+
+	registration := registerTestSession(t, baseUrl)
+	wsUrl := utils.GetRemoteUrlBroadcastPreVoteWebSocket(baseUrl, string(registration.SessionId))
+
+	tests := []struct {
+		name        string
+		origin      string
+		wantAllowed bool
+	}{
+		{
+			name:        "no origin",
+			wantAllowed: true,
+		},
+		{
+			name:        "same host",
+			origin:      baseUrl,
+			wantAllowed: true,
+		},
+		{
+			name:        "allowed origin",
+			origin:      "https://allowed.example",
+			wantAllowed: true,
+		},
+		{
+			name:   "other origin",
+			origin: "https://evil.example",
+		},
+		{
+			name:   "invalid origin",
+			origin: "://",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{constants.STREAMING_HEADER_SESSION_KEY: []string{string(registration.SessionKey)}}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+
+			conn, err := websocket.Dial(context.Background(), wsUrl, header)
+			if tt.wantAllowed {
+				require.NoError(t, err)
+				_ = conn.Close()
+				return
+			}
+
+			var handshakeErr *websocket.HandshakeError
+			require.ErrorAs(t, err, &handshakeErr)
+			require.Equal(t, http.StatusForbidden, handshakeErr.Response.StatusCode)
+		})
+	}
+}
+
+func TestServer_ChainRegistry(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{ChainRegistry: types.DefaultChainRegistry()}))
+	defer srv.Close()
+	baseUrl := srv.URL
+
+	status, _ := doRequest(t, http.MethodPost, utils.GetRemoteUrlRegisterPreVoteStreamingSession(baseUrl, "cosmoshub-5"), "", testCodec.EncodeStreamingLightValidators(testValidators))
+	require.Equal(t, http.StatusBadRequest, status, "unknown chain")
+
+	registration := registerTestSession(t, baseUrl)
+	status, bz := doRequest(t, http.MethodGet, utils.GetPublicUrlViewPreVoteStreamingSession(baseUrl, string(registration.SessionId)), "", nil)
+	require.Equal(t, http.StatusOK, status)
+
+	var view types.PreVoteStreamingSessionViewResponse
+	require.NoError(t, json.Unmarshal(bz, &view))
+	require.Equal(t, "cosmoshub-4", view.ChainId)
+	require.Equal(t, "Cosmos Hub", view.ChainName)
+}
+
+func TestServer_BroadcastSignature(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{RequireBroadcastSignature: true}))
+	defer srv.Close()
+	baseUrl := srv.URL
+
+	registration := registerTestSession(t, baseUrl)
+	url := utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, string(registration.SessionId))
+	encodedInf := testCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(0))
+
+	broadcastPath := "/" + strings.ReplaceAll(constants.STREAMING_PATH_BROADCAST_PRE_VOTE, ":sessionId", string(registration.SessionId))
+
+	doSignedRequest := func(method, url string, sig types.BroadcastSignature, body []byte) int {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", constants.STREAMING_CONTENT_TYPE)
+		req.Header.Set(constants.STREAMING_HEADER_SIGNATURE, sig.Signature)
+		req.Header.Set(constants.STREAMING_HEADER_SIGNATURE_TIMESTAMP, sig.TimestampString())
+		req.Header.Set(constants.STREAMING_HEADER_SIGNATURE_NONCE, sig.Nonce)
+
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		return res.StatusCode
+	}
+
+	sig, err := types.SignBroadcast(registration.SessionId, registration.SessionKey, http.MethodPost, broadcastPath, encodedInf, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, doSignedRequest(http.MethodPost, url, sig, encodedInf))
+	require.Equal(t, http.StatusUnauthorized, doSignedRequest(http.MethodPost, url, sig, encodedInf), "replayed")
+
+	sig, err = types.SignBroadcast(registration.SessionId, registration.SessionKey, http.MethodPost, broadcastPath, encodedInf, time.Now())
+	require.NoError(t, err)
+	tampered := testCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(1))
+	require.Equal(t, http.StatusUnauthorized, doSignedRequest(http.MethodPost, url, sig, tampered), "tampered body")
+
+	sig, err = types.SignBroadcast(registration.SessionId, registration.SessionKey, http.MethodPost, broadcastPath, encodedInf, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, doSignedRequest(http.MethodPost, url, sig, encodedInf), "expired")
+
+	t.Run("signature of another route is rejected", func(t *testing.T) {
+		// the WebSocket handshake signs an empty payload, same as revoking
+		wsPath := "/" + strings.ReplaceAll(constants.STREAMING_PATH_BROADCAST_PRE_VOTE_WS, ":sessionId", string(registration.SessionId))
+		sig, err := types.SignBroadcast(registration.SessionId, registration.SessionKey, http.MethodGet, wsPath, nil, time.Now())
+		require.NoError(t, err)
+
+		revokeUrl := utils.GetRemoteUrlRevokePreVoteStreamingSession(baseUrl, string(registration.SessionId))
+		require.Equal(t, http.StatusUnauthorized, doSignedRequest(http.MethodPost, revokeUrl, sig, nil))
+
+		sig, err = types.SignBroadcast(registration.SessionId, registration.SessionKey, http.MethodPost, broadcastPath, encodedInf, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, doSignedRequest(http.MethodPost, url, sig, encodedInf), "session must not be revoked")
+	})
+
+	status, _ := doRequest(t, http.MethodPost, url, registration.SessionKey, encodedInf)
+	require.Equal(t, http.StatusUnauthorized, status, "raw session key is not accepted")
+}
+
+func TestInMemorySessionStore_Expiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewInMemorySessionStore().(*inMemorySessionStore)
+	store.now = func() time.Time {
+		return now
+	}
+
+	require.NoError(t, store.Put(PreVoteStreamingSession{PreVoteStreamingSession: types.StartPreVoteStreamingSession("a", time.Minute, 0, now)}))
+	_, found := store.Get("a")
+	require.True(t, found)
+
+	now = now.Add(2 * time.Minute)
+	_, found = store.Get("a")
+	require.False(t, found)
+
+	require.NoError(t, store.Put(PreVoteStreamingSession{PreVoteStreamingSession: types.StartPreVoteStreamingSession("b", time.Minute, 0, now)}))
+	require.Len(t, store.sessions, 1, "expired session must be pruned")
+
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, store.Put(PreVoteStreamingSession{PreVoteStreamingSession: types.StartPreVoteStreamingSession("c", time.Second, 0, now)}))
+	now = now.Add(inMemorySessionPruneInterval / 2)
+	_, found = store.Get("c")
+	require.False(t, found)
+	require.NoError(t, store.Put(PreVoteStreamingSession{PreVoteStreamingSession: types.StartPreVoteStreamingSession("d", time.Minute, 0, now)}))
+	require.Len(t, store.sessions, 2, "sessions must not be pruned more than once per interval")
+}
+
+func TestSessionStore_Update(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	const sessionId types.PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+
+	inMemoryStore := NewInMemorySessionStore().(*inMemorySessionStore)
+	inMemoryStore.now = func() time.Time {
+		return now
+	}
+	fileStore := openTestFileSessionStore(t, filepath.Join(t.TempDir(), "sessions.log"), now)
+	defer func() {
+		_ = fileStore.Close()
+	}()
+
+	for name, store := range map[string]SessionStore{"in-memory": inMemoryStore, "file": fileStore} {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Update(sessionId, func(*PreVoteStreamingSession) error {
+				return nil
+			})
+			require.ErrorIs(t, err, ErrSessionNotFound)
+
+			require.NoError(t, store.Put(newTestFileSession(sessionId, now)))
+
+			updated, err := store.Update(sessionId, func(session *PreVoteStreamingSession) error {
+				session.LastBroadcastSequence++
+				return nil
+			})
+			require.NoError(t, err)
+			require.EqualValues(t, 8, updated.LastBroadcastSequence)
+
+			errRejected := fmt.Errorf("rejected")
+			_, err = store.Update(sessionId, func(session *PreVoteStreamingSession) error {
+				session.LastBroadcastSequence++
+				return errRejected
+			})
+			require.ErrorIs(t, err, errRejected)
+			got, found := store.Get(sessionId)
+			require.True(t, found)
+			require.EqualValues(t, 8, got.LastBroadcastSequence, "rejected update must not be stored")
+		})
+	}
+}
+
+func TestServer_ConcurrentBroadcastAndRevoke(t *testing.T) {
+	srv := httptest.NewServer(NewServer(Config{}))
+	defer srv.Close()
+
+	registration := registerTestSession(t, srv.URL)
+	sessionId := string(registration.SessionId)
+	encodedInf := testCodec.EncodeStreamingNextBlockVotingInformation(newTestInformation(0, 1))
+
+	var wg sync.WaitGroup
+	broadcastStatuses := make([]int, 20)
+	for i := range broadcastStatuses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			broadcastStatuses[i], _ = doRequest(t, http.MethodPost, utils.GetRemoteUrlBroadcastPreVoteDuringStreamingSession(srv.URL, sessionId), registration.SessionKey, encodedInf)
+		}(i)
+	}
+	status, bz := doRequest(t, http.MethodPost, utils.GetRemoteUrlRevokePreVoteStreamingSession(srv.URL, sessionId), registration.SessionKey, nil)
+	require.Equal(t, http.StatusOK, status, string(bz))
+	wg.Wait()
+
+	for _, status := range broadcastStatuses {
+		require.Contains(t, []int{http.StatusOK, http.StatusGone}, status)
+	}
+
+	status, _ = doRequest(t, http.MethodGet, utils.GetPublicUrlViewPreVoteStreamingSession(srv.URL, sessionId), "", nil)
+	require.Equal(t, http.StatusGone, status, "broadcasts must not undo the revoke")
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"sync"
+	"time"
+)
+
+// ErrSessionNotFound is returned by SessionStore.Update when the session does not exist or had been expired.
+var ErrSessionNotFound = fmt.Errorf("session not found")
+
+// PreVoteStreamingSession holds the state of a pre-vote streaming session.
+type PreVoteStreamingSession struct {
+	types.PreVoteStreamingSession
+
+	// SessionKeyHash verifies the raw session key header, the session key itself is never kept.
+	SessionKeyHash types.PreVoteStreamingSessionKeyHash
+
+	// BroadcastVerificationKey verifies the signature headers.
+	BroadcastVerificationKey types.BroadcastVerificationKey
+
+	// EncodedLightValidators is the encoded validators provided at registration or resuming.
+	EncodedLightValidators []byte
+	Validators             types.StreamingLightValidators
+
+	// EncodedNextBlockVotingInformation is the latest broadcast encoded data, nil if not any yet.
+	EncodedNextBlockVotingInformation []byte
+	NextBlockVotingInformation        *types.StreamingNextBlockVotingInformation
+
+	// LastBroadcastSequence is the sequence of the latest frame broadcast over WebSocket, zero if none.
+	LastBroadcastSequence uint64
+}
+
+// SessionStore stores pre-vote streaming sessions, implementations must be safe for concurrent use.
+type SessionStore interface {
+	// Get returns a copy of the session, found is false if the session does not exist or had been expired.
+	// Revoked sessions are returned until expired.
+	Get(sessionId types.PreVoteStreamingSessionId) (session PreVoteStreamingSession, found bool)
+
+	// Put creates or replaces the session.
+	Put(session PreVoteStreamingSession) error
+
+	// Update applies the update to a copy of the session and stores it, atomically with other Put and Update
+	// of the same session, so concurrent changes are not lost. The update must not change the session id.
+	// If the update returns an error, the session is not changed and the error is returned as is.
+	// Returns ErrSessionNotFound if the session does not exist or had been expired.
+	Update(sessionId types.PreVoteStreamingSessionId, update func(session *PreVoteStreamingSession) error) (PreVoteStreamingSession, error)
+}
+
+var _ SessionStore = (*inMemorySessionStore)(nil)
+
+// inMemorySessionPruneInterval is the minimum interval between two prunes of the expired in-memory sessions.
+const inMemorySessionPruneInterval = time.Minute
+
+type inMemorySessionStore struct {
+	mu        sync.RWMutex
+	sessions  map[types.PreVoteStreamingSessionId]PreVoteStreamingSession
+	now       func() time.Time
+	nextPrune time.Time
+}
+
+// NewInMemorySessionStore returns a SessionStore keeping sessions in memory,
+// sessions expire by their own TTL, see types.PreVoteStreamingSession.
+func NewInMemorySessionStore() SessionStore {
+	return &inMemorySessionStore{
+		sessions: make(map[types.PreVoteStreamingSessionId]PreVoteStreamingSession),
+		now:      time.Now,
+	}
+}
+
+func (s *inMemorySessionStore) Get(sessionId types.PreVoteStreamingSessionId) (PreVoteStreamingSession, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, found := s.sessions[sessionId]
+	if !found || session.IsExpired(s.now()) {
+		return PreVoteStreamingSession{}, false
+	}
+	return session, true
+}
+
+func (s *inMemorySessionStore) Put(session PreVoteStreamingSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.put(session)
+	return nil
+}
+
+func (s *inMemorySessionStore) Update(sessionId types.PreVoteStreamingSessionId, update func(session *PreVoteStreamingSession) error) (PreVoteStreamingSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, found := s.sessions[sessionId]
+	if !found || session.IsExpired(s.now()) {
+		return PreVoteStreamingSession{}, ErrSessionNotFound
+	}
+	if err := update(&session); err != nil {
+		return PreVoteStreamingSession{}, err
+	}
+
+	s.put(session)
+	return session, nil
+}
+
+// put stores the session, mu must be held.
+func (s *inMemorySessionStore) put(session PreVoteStreamingSession) {
+	// prune expired sessions to keep the memory bounded, at most once per interval so a put stays cheap
+	if now := s.now(); !now.Before(s.nextPrune) {
+		for sessionId, existing := range s.sessions {
+			if existing.IsExpired(now) {
+				delete(s.sessions, sessionId)
+			}
+		}
+		s.nextPrune = now.Add(inMemorySessionPruneInterval)
+	}
+
+	s.sessions[session.SessionId] = session
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/constants"
+	"github.com/bcdevtools/cvp-streaming-core/internal/websocket"
+	"github.com/bcdevtools/cvp-streaming-core/types"
+	"net/http"
+	"strings"
+)
+
+// errDuplicateWebSocketFrame rejects the session update of an already accepted frame, so nothing is stored.
+var errDuplicateWebSocketFrame = fmt.Errorf("duplicated frame")
+
+// handleBroadcastWebSocket streams the broadcast frames over WebSocket.
+//
+// The broadcaster authenticates once at the handshake, the signature headers sign an empty payload.
+// Right after the upgrade, the server sends the acknowledgement of the last accepted sequence of the session,
+// then each binary frame sent by the broadcaster is processed in order and acknowledged by a JSON text message.
+// Frames are read one by one, so a broadcaster sending faster than the server processes is slowed down by TCP.
+// Frames of sequence not greater than the last accepted sequence are duplicated by reconnecting broadcasters,
+// they are acknowledged without being processed again.
+func (s *Server) handleBroadcastWebSocket(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	session, ok := s.authorizedSession(w, r, params, nil)
+	if !ok {
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r, s.checkWebSocketOrigin)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	maxPayloadBytes := s.config.MaxEncodedLightValidatorsBytes
+	if s.config.MaxEncodedNextBlockVotingInformationBytes > maxPayloadBytes {
+		maxPayloadBytes = s.config.MaxEncodedNextBlockVotingInformationBytes
+	}
+	conn.SetReadLimit(constants.STREAMING_WS_FRAME_HEADER_SIZE + maxPayloadBytes)
+	conn.SetIdleTimeout(s.config.WebSocketIdleTimeout)
+
+	if writeAck(conn, types.PreVoteStreamingBroadcastAck{Sequence: session.LastBroadcastSequence}) != nil {
+		return
+	}
+
+	for {
+		opcode, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if opcode != websocket.OpBinary || len(message) < constants.STREAMING_WS_FRAME_HEADER_SIZE {
+			_ = conn.CloseWithStatus(websocket.CloseProtocolError, "malformed frame")
+			return
+		}
+
+		sequence := binary.BigEndian.Uint64(message[:8])
+		kind := message[8]
+		payload := message[constants.STREAMING_WS_FRAME_HEADER_SIZE:]
+
+		// update atomically every frame, the session may be changed by other requests
+		ack := types.PreVoteStreamingBroadcastAck{Sequence: sequence}
+		var inactive error
+		updated, err := s.config.Store.Update(session.SessionId, func(session *PreVoteStreamingSession) error {
+			if inactive = session.EnsureActive(s.now()); inactive != nil {
+				return inactive
+			}
+			if sequence <= session.LastBroadcastSequence {
+				return errDuplicateWebSocketFrame
+			}
+			if err := s.acceptWebSocketFrame(session, kind, payload); err != nil {
+				ack.Error = err.Error()
+			}
+			session.LastBroadcastSequence = sequence
+			return session.RecordBroadcast(s.now()) // active ensured above
+		})
+		switch {
+		case err == nil:
+			if ack.Error == "" && kind == constants.STREAMING_WS_FRAME_KIND_NEXT_BLOCK_VOTING_INFO {
+				s.hub.publish(updated.SessionId, payload)
+			}
+		case errors.Is(err, errDuplicateWebSocketFrame):
+			// already accepted, only acknowledge again
+		case errors.Is(err, ErrSessionNotFound):
+			_ = conn.CloseWithStatus(websocket.ClosePolicyViolation, "session not found")
+			return
+		case inactive != nil:
+			_ = conn.CloseWithStatus(websocket.ClosePolicyViolation, inactive.Error())
+			return
+		default:
+			_ = conn.CloseWithStatus(websocket.CloseInternalError, "failed to store session")
+			return
+		}
+
+		if writeAck(conn, ack) != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) acceptWebSocketFrame(session *PreVoteStreamingSession, kind byte, payload []byte) error {
+	switch kind {
+	case constants.STREAMING_WS_FRAME_KIND_NEXT_BLOCK_VOTING_INFO:
+		if len(payload) > s.config.MaxEncodedNextBlockVotingInformationBytes {
+			return fmt.Errorf("frame exceeds %d bytes", s.config.MaxEncodedNextBlockVotingInformationBytes)
+		}
+		return s.acceptNextBlockVotingInformation(session, payload)
+	case constants.STREAMING_WS_FRAME_KIND_LIGHT_VALIDATORS:
+		if len(payload) > s.config.MaxEncodedLightValidatorsBytes {
+			return fmt.Errorf("frame exceeds %d bytes", s.config.MaxEncodedLightValidatorsBytes)
+		}
+		return s.replaceLightValidators(session, payload)
+	default:
+		return fmt.Errorf("unknown frame kind %d", kind)
+	}
+}
+
+// checkWebSocketOrigin allows the handshakes from the same host or one of Config.WebSocketAllowedOrigins.
+func (s *Server) checkWebSocketOrigin(r *http.Request) bool {
+	if websocket.CheckSameOrigin(r) {
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	for _, allowed := range s.config.WebSocketAllowedOrigins {
+		if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeAck(conn *websocket.Conn, ack types.PreVoteStreamingBroadcastAck) error {
+	bz, err := json.Marshal(ack)
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.OpText, bz)
+}
@@ -2,5 +2,20 @@ package types
 
 type PreVoteStreamingSessionRegistrationResponse struct {
 	SessionId  PreVoteStreamingSessionId  `json:"session-id"`
-	SessionKey PreVoteStreamingSessionKey `json:"session-key"`
+	SessionKey PreVoteStreamingSessionKey `json:"session-key,omitempty"` // omitted when resuming, the session key does not change
+}
+
+type PreVoteStreamingSessionViewResponse struct {
+	ChainId                    string                               `json:"chain-id"`
+	ChainName                  string                               `json:"chain-name"` // display name of the chain, or the chain id if unknown
+	Validators                 StreamingLightValidators             `json:"validators"`
+	NextBlockVotingInformation *StreamingNextBlockVotingInformation `json:"next-block-voting-info,omitempty"`
+}
+
+// PreVoteStreamingBroadcastAck is the acknowledgement of a WebSocket broadcast frame.
+// Right after the connection is established, the server sends an acknowledgement of the last accepted sequence
+// of the session, zero if none, so the reconnected broadcaster knows where to resume.
+type PreVoteStreamingBroadcastAck struct {
+	Sequence uint64 `json:"seq"`
+	Error    string `json:"error,omitempty"` // not empty if the frame was rejected
 }
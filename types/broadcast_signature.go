@@ -0,0 +1,247 @@
+package types
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrBroadcastSignatureInvalid is returned when the signature does not match the signed content.
+	ErrBroadcastSignatureInvalid = fmt.Errorf("invalid broadcast signature")
+
+	// ErrBroadcastSignatureExpired is returned when the timestamp is out of the replay window.
+	ErrBroadcastSignatureExpired = fmt.Errorf("broadcast signature timestamp out of replay window")
+
+	// ErrBroadcastSignatureReplayed is returned when the nonce had been used within the replay window.
+	ErrBroadcastSignatureReplayed = fmt.Errorf("broadcast signature nonce had been used")
+)
+
+// broadcastSigningKeyDerivationLabel separates the signing key from other usages of the session key.
+const broadcastSigningKeyDerivationLabel = "cvp-broadcast-signing-v2"
+
+// BroadcastSignature authenticates a broadcast request without revealing the session key.
+//
+// The signature is the hex of the Ed25519 signature, by the key pair derived from the session key,
+// of the HTTP method, the path of the request URL, the session id, the timestamp, the nonce and the payload,
+// so a signature is not accepted by other routes than the signed one.
+// Servers verify it by the BroadcastVerificationKey of the session, without keeping the session key.
+//
+// It is not an HMAC: verifying an HMAC needs the key which signs, so servers would have to keep a signing credential,
+// which PreVoteStreamingSessionKeyHash is meant to avoid. The Ed25519 seed is the HMAC-SHA256 of a label by the session key.
+type BroadcastSignature struct {
+	// Timestamp is the signing time, in Unix milliseconds.
+	Timestamp int64
+	// Nonce is 16 random bytes in lower case hex, unique per request.
+	Nonce     string
+	Signature string
+}
+
+var regexpBroadcastNonce = regexp.MustCompile(`^[a-f\d]{32}$`)
+var regexpBroadcastSignature = regexp.MustCompile(`^[a-f\d]{128}$`)
+
+// BroadcastVerificationKey is the hex of the Ed25519 public key derived from a session key,
+// verifying the broadcast signatures of the session. It can not sign, so servers store it instead of the session key.
+type BroadcastVerificationKey string
+
+var regexpBroadcastVerificationKey = regexp.MustCompile(`^[a-f\d]{64}$`)
+
+// BroadcastVerificationKey returns the key verifying the broadcast signatures signed by the session key.
+func (sk PreVoteStreamingSessionKey) BroadcastVerificationKey() (BroadcastVerificationKey, error) {
+	signingKey, err := deriveBroadcastSigningKey(sk)
+	if err != nil {
+		return "", err
+	}
+	return BroadcastVerificationKey(hex.EncodeToString(signingKey.Public().(ed25519.PublicKey))), nil
+}
+
+// ValidateBasic returns an error if the verification key is invalid format.
+func (k BroadcastVerificationKey) ValidateBasic() error {
+	if len(k) == 0 {
+		return fmt.Errorf("empty")
+	}
+
+	if !regexpBroadcastVerificationKey.MatchString(string(k)) {
+		return fmt.Errorf("invalid format")
+	}
+
+	return nil
+}
+
+// SignBroadcast signs the payload of the request, of the method and the URL path, within the session,
+// with a new random nonce.
+func SignBroadcast(sessionId PreVoteStreamingSessionId, sessionKey PreVoteStreamingSessionKey, method, path string, payload []byte, now time.Time) (BroadcastSignature, error) {
+	bufferNonce := make([]byte, 16)
+	if _, err := rand.Read(bufferNonce); err != nil {
+		return BroadcastSignature{}, errors.Wrap(err, "failed to generate random bytes")
+	}
+
+	sig := BroadcastSignature{
+		Timestamp: now.UnixMilli(),
+		Nonce:     hex.EncodeToString(bufferNonce),
+	}
+	signingKey, err := deriveBroadcastSigningKey(sessionKey)
+	if err != nil {
+		return BroadcastSignature{}, err
+	}
+	message := broadcastSignedMessage(sessionId, method, path, sig.Timestamp, sig.Nonce, payload)
+	sig.Signature = hex.EncodeToString(ed25519.Sign(signingKey, message))
+
+	return sig, nil
+}
+
+// ParseBroadcastSignature parses the signature parts, as transferred in the request headers.
+func ParseBroadcastSignature(timestamp, nonce, signature string) (BroadcastSignature, error) {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || ts < 0 {
+		return BroadcastSignature{}, fmt.Errorf("invalid timestamp %s", timestamp)
+	}
+	if !regexpBroadcastNonce.MatchString(nonce) {
+		return BroadcastSignature{}, fmt.Errorf("invalid nonce format %s", nonce)
+	}
+	if !regexpBroadcastSignature.MatchString(signature) {
+		return BroadcastSignature{}, fmt.Errorf("invalid signature format")
+	}
+	return BroadcastSignature{
+		Timestamp: ts,
+		Nonce:     nonce,
+		Signature: signature,
+	}, nil
+}
+
+// TimestampString returns the timestamp in decimal, as transferred in the request header.
+func (s BroadcastSignature) TimestampString() string {
+	return strconv.FormatInt(s.Timestamp, 10)
+}
+
+// deriveBroadcastSigningKey derives the Ed25519 key pair from the session key, so the session key itself is never used directly.
+func deriveBroadcastSigningKey(sessionKey PreVoteStreamingSessionKey) (ed25519.PrivateKey, error) {
+	if err := sessionKey.ValidateBasic(); err != nil {
+		return nil, errors.Wrap(err, "invalid session key")
+	}
+	bzKey, _ := hex.DecodeString(string(sessionKey))
+
+	h := hmac.New(sha256.New, bzKey)
+	h.Write([]byte(broadcastSigningKeyDerivationLabel))
+	return ed25519.NewKeyFromSeed(h.Sum(nil)), nil
+}
+
+func broadcastSignedMessage(sessionId PreVoteStreamingSessionId, method, path string, timestamp int64, nonce string, payload []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(method)
+	buf.WriteByte('\n')
+	buf.WriteString(path)
+	buf.WriteByte('\n')
+	buf.WriteString(string(sessionId))
+	buf.WriteByte('\n')
+	buf.WriteString(strconv.FormatInt(timestamp, 10))
+	buf.WriteByte('\n')
+	buf.WriteString(nonce)
+	buf.WriteByte('\n')
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+// BroadcastSignatureVerifier verifies broadcast signatures, rejecting the ones with timestamp out of the replay window
+// or nonce had been used within the replay window. It is safe for concurrent use.
+//
+// Used nonces are grouped into buckets by their expiry, a bucket is dropped as a whole once all its nonces expired,
+// so verifying does not walk the used nonces.
+type BroadcastSignatureVerifier struct {
+	replayWindow time.Duration
+	bucketSize   time.Duration
+
+	mu         sync.Mutex
+	usedNonces map[string]struct{} // key is session id and nonce
+	buckets    map[int64][]string  // key is the end of the bucket in Unix nanoseconds, value is the keys of usedNonces
+	nextPrune  time.Time           // end of the earliest bucket
+}
+
+// broadcastNonceBuckets is the number of buckets per replay window, bounding how long expired nonces are kept.
+const broadcastNonceBuckets = 4
+
+// NewBroadcastSignatureVerifier returns a new verifier, accepting timestamps within the replay window
+// before or after the verification time.
+func NewBroadcastSignatureVerifier(replayWindow time.Duration) *BroadcastSignatureVerifier {
+	bucketSize := replayWindow / broadcastNonceBuckets
+	if bucketSize < time.Millisecond {
+		bucketSize = time.Millisecond
+	}
+	return &BroadcastSignatureVerifier{
+		replayWindow: replayWindow,
+		bucketSize:   bucketSize,
+		usedNonces:   make(map[string]struct{}),
+		buckets:      make(map[int64][]string),
+	}
+}
+
+// Verify returns nil if the signature is valid for the payload of the request, of the method and the URL path,
+// within the session of the verification key.
+// Returned errors match ErrBroadcastSignatureInvalid, ErrBroadcastSignatureExpired or ErrBroadcastSignatureReplayed.
+func (v *BroadcastSignatureVerifier) Verify(sessionId PreVoteStreamingSessionId, verificationKey BroadcastVerificationKey, method, path string, sig BroadcastSignature, payload []byte, now time.Time) error {
+	signedAt := time.UnixMilli(sig.Timestamp)
+	if signedAt.Before(now.Add(-v.replayWindow)) || signedAt.After(now.Add(v.replayWindow)) {
+		return ErrBroadcastSignatureExpired
+	}
+
+	if err := verificationKey.ValidateBasic(); err != nil {
+		return errors.Wrap(err, "invalid verification key")
+	}
+	publicKey, _ := hex.DecodeString(string(verificationKey))
+
+	signature, err := hex.DecodeString(sig.Signature)
+	message := broadcastSignedMessage(sessionId, method, path, sig.Timestamp, sig.Nonce, payload)
+	if err != nil || !ed25519.Verify(publicKey, message, signature) {
+		return ErrBroadcastSignatureInvalid
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.nextPrune.IsZero() && !now.Before(v.nextPrune) {
+		v.prune(now)
+	}
+
+	nonceKey := string(sessionId) + "/" + sig.Nonce
+	if _, used := v.usedNonces[nonceKey]; used {
+		return ErrBroadcastSignatureReplayed
+	}
+	v.usedNonces[nonceKey] = struct{}{}
+
+	// the timestamp is accepted until window after it, the nonce must be remembered until then
+	bucketEnd := signedAt.Add(v.replayWindow).Truncate(v.bucketSize).Add(v.bucketSize)
+	v.buckets[bucketEnd.UnixNano()] = append(v.buckets[bucketEnd.UnixNano()], nonceKey)
+	if v.nextPrune.IsZero() || bucketEnd.Before(v.nextPrune) {
+		v.nextPrune = bucketEnd
+	}
+
+	return nil
+}
+
+// prune drops the buckets ended by now, mu must be held.
+// The number of buckets is bounded by the replay window, see broadcastNonceBuckets.
+func (v *BroadcastSignatureVerifier) prune(now time.Time) {
+	v.nextPrune = time.Time{}
+	for end, nonceKeys := range v.buckets {
+		bucketEnd := time.Unix(0, end)
+		if now.Before(bucketEnd) {
+			if v.nextPrune.IsZero() || bucketEnd.Before(v.nextPrune) {
+				v.nextPrune = bucketEnd
+			}
+			continue
+		}
+		for _, nonceKey := range nonceKeys {
+			delete(v.usedNonces, nonceKey)
+		}
+		delete(v.buckets, end)
+	}
+}
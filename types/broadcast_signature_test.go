@@ -0,0 +1,287 @@
+package types
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBroadcastSignatureVerifier_Verify(t *testing.T) {
+	const sessionId PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+	const sessionKey PreVoteStreamingSessionKey = "2222222222222222222222222222222222222222222222222222222222222222"
+	const method = http.MethodPost
+	const path = "/broadcast/pre-vote/" + string(sessionId)
+	payload := []byte("payload")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		signedAt   time.Time
+		sessionId  PreVoteStreamingSessionId
+		sessionKey PreVoteStreamingSessionKey
+		method     string // default is the verified method
+		path       string // default is the verified path
+		payload    []byte
+		verified   []byte
+		wantErr    error
+	}{
+		{
+			name:       "valid",
+			signedAt:   now,
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+			payload:    payload,
+			verified:   payload,
+		},
+		{
+			name:       "valid, empty payload",
+			signedAt:   now,
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+		},
+		{
+			name:       "valid, signed within the window before",
+			signedAt:   now.Add(-time.Minute),
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+			payload:    payload,
+			verified:   payload,
+		},
+		{
+			name:       "valid, signed within the window after",
+			signedAt:   now.Add(time.Minute),
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+			payload:    payload,
+			verified:   payload,
+		},
+		{
+			name:       "expired",
+			signedAt:   now.Add(-time.Minute - time.Millisecond),
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+			payload:    payload,
+			verified:   payload,
+			wantErr:    ErrBroadcastSignatureExpired,
+		},
+		{
+			name:       "signed in the future",
+			signedAt:   now.Add(time.Minute + time.Millisecond),
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+			payload:    payload,
+			verified:   payload,
+			wantErr:    ErrBroadcastSignatureExpired,
+		},
+		{
+			name:       "tampered payload",
+			signedAt:   now,
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+			payload:    payload,
+			verified:   []byte("tampered"),
+			wantErr:    ErrBroadcastSignatureInvalid,
+		},
+		{
+			name:       "signed by another session key",
+			signedAt:   now,
+			sessionId:  sessionId,
+			sessionKey: "3333333333333333333333333333333333333333333333333333333333333333",
+			payload:    payload,
+			verified:   payload,
+			wantErr:    ErrBroadcastSignatureInvalid,
+		},
+		{
+			name:       "signed for another route",
+			signedAt:   now,
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+			path:       "/revoke-session/pre-vote/" + string(sessionId),
+			payload:    payload,
+			verified:   payload,
+			wantErr:    ErrBroadcastSignatureInvalid,
+		},
+		{
+			name:       "signed for another method",
+			signedAt:   now,
+			sessionId:  sessionId,
+			sessionKey: sessionKey,
+			method:     http.MethodGet,
+			payload:    payload,
+			verified:   payload,
+			wantErr:    ErrBroadcastSignatureInvalid,
+		},
+		{
+			name:       "signed for another session",
+			signedAt:   now,
+			sessionId:  "cosmoshub-4_4444444444444444444444444444444444444444444444444444444444444444",
+			sessionKey: sessionKey,
+			payload:    payload,
+			verified:   payload,
+			wantErr:    ErrBroadcastSignatureInvalid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewBroadcastSignatureVerifier(time.Minute)
+
+			signedMethod, signedPath := method, path
+			if tt.method != "" {
+				signedMethod = tt.method
+			}
+			if tt.path != "" {
+				signedPath = tt.path
+			}
+			sig, err := SignBroadcast(tt.sessionId, tt.sessionKey, signedMethod, signedPath, tt.payload, tt.signedAt)
+			require.NoError(t, err)
+
+			verificationKey, err := sessionKey.BroadcastVerificationKey()
+			require.NoError(t, err)
+
+			err = verifier.Verify(sessionId, verificationKey, method, path, sig, tt.verified, now)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestBroadcastSignatureVerifier_Replay(t *testing.T) {
+	const sessionId PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+	const otherSessionId PreVoteStreamingSessionId = "cosmoshub-4_4444444444444444444444444444444444444444444444444444444444444444"
+	const sessionKey PreVoteStreamingSessionKey = "2222222222222222222222222222222222222222222222222222222222222222"
+	const method = http.MethodPost
+	const path = "/broadcast/pre-vote/" + string(sessionId)
+	payload := []byte("payload")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	verifier := NewBroadcastSignatureVerifier(time.Minute)
+	verificationKey, err := sessionKey.BroadcastVerificationKey()
+	require.NoError(t, err)
+
+	sig, err := SignBroadcast(sessionId, sessionKey, method, path, payload, now)
+	require.NoError(t, err)
+	require.NoError(t, verifier.Verify(sessionId, verificationKey, method, path, sig, payload, now))
+	require.ErrorIs(t, verifier.Verify(sessionId, verificationKey, method, path, sig, payload, now.Add(time.Second)), ErrBroadcastSignatureReplayed)
+
+	// same nonce within another session is not a replay
+	otherSig := BroadcastSignature{Timestamp: sig.Timestamp, Nonce: sig.Nonce}
+	signingKey, err := deriveBroadcastSigningKey(sessionKey)
+	require.NoError(t, err)
+	otherSig.Signature = hex.EncodeToString(ed25519.Sign(signingKey, broadcastSignedMessage(otherSessionId, method, path, otherSig.Timestamp, otherSig.Nonce, payload)))
+	require.NoError(t, verifier.Verify(otherSessionId, verificationKey, method, path, otherSig, payload, now))
+
+	// used nonces are forgotten after the window, when the timestamp is no longer accepted
+	require.ErrorIs(t, verifier.Verify(sessionId, verificationKey, method, path, sig, payload, now.Add(time.Minute+time.Millisecond)), ErrBroadcastSignatureExpired)
+	require.Len(t, verifier.usedNonces, 2, "nonces are pruned by the bucket of their expiry")
+	later := now.Add(time.Minute + time.Minute/broadcastNonceBuckets)
+	laterSig, err := SignBroadcast(sessionId, sessionKey, method, path, payload, later)
+	require.NoError(t, err)
+	require.NoError(t, verifier.Verify(sessionId, verificationKey, method, path, laterSig, payload, later))
+	require.Len(t, verifier.usedNonces, 1, "expired nonces must be pruned")
+	require.Len(t, verifier.buckets, 1)
+}
+
+func TestParseBroadcastSignature(t *testing.T) {
+	validNonce := strings.Repeat("a", 32)
+	validSignature := strings.Repeat("b", 128)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		nonce     string
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "valid",
+			timestamp: "1704067200000",
+			nonce:     validNonce,
+			signature: validSignature,
+		},
+		{
+			name:      "invalid timestamp",
+			timestamp: "1704067200000a",
+			nonce:     validNonce,
+			signature: validSignature,
+			wantErr:   true,
+		},
+		{
+			name:      "negative timestamp",
+			timestamp: "-1",
+			nonce:     validNonce,
+			signature: validSignature,
+			wantErr:   true,
+		},
+		{
+			name:      "empty timestamp",
+			timestamp: "",
+			nonce:     validNonce,
+			signature: validSignature,
+			wantErr:   true,
+		},
+		{
+			name:      "upper case nonce",
+			timestamp: "1704067200000",
+			nonce:     strings.Repeat("A", 32),
+			signature: validSignature,
+			wantErr:   true,
+		},
+		{
+			name:      "short nonce",
+			timestamp: "1704067200000",
+			nonce:     validNonce[1:],
+			signature: validSignature,
+			wantErr:   true,
+		},
+		{
+			name:      "short signature",
+			timestamp: "1704067200000",
+			nonce:     validNonce,
+			signature: validSignature[1:],
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBroadcastSignature(tt.timestamp, tt.nonce, tt.signature)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.timestamp, got.TimestampString())
+			require.Equal(t, tt.nonce, got.Nonce)
+			require.Equal(t, tt.signature, got.Signature)
+		})
+	}
+}
+
+func TestPreVoteStreamingSessionKey_BroadcastVerificationKey(t *testing.T) {
+	const sessionKey PreVoteStreamingSessionKey = "2222222222222222222222222222222222222222222222222222222222222222"
+
+	verificationKey, err := sessionKey.BroadcastVerificationKey()
+	require.NoError(t, err)
+	require.NoError(t, verificationKey.ValidateBasic())
+	require.NotContains(t, string(verificationKey), string(sessionKey))
+
+	again, err := sessionKey.BroadcastVerificationKey()
+	require.NoError(t, err)
+	require.Equal(t, verificationKey, again, "must be deterministic")
+
+	other, err := PreVoteStreamingSessionKey("3333333333333333333333333333333333333333333333333333333333333333").BroadcastVerificationKey()
+	require.NoError(t, err)
+	require.NotEqual(t, verificationKey, other)
+
+	_, err = PreVoteStreamingSessionKey("invalid").BroadcastVerificationKey()
+	require.Error(t, err)
+
+	require.Error(t, BroadcastVerificationKey("").ValidateBasic())
+	require.Error(t, BroadcastVerificationKey(strings.ToUpper(string(verificationKey))).ValidateBasic())
+}
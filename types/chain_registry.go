@@ -0,0 +1,134 @@
+package types
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrUnknownChainId is returned when the chain id is not in the chain registry.
+var ErrUnknownChainId = fmt.Errorf("unknown chain id")
+
+//go:embed chain_registry.json
+var defaultChainRegistryJson []byte
+
+// ChainInfo describes a chain known by the ChainRegistry.
+type ChainInfo struct {
+	ChainId      string `json:"chain-id"`
+	DisplayName  string `json:"display-name"`
+	Bech32Prefix string `json:"bech32-prefix"`
+}
+
+var regexpBech32Prefix = regexp.MustCompile(`^[a-z][a-z\d]*$`)
+
+// ValidateBasic returns an error if any field is invalid format.
+func (c ChainInfo) ValidateBasic() error {
+	if !regexpChainId.MatchString(c.ChainId) {
+		return fmt.Errorf("invalid chain id %s", c.ChainId)
+	}
+	if strings.TrimSpace(c.DisplayName) == "" {
+		return fmt.Errorf("empty display name of chain %s", c.ChainId)
+	}
+	if !regexpBech32Prefix.MatchString(c.Bech32Prefix) {
+		return fmt.Errorf("invalid bech32 prefix %s of chain %s", c.Bech32Prefix, c.ChainId)
+	}
+	return nil
+}
+
+// ChainRegistry is a list of known chains, used to restrict the chains which sessions can be registered for,
+// and to look up the display names of chains for viewers.
+//
+// A nil *ChainRegistry knows no chain and restricts nothing, so it can be used as an optional registry.
+// ChainRegistry is immutable and safe for concurrent use.
+type ChainRegistry struct {
+	chains map[string]ChainInfo
+}
+
+// NewChainRegistry returns a registry of the given chains, returns error if any chain is invalid or duplicated.
+func NewChainRegistry(chains []ChainInfo) (*ChainRegistry, error) {
+	r := &ChainRegistry{
+		chains: make(map[string]ChainInfo, len(chains)),
+	}
+	for _, chain := range chains {
+		if err := chain.ValidateBasic(); err != nil {
+			return nil, err
+		}
+		if _, duplicated := r.chains[chain.ChainId]; duplicated {
+			return nil, fmt.Errorf("duplicated chain id %s", chain.ChainId)
+		}
+		r.chains[chain.ChainId] = chain
+	}
+	return r, nil
+}
+
+// ParseChainRegistryJson parses a JSON list of ChainInfo into a registry.
+func ParseChainRegistryJson(bz []byte) (*ChainRegistry, error) {
+	var chains []ChainInfo
+	if err := json.Unmarshal(bz, &chains); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal chain registry")
+	}
+	return NewChainRegistry(chains)
+}
+
+// DefaultChainRegistry returns a new registry of the well-known chains embedded in this module.
+func DefaultChainRegistry() *ChainRegistry {
+	r, err := ParseChainRegistryJson(defaultChainRegistryJson)
+	if err != nil {
+		panic(errors.Wrap(err, "invalid embedded chain registry"))
+	}
+	return r
+}
+
+// Get returns the chain info of the chain id, found is false if the chain is not in the registry.
+func (r *ChainRegistry) Get(chainId string) (chain ChainInfo, found bool) {
+	if r == nil {
+		return ChainInfo{}, false
+	}
+	chain, found = r.chains[chainId]
+	return
+}
+
+// DisplayName returns the display name of the chain, or the chain id itself if the chain is not in the registry.
+func (r *ChainRegistry) DisplayName(chainId string) string {
+	if chain, found := r.Get(chainId); found {
+		return chain.DisplayName
+	}
+	return chainId
+}
+
+// ChainIds returns the chain ids in the registry, sorted.
+func (r *ChainRegistry) ChainIds() []string {
+	if r == nil {
+		return nil
+	}
+	chainIds := make([]string, 0, len(r.chains))
+	for chainId := range r.chains {
+		chainIds = append(chainIds, chainId)
+	}
+	sort.Strings(chainIds)
+	return chainIds
+}
+
+// ValidateChainId returns ErrUnknownChainId if the chain is not in the registry, nil registry accepts any chain.
+func (r *ChainRegistry) ValidateChainId(chainId string) error {
+	if r == nil {
+		return nil
+	}
+	if _, found := r.chains[chainId]; !found {
+		return errors.Wrap(ErrUnknownChainId, chainId)
+	}
+	return nil
+}
+
+// NewPreVoteStreamingSession is the same as the NewPreVoteStreamingSession function,
+// but also requires the chain is in the registry. Nil registry accepts any chain.
+func (r *ChainRegistry) NewPreVoteStreamingSession(chainId string) (PreVoteStreamingSessionId, PreVoteStreamingSessionKey, error) {
+	if err := r.ValidateChainId(chainId); err != nil {
+		return "", "", err
+	}
+	return NewPreVoteStreamingSession(chainId)
+}
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDefaultChainRegistry(t *testing.T) {
+	r := DefaultChainRegistry()
+
+	chain, found := r.Get("cosmoshub-4")
+	require.True(t, found)
+	require.Equal(t, ChainInfo{ChainId: "cosmoshub-4", DisplayName: "Cosmos Hub", Bech32Prefix: "cosmos"}, chain)
+
+	require.Equal(t, "Evmos", r.DisplayName("evmos_9001-2"))
+	require.Equal(t, "cosmoshub-5", r.DisplayName("cosmoshub-5"), "unknown chain falls back to the chain id")
+
+	chainIds := r.ChainIds()
+	require.Contains(t, chainIds, "osmosis-1")
+	require.IsIncreasing(t, chainIds)
+}
+
+func TestParseChainRegistryJson(t *testing.T) {
+	tests := []struct {
+		name         string
+		json         string
+		wantChainIds []string
+		wantErr      bool
+	}{
+		{
+			name:         "normal",
+			json:         `[{"chain-id":"osmosis-1","display-name":"Osmosis","bech32-prefix":"osmo"},{"chain-id":"cosmoshub-4","display-name":"Cosmos Hub","bech32-prefix":"cosmos"}]`,
+			wantChainIds: []string{"cosmoshub-4", "osmosis-1"},
+		},
+		{
+			name:         "empty list",
+			json:         `[]`,
+			wantChainIds: []string{},
+		},
+		{
+			name:    "not a list",
+			json:    `{"chain-id":"cosmoshub-4"}`,
+			wantErr: true,
+		},
+		{
+			name:    "duplicated chain id",
+			json:    `[{"chain-id":"cosmoshub-4","display-name":"Cosmos Hub","bech32-prefix":"cosmos"},{"chain-id":"cosmoshub-4","display-name":"Hub","bech32-prefix":"cosmos"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid chain id",
+			json:    `[{"chain-id":" cosmoshub-4","display-name":"Cosmos Hub","bech32-prefix":"cosmos"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "blank display name",
+			json:    `[{"chain-id":"cosmoshub-4","display-name":"  ","bech32-prefix":"cosmos"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "upper case bech32 prefix",
+			json:    `[{"chain-id":"cosmoshub-4","display-name":"Cosmos Hub","bech32-prefix":"COSMOS"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "missing bech32 prefix",
+			json:    `[{"chain-id":"cosmoshub-4","display-name":"Cosmos Hub"}]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChainRegistryJson([]byte(tt.json))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantChainIds, got.ChainIds())
+		})
+	}
+}
+
+func TestChainRegistry_NewPreVoteStreamingSession(t *testing.T) {
+	r, err := NewChainRegistry([]ChainInfo{{ChainId: "cosmoshub-4", DisplayName: "Cosmos Hub", Bech32Prefix: "cosmos"}})
+	require.NoError(t, err)
+
+	sessionId, sessionKey, err := r.NewPreVoteStreamingSession("cosmoshub-4")
+	require.NoError(t, err)
+	require.True(t, sessionId.ForChainId("cosmoshub-4"))
+	require.NoError(t, sessionKey.ValidateBasic())
+
+	_, _, err = r.NewPreVoteStreamingSession("cosmoshub-5")
+	require.ErrorIs(t, err, ErrUnknownChainId)
+
+	t.Run("nil registry accepts any chain", func(t *testing.T) {
+		var nilRegistry *ChainRegistry
+		sessionId, _, err := nilRegistry.NewPreVoteStreamingSession("cosmoshub-5")
+		require.NoError(t, err)
+		require.True(t, sessionId.ForChainId("cosmoshub-5"))
+
+		_, _, err = nilRegistry.NewPreVoteStreamingSession(" bad")
+		require.Error(t, err, "chain id syntax is still checked")
+
+		_, found := nilRegistry.Get("cosmoshub-4")
+		require.False(t, found)
+		require.Equal(t, "cosmoshub-4", nilRegistry.DisplayName("cosmoshub-4"))
+		require.Empty(t, nilRegistry.ChainIds())
+	})
+}
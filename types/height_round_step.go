@@ -0,0 +1,267 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RoundStepType is the step of a consensus round, values are the same as CometBFT.
+type RoundStepType uint8
+
+const (
+	RoundStepNewHeight     RoundStepType = 0x01 // Wait til CommitTime + timeoutCommit
+	RoundStepNewRound      RoundStepType = 0x02 // Setup new round and go to RoundStepPropose
+	RoundStepPropose       RoundStepType = 0x03 // Did propose, gossip proposal
+	RoundStepPrevote       RoundStepType = 0x04 // Did prevote, gossip prevotes
+	RoundStepPrevoteWait   RoundStepType = 0x05 // Did receive any +2/3 prevotes, start timeout
+	RoundStepPrecommit     RoundStepType = 0x06 // Did precommit, gossip precommits
+	RoundStepPrecommitWait RoundStepType = 0x07 // Did receive any +2/3 precommits, start timeout
+	RoundStepCommit        RoundStepType = 0x08 // Entered commit state machine
+)
+
+// IsValid returns true if the step is one of the known CometBFT round steps.
+func (s RoundStepType) IsValid() bool {
+	return s >= RoundStepNewHeight && s <= RoundStepCommit
+}
+
+// String returns the CometBFT name of the step.
+func (s RoundStepType) String() string {
+	switch s {
+	case RoundStepNewHeight:
+		return "RoundStepNewHeight"
+	case RoundStepNewRound:
+		return "RoundStepNewRound"
+	case RoundStepPropose:
+		return "RoundStepPropose"
+	case RoundStepPrevote:
+		return "RoundStepPrevote"
+	case RoundStepPrevoteWait:
+		return "RoundStepPrevoteWait"
+	case RoundStepPrecommit:
+		return "RoundStepPrecommit"
+	case RoundStepPrecommitWait:
+		return "RoundStepPrecommitWait"
+	case RoundStepCommit:
+		return "RoundStepCommit"
+	default:
+		return "RoundStepUnknown"
+	}
+}
+
+// MaxHeightRoundStepTextLength is the maximum length of the text form of a valid HeightRoundStep:
+// 19 digits of max int64 height, 10 digits of max int32 round, 1 digit step and 2 separators.
+const MaxHeightRoundStepTextLength = 19 + 1 + 10 + 1 + 1
+
+// HeightRoundStep is the consensus state of a chain, represented as "height/round/step" in text form,
+// for example "100/0/3" is height 100, round 0, step RoundStepPropose.
+type HeightRoundStep struct {
+	Height int64
+	Round  int32
+	Step   RoundStepType
+
+	// legacyText is the original text form parsed by ParseLegacyHeightRoundStep when any number overflows its field,
+	// the overflowed fields are clamped to their maximum and String returns the original text.
+	legacyText string
+}
+
+var _ json.Marshaler = HeightRoundStep{}
+var _ json.Unmarshaler = (*HeightRoundStep)(nil)
+
+// ParseHeightRoundStep parses the text form "height/round/step" into HeightRoundStep.
+func ParseHeightRoundStep(str string) (HeightRoundStep, error) {
+	hrs, err := parseHeightRoundStepText(str)
+	if err != nil {
+		return HeightRoundStep{}, err
+	}
+	if err := hrs.Validate(); err != nil {
+		return HeightRoundStep{}, err
+	}
+
+	return hrs, nil
+}
+
+// ParseLegacyHeightRoundStep parses the text form "height/round/step" as accepted before HeightRoundStep was structured,
+// when any digits were accepted. Unlike ParseHeightRoundStep, the step is not required to be known
+// and numbers overflowing the fields are kept in the text form, see ValidateLegacy,
+// so payloads encoded in text form by the older versions are still decoded and encoded back unchanged.
+func ParseLegacyHeightRoundStep(str string) (HeightRoundStep, error) {
+	spl, err := splitHeightRoundStepText(str)
+	if err != nil {
+		return HeightRoundStep{}, err
+	}
+
+	var hrs HeightRoundStep
+	var overflowed bool
+	height, err := strconv.ParseInt(spl[0], 10, 64)
+	overflowed = overflowed || err != nil
+	hrs.Height = height // clamped to max int64 on overflow
+
+	round, err := strconv.ParseInt(spl[1], 10, 32)
+	overflowed = overflowed || err != nil
+	hrs.Round = int32(round) // clamped to max int32 on overflow
+
+	step, err := strconv.ParseUint(spl[2], 10, 8)
+	overflowed = overflowed || err != nil
+	hrs.Step = RoundStepType(step) // clamped to max uint8 on overflow
+
+	if overflowed {
+		hrs.legacyText = str
+	}
+	return hrs, nil
+}
+
+func parseHeightRoundStepText(str string) (HeightRoundStep, error) {
+	spl, err := splitHeightRoundStepText(str)
+	if err != nil {
+		return HeightRoundStep{}, err
+	}
+
+	height, err := strconv.ParseInt(spl[0], 10, 64)
+	if err != nil {
+		return HeightRoundStep{}, fmt.Errorf("invalid height %s", spl[0])
+	}
+
+	round, err := strconv.ParseInt(spl[1], 10, 32)
+	if err != nil {
+		return HeightRoundStep{}, fmt.Errorf("invalid round %s", spl[1])
+	}
+
+	step, err := strconv.ParseUint(spl[2], 10, 8)
+	if err != nil {
+		return HeightRoundStep{}, fmt.Errorf("invalid step %s", spl[2])
+	}
+
+	return HeightRoundStep{
+		Height: height,
+		Round:  int32(round),
+		Step:   RoundStepType(step),
+	}, nil
+}
+
+// splitHeightRoundStepText splits the text form into the digits of height, round and step.
+func splitHeightRoundStepText(str string) ([]string, error) {
+	spl := strings.Split(str, "/")
+	if len(spl) != 3 {
+		return nil, fmt.Errorf("invalid format %s", str)
+	}
+
+	for _, part := range spl {
+		if len(part) < 1 || strings.Trim(part, "0123456789") != "" {
+			return nil, fmt.Errorf("invalid format %s", str)
+		}
+	}
+	return spl, nil
+}
+
+// MustParseHeightRoundStep is the same as ParseHeightRoundStep but panic on error.
+func MustParseHeightRoundStep(str string) HeightRoundStep {
+	hrs, err := ParseHeightRoundStep(str)
+	if err != nil {
+		panic(err)
+	}
+	return hrs
+}
+
+// MustParseLegacyHeightRoundStep is the same as ParseLegacyHeightRoundStep but panic on error.
+func MustParseLegacyHeightRoundStep(str string) HeightRoundStep {
+	hrs, err := ParseLegacyHeightRoundStep(str)
+	if err != nil {
+		panic(err)
+	}
+	return hrs
+}
+
+// Validate returns an error if height or round is negative, or step is unknown,
+// or any number of the legacy text form overflows its field.
+func (h HeightRoundStep) Validate() error {
+	if h.legacyText != "" {
+		return fmt.Errorf("invalid height round step %s, overflows the fields", h.legacyText)
+	}
+	if h.Height < 0 {
+		return fmt.Errorf("invalid height %d, must not be negative", h.Height)
+	}
+	if h.Round < 0 {
+		return fmt.Errorf("invalid round %d, must not be negative", h.Round)
+	}
+	if !h.Step.IsValid() {
+		return fmt.Errorf("invalid step %d", h.Step)
+	}
+	return nil
+}
+
+// ValidateLegacy returns an error if height or round is negative, so the text form is not the legacy digits form.
+// Any step and the numbers overflowing the fields, see ParseLegacyHeightRoundStep, are accepted.
+func (h HeightRoundStep) ValidateLegacy() error {
+	if h.Height < 0 {
+		return fmt.Errorf("invalid height %d, must not be negative", h.Height)
+	}
+	if h.Round < 0 {
+		return fmt.Errorf("invalid round %d, must not be negative", h.Round)
+	}
+	return nil
+}
+
+// IsZero returns true if this is the zero value, which is not a valid HeightRoundStep.
+func (h HeightRoundStep) IsZero() bool {
+	return h == HeightRoundStep{}
+}
+
+// String returns the text form "height/round/step".
+func (h HeightRoundStep) String() string {
+	if h.legacyText != "" {
+		return h.legacyText
+	}
+	return fmt.Sprintf("%d/%d/%d", h.Height, h.Round, h.Step)
+}
+
+// Compare returns -1, 0 or 1 if this is before, same as or after the other, in order of height, round and step.
+func (h HeightRoundStep) Compare(other HeightRoundStep) int {
+	if c := compareInt64(h.Height, other.Height); c != 0 {
+		return c
+	}
+	if c := compareInt64(int64(h.Round), int64(other.Round)); c != 0 {
+		return c
+	}
+	return compareInt64(int64(h.Step), int64(other.Step))
+}
+
+// MarshalJSON marshals the HeightRoundStep as the text form, zero value is marshalled as empty string.
+func (h HeightRoundStep) MarshalJSON() ([]byte, error) {
+	if h.IsZero() {
+		return json.Marshal("")
+	}
+	return json.Marshal(h.String())
+}
+
+// UnmarshalJSON unmarshals the text form into HeightRoundStep, empty string is unmarshalled as zero value.
+// It is as lenient as ParseLegacyHeightRoundStep, the encoders validate the result.
+func (h *HeightRoundStep) UnmarshalJSON(bz []byte) error {
+	var str string
+	if err := json.Unmarshal(bz, &str); err != nil {
+		return err
+	}
+
+	if str == "" {
+		*h = HeightRoundStep{}
+		return nil
+	}
+
+	hrs, err := ParseLegacyHeightRoundStep(str)
+	if err != nil {
+		return err
+	}
+	*h = hrs
+	return nil
+}
+
+func compareInt64(a, b int64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
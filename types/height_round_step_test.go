@@ -0,0 +1,212 @@
+package types
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestParseHeightRoundStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		str     string
+		want    HeightRoundStep
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			str:  "100/2/3",
+			want: HeightRoundStep{Height: 100, Round: 2, Step: RoundStepPropose},
+		},
+		{
+			name: "largest",
+			str:  "9223372036854775807/2147483647/8",
+			want: HeightRoundStep{Height: math.MaxInt64, Round: math.MaxInt32, Step: RoundStepCommit},
+		},
+		{
+			name:    "empty",
+			str:     "",
+			wantErr: true,
+		},
+		{
+			name:    "missing step",
+			str:     "100/2",
+			wantErr: true,
+		},
+		{
+			name:    "too many parts",
+			str:     "100/2/3/4",
+			wantErr: true,
+		},
+		{
+			name:    "empty part",
+			str:     "100//3",
+			wantErr: true,
+		},
+		{
+			name:    "negative height",
+			str:     "-1/2/3",
+			wantErr: true,
+		},
+		{
+			name:    "signed round",
+			str:     "100/+2/3",
+			wantErr: true,
+		},
+		{
+			name:    "height overflow",
+			str:     "9223372036854775808/2/3",
+			wantErr: true,
+		},
+		{
+			name:    "round overflow",
+			str:     "100/2147483648/3",
+			wantErr: true,
+		},
+		{
+			name:    "step zero",
+			str:     "100/2/0",
+			wantErr: true,
+		},
+		{
+			name:    "unknown step",
+			str:     "100/2/9",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHeightRoundStep(tt.str)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.str, got.String())
+			require.LessOrEqual(t, len(got.String()), MaxHeightRoundStepTextLength)
+		})
+	}
+}
+
+func TestParseLegacyHeightRoundStep(t *testing.T) {
+	got, err := ParseLegacyHeightRoundStep("100/2/9")
+	require.NoError(t, err)
+	require.Equal(t, HeightRoundStep{Height: 100, Round: 2, Step: 9}, got)
+	require.Error(t, got.Validate())
+
+	got, err = ParseLegacyHeightRoundStep("100/2/0")
+	require.NoError(t, err)
+	require.Equal(t, HeightRoundStep{Height: 100, Round: 2}, got)
+
+	// numbers overflowing the fields are clamped, the text form is kept unchanged
+	for _, str := range []string{"1/2/300", "1/9999999999/3", "99999999999999999999/2/3", "999999999/9999/9999"} {
+		got, err := ParseLegacyHeightRoundStep(str)
+		require.NoError(t, err, str)
+		require.Equal(t, str, got.String())
+		require.NoError(t, got.ValidateLegacy())
+		require.ErrorContains(t, got.Validate(), "overflows the fields")
+	}
+	got = MustParseLegacyHeightRoundStep("1/9999999999/300")
+	require.Equal(t, int64(1), got.Height)
+	require.Equal(t, int32(math.MaxInt32), got.Round)
+	require.Equal(t, RoundStepType(math.MaxUint8), got.Step)
+
+	for _, str := range []string{"100/2", "100/2/a", "100/-2/3", "100//3"} {
+		_, err := ParseLegacyHeightRoundStep(str)
+		require.Error(t, err, str)
+	}
+}
+
+func TestHeightRoundStep_Compare(t *testing.T) {
+	base := HeightRoundStep{Height: 100, Round: 1, Step: RoundStepPrevote}
+	tests := []struct {
+		name  string
+		other HeightRoundStep
+		want  int
+	}{
+		{
+			name:  "same",
+			other: base,
+			want:  0,
+		},
+		{
+			name:  "lower height, higher round and step",
+			other: HeightRoundStep{Height: 99, Round: 5, Step: RoundStepCommit},
+			want:  1,
+		},
+		{
+			name:  "higher height",
+			other: HeightRoundStep{Height: 101, Round: 0, Step: RoundStepNewHeight},
+			want:  -1,
+		},
+		{
+			name:  "same height, lower round",
+			other: HeightRoundStep{Height: 100, Round: 0, Step: RoundStepCommit},
+			want:  1,
+		},
+		{
+			name:  "same height and round, higher step",
+			other: HeightRoundStep{Height: 100, Round: 1, Step: RoundStepPrecommit},
+			want:  -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, base.Compare(tt.other))
+			require.Equal(t, -tt.want, tt.other.Compare(base))
+		})
+	}
+}
+
+func TestRoundStepType_String(t *testing.T) {
+	require.Equal(t, "RoundStepNewHeight", RoundStepNewHeight.String())
+	require.Equal(t, "RoundStepPrevoteWait", RoundStepPrevoteWait.String())
+	require.Equal(t, "RoundStepCommit", RoundStepCommit.String())
+	require.Equal(t, "RoundStepUnknown", RoundStepType(0).String())
+	require.Equal(t, "RoundStepUnknown", RoundStepType(9).String())
+}
+
+func TestHeightRoundStep_JSON(t *testing.T) {
+	t.Run("compatible with the text form", func(t *testing.T) {
+		inf := StreamingNextBlockVotingInformation{
+			HeightRoundStep: HeightRoundStep{Height: 100, Round: 0, Step: RoundStepPrevote},
+		}
+		bz, err := json.Marshal(inf)
+		require.NoError(t, err)
+		require.Equal(t, `{"hrs":"100/0/4"}`, string(bz))
+
+		var got StreamingNextBlockVotingInformation
+		require.NoError(t, json.Unmarshal(bz, &got))
+		require.Equal(t, inf, got)
+	})
+
+	t.Run("zero value is empty string", func(t *testing.T) {
+		bz, err := json.Marshal(StreamingNextBlockVotingInformation{})
+		require.NoError(t, err)
+		require.Equal(t, `{"hrs":""}`, string(bz))
+
+		var got StreamingNextBlockVotingInformation
+		require.NoError(t, json.Unmarshal(bz, &got))
+		require.True(t, got.HeightRoundStep.IsZero())
+	})
+
+	t.Run("accept the legacy text form", func(t *testing.T) {
+		for _, str := range []string{"1/2/9", "1/2/300", "1/9999999999/3"} {
+			var got StreamingNextBlockVotingInformation
+			require.NoError(t, json.Unmarshal([]byte(`{"hrs":"`+str+`"}`), &got), str)
+			require.Equal(t, str, got.HeightRoundStep.String())
+
+			bz, err := json.Marshal(got)
+			require.NoError(t, err)
+			require.Contains(t, string(bz), `"hrs":"`+str+`"`)
+		}
+	})
+
+	t.Run("reject invalid text form", func(t *testing.T) {
+		var got StreamingNextBlockVotingInformation
+		require.Error(t, json.Unmarshal([]byte(`{"hrs":"100/0"}`), &got))
+		require.Error(t, json.Unmarshal([]byte(`{"hrs":100}`), &got))
+	})
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// preVoteStreamingSessionKeyHashPrefix identifies the hash algorithm, so it can be upgraded later.
+const preVoteStreamingSessionKeyHashPrefix = "sha256$"
+
+// PreVoteStreamingSessionKeyHash is a salted hash of a PreVoteStreamingSessionKey,
+// for servers to store instead of the session key, so a leaked session database does not leak the session keys.
+//
+// Format is sha256$<salt>$<digest>, where salt is 16 random bytes and digest is SHA-256 of the salt and the session key,
+// both in lower case hex. Session keys are 32 random bytes, so a fast hash is enough.
+//
+// Broadcast signatures are verified by the BroadcastVerificationKey, which servers store along with the hash.
+type PreVoteStreamingSessionKeyHash string
+
+var regexpPreVoteStreamingSessionKeyHash = regexp.MustCompile(`^sha256\$[a-f\d]{32}\$[a-f\d]{64}$`)
+
+// Hash returns a new salted hash of the session key.
+func (sk PreVoteStreamingSessionKey) Hash() (PreVoteStreamingSessionKeyHash, error) {
+	if err := sk.ValidateBasic(); err != nil {
+		return "", errors.Wrap(err, "invalid session key")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "failed to generate random bytes")
+	}
+
+	return PreVoteStreamingSessionKeyHash(fmt.Sprintf("%s%x$%x", preVoteStreamingSessionKeyHashPrefix, salt, digestPreVoteStreamingSessionKey(salt, sk))), nil
+}
+
+// ValidateBasic returns an error if the hash is invalid format.
+func (h PreVoteStreamingSessionKeyHash) ValidateBasic() error {
+	if len(h) == 0 {
+		return fmt.Errorf("empty")
+	}
+
+	if !regexpPreVoteStreamingSessionKeyHash.MatchString(string(h)) {
+		return fmt.Errorf("invalid format")
+	}
+
+	return nil
+}
+
+// Verify returns true if the hash is of the given session key, in constant time.
+func (h PreVoteStreamingSessionKeyHash) Verify(sk PreVoteStreamingSessionKey) bool {
+	if h.ValidateBasic() != nil || sk.ValidateBasic() != nil {
+		return false
+	}
+
+	hexSalt, hexDigest, _ := strings.Cut(strings.TrimPrefix(string(h), preVoteStreamingSessionKeyHashPrefix), "$")
+	salt, _ := hex.DecodeString(hexSalt)
+	digest, _ := hex.DecodeString(hexDigest)
+
+	return subtle.ConstantTimeCompare(digest, digestPreVoteStreamingSessionKey(salt, sk)) == 1
+}
+
+func digestPreVoteStreamingSessionKey(salt []byte, sk PreVoteStreamingSessionKey) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(sk))
+	return h.Sum(nil)
+}
+
+// MigrateStoredPreVoteStreamingSessionKey converts a stored session key into hash,
+// for migrating the session databases which stored plaintext session keys.
+//
+// If the stored value is already a hash, it is returned as is with migrated is false.
+// If the stored value is a plaintext session key, the new hash is returned with migrated is true.
+func MigrateStoredPreVoteStreamingSessionKey(stored string) (hash PreVoteStreamingSessionKeyHash, migrated bool, err error) {
+	if existing := PreVoteStreamingSessionKeyHash(stored); existing.ValidateBasic() == nil {
+		return existing, false, nil
+	}
+
+	sk := PreVoteStreamingSessionKey(stored)
+	if err := sk.ValidateBasic(); err != nil {
+		return "", false, fmt.Errorf("stored value is neither a session key nor a session key hash")
+	}
+
+	hash, err = sk.Hash()
+	if err != nil {
+		return "", false, err
+	}
+	return hash, true, nil
+}
+
+// VerifyStoredPreVoteStreamingSessionKey verifies the session key against the stored value,
+// which can be either a hash or a plaintext session key not migrated yet, in constant time.
+func VerifyStoredPreVoteStreamingSessionKey(stored string, sk PreVoteStreamingSessionKey) bool {
+	if hash := PreVoteStreamingSessionKeyHash(stored); hash.ValidateBasic() == nil {
+		return hash.Verify(sk)
+	}
+
+	if sk.ValidateBasic() != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(sk)) == 1
+}
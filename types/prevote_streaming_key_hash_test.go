@@ -0,0 +1,150 @@
+package types
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestPreVoteStreamingSessionKeyHash(t *testing.T) {
+	const sessionKey PreVoteStreamingSessionKey = "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2"
+	const otherSessionKey PreVoteStreamingSessionKey = "3333333333333333333333333333333333333333333333333333333333333333"
+
+	hash, err := sessionKey.Hash()
+	require.NoError(t, err)
+	require.NoError(t, hash.ValidateBasic())
+	require.NotContains(t, string(hash), string(sessionKey))
+
+	require.True(t, hash.Verify(sessionKey))
+	require.False(t, hash.Verify(otherSessionKey))
+	require.False(t, hash.Verify(""))
+	require.False(t, hash.Verify(PreVoteStreamingSessionKey(strings.ToUpper(string(sessionKey)))))
+
+	anotherHash, err := sessionKey.Hash()
+	require.NoError(t, err)
+	require.NotEqual(t, hash, anotherHash, "salt must be random")
+	require.True(t, anotherHash.Verify(sessionKey))
+
+	_, err = PreVoteStreamingSessionKey("invalid").Hash()
+	require.Error(t, err)
+}
+
+func TestPreVoteStreamingSessionKeyHash_ValidateBasic(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    PreVoteStreamingSessionKeyHash
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			hash:    PreVoteStreamingSessionKeyHash("sha256$" + strings.Repeat("a", 32) + "$" + strings.Repeat("b", 64)),
+			wantErr: false,
+		},
+		{
+			name:    "empty",
+			hash:    "",
+			wantErr: true,
+		},
+		{
+			name:    "unknown algorithm",
+			hash:    PreVoteStreamingSessionKeyHash("md5$" + strings.Repeat("a", 32) + "$" + strings.Repeat("b", 64)),
+			wantErr: true,
+		},
+		{
+			name:    "short salt",
+			hash:    PreVoteStreamingSessionKeyHash("sha256$" + strings.Repeat("a", 30) + "$" + strings.Repeat("b", 64)),
+			wantErr: true,
+		},
+		{
+			name:    "upper case digest",
+			hash:    PreVoteStreamingSessionKeyHash("sha256$" + strings.Repeat("a", 32) + "$" + strings.Repeat("B", 64)),
+			wantErr: true,
+		},
+		{
+			name:    "plaintext session key",
+			hash:    PreVoteStreamingSessionKeyHash(strings.Repeat("a", 64)),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.hash.ValidateBasic(); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateBasic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrateStoredPreVoteStreamingSessionKey(t *testing.T) {
+	const sessionKey PreVoteStreamingSessionKey = "2222222222222222222222222222222222222222222222222222222222222222"
+
+	hash, migrated, err := MigrateStoredPreVoteStreamingSessionKey(string(sessionKey))
+	require.NoError(t, err)
+	require.True(t, migrated)
+	require.True(t, hash.Verify(sessionKey))
+
+	again, migrated, err := MigrateStoredPreVoteStreamingSessionKey(string(hash))
+	require.NoError(t, err)
+	require.False(t, migrated)
+	require.Equal(t, hash, again, "hash must be kept as is")
+
+	_, _, err = MigrateStoredPreVoteStreamingSessionKey("invalid")
+	require.Error(t, err)
+}
+
+func TestVerifyStoredPreVoteStreamingSessionKey(t *testing.T) {
+	const sessionKey PreVoteStreamingSessionKey = "2222222222222222222222222222222222222222222222222222222222222222"
+	const otherSessionKey PreVoteStreamingSessionKey = "3333333333333333333333333333333333333333333333333333333333333333"
+
+	hash, err := sessionKey.Hash()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		stored     string
+		sessionKey PreVoteStreamingSessionKey
+		want       bool
+	}{
+		{
+			name:       "hash",
+			stored:     string(hash),
+			sessionKey: sessionKey,
+			want:       true,
+		},
+		{
+			name:       "hash, wrong key",
+			stored:     string(hash),
+			sessionKey: otherSessionKey,
+			want:       false,
+		},
+		{
+			name:       "plaintext",
+			stored:     string(sessionKey),
+			sessionKey: sessionKey,
+			want:       true,
+		},
+		{
+			name:       "plaintext, wrong key",
+			stored:     string(sessionKey),
+			sessionKey: otherSessionKey,
+			want:       false,
+		},
+		{
+			name:       "empty stored and empty key",
+			stored:     "",
+			sessionKey: "",
+			want:       false,
+		},
+		{
+			name:       "hash as the key",
+			stored:     string(hash),
+			sessionKey: PreVoteStreamingSessionKey(hash),
+			want:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, VerifyStoredPreVoteStreamingSessionKey(tt.stored, tt.sessionKey))
+		})
+	}
+}
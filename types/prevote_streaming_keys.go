@@ -54,9 +54,18 @@ func (sid PreVoteStreamingSessionId) ValidateBasic() error {
 	return nil
 }
 
+// ChainId returns the chain id of the session id, empty if the session id is invalid format.
+func (sid PreVoteStreamingSessionId) ChainId() string {
+	if sid.ValidateBasic() != nil {
+		return ""
+	}
+	return string(sid[:strings.LastIndex(string(sid), "_")])
+}
+
 // ForChainId returns true if the session id value is for the given chain id.
+// The chain id must match exactly, a session id of chain "abc_def" is not for chain "abc".
 func (sid PreVoteStreamingSessionId) ForChainId(chainId string) bool {
-	return strings.HasPrefix(string(sid), chainId+"_") && sid.ValidateBasic() == nil
+	return chainId != "" && sid.ChainId() == chainId
 }
 
 var regexpPreVoteStreamingSessionKey = regexp.MustCompile(`^[a-f\d]{64}$`)
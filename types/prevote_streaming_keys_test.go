@@ -49,3 +49,83 @@ func TestNewPreVoteStreamingSession(t *testing.T) {
 		})
 	}
 }
+
+func TestPreVoteStreamingSessionId_ChainId(t *testing.T) {
+	tests := []struct {
+		name      string
+		sessionId PreVoteStreamingSessionId
+		want      string
+	}{
+		{
+			name:      "normal",
+			sessionId: "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111",
+			want:      "cosmoshub-4",
+		},
+		{
+			name:      "chain id contains underscore",
+			sessionId: "evmos_9001-2_1111111111111111111111111111111111111111111111111111111111111111",
+			want:      "evmos_9001-2",
+		},
+		{
+			name:      "invalid session id",
+			sessionId: "cosmoshub-4",
+			want:      "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.sessionId.ChainId())
+		})
+	}
+}
+
+func TestPreVoteStreamingSessionId_ForChainId(t *testing.T) {
+	tests := []struct {
+		name      string
+		sessionId PreVoteStreamingSessionId
+		chainId   string
+		want      bool
+	}{
+		{
+			name:      "normal",
+			sessionId: "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111",
+			chainId:   "cosmoshub-4",
+			want:      true,
+		},
+		{
+			name:      "another chain",
+			sessionId: "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111",
+			chainId:   "osmosis-1",
+			want:      false,
+		},
+		{
+			name:      "chain id is a prefix of the chain id of the session",
+			sessionId: "abc_def_1111111111111111111111111111111111111111111111111111111111111111",
+			chainId:   "abc",
+			want:      false,
+		},
+		{
+			name:      "chain id contains underscore",
+			sessionId: "abc_def_1111111111111111111111111111111111111111111111111111111111111111",
+			chainId:   "abc_def",
+			want:      true,
+		},
+		{
+			name:      "empty chain id",
+			sessionId: "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111",
+			chainId:   "",
+			want:      false,
+		},
+		{
+			name:      "invalid session id",
+			sessionId: "cosmoshub-4_1111",
+			chainId:   "cosmoshub-4",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.sessionId.ForChainId(tt.chainId))
+		})
+	}
+}
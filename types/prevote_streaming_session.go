@@ -0,0 +1,165 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrPreVoteStreamingSessionExpired is returned when the session had been inactive longer than its TTL.
+	ErrPreVoteStreamingSessionExpired = fmt.Errorf("session expired")
+
+	// ErrPreVoteStreamingSessionRevoked is returned when the session had been revoked.
+	ErrPreVoteStreamingSessionRevoked = fmt.Errorf("session revoked")
+
+	// ErrPreVoteStreamingSessionResumeLimitReached is returned when the session had been resumed the maximum times.
+	ErrPreVoteStreamingSessionResumeLimitReached = fmt.Errorf("session resume limit reached")
+)
+
+// PreVoteStreamingSessionStatus is the lifecycle status of a pre-vote streaming session.
+type PreVoteStreamingSessionStatus int8
+
+const (
+	PreVoteStreamingSessionStatusActive PreVoteStreamingSessionStatus = iota
+	PreVoteStreamingSessionStatusExpired
+	PreVoteStreamingSessionStatusRevoked
+)
+
+func (s PreVoteStreamingSessionStatus) String() string {
+	switch s {
+	case PreVoteStreamingSessionStatusActive:
+		return "active"
+	case PreVoteStreamingSessionStatusExpired:
+		return "expired"
+	case PreVoteStreamingSessionStatusRevoked:
+		return "revoked"
+	default:
+		return fmt.Sprintf("unknown(%d)", int8(s))
+	}
+}
+
+// PreVoteStreamingSession is the lifecycle of a pre-vote streaming session.
+//
+// A session is active until it had been inactive, neither resumed nor broadcast, longer than the TTL,
+// or until revoked. Expired and revoked are final, the state-transition methods return
+// ErrPreVoteStreamingSessionExpired or ErrPreVoteStreamingSessionRevoked without changing the session.
+type PreVoteStreamingSession struct {
+	SessionId PreVoteStreamingSessionId
+	ChainId   string
+
+	CreatedAt time.Time
+	// LastResumedAt is the time of the latest resuming, zero if never resumed.
+	LastResumedAt time.Time
+	// LastBroadcastAt is the time of the latest broadcasting, zero if nothing had been broadcast.
+	LastBroadcastAt time.Time
+
+	// Ttl is the maximum inactive duration, zero means the session never expires.
+	Ttl time.Duration
+
+	ResumeCount int
+	// MaxResumeCount is the maximum number of resuming, zero means unlimited.
+	MaxResumeCount int
+
+	Revoked   bool
+	RevokedAt time.Time
+}
+
+// StartPreVoteStreamingSession returns the lifecycle of a newly registered session.
+func StartPreVoteStreamingSession(sessionId PreVoteStreamingSessionId, ttl time.Duration, maxResumeCount int, now time.Time) PreVoteStreamingSession {
+	return PreVoteStreamingSession{
+		SessionId:      sessionId,
+		ChainId:        sessionId.ChainId(),
+		CreatedAt:      now,
+		Ttl:            ttl,
+		MaxResumeCount: maxResumeCount,
+	}
+}
+
+// LastActiveAt returns the time of the latest registration, resuming or broadcasting.
+func (s PreVoteStreamingSession) LastActiveAt() time.Time {
+	lastActiveAt := s.CreatedAt
+	if s.LastResumedAt.After(lastActiveAt) {
+		lastActiveAt = s.LastResumedAt
+	}
+	if s.LastBroadcastAt.After(lastActiveAt) {
+		lastActiveAt = s.LastBroadcastAt
+	}
+	return lastActiveAt
+}
+
+// ExpiresAt returns the time the session expires if no more activity, zero if the session never expires.
+func (s PreVoteStreamingSession) ExpiresAt() time.Time {
+	if s.Ttl <= 0 {
+		return time.Time{}
+	}
+	return s.LastActiveAt().Add(s.Ttl)
+}
+
+// IsExpired returns true if the session had been inactive longer than the TTL.
+func (s PreVoteStreamingSession) IsExpired(now time.Time) bool {
+	return s.Ttl > 0 && now.After(s.ExpiresAt())
+}
+
+// Status returns the status of the session at the given time, revoked takes precedence over expired.
+func (s PreVoteStreamingSession) Status(now time.Time) PreVoteStreamingSessionStatus {
+	if s.Revoked {
+		return PreVoteStreamingSessionStatusRevoked
+	}
+	if s.IsExpired(now) {
+		return PreVoteStreamingSessionStatusExpired
+	}
+	return PreVoteStreamingSessionStatusActive
+}
+
+// EnsureActive returns ErrPreVoteStreamingSessionRevoked or ErrPreVoteStreamingSessionExpired
+// if the session is no longer active at the given time.
+func (s PreVoteStreamingSession) EnsureActive(now time.Time) error {
+	switch s.Status(now) {
+	case PreVoteStreamingSessionStatusRevoked:
+		return ErrPreVoteStreamingSessionRevoked
+	case PreVoteStreamingSessionStatusExpired:
+		return ErrPreVoteStreamingSessionExpired
+	default:
+		return nil
+	}
+}
+
+// Resume records a resuming of the session,
+// returns ErrPreVoteStreamingSessionResumeLimitReached if the session had been resumed the maximum times.
+func (s *PreVoteStreamingSession) Resume(now time.Time) error {
+	if err := s.EnsureActive(now); err != nil {
+		return err
+	}
+	if s.MaxResumeCount > 0 && s.ResumeCount >= s.MaxResumeCount {
+		return ErrPreVoteStreamingSessionResumeLimitReached
+	}
+
+	s.ResumeCount++
+	s.LastResumedAt = now
+	return nil
+}
+
+// RecordBroadcast records a broadcasting within the session.
+func (s *PreVoteStreamingSession) RecordBroadcast(now time.Time) error {
+	if err := s.EnsureActive(now); err != nil {
+		return err
+	}
+
+	s.LastBroadcastAt = now
+	return nil
+}
+
+// Revoke revokes the session, revoking a revoked session is no-op.
+// An expired session can not be revoked, because it is no longer usable anyway.
+func (s *PreVoteStreamingSession) Revoke(now time.Time) error {
+	if s.Revoked {
+		return nil
+	}
+	if s.IsExpired(now) {
+		return ErrPreVoteStreamingSessionExpired
+	}
+
+	s.Revoked = true
+	s.RevokedAt = now
+	return nil
+}
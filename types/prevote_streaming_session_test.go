@@ -0,0 +1,97 @@
+package types
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testSessionId PreVoteStreamingSessionId = "cosmoshub-4_1111111111111111111111111111111111111111111111111111111111111111"
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestPreVoteStreamingSession_Expiry(t *testing.T) {
+	clock := newFakeClock()
+	session := StartPreVoteStreamingSession(testSessionId, time.Minute, 0, clock.Now())
+	require.Equal(t, "cosmoshub-4", session.ChainId)
+	require.Equal(t, PreVoteStreamingSessionStatusActive, session.Status(clock.Now()))
+	require.Equal(t, clock.Now().Add(time.Minute), session.ExpiresAt())
+
+	clock.Advance(50 * time.Second)
+	require.NoError(t, session.RecordBroadcast(clock.Now()))
+	require.Equal(t, clock.Now(), session.LastActiveAt())
+
+	clock.Advance(50 * time.Second)
+	require.NoError(t, session.Resume(clock.Now()), "broadcasting extends the expiry")
+	require.Equal(t, 1, session.ResumeCount)
+
+	clock.Advance(time.Minute)
+	require.False(t, session.IsExpired(clock.Now()), "expires after, not at, the TTL")
+
+	clock.Advance(time.Millisecond)
+	require.True(t, session.IsExpired(clock.Now()))
+	require.Equal(t, PreVoteStreamingSessionStatusExpired, session.Status(clock.Now()))
+
+	before := session
+	require.ErrorIs(t, session.RecordBroadcast(clock.Now()), ErrPreVoteStreamingSessionExpired)
+	require.ErrorIs(t, session.Resume(clock.Now()), ErrPreVoteStreamingSessionExpired)
+	require.ErrorIs(t, session.Revoke(clock.Now()), ErrPreVoteStreamingSessionExpired)
+	require.Equal(t, before, session, "failed transitions must not change the session")
+}
+
+func TestPreVoteStreamingSession_NeverExpires(t *testing.T) {
+	clock := newFakeClock()
+	session := StartPreVoteStreamingSession(testSessionId, 0, 0, clock.Now())
+	require.True(t, session.ExpiresAt().IsZero())
+
+	clock.Advance(24 * 365 * time.Hour)
+	require.False(t, session.IsExpired(clock.Now()))
+	require.NoError(t, session.EnsureActive(clock.Now()))
+}
+
+func TestPreVoteStreamingSession_ResumeLimit(t *testing.T) {
+	clock := newFakeClock()
+	session := StartPreVoteStreamingSession(testSessionId, time.Minute, 2, clock.Now())
+
+	require.NoError(t, session.Resume(clock.Now()))
+	require.NoError(t, session.Resume(clock.Now()))
+	require.ErrorIs(t, session.Resume(clock.Now()), ErrPreVoteStreamingSessionResumeLimitReached)
+	require.Equal(t, 2, session.ResumeCount)
+	require.NoError(t, session.RecordBroadcast(clock.Now()), "broadcasting is not limited")
+}
+
+func TestPreVoteStreamingSession_Revoke(t *testing.T) {
+	clock := newFakeClock()
+	session := StartPreVoteStreamingSession(testSessionId, time.Minute, 0, clock.Now())
+
+	clock.Advance(time.Second)
+	require.NoError(t, session.Revoke(clock.Now()))
+	require.True(t, session.Revoked)
+	require.Equal(t, clock.Now(), session.RevokedAt)
+	require.Equal(t, PreVoteStreamingSessionStatusRevoked, session.Status(clock.Now()))
+
+	clock.Advance(time.Second)
+	require.NoError(t, session.Revoke(clock.Now()), "revoking again is no-op")
+	require.Equal(t, clock.Now().Add(-time.Second), session.RevokedAt)
+
+	require.ErrorIs(t, session.Resume(clock.Now()), ErrPreVoteStreamingSessionRevoked)
+	require.ErrorIs(t, session.RecordBroadcast(clock.Now()), ErrPreVoteStreamingSessionRevoked)
+
+	clock.Advance(time.Hour)
+	require.Equal(t, PreVoteStreamingSessionStatusRevoked, session.Status(clock.Now()), "revoked takes precedence")
+}
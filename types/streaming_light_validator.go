@@ -1,9 +1,79 @@
 package types
 
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"github.com/pkg/errors"
+	"strings"
+)
+
 type StreamingLightValidators []StreamingLightValidator
 
 type StreamingLightValidator struct {
 	Index                     int     `json:"i"`
 	VotingPowerDisplayPercent float64 `json:"vdp"`
 	Moniker                   string  `json:"m"`
+	OperatorAddress           string  `json:"oa,omitempty"` // optional, bech32 valoper address
+	ConsensusAddress          string  `json:"ca,omitempty"` // optional, hex consensus address
+}
+
+// ConsensusAddressBytes is the size of the consensus address, which is the first 20 bytes of SHA-256 of the consensus public key.
+const ConsensusAddressBytes = 20
+
+// ValidateAddresses returns an error if any of the optional operator address and consensus address is present but invalid.
+func (v StreamingLightValidator) ValidateAddresses() error {
+	if v.OperatorAddress != "" {
+		if err := ValidateOperatorAddress(v.OperatorAddress); err != nil {
+			return errors.Wrap(err, "invalid operator address")
+		}
+	}
+	if v.ConsensusAddress != "" {
+		if err := ValidateConsensusAddress(v.ConsensusAddress); err != nil {
+			return errors.Wrap(err, "invalid consensus address")
+		}
+	}
+	return nil
+}
+
+// ValidateOperatorAddress returns an error if the given address is not a valid bech32 validator operator address,
+// with the human-readable part ends with "valoper" and 20 or 32 bytes of data.
+func ValidateOperatorAddress(address string) error {
+	if len(address) == 0 {
+		return fmt.Errorf("empty")
+	}
+
+	hrp, data, err := utils.Bech32Decode(address)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(hrp, "valoper") {
+		return fmt.Errorf("not a validator operator address, human-readable part %s", hrp)
+	}
+
+	if len(data) != 20 && len(data) != 32 {
+		return fmt.Errorf("invalid address length %d, must be 20 or 32 bytes", len(data))
+	}
+
+	return nil
+}
+
+// ValidateConsensusAddress returns an error if the given address is not a valid hex consensus address of 20 bytes.
+// Both upper and lower case are accepted.
+func ValidateConsensusAddress(address string) error {
+	if len(address) == 0 {
+		return fmt.Errorf("empty")
+	}
+
+	bz, err := hex.DecodeString(address)
+	if err != nil {
+		return fmt.Errorf("invalid hex format")
+	}
+
+	if len(bz) != ConsensusAddressBytes {
+		return fmt.Errorf("invalid address length %d, must be %d bytes", len(bz), ConsensusAddressBytes)
+	}
+
+	return nil
 }
@@ -0,0 +1,111 @@
+package types
+
+import (
+	"bytes"
+	"github.com/bcdevtools/cvp-streaming-core/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func mustBech32(t *testing.T, hrp string, data []byte) string {
+	address, err := utils.Bech32Encode(hrp, data)
+	require.NoError(t, err)
+	return address
+}
+
+//goland:noinspection SpellCheckingInspection
+func TestValidateOperatorAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{
+			name:    "normal",
+			address: mustBech32(t, "cosmosvaloper", bytes.Repeat([]byte{1}, 20)),
+		},
+		{
+			name:    "32 bytes address",
+			address: mustBech32(t, "osmovaloper", bytes.Repeat([]byte{1}, 32)),
+		},
+		{
+			name:    "empty",
+			address: "",
+			wantErr: true,
+		},
+		{
+			name:    "account address",
+			address: mustBech32(t, "cosmos", bytes.Repeat([]byte{1}, 20)),
+			wantErr: true,
+		},
+		{
+			name:    "bad length",
+			address: mustBech32(t, "cosmosvaloper", bytes.Repeat([]byte{1}, 21)),
+			wantErr: true,
+		},
+		{
+			name:    "bad checksum",
+			address: mustBech32(t, "cosmosvaloper", bytes.Repeat([]byte{1}, 20)) + "q",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOperatorAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOperatorAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConsensusAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{
+			name:    "upper case",
+			address: "0123456789ABCDEF0123456789ABCDEF01234567",
+		},
+		{
+			name:    "lower case",
+			address: "0123456789abcdef0123456789abcdef01234567",
+		},
+		{
+			name:    "empty",
+			address: "",
+			wantErr: true,
+		},
+		{
+			name:    "not hex",
+			address: "0123456789ABCDEF0123456789ABCDEF0123456X",
+			wantErr: true,
+		},
+		{
+			name:    "bad length",
+			address: "0123456789ABCDEF0123456789ABCDEF012345",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateConsensusAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConsensusAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStreamingLightValidator_ValidateAddresses(t *testing.T) {
+	operatorAddress := mustBech32(t, "cosmosvaloper", bytes.Repeat([]byte{1}, 20))
+	require.NoError(t, StreamingLightValidator{}.ValidateAddresses())
+	require.NoError(t, StreamingLightValidator{
+		OperatorAddress:  operatorAddress,
+		ConsensusAddress: "0123456789ABCDEF0123456789ABCDEF01234567",
+	}.ValidateAddresses())
+	require.ErrorContains(t, StreamingLightValidator{OperatorAddress: "cosmos"}.ValidateAddresses(), "invalid operator address")
+	require.ErrorContains(t, StreamingLightValidator{ConsensusAddress: "AB"}.ValidateAddresses(), "invalid consensus address")
+}
@@ -3,7 +3,7 @@ package types
 import "time"
 
 type StreamingNextBlockVotingInformation struct {
-	HeightRoundStep       string                        `json:"hrs"`
+	HeightRoundStep       HeightRoundStep               `json:"hrs"`
 	Duration              time.Duration                 `json:"d,omitempty"`
 	PreVotedPercent       float64                       `json:"pv,omitempty"`
 	PreCommitVotedPercent float64                       `json:"pc,omitempty"`
@@ -16,4 +16,8 @@ type StreamingValidatorVoteState struct {
 	PreVoted          bool   `json:"pv,omitempty"`
 	VotedZeroes       bool   `json:"vz,omitempty"`
 	PreCommitVoted    bool   `json:"pc,omitempty"`
+
+	// PreCommittedBlockHash is the fingerprint of the block hash which the validator pre-committed,
+	// same format as PreVotedBlockHash. Only encoded by v8 and later codecs, empty if not available.
+	PreCommittedBlockHash string `json:"pch,omitempty"`
 }
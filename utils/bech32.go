@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+//goland:noinspection SpellCheckingInspection
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// bech32MaxLength is the maximum length of a bech32 string, as defined by BIP-173.
+const bech32MaxLength = 90
+
+// Bech32Decode decodes the given bech32 string, verifies the checksum and returns the human-readable part
+// and the data converted from 5-bit groups back to bytes.
+// Mixed case input is rejected, the returned human-readable part is always lowercase.
+func Bech32Decode(input string) (hrp string, data []byte, err error) {
+	if len(input) > bech32MaxLength {
+		return "", nil, fmt.Errorf("bech32 string too long: %d/%d", len(input), bech32MaxLength)
+	}
+	if strings.ToLower(input) != input && strings.ToUpper(input) != input {
+		return "", nil, fmt.Errorf("bech32 string must not be mixed case")
+	}
+	input = strings.ToLower(input)
+
+	separatorIndex := strings.LastIndexByte(input, '1')
+	if separatorIndex < 1 || separatorIndex+7 > len(input) {
+		return "", nil, fmt.Errorf("invalid bech32 separator position")
+	}
+
+	hrp = input[:separatorIndex]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("invalid character in human-readable part at %d", i)
+		}
+	}
+
+	values := make([]byte, 0, len(input)-separatorIndex-1)
+	for i := separatorIndex + 1; i < len(input); i++ {
+		v := strings.IndexByte(bech32Charset, input[i])
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid character in data part at %d", i)
+		}
+		values = append(values, byte(v))
+	}
+
+	if bech32Polymod(append(bech32HrpExpand(hrp), values...)) != 1 {
+		return "", nil, fmt.Errorf("invalid bech32 checksum")
+	}
+
+	data, err = convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return hrp, data, nil
+}
+
+// Bech32Encode encodes the given data with the given human-readable part into a lowercase bech32 string.
+func Bech32Encode(hrp string, data []byte) (string, error) {
+	if len(hrp) < 1 {
+		return "", fmt.Errorf("empty human-readable part")
+	}
+	if strings.ToLower(hrp) != hrp {
+		return "", fmt.Errorf("human-readable part must be lowercase")
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", fmt.Errorf("invalid character in human-readable part at %d", i)
+		}
+	}
+
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	polymod := bech32Polymod(append(append(bech32HrpExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+
+	if sb.Len() > bech32MaxLength {
+		return "", fmt.Errorf("bech32 string too long: %d/%d", sb.Len(), bech32MaxLength)
+	}
+
+	return sb.String(), nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// convertBits regroups the given data from fromBits-bit groups into toBits-bit groups.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var acc uint32
+	var bits uint
+	maxV := uint32(1<<toBits) - 1
+	converted := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for _, v := range data {
+		if uint32(v)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data range: %d", v)
+		}
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			converted = append(converted, byte(acc>>bits&maxV))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			converted = append(converted, byte(acc<<(toBits-bits)&maxV))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxV != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return converted, nil
+}
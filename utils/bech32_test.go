@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+//goland:noinspection SpellCheckingInspection
+func TestBech32Decode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantHrp string
+		wantErr bool
+	}{
+		{
+			name:    "valid, uppercase",
+			input:   "A12UEL5L",
+			wantHrp: "a",
+		},
+		{
+			name:    "valid, lowercase",
+			input:   "a12uel5l",
+			wantHrp: "a",
+		},
+		{
+			name:    "valid, long human-readable part",
+			input:   "an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+			wantHrp: "an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio",
+		},
+		{
+			name:    "valid, all data characters",
+			input:   "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+			wantHrp: "abcdef",
+		},
+		{
+			name:    "valid, separator in human-readable part",
+			input:   "split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+			wantHrp: "split",
+		},
+		{
+			name:    "no separator",
+			input:   "pzry9x0s0muk",
+			wantErr: true,
+		},
+		{
+			name:    "empty human-readable part",
+			input:   "1pzry9x0s0muk",
+			wantErr: true,
+		},
+		{
+			name:    "invalid data character",
+			input:   "x1b4n0q5v",
+			wantErr: true,
+		},
+		{
+			name:    "checksum too short",
+			input:   "li1dgmt3",
+			wantErr: true,
+		},
+		{
+			name:    "checksum calculated with uppercase human-readable part",
+			input:   "A1G7SGD8",
+			wantErr: true,
+		},
+		{
+			name:    "mixed case",
+			input:   "A12uEL5L",
+			wantErr: true,
+		},
+		{
+			name:    "bad checksum",
+			input:   "a12uel5m",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHrp, _, err := Bech32Decode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Bech32Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotHrp != tt.wantHrp {
+				t.Errorf("Bech32Decode() hrp = %v, want %v", gotHrp, tt.wantHrp)
+			}
+		})
+	}
+}
+
+//goland:noinspection SpellCheckingInspection
+func TestBech32Encode(t *testing.T) {
+	tests := []struct {
+		name    string
+		hrp     string
+		data    []byte
+		wantErr bool
+	}{
+		{
+			name: "20 bytes address",
+			hrp:  "cosmosvaloper",
+			data: bytes.Repeat([]byte{0xAB}, 20),
+		},
+		{
+			name: "32 bytes address",
+			hrp:  "osmovaloper",
+			data: bytes.Repeat([]byte{0x01}, 32),
+		},
+		{
+			name: "empty data",
+			hrp:  "a",
+			data: nil,
+		},
+		{
+			name:    "empty human-readable part",
+			hrp:     "",
+			data:    []byte{1},
+			wantErr: true,
+		},
+		{
+			name:    "uppercase human-readable part",
+			hrp:     "COSMOS",
+			data:    []byte{1},
+			wantErr: true,
+		},
+		{
+			name:    "too long",
+			hrp:     "cosmosvaloper",
+			data:    bytes.Repeat([]byte{0x01}, 64),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Bech32Encode(tt.hrp, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Bech32Encode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			gotHrp, gotData, err := Bech32Decode(got)
+			if err != nil {
+				t.Errorf("Bech32Decode() error = %v", err)
+				return
+			}
+			if gotHrp != tt.hrp {
+				t.Errorf("Bech32Decode() hrp = %v, want %v", gotHrp, tt.hrp)
+			}
+			if !bytes.Equal(gotData, tt.data) {
+				t.Errorf("Bech32Decode() data = %X, want %X", gotData, tt.data)
+			}
+		})
+	}
+
+	if got, _ := Bech32Encode("a", nil); got != "a12uel5l" {
+		t.Errorf("Bech32Encode() = %s, want a12uel5l", got)
+	}
+}
@@ -1,5 +1,7 @@
 package utils
 
+import "unicode/utf8"
+
 func TruncateStringUntilBufferLessThanXBytesOrFillWithSpaceSuffix(input string, maxBytes int) []byte {
 	bzInput := []byte(input)
 	for len(bzInput) > maxBytes {
@@ -16,3 +18,19 @@ func TruncateStringUntilBufferLessThanXBytesOrFillWithSpaceSuffix(input string,
 	}
 	return bzOutput
 }
+
+// TruncateStringUntilBufferLessThanXBytes truncates the input, at UTF-8 rune boundary,
+// until it is not longer than maxBytes. Shorter input is returned as is, without padding.
+func TruncateStringUntilBufferLessThanXBytes(input string, maxBytes int) string {
+	if len(input) <= maxBytes {
+		return input
+	}
+	if maxBytes < 1 {
+		return ""
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(input[cut]) {
+		cut--
+	}
+	return input[:cut]
+}
@@ -47,3 +47,68 @@ func TestTruncateStringUntilBufferLessThanXBytesOrFillWithSpaceSuffix(t *testing
 		})
 	}
 }
+
+func TestTruncateStringUntilBufferLessThanXBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxBytes int
+		want     string
+	}{
+		{
+			name:     "less than 70 bytes",
+			input:    "abc",
+			maxBytes: 70,
+			want:     "abc",
+		},
+		{
+			name:     "keep trailing spaces",
+			input:    " abc  ",
+			maxBytes: 70,
+			want:     " abc  ",
+		},
+		{
+			name:     "more than 20 bytes",
+			input:    "123456789012345678901",
+			maxBytes: 20,
+			want:     "12345678901234567890",
+		},
+		{
+			name:     "exactly 20 bytes",
+			input:    "12345678901234567890",
+			maxBytes: 20,
+			want:     "12345678901234567890",
+		},
+		{
+			name:     "empty",
+			input:    "",
+			maxBytes: 20,
+			want:     "",
+		},
+		{
+			name:     "zero max bytes",
+			input:    "abc",
+			maxBytes: 0,
+			want:     "",
+		},
+		{
+			name:     "UTF8 more than 20 bytes",
+			input:    "✅✅✅✅✅✅✅✅✅✅✅✅✅✅✅✅✅✅✅✅",
+			maxBytes: 20,
+			want:     "✅✅✅✅✅✅",
+		},
+		{
+			name:     "UTF8 cut at rune boundary",
+			input:    "ab✅",
+			maxBytes: 4,
+			want:     "ab",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateStringUntilBufferLessThanXBytes(tt.input, tt.maxBytes); got != tt.want {
+				t.Errorf("TruncateStringUntilBufferLessThanXBytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,10 @@ func GetRemoteUrlResumePreVoteStreamingSession(baseUrl, sessionId string) string
 	return strings.TrimSuffix(baseUrl, "/") + "/" + strings.ReplaceAll(constants.STREAMING_PATH_RESUME_PRE_VOTE, ":sessionId", sessionId)
 }
 
+func GetRemoteUrlRevokePreVoteStreamingSession(baseUrl, sessionId string) string {
+	return strings.TrimSuffix(baseUrl, "/") + "/" + strings.ReplaceAll(constants.STREAMING_PATH_REVOKE_PRE_VOTE, ":sessionId", sessionId)
+}
+
 func GetRemoteUrlBroadcastPreVoteDuringStreamingSession(baseUrl, sessionId string) string {
 	return strings.TrimSuffix(baseUrl, "/") + "/" + strings.ReplaceAll(constants.STREAMING_PATH_BROADCAST_PRE_VOTE, ":sessionId", sessionId)
 }
@@ -24,3 +28,18 @@ func GetPublicUrlViewPreVoteStreamingSession(baseUrl, sessionId string) string {
 func GetUrlFetchPreVoteStreamingSessionUpdate(baseUrl, sessionId string) string {
 	return strings.TrimSuffix(baseUrl, "/") + "/" + strings.ReplaceAll(constants.STREAMING_PATH_VIEW_PRE_VOTE_FETCH_UPDATE, ":sessionId", sessionId)
 }
+
+func GetUrlSubscribePreVoteStreamingSessionUpdates(baseUrl, sessionId string) string {
+	return strings.TrimSuffix(baseUrl, "/") + "/" + strings.ReplaceAll(constants.STREAMING_PATH_VIEW_PRE_VOTE_SUBSCRIBE, ":sessionId", sessionId)
+}
+
+// GetRemoteUrlBroadcastPreVoteWebSocket returns the WebSocket URL, the http and https schemes of the base URL
+// are converted into ws and wss respectively.
+func GetRemoteUrlBroadcastPreVoteWebSocket(baseUrl, sessionId string) string {
+	if strings.HasPrefix(baseUrl, "https://") {
+		baseUrl = "wss://" + strings.TrimPrefix(baseUrl, "https://")
+	} else if strings.HasPrefix(baseUrl, "http://") {
+		baseUrl = "ws://" + strings.TrimPrefix(baseUrl, "http://")
+	}
+	return strings.TrimSuffix(baseUrl, "/") + "/" + strings.ReplaceAll(constants.STREAMING_PATH_BROADCAST_PRE_VOTE_WS, ":sessionId", sessionId)
+}
@@ -60,6 +60,35 @@ func TestGetRemoteUrlResumePreVoteStreamingSession(t *testing.T) {
 	}
 }
 
+func TestGetRemoteUrlRevokePreVoteStreamingSession(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseUrl   string
+		sessionId string
+		want      string
+	}{
+		{
+			name:      "normal",
+			baseUrl:   "https://cvp.bcdev.tools",
+			sessionId: "sample-session-id-1",
+			want:      "https://cvp.bcdev.tools/revoke-session/pre-vote/sample-session-id-1",
+		},
+		{
+			name:      "normal with suffix slash",
+			baseUrl:   "http://localhost:8080/",
+			sessionId: "sample-session-id-2",
+			want:      "http://localhost:8080/revoke-session/pre-vote/sample-session-id-2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetRemoteUrlRevokePreVoteStreamingSession(tt.baseUrl, tt.sessionId); got != tt.want {
+				t.Errorf("GetRemoteUrlRevokePreVoteStreamingSession() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetRemoteUrlBroadcastPreVoteDuringStreamingSession(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -146,3 +175,67 @@ func TestGetUrlFetchPreVoteStreamingSessionUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUrlSubscribePreVoteStreamingSessionUpdates(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseUrl   string
+		sessionId string
+		want      string
+	}{
+		{
+			name:      "normal",
+			baseUrl:   "https://cvp.bcdev.tools",
+			sessionId: "sample-session-id-1",
+			want:      "https://cvp.bcdev.tools/pvtop/sample-session-id-1/events",
+		},
+		{
+			name:      "normal with suffix slash",
+			baseUrl:   "http://localhost:8080/",
+			sessionId: "sample-session-id-2",
+			want:      "http://localhost:8080/pvtop/sample-session-id-2/events",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetUrlSubscribePreVoteStreamingSessionUpdates(tt.baseUrl, tt.sessionId); got != tt.want {
+				t.Errorf("GetUrlSubscribePreVoteStreamingSessionUpdates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRemoteUrlBroadcastPreVoteWebSocket(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseUrl   string
+		sessionId string
+		want      string
+	}{
+		{
+			name:      "https to wss",
+			baseUrl:   "https://cvp.bcdev.tools",
+			sessionId: "sample-session-id-1",
+			want:      "wss://cvp.bcdev.tools/broadcast-ws/pre-vote/sample-session-id-1",
+		},
+		{
+			name:      "http to ws, with suffix slash",
+			baseUrl:   "http://localhost:8080/",
+			sessionId: "sample-session-id-2",
+			want:      "ws://localhost:8080/broadcast-ws/pre-vote/sample-session-id-2",
+		},
+		{
+			name:      "keep ws",
+			baseUrl:   "ws://localhost:8080",
+			sessionId: "sample-session-id-3",
+			want:      "ws://localhost:8080/broadcast-ws/pre-vote/sample-session-id-3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetRemoteUrlBroadcastPreVoteWebSocket(tt.baseUrl, tt.sessionId); got != tt.want {
+				t.Errorf("GetRemoteUrlBroadcastPreVoteWebSocket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}